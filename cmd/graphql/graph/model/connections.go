@@ -0,0 +1,19 @@
+package model
+
+import "github.com/sy-software/minerva-owl/internal/core/ports"
+
+// OrganizationConnection is a page of organizations following the Relay connection spec
+type OrganizationConnection struct {
+	Edges    []*OrganizationEdge `json:"edges"`
+	PageInfo *PageInfo           `json:"pageInfo"`
+	// Filters used to build this connection, required to resolve the total count
+	Filters []ports.Filter `json:"-"`
+}
+
+// UserConnection is a page of users following the Relay connection spec
+type UserConnection struct {
+	Edges    []*UserEdge `json:"edges"`
+	PageInfo *PageInfo   `json:"pageInfo"`
+	// Filters used to build this connection, required to resolve the total count
+	Filters []ports.Filter `json:"-"`
+}
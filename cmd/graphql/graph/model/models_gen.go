@@ -3,13 +3,47 @@
 package model
 
 import (
+	"fmt"
+	"io"
+	"strconv"
 	"time"
 )
 
+type BulkItemResult struct {
+	Index   int     `json:"index"`
+	ID      *string `json:"id"`
+	Success bool    `json:"success"`
+	Error   *string `json:"error"`
+}
+
+type BulkPayload struct {
+	Results   []*BulkItemResult `json:"results"`
+	Succeeded int               `json:"succeeded"`
+	Failed    int               `json:"failed"`
+}
+
+type IDFilter struct {
+	Eq  *string  `json:"eq"`
+	Ne  *string  `json:"ne"`
+	In  []string `json:"in"`
+	Nin []string `json:"nin"`
+}
+
 type NewOrganization struct {
 	Name        string  `json:"name"`
 	Description string  `json:"description"`
 	Logo        *string `json:"logo"`
+	Owner       *string `json:"owner"`
+}
+
+type NewTeam struct {
+	Organization string   `json:"organization"`
+	Name         string   `json:"name"`
+	Description  string   `json:"description"`
+	Leader       *string  `json:"leader"`
+	Color        *string  `json:"color"`
+	Icon         *string  `json:"icon"`
+	Techs        []string `json:"techs"`
 }
 
 type NewUser struct {
@@ -29,22 +63,73 @@ type Organization struct {
 	Logo        *string `json:"logo"`
 }
 
-type UpdateOrganization struct {
-	ID          string  `json:"id"`
-	Name        *string `json:"name"`
-	Description *string `json:"description"`
-	Logo        *string `json:"logo"`
+type OrganizationEdge struct {
+	Cursor string        `json:"cursor"`
+	Node   *Organization `json:"node"`
 }
 
-type UpdateUser struct {
-	ID       string  `json:"id"`
-	Username string  `json:"username"`
-	Name     string  `json:"name"`
-	Picture  *string `json:"picture"`
-	Role     string  `json:"role"`
-	Provider string  `json:"provider"`
-	TokenID  string  `json:"tokenID"`
-	Status   string  `json:"status"`
+type OrganizationOrderBy struct {
+	Field     OrganizationSortField `json:"field"`
+	Direction SortDirection         `json:"direction"`
+}
+
+type OrganizationWhere struct {
+	And         []*OrganizationWhere `json:"and"`
+	Or          []*OrganizationWhere `json:"or"`
+	Not         *OrganizationWhere   `json:"not"`
+	ID          *IDFilter            `json:"id"`
+	Name        *StringFilter        `json:"name"`
+	Description *StringFilter        `json:"description"`
+	Logo        *StringFilter        `json:"logo"`
+}
+
+type PageInfo struct {
+	HasNextPage     bool    `json:"hasNextPage"`
+	HasPreviousPage bool    `json:"hasPreviousPage"`
+	StartCursor     *string `json:"startCursor"`
+	EndCursor       *string `json:"endCursor"`
+}
+
+type StringFilter struct {
+	Eq       *string  `json:"eq"`
+	Ne       *string  `json:"ne"`
+	In       []string `json:"in"`
+	Nin      []string `json:"nin"`
+	Gt       *string  `json:"gt"`
+	Gte      *string  `json:"gte"`
+	Lt       *string  `json:"lt"`
+	Lte      *string  `json:"lte"`
+	Prefix   *string  `json:"prefix"`
+	Contains *string  `json:"contains"`
+	Exists   *bool    `json:"exists"`
+}
+
+type Team struct {
+	ID           string   `json:"id"`
+	Name         string   `json:"name"`
+	Description  string   `json:"description"`
+	Organization string   `json:"organization"`
+	Leader       *string  `json:"leader"`
+	Color        *string  `json:"color"`
+	Icon         *string  `json:"icon"`
+	Techs        []string `json:"techs"`
+}
+
+type TimeFilter struct {
+	Eq     *time.Time   `json:"eq"`
+	Ne     *time.Time   `json:"ne"`
+	In     []*time.Time `json:"in"`
+	Nin    []*time.Time `json:"nin"`
+	Gt     *time.Time   `json:"gt"`
+	Gte    *time.Time   `json:"gte"`
+	Lt     *time.Time   `json:"lt"`
+	Lte    *time.Time   `json:"lte"`
+	Exists *bool        `json:"exists"`
+}
+
+type UpsertUserPayload struct {
+	User    *User `json:"user"`
+	Created bool  `json:"created"`
 }
 
 type User struct {
@@ -59,3 +144,158 @@ type User struct {
 	UpdateDate time.Time `json:"updateDate"`
 	Status     string    `json:"status"`
 }
+
+type UserEdge struct {
+	Cursor string `json:"cursor"`
+	Node   *User  `json:"node"`
+}
+
+type UserOrderBy struct {
+	Field     UserSortField `json:"field"`
+	Direction SortDirection `json:"direction"`
+}
+
+type UserWhere struct {
+	And        []*UserWhere  `json:"and"`
+	Or         []*UserWhere  `json:"or"`
+	Not        *UserWhere    `json:"not"`
+	ID         *IDFilter     `json:"id"`
+	Username   *StringFilter `json:"username"`
+	Name       *StringFilter `json:"name"`
+	Role       *StringFilter `json:"role"`
+	Provider   *StringFilter `json:"provider"`
+	Status     *StringFilter `json:"status"`
+	CreateDate *TimeFilter   `json:"createDate"`
+	UpdateDate *TimeFilter   `json:"updateDate"`
+}
+
+type OrganizationSortField string
+
+const (
+	OrganizationSortFieldName        OrganizationSortField = "NAME"
+	OrganizationSortFieldDescription OrganizationSortField = "DESCRIPTION"
+)
+
+var AllOrganizationSortField = []OrganizationSortField{
+	OrganizationSortFieldName,
+	OrganizationSortFieldDescription,
+}
+
+func (e OrganizationSortField) IsValid() bool {
+	switch e {
+	case OrganizationSortFieldName, OrganizationSortFieldDescription:
+		return true
+	}
+	return false
+}
+
+func (e OrganizationSortField) String() string {
+	return string(e)
+}
+
+func (e *OrganizationSortField) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = OrganizationSortField(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid OrganizationSortField", str)
+	}
+	return nil
+}
+
+func (e OrganizationSortField) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type SortDirection string
+
+const (
+	SortDirectionAsc  SortDirection = "ASC"
+	SortDirectionDesc SortDirection = "DESC"
+)
+
+var AllSortDirection = []SortDirection{
+	SortDirectionAsc,
+	SortDirectionDesc,
+}
+
+func (e SortDirection) IsValid() bool {
+	switch e {
+	case SortDirectionAsc, SortDirectionDesc:
+		return true
+	}
+	return false
+}
+
+func (e SortDirection) String() string {
+	return string(e)
+}
+
+func (e *SortDirection) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = SortDirection(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid SortDirection", str)
+	}
+	return nil
+}
+
+func (e SortDirection) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type UserSortField string
+
+const (
+	UserSortFieldUsername   UserSortField = "USERNAME"
+	UserSortFieldName       UserSortField = "NAME"
+	UserSortFieldRole       UserSortField = "ROLE"
+	UserSortFieldStatus     UserSortField = "STATUS"
+	UserSortFieldCreateDate UserSortField = "CREATE_DATE"
+	UserSortFieldUpdateDate UserSortField = "UPDATE_DATE"
+)
+
+var AllUserSortField = []UserSortField{
+	UserSortFieldUsername,
+	UserSortFieldName,
+	UserSortFieldRole,
+	UserSortFieldStatus,
+	UserSortFieldCreateDate,
+	UserSortFieldUpdateDate,
+}
+
+func (e UserSortField) IsValid() bool {
+	switch e {
+	case UserSortFieldUsername, UserSortFieldName, UserSortFieldRole, UserSortFieldStatus, UserSortFieldCreateDate, UserSortFieldUpdateDate:
+		return true
+	}
+	return false
+}
+
+func (e UserSortField) String() string {
+	return string(e)
+}
+
+func (e *UserSortField) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = UserSortField(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid UserSortField", str)
+	}
+	return nil
+}
+
+func (e UserSortField) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
@@ -0,0 +1,31 @@
+package model
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/99designs/gqlgen/graphql"
+)
+
+// UpdateInput holds the fields of an update input as they were sent, so a missing field
+// can be told apart from an explicit null. The fields are validated against the schema
+// before the input is unmarshaled
+type UpdateInput struct {
+	Fields map[string]interface{}
+}
+
+// UnmarshalGQL implements the graphql.Unmarshaler interface
+func (input *UpdateInput) UnmarshalGQL(v interface{}) error {
+	fields, ok := v.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("update inputs must be objects")
+	}
+
+	input.Fields = fields
+	return nil
+}
+
+// MarshalGQL implements the graphql.Marshaler interface
+func (input UpdateInput) MarshalGQL(w io.Writer) {
+	graphql.MarshalMap(input.Fields).MarshalGQL(w)
+}
@@ -9,6 +9,7 @@ import (
 // It serves as dependency injection for your app, add any dependencies you require here.
 
 type Resolver struct {
-	OrgHandler handlers.OrganizationGraphqlHandler
-	UsrHandler handlers.UserGraphqlHandler
+	OrgHandler  handlers.OrganizationGraphqlHandler
+	UsrHandler  handlers.UserGraphqlHandler
+	TeamHandler handlers.TeamGraphqlHandler
 }
@@ -11,54 +11,126 @@ import (
 )
 
 func (r *mutationResolver) CreateOrganization(ctx context.Context, input model.NewOrganization) (*model.Organization, error) {
-	return r.OrgHandler.Create(input.Name, input.Description, input.Logo)
+	return r.OrgHandler.Create(ctx, input.Name, input.Description, input.Logo, input.Owner)
 }
 
-func (r *mutationResolver) UpdateOrganization(ctx context.Context, input model.UpdateOrganization) (*model.Organization, error) {
-	return r.OrgHandler.Update(input.ID, input.Name, input.Description, input.Logo)
+func (r *mutationResolver) UpdateOrganization(ctx context.Context, input model.UpdateInput) (*model.Organization, error) {
+	return r.OrgHandler.Update(ctx, input.Fields)
 }
 
 func (r *mutationResolver) DeleteOrganization(ctx context.Context, id string) (*model.Organization, error) {
-	return r.OrgHandler.Delete(id)
+	return r.OrgHandler.Delete(ctx, id)
+}
+
+func (r *mutationResolver) CreateOrganizations(ctx context.Context, input []*model.NewOrganization, stopOnError *bool) (*model.BulkPayload, error) {
+	return r.OrgHandler.CreateMany(ctx, input, stopOnError)
+}
+
+func (r *mutationResolver) UpdateOrganizations(ctx context.Context, input []*model.UpdateInput, stopOnError *bool) (*model.BulkPayload, error) {
+	return r.OrgHandler.UpdateMany(ctx, input, stopOnError)
+}
+
+func (r *mutationResolver) DeleteOrganizations(ctx context.Context, ids []string, stopOnError *bool) (*model.BulkPayload, error) {
+	return r.OrgHandler.DeleteMany(ctx, ids, stopOnError)
 }
 
 func (r *mutationResolver) CreateUser(ctx context.Context, input model.NewUser) (*model.User, error) {
-	return r.UsrHandler.Create(input)
+	return r.UsrHandler.Create(ctx, input)
+}
+
+func (r *mutationResolver) UpdateUser(ctx context.Context, input model.UpdateInput) (*model.User, error) {
+	return r.UsrHandler.Update(ctx, input.Fields)
 }
 
-func (r *mutationResolver) UpdateUser(ctx context.Context, input model.UpdateUser) (*model.User, error) {
-	return r.UsrHandler.Update(input)
+func (r *mutationResolver) UpsertUser(ctx context.Context, input model.NewUser) (*model.UpsertUserPayload, error) {
+	return r.UsrHandler.Upsert(ctx, input)
 }
 
 func (r *mutationResolver) DeleteUser(ctx context.Context, id string) (*model.User, error) {
-	return r.UsrHandler.Delete(id)
+	return r.UsrHandler.Delete(ctx, id)
+}
+
+func (r *mutationResolver) CreateUsers(ctx context.Context, input []*model.NewUser, stopOnError *bool) (*model.BulkPayload, error) {
+	return r.UsrHandler.CreateMany(ctx, input, stopOnError)
+}
+
+func (r *mutationResolver) UpdateUsers(ctx context.Context, input []*model.UpdateInput, stopOnError *bool) (*model.BulkPayload, error) {
+	return r.UsrHandler.UpdateMany(ctx, input, stopOnError)
+}
+
+func (r *mutationResolver) DeleteUsers(ctx context.Context, ids []string, stopOnError *bool) (*model.BulkPayload, error) {
+	return r.UsrHandler.DeleteMany(ctx, ids, stopOnError)
+}
+
+func (r *mutationResolver) CreateTeam(ctx context.Context, input model.NewTeam) (*model.Team, error) {
+	return r.TeamHandler.Create(ctx, input)
+}
+
+func (r *mutationResolver) AddTeamTech(ctx context.Context, id string, tech string) (*model.Team, error) {
+	return r.TeamHandler.AddTech(ctx, id, tech)
+}
+
+func (r *mutationResolver) RemoveTeamTech(ctx context.Context, id string, tech string) (*model.Team, error) {
+	return r.TeamHandler.RemoveTech(ctx, id, tech)
+}
+
+func (r *organizationConnectionResolver) TotalCount(ctx context.Context, obj *model.OrganizationConnection) (int, error) {
+	return r.OrgHandler.TotalCount(ctx, obj)
 }
 
-func (r *queryResolver) Organizations(ctx context.Context, page *int, pageSize *int) ([]*model.Organization, error) {
-	return r.OrgHandler.Query(page, pageSize)
+func (r *queryResolver) Organizations(ctx context.Context, where *model.OrganizationWhere, page *int, pageSize *int, orderBy []*model.OrganizationOrderBy) ([]*model.Organization, error) {
+	return r.OrgHandler.Query(ctx, where, page, pageSize, orderBy...)
+}
+
+func (r *queryResolver) OrganizationsConnection(ctx context.Context, where *model.OrganizationWhere, first *int, after *string) (*model.OrganizationConnection, error) {
+	return r.OrgHandler.QueryConnection(ctx, where, first, after)
 }
 
 func (r *queryResolver) Organization(ctx context.Context, id string) (*model.Organization, error) {
-	return r.OrgHandler.QueryById(id)
+	return r.OrgHandler.QueryById(ctx, id)
+}
+
+func (r *queryResolver) Users(ctx context.Context, role *string, where *model.UserWhere, page *int, pageSize *int, orderBy []*model.UserOrderBy) ([]*model.User, error) {
+	return r.UsrHandler.Query(ctx, role, where, page, pageSize, orderBy...)
 }
 
-func (r *queryResolver) Users(ctx context.Context, role *string, page *int, pageSize *int) ([]*model.User, error) {
-	return r.UsrHandler.Query(role, page, pageSize)
+func (r *queryResolver) UsersConnection(ctx context.Context, role *string, where *model.UserWhere, first *int, after *string) (*model.UserConnection, error) {
+	return r.UsrHandler.QueryConnection(ctx, role, where, first, after)
 }
 
 func (r *queryResolver) User(ctx context.Context, id string) (*model.User, error) {
-	return r.UsrHandler.QueryById(id)
+	return r.UsrHandler.QueryById(ctx, id)
 }
 
 func (r *queryResolver) UserByUsername(ctx context.Context, username string) (*model.User, error) {
-	return r.UsrHandler.QueryByUsername(username)
+	return r.UsrHandler.QueryByUsername(ctx, username)
+}
+
+func (r *queryResolver) Team(ctx context.Context, id string) (*model.Team, error) {
+	return r.TeamHandler.QueryById(ctx, id)
+}
+
+func (r *userConnectionResolver) TotalCount(ctx context.Context, obj *model.UserConnection) (int, error) {
+	return r.UsrHandler.TotalCount(ctx, obj)
 }
 
 // Mutation returns generated.MutationResolver implementation.
 func (r *Resolver) Mutation() generated.MutationResolver { return &mutationResolver{r} }
 
+// OrganizationConnection returns generated.OrganizationConnectionResolver implementation.
+func (r *Resolver) OrganizationConnection() generated.OrganizationConnectionResolver {
+	return &organizationConnectionResolver{r}
+}
+
 // Query returns generated.QueryResolver implementation.
 func (r *Resolver) Query() generated.QueryResolver { return &queryResolver{r} }
 
+// UserConnection returns generated.UserConnectionResolver implementation.
+func (r *Resolver) UserConnection() generated.UserConnectionResolver {
+	return &userConnectionResolver{r}
+}
+
 type mutationResolver struct{ *Resolver }
+type organizationConnectionResolver struct{ *Resolver }
 type queryResolver struct{ *Resolver }
+type userConnectionResolver struct{ *Resolver }
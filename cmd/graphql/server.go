@@ -2,7 +2,6 @@ package main
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"net/http"
 	"os"
@@ -21,19 +20,23 @@ import (
 	"github.com/sy-software/minerva-owl/internal/core/service"
 	"github.com/sy-software/minerva-owl/internal/handlers"
 	"github.com/sy-software/minerva-owl/internal/repositories"
-	"github.com/sy-software/minerva-owl/internal/repositories/mongodb"
+	"github.com/sy-software/minerva-owl/internal/repositories/metrics"
+	"github.com/sy-software/minerva-owl/internal/repositories/storage"
+	"github.com/sy-software/minerva-owl/internal/repositories/tracing"
 )
 
 // Defining the Graphql handler
 func graphqlHandler(config *domain.Config, resolver *graph.Resolver) gin.HandlerFunc {
 	srv := handler.NewDefaultServer(generated.NewExecutableSchema(generated.Config{Resolvers: resolver}))
 
-	srv.SetRecoverFunc(func(ctx context.Context, err interface{}) error {
-		handlers.ErrorLogger(ctx)
-		return errors.New("internal server error")
-	})
+	srv.SetRecoverFunc(handlers.RecoverFunc)
+	srv.SetErrorPresenter(handlers.NewErrorPresenter(config.Production(), handlers.LogErrorReporter))
 
 	srv.AroundOperations(handlers.GQLOperationMiddleware)
+	srv.AroundOperations(handlers.GQLMetricsMiddleware)
+	srv.AroundOperations(handlers.GQLTracingMiddleware)
+	srv.AroundFields(handlers.GQLResolverMetricsMiddleware)
+	srv.AroundFields(handlers.GQLResolverTracingMiddleware)
 
 	return func(c *gin.Context) {
 		srv.ServeHTTP(c.Writer, c.Request)
@@ -49,6 +52,17 @@ func playgroundHandler() gin.HandlerFunc {
 	}
 }
 
+// listen serves srv until it's shut down
+func listen(name string, srv *http.Server) {
+	err := srv.ListenAndServe()
+
+	if err != http.ErrServerClosed {
+		log.Panic().Err(err).Msgf("%s crashed", name)
+	} else {
+		log.Info().Msgf("%s closed", name)
+	}
+}
+
 func main() {
 	minervaLog.ConfigureLogger(minervaLog.LogLevel(os.Getenv("LOG_LEVEL")), os.Getenv("CONSOLE_OUTPUT") != "")
 	log.Info().Msg("Starting server")
@@ -56,54 +70,83 @@ func main() {
 	configRepo := repositories.ConfigRepo{}
 	config := configRepo.Get()
 
-	mdbInstance, err := mongodb.GetMongoDB(config.MongoDBConfig)
+	if _, err := service.NewIDGenerator(config.IDGenerator); err != nil {
+		log.Error().Err(err).Msg("Invalid id generator")
+		os.Exit(1)
+	}
 
+	shutdownTracing, err := handlers.SetupTracing(config.Tracing)
 	if err != nil {
-		log.Error().Stack().Err(err).Msg("Can't initialize Mongo DB")
+		log.Error().Err(err).Msg("Can't initialize tracing")
 		os.Exit(1)
 	}
 
-	repo, err := mongodb.NewMongoRepo(mdbInstance, &config)
+	store, err := storage.New(&config)
+
 	if err != nil {
-		log.Fatal().Err(err).Msg("Can't start Mongo DB Repo")
+		log.Error().Stack().Err(err).Msgf("Can't initialize storage backend: %q", config.Storage.Backend)
 		os.Exit(1)
 	}
 
-	defer mdbInstance.Close()
+	defer store.Close()
 
-	orgService := service.NewOrgService(repo, config)
-	usrService := service.NewUserService(repo, config)
+	repository := tracing.NewTracedRepo(metrics.NewInstrumentedRepo(store.Repository))
+	orgService := service.NewOrgService(repository, config)
+	usrService := service.NewUserService(repository, config)
+	teamService := service.NewTeamService(repository, config)
 	orgHandler := handlers.NewOrgGraphqlHandler(*orgService)
 	usrHandler := handlers.NewUserGraphqlHandler(*usrService)
+	teamHandler := handlers.NewTeamGraphqlHandler(*teamService)
 
 	r := gin.New()
 	r.Use(handlers.GinCtxToCtxMiddleware())
+	r.Use(handlers.TracingMiddleware("gin"))
 	r.Use(handlers.LogMiddleware("gin"))
+	r.Use(handlers.MetricsMiddleware())
 
-	r.POST("/query", graphqlHandler(&config, &graph.Resolver{
-		OrgHandler: *orgHandler,
-		UsrHandler: *usrHandler,
-	}))
+	resolver := &graph.Resolver{
+		OrgHandler:  *orgHandler,
+		UsrHandler:  *usrHandler,
+		TeamHandler: *teamHandler,
+	}
+	r.POST(
+		"/query",
+		handlers.TimeoutMiddleware(config.RequestTimeout*time.Second),
+		handlers.LoadersMiddleware(*orgService, *usrService, *teamService),
+		graphqlHandler(&config, resolver),
+	)
 	r.GET("/", playgroundHandler())
 
+	health := handlers.NewHealth(&config, store)
+	r.GET("/healthz", health.LivenessHandler())
+	r.GET("/readyz", health.ReadinessHandler())
+
 	address := fmt.Sprintf("%s:%s", config.Host, config.Port)
 	srv := &http.Server{
 		Addr:    address,
 		Handler: r,
 	}
 
-	// Initializing the server in a goroutine so that
-	// it won't block the graceful shutdown handling below
-	go func() {
-		log.Info().Msgf("connect to http://%s/ for GraphQL playground", address)
-		err := srv.ListenAndServe()
-
-		if err != http.ErrServerClosed {
-			log.Panic().Err(err).Msg("Server crashed")
-		} else {
-			log.Info().Msg("Server closed")
+	var adminSrv *http.Server
+	if config.Admin.Port != "" {
+		adminSrv = &http.Server{
+			Addr:    fmt.Sprintf("%s:%s", config.Admin.Host, config.Admin.Port),
+			Handler: handlers.NewAdminRouter(&config, health),
 		}
-	}()
+	} else {
+		r.GET("/metrics", handlers.MetricsHandler())
+		r.GET("/health", health.DetailsHandler())
+	}
+
+	// Initializing the servers in goroutines so that
+	// they won't block the graceful shutdown handling below
+	log.Info().Msgf("connect to http://%s/ for GraphQL playground", address)
+	go listen("Server", srv)
+
+	if adminSrv != nil {
+		log.Info().Msgf("admin endpoints listening on http://%s/", adminSrv.Addr)
+		go listen("Admin server", adminSrv)
+	}
 
 	// Create context that listens for the interrupt signal from the OS.
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
@@ -116,6 +159,10 @@ func main() {
 	stop()
 	log.Info().Msg("shutting down gracefully, press Ctrl+C again to force")
 
+	// Fail readiness first so load balancers stop sending new requests
+	health.ShuttingDown()
+	time.Sleep(config.Health.ShutdownDelay * time.Second)
+
 	// The context is used to inform the server it has 5 seconds to finish
 	// the request it is currently handling
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -123,4 +170,15 @@ func main() {
 	if err := srv.Shutdown(ctx); err != nil {
 		log.Panic().Err(err).Msg("Server forced to shutdown")
 	}
+
+	// The admin server closes last so metrics and health stay available while draining
+	if adminSrv != nil {
+		if err := adminSrv.Shutdown(ctx); err != nil {
+			log.Panic().Err(err).Msg("Admin server forced to shutdown")
+		}
+	}
+
+	if err := shutdownTracing(ctx); err != nil {
+		log.Error().Err(err).Msg("Can't flush pending spans")
+	}
 }
@@ -6,12 +6,24 @@ require (
 	github.com/99designs/gqlgen v0.13.0
 	github.com/gin-gonic/gin v1.7.4
 	github.com/gocql/gocql v0.0.0-20210621133426-d83b80dfb480
+	github.com/golang-migrate/migrate/v4 v4.14.1
 	github.com/google/go-cmp v0.5.6
 	github.com/google/uuid v1.2.0
+	github.com/mattn/go-sqlite3 v1.14.16
+	github.com/oklog/ulid/v2 v2.0.2
 	github.com/pierrec/lz4/v4 v4.1.8 // indirect
+	github.com/prometheus/client_golang v1.11.0
+	github.com/prometheus/client_model v0.2.0
 	github.com/rs/zerolog v1.23.0
+	github.com/scylladb/go-reflectx v1.0.1
 	github.com/scylladb/gocqlx/v2 v2.4.0
 	github.com/sy-software/minerva-go-utils v0.0.0-20210818225928-36f6fc1f86fb
 	github.com/vektah/gqlparser/v2 v2.1.0
+	go.etcd.io/bbolt v1.3.6
 	go.mongodb.org/mongo-driver v1.5.4
+	go.opentelemetry.io/otel v1.0.1
+	go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp v1.0.1
+	go.opentelemetry.io/otel/exporters/stdout/stdouttrace v1.0.1
+	go.opentelemetry.io/otel/sdk v1.0.1
+	go.opentelemetry.io/otel/trace v1.0.1
 )
@@ -3,6 +3,7 @@ package domain
 import (
 	"encoding/json"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/rs/zerolog/log"
@@ -46,6 +47,105 @@ type MDBConfig struct {
 	MaxPoolSize int `json:"maxPoolSize,omitempty"`
 }
 
+// BoltConfig holds the embedded single file database related configurations
+type BoltConfig struct {
+	// Path of the database file, default: owl.db
+	Path string `json:"path,omitempty"`
+	// How long to wait for the file lock held by another process, default: 10 seconds
+	Timeout time.Duration `json:"timeout,omitempty"`
+	// Indexed fields of each collection, filters by eq, in and prefix on them
+	// don't need to read the whole collection
+	Indexes map[string][]string `json:"indexes,omitempty"`
+}
+
+// SQLConfig holds the relational database related configurations
+type SQLConfig struct {
+	// Name of the database/sql driver, it also selects the SQL dialect, default: sqlite3
+	Driver string `json:"driver,omitempty"`
+	// Driver specific data source name, default: owl.sqlite
+	DSN string `json:"dsn,omitempty"`
+	// Maximum number of open connections, SQLite only supports a single writer, default: 1
+	MaxOpenConns int `json:"maxOpenConns,omitempty"`
+	// Seconds to wait for the database to respond, default: 10
+	Timeout time.Duration `json:"timeout,omitempty"`
+}
+
+// BackendConfig defines a named storage backend with its own connection
+type BackendConfig struct {
+	// Backend type: mongo, cassandra, bolt, sql or memory
+	Type string `json:"type,omitempty"`
+	// Connection settings for mongo backends, default: the top level mongoDB settings
+	MongoDB *MDBConfig `json:"mongoDB,omitempty"`
+	// Connection settings for cassandra backends, default: the top level cassandraDB settings
+	CassandraDB *CDBConfig `json:"cassandraDB,omitempty"`
+	// File settings for bolt backends, default: the top level boltDB settings
+	BoltDB *BoltConfig `json:"boltDB,omitempty"`
+	// Connection settings for sql backends, default: the top level sqlDB settings
+	SQLDB *SQLConfig `json:"sqlDB,omitempty"`
+}
+
+// StorageConfig holds the data storage related configurations
+type StorageConfig struct {
+	// Which backend stores our data when a collection has no route, default: mongo
+	//
+	// It can be either a backend type (mongo, cassandra, bolt, sql or memory) using the top level
+	// connection settings, or the name of a backend defined in Backends
+	Backend string `json:"backend,omitempty"`
+	// Routes maps a collection name to the name of the backend storing it
+	Routes map[string]string `json:"routes,omitempty"`
+	// Backends defines additional named backends
+	Backends map[string]BackendConfig `json:"backends,omitempty"`
+}
+
+// CollectionCacheConfig holds the read-through cache settings of a collection
+type CollectionCacheConfig struct {
+	// Cache the items read by id
+	Enabled bool `json:"enabled,omitempty"`
+	// Seconds an item is kept in the cache, default: the default collection TTL
+	TTL time.Duration `json:"ttl,omitempty"`
+}
+
+// CacheConfig holds the read-through cache settings
+type CacheConfig struct {
+	// Max number of items kept in the in-process cache, default: 10000
+	MaxItems int `json:"maxItems,omitempty"`
+	// Settings of the collections not listed in Collections, default: disabled with a 60 seconds TTL
+	Default CollectionCacheConfig `json:"default,omitempty"`
+	// Collections maps a collection name to its cache settings
+	Collections map[string]CollectionCacheConfig `json:"collections,omitempty"`
+}
+
+// TracingConfig holds the OpenTelemetry tracing settings
+type TracingConfig struct {
+	// Where spans are exported: none, stdout or otlp, default: none
+	Exporter string `json:"exporter,omitempty"`
+	// OTLP/HTTP collector host and port, default: localhost:4318
+	Endpoint string `json:"endpoint,omitempty"`
+	// Send the spans to the collector without TLS
+	Insecure bool `json:"insecure,omitempty"`
+	// Fraction of the new traces sampled between 0 and 1, incoming sampled traces are always kept, default: 1
+	SampleRatio float64 `json:"sampleRatio,omitempty"`
+	// Service name reported with the spans, default: minerva-owl
+	ServiceName string `json:"serviceName,omitempty"`
+}
+
+// HealthConfig holds the health check settings
+type HealthConfig struct {
+	// Seconds each backend has to answer the readiness ping, default: 2
+	Timeout time.Duration `json:"timeout,omitempty"`
+	// Seconds readiness fails before the server stops accepting requests on shutdown,
+	// so load balancers stop sending traffic first, default: 0
+	ShutdownDelay time.Duration `json:"shutdownDelay,omitempty"`
+}
+
+// AdminConfig holds the settings of the listener serving the operational endpoints
+type AdminConfig struct {
+	// Admin listener bind IP, default: 127.0.0.1
+	Host string `json:"host,omitempty"`
+	// Admin listener bind port, metrics and health details are served on the public port when empty
+	Port string `json:"port,omitempty"`
+}
+
 type Pagination struct {
 	// Default page size if no specified
 	PageSize int `json:"pageSize,omitempty"`
@@ -58,16 +158,39 @@ type KeyList struct {
 	Auth string `json:"auth,omitempty"`
 }
 
+// PRODUCTION_ENV is the Config.Environment hiding internal error messages from the clients
+const PRODUCTION_ENV = "production"
+
 // Config contains all configuration for this service
 type Config struct {
-	CassandraDB   CDBConfig `json:"cassandraDB"`
-	MongoDBConfig MDBConfig `json:"mongoDB"`
+	CassandraDB   CDBConfig  `json:"cassandraDB"`
+	MongoDBConfig MDBConfig  `json:"mongoDB"`
+	BoltDB        BoltConfig `json:"boltDB,omitempty"`
+	SQLDB         SQLConfig  `json:"sqlDB,omitempty"`
+	// Data storage settings
+	Storage StorageConfig `json:"storage,omitempty"`
+	// Read-through cache settings
+	Cache CacheConfig `json:"cache,omitempty"`
+	// OpenTelemetry tracing settings
+	Tracing TracingConfig `json:"tracing,omitempty"`
+	// Strategy used to create the ids of new entities: objectid, uuid or ulid, default: objectid
+	IDGenerator string `json:"idGenerator,omitempty"`
+	// Deployment environment, production hides internal error messages, default: development
+	Environment string `json:"environment,omitempty"`
 	// Server bind IP default 0.0.0.0
 	Host string `json:"host,omitempty"`
 	// Server bind port default 8080
 	Port string `json:"port,omitempty"`
+	// Admin listener settings, it serves metrics, health, pprof and the effective configuration
+	Admin AdminConfig `json:"admin,omitempty"`
+	// Seconds a GraphQL request can take before its operations are cancelled, default: 30
+	RequestTimeout time.Duration `json:"requestTimeout,omitempty"`
+	// Health, readiness and liveness checks settings
+	Health HealthConfig `json:"health,omitempty"`
 	// Default pagination settings
 	Pagination Pagination `json:"pagination,omitempty"`
+	// Names of the areas created with every new organization, default: Engineering and Design
+	DefaultAreas []string `json:"defaultAreas,omitempty"`
 	// Encryption and security keys
 	Keys KeyList `json:"keys,omitempty"`
 }
@@ -89,15 +212,55 @@ func DefaultConfig() Config {
 			ConnectTimeout: 10,
 			MaxPoolSize:    50,
 		},
-		Host: "0.0.0.0",
-		Port: "8080",
+		BoltDB: BoltConfig{
+			Path:    "owl.db",
+			Timeout: 10,
+		},
+		SQLDB: SQLConfig{
+			Driver:       "sqlite3",
+			DSN:          "owl.sqlite",
+			MaxOpenConns: 1,
+			Timeout:      10,
+		},
+		Storage: StorageConfig{
+			Backend: "mongo",
+		},
+		Cache: CacheConfig{
+			MaxItems: 10000,
+			Default: CollectionCacheConfig{
+				TTL: 60,
+			},
+		},
+		Tracing: TracingConfig{
+			Exporter:    "none",
+			Endpoint:    "localhost:4318",
+			SampleRatio: 1,
+			ServiceName: "minerva-owl",
+		},
+		IDGenerator: "objectid",
+		Environment: "development",
+		Host:        "0.0.0.0",
+		Port:        "8080",
+		Admin: AdminConfig{
+			Host: "127.0.0.1",
+		},
+		RequestTimeout: 30,
+		Health: HealthConfig{
+			Timeout: 2,
+		},
 		Pagination: Pagination{
 			PageSize:    10,
 			MaxPageSize: 100,
 		},
+		DefaultAreas: []string{"Engineering", "Design"},
 	}
 }
 
+// Production tells if the service runs in the production environment
+func (config Config) Production() bool {
+	return strings.EqualFold(strings.TrimSpace(config.Environment), PRODUCTION_ENV)
+}
+
 // LoadConfiguration Loads the configuration object from a json file
 func LoadConfiguration(file string) Config {
 	config := DefaultConfig()
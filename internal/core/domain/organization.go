@@ -1,5 +1,7 @@
 package domain
 
+const ORG_COL_NAME = "organizations"
+
 // Organization is the main element in the data model
 //
 // An organization witholds: Areas, Teams, Users, Software components
@@ -21,20 +23,34 @@ type Area struct {
 	Icon         string `bson:"icon,omitempty" json:"icon,omitempty"`
 }
 
+// ORG_ROLE_OWNER is the membership role of the user who created an organization
+const ORG_ROLE_OWNER = "owner"
+
+// Membership gives a user a role inside an organization
+type Membership struct {
+	Id           string `bson:"_id,omitempty" json:"id,omitempty"`
+	Organization string `bson:"organization,omitempty" json:"organization,omitempty"`
+	User         string `bson:"user,omitempty" json:"user,omitempty"`
+	Role         string `bson:"role,omitempty" json:"role,omitempty"`
+}
+
+const TEAM_COL_NAME = "teams"
+
 // Team represents a unit of people working on a commong goal
 //
 // A Team is managed by a "Leader". And it's composed of multiple
 // team members
 // TODO: Should a team belong to a single Area?
 type Team struct {
-	Id           string   `bson:"_id,omitempty" json:"id,omitempty"`
-	Name         string   `bson:"name,omitempty" json:"name,omitempty"`
-	Description  string   `bson:"description,omitempty" json:"description,omitempty"`
-	Organization string   `bson:"organization,omitempty" json:"organization,omitempty"`
-	Leader       string   `bson:"leader,omitempty" json:"leader,omitempty"`
-	Color        string   `bson:"color,omitempty" json:"color,omitempty"`
-	Icon         string   `bson:"icon,omitempty" json:"icon,omitempty"`
-	Techs        []string `bson:"techs,omitempty" json:"techs,omitempty"`
+	Id           string `bson:"_id,omitempty" json:"id,omitempty"`
+	Name         string `bson:"name,omitempty" json:"name,omitempty"`
+	Description  string `bson:"description,omitempty" json:"description,omitempty"`
+	Organization string `bson:"organization,omitempty" json:"organization,omitempty"`
+	Leader       string `bson:"leader,omitempty" json:"leader,omitempty"`
+	Color        string `bson:"color,omitempty" json:"color,omitempty"`
+	Icon         string `bson:"icon,omitempty" json:"icon,omitempty"`
+	// Stored as a set in Cassandra so techs can be added atomically
+	Techs []string `bson:"techs,omitempty" json:"techs,omitempty" cql:"set"`
 }
 
 // Tech is a definition of tools, languages, frameworks, etc. Used within an Organization
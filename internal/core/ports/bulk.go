@@ -0,0 +1,48 @@
+package ports
+
+import "errors"
+
+// ErrBulkAborted is the error of the items not processed by a bulk operation
+// because a previous item failed and the operation stops on errors
+var ErrBulkAborted = errors.New("not processed, a previous item failed")
+
+// BulkResult is the outcome of a single item of a bulk operation
+type BulkResult struct {
+	// Id of the item, empty when the item couldn't be created
+	Id string
+	// Err is nil when the item was processed successfully
+	Err error
+}
+
+// BulkPatch changes the fields in Mask of the item with Id
+type BulkPatch struct {
+	Id   string
+	Mask FieldMask
+}
+
+// RunBulk calls fn with the index of every item in order and returns a result per item.
+// When stopOnError is true the items after the first error are not processed
+// and their result is ErrBulkAborted
+func RunBulk(size int, stopOnError bool, fn func(index int) (string, error)) []BulkResult {
+	results := make([]BulkResult, size)
+	for index := range results {
+		id, err := fn(index)
+		results[index] = BulkResult{Id: id, Err: err}
+
+		if err != nil && stopOnError {
+			AbortBulk(results[index+1:])
+			break
+		}
+	}
+
+	return results
+}
+
+// AbortBulk sets ErrBulkAborted as the error of the results without one
+func AbortBulk(results []BulkResult) {
+	for index := range results {
+		if results[index].Err == nil {
+			results[index].Err = ErrBulkAborted
+		}
+	}
+}
@@ -0,0 +1,19 @@
+package ports
+
+import (
+	"context"
+	"time"
+)
+
+// Cache stores serialized items by key, used by the read-through repository cache.
+//
+// An implementation shared by every instance (E.G.: Redis) can be plugged in for
+// multi-instance deployments. Implementations must be safe for concurrent use
+type Cache interface {
+	// Get returns the value stored with key and true, or false when there is none or it expired
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	// Set stores value with key for ttl
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// Delete removes the values stored with any of the keys
+	Delete(ctx context.Context, keys ...string) error
+}
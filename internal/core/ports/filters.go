@@ -0,0 +1,210 @@
+package ports
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Operators supported by Filter
+const (
+	// OP_EQ matches fields equal to the value, it's the default operator
+	OP_EQ = "eq"
+	// OP_NE matches fields not equal to the value
+	OP_NE = "ne"
+	// OP_IN matches fields equal to any of the values in a slice
+	OP_IN = "in"
+	// OP_NIN matches fields not equal to any of the values in a slice
+	OP_NIN = "nin"
+	// OP_GT matches fields greater than the value
+	OP_GT = "gt"
+	// OP_GTE matches fields greater than or equal to the value
+	OP_GTE = "gte"
+	// OP_LT matches fields lower than the value
+	OP_LT = "lt"
+	// OP_LTE matches fields lower than or equal to the value
+	OP_LTE = "lte"
+	// OP_PREFIX matches string fields starting with the value
+	OP_PREFIX = "prefix"
+	// OP_CONTAINS matches string fields containing the value, or array
+	// fields with an element containing the value
+	OP_CONTAINS = "contains"
+	// OP_EXISTS matches fields with a value when the value is true,
+	// or missing fields when the value is false
+	OP_EXISTS = "exists"
+	// OP_AND matches when all the nested filters match
+	OP_AND = "and"
+	// OP_OR matches when any of the nested filters match
+	OP_OR = "or"
+	// OP_NOT matches when the nested filter doesn't match
+	OP_NOT = "not"
+)
+
+// ErrInvalidFilter must be thrown when a repository can't apply a filter
+type ErrInvalidFilter struct {
+	// The filter that can't be applied
+	Filter Filter
+	// Why the filter can't be applied
+	Reason string
+}
+
+func (err ErrInvalidFilter) Error() string {
+	if err.Filter.Name == "" {
+		return fmt.Sprintf("invalid %q filter: %v", err.Filter.Op(), err.Reason)
+	}
+
+	return fmt.Sprintf("invalid %q filter for %q: %v", err.Filter.Op(), err.Filter.Name, err.Reason)
+}
+
+// Filter is used to privide an abstraction for repository specific filters
+// Each repository have the responsibility to parse the filters into the right
+// query representation (E.G.: SQL, CQL, etc.)
+//
+// Field filters compare the field Name against Value using Operator, logical filters
+// (and, or, not) combine the nested Filters and have no Name. Name uses the same
+// field names as the storage tags of the domain models, nested fields are separated by dots
+type Filter struct {
+	Name     string
+	Operator string
+	Value    interface{}
+	Filters  []Filter
+}
+
+// Op returns the filter operator, OP_EQ when it's not set
+func (filter Filter) Op() string {
+	if filter.Operator == "" {
+		return OP_EQ
+	}
+
+	return filter.Operator
+}
+
+// IsLogical tells if the filter combines other filters instead of comparing a field
+func (filter Filter) IsLogical() bool {
+	switch filter.Operator {
+	case OP_AND, OP_OR, OP_NOT:
+		return true
+	}
+
+	return false
+}
+
+// Eq creates a filter matching name equal to value
+func Eq(name string, value interface{}) Filter {
+	return Filter{Name: name, Operator: OP_EQ, Value: value}
+}
+
+// Ne creates a filter matching name not equal to value
+func Ne(name string, value interface{}) Filter {
+	return Filter{Name: name, Operator: OP_NE, Value: value}
+}
+
+// In creates a filter matching name equal to any of values
+func In(name string, values ...interface{}) Filter {
+	return Filter{Name: name, Operator: OP_IN, Value: values}
+}
+
+// InIds creates a filter matching the items with any of the ids
+func InIds(ids ...string) Filter {
+	values := make([]interface{}, len(ids))
+	for index, id := range ids {
+		values[index] = id
+	}
+
+	return In("_id", values...)
+}
+
+// Nin creates a filter matching name not equal to any of values
+func Nin(name string, values ...interface{}) Filter {
+	return Filter{Name: name, Operator: OP_NIN, Value: values}
+}
+
+// Gt creates a filter matching name greater than value
+func Gt(name string, value interface{}) Filter {
+	return Filter{Name: name, Operator: OP_GT, Value: value}
+}
+
+// Gte creates a filter matching name greater than or equal to value
+func Gte(name string, value interface{}) Filter {
+	return Filter{Name: name, Operator: OP_GTE, Value: value}
+}
+
+// Lt creates a filter matching name lower than value
+func Lt(name string, value interface{}) Filter {
+	return Filter{Name: name, Operator: OP_LT, Value: value}
+}
+
+// Lte creates a filter matching name lower than or equal to value
+func Lte(name string, value interface{}) Filter {
+	return Filter{Name: name, Operator: OP_LTE, Value: value}
+}
+
+// Prefix creates a filter matching name starting with prefix
+func Prefix(name string, prefix string) Filter {
+	return Filter{Name: name, Operator: OP_PREFIX, Value: prefix}
+}
+
+// Contains creates a filter matching name containing value
+func Contains(name string, value string) Filter {
+	return Filter{Name: name, Operator: OP_CONTAINS, Value: value}
+}
+
+// Exists creates a filter matching name having a value when exists is true
+func Exists(name string, exists bool) Filter {
+	return Filter{Name: name, Operator: OP_EXISTS, Value: exists}
+}
+
+// And creates a filter matching all the filters
+func And(filters ...Filter) Filter {
+	return Filter{Operator: OP_AND, Filters: filters}
+}
+
+// Or creates a filter matching any of the filters
+func Or(filters ...Filter) Filter {
+	return Filter{Operator: OP_OR, Filters: filters}
+}
+
+// Not creates a filter matching when filter doesn't match
+func Not(filter Filter) Filter {
+	return Filter{Operator: OP_NOT, Filters: []Filter{filter}}
+}
+
+// Validate checks the filter and all its nested filters are well formed
+func (filter Filter) Validate() error {
+	switch filter.Op() {
+	case OP_AND, OP_OR:
+		if len(filter.Filters) == 0 {
+			return ErrInvalidFilter{Filter: filter, Reason: "at least one nested filter is required"}
+		}
+	case OP_NOT:
+		if len(filter.Filters) != 1 {
+			return ErrInvalidFilter{Filter: filter, Reason: "exactly one nested filter is required"}
+		}
+	case OP_EQ, OP_NE, OP_GT, OP_GTE, OP_LT, OP_LTE:
+	case OP_IN, OP_NIN:
+		if kind := reflect.ValueOf(filter.Value).Kind(); kind != reflect.Slice && kind != reflect.Array {
+			return ErrInvalidFilter{Filter: filter, Reason: "value must be a list"}
+		}
+	case OP_PREFIX, OP_CONTAINS:
+		if _, ok := filter.Value.(string); !ok {
+			return ErrInvalidFilter{Filter: filter, Reason: "value must be a string"}
+		}
+	case OP_EXISTS:
+		if _, ok := filter.Value.(bool); !ok {
+			return ErrInvalidFilter{Filter: filter, Reason: "value must be a boolean"}
+		}
+	default:
+		return ErrInvalidFilter{Filter: filter, Reason: "unknown operator"}
+	}
+
+	if filter.IsLogical() {
+		for _, nested := range filter.Filters {
+			if err := nested.Validate(); err != nil {
+				return err
+			}
+		}
+	} else if filter.Name == "" {
+		return ErrInvalidFilter{Filter: filter, Reason: "field name is required"}
+	}
+
+	return nil
+}
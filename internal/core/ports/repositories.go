@@ -1,6 +1,7 @@
 package ports
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/sy-software/minerva-owl/internal/core/domain"
@@ -22,27 +23,107 @@ func (err ErrItemNotFound) Error() string {
 	}
 }
 
-// Filter is used to privide an abstraction for repository specific filters
-// Each repository have the responsibility to parse the filters into the right
-// query representation (E.G.: SQL, CQL, etc.)
-type Filter struct {
-	Name  string
-	Value interface{}
+// ErrDuplicatedId must be thrown when an item is created with the id of an existing item
+type ErrDuplicatedId struct {
+	// The Id of the existing item
+	Id string
+	// Which domain model this item belongs to
+	Model string
 }
 
+func (err ErrDuplicatedId) Error() string {
+	return fmt.Sprintf("%v with Id: %v already exists", err.Model, err.Id)
+}
+
+// ErrInvalidCursor must be thrown when a pagination cursor can't be decoded by the repository
+type ErrInvalidCursor struct {
+	Cursor string
+}
+
+func (err ErrInvalidCursor) Error() string {
+	return fmt.Sprintf("invalid cursor: %q", err.Cursor)
+}
+
+// ErrInvalidSort must be thrown when a repository can't order the items by a field
+type ErrInvalidSort struct {
+	// The requested sort field
+	Field string
+	// Why the repository can't sort by this field
+	Reason string
+}
+
+func (err ErrInvalidSort) Error() string {
+	return fmt.Sprintf("can't sort by %q: %v", err.Field, err.Reason)
+}
+
+// PageInfo describes a page of items fetched with cursor based pagination
+//
+// Cursors are opaque values only meaningful for the repository that created them
+type PageInfo struct {
+	// The cursor of each item in the page, in the same order as the results
+	Cursors []string
+	// The cursor of the last item, used to request the next page
+	EndCursor string
+	// Tells if there are more items after this page
+	HasNextPage bool
+	// Tells if there are items before this page
+	HasPreviousPage bool
+}
+
+// Sort describes the order of the items returned by a repository
+//
+// Field uses the same name as filters (E.G.: the bson tag of the domain model),
+// when multiple sorts are provided the first one takes precedence
+type Sort struct {
+	Field      string
+	Descending bool
+}
+
+// Repository is the generic storage used by the services, every operation stops when ctx
+// is cancelled or its deadline is exceeded
 type Repository interface {
-	// List returns a single page of items
-	List(collection string, results interface{}, skip int, limit int, filters ...Filter) error
+	// List returns a single page of items ordered by sort, without sort the order
+	// is repository specific but stable between calls
+	List(ctx context.Context, collection string, results interface{}, skip int, limit int, sort []Sort, filters ...Filter) error
+	// ListPage returns up to limit items after the provided cursor,
+	// an empty cursor returns the first page.
+	//
+	// Items are returned in a stable order so a cursor always resumes where the
+	// previous page ended, even if new items are created in between
+	ListPage(ctx context.Context, collection string, results interface{}, after string, limit int, filters ...Filter) (PageInfo, error)
+	// Count returns how many items match the filters
+	Count(ctx context.Context, collection string, filters ...Filter) (int, error)
 	// Get returns a single item filter by id
-	Get(collection string, id string, result interface{}) error
+	Get(ctx context.Context, collection string, id string, result interface{}) error
+	// GetMany appends to results, a pointer to a slice, the items with any of the ids in no
+	// particular order. Ids without an item are skipped instead of returning ErrItemNotFound
+	GetMany(ctx context.Context, collection string, ids []string, results interface{}) error
 	// Get returns a single item filtered with the provided filters
-	GetOne(collection string, result interface{}, filter ...Filter) error
+	GetOne(ctx context.Context, collection string, result interface{}, filter ...Filter) error
 	// Create saves a new item into the repository and returns the assigned Id
-	Create(collection string, entity interface{}) (string, error)
+	Create(ctx context.Context, collection string, entity interface{}) (string, error)
 	// Update looks for an existing item and update the values omiting the fields in omit
-	Update(collection string, id string, entity interface{}, omit ...string) error
+	Update(ctx context.Context, collection string, id string, entity interface{}, omit ...string) error
+	// Upsert updates the first item matching the filters with the values of entity, like Update
+	// omitting the fields in onInsertOnly, or creates entity when no item matches.
+	// Returns the id of the stored item and true when it was created
+	Upsert(ctx context.Context, collection string, filters []Filter, entity interface{}, onInsertOnly ...string) (string, bool, error)
+	// Patch changes only the fields in mask of the item with id, the item is not read first
+	Patch(ctx context.Context, collection string, id string, mask FieldMask) error
 	// Delete removes the item with the specified id from the repo
-	Delete(collection string, id string) error
+	Delete(ctx context.Context, collection string, id string) error
+	// CreateMany saves every entity, like Create, and returns a result per entity in the same order.
+	// When stopOnError is true the entities after the first error are not saved.
+	// The error is only returned when the whole operation fails
+	CreateMany(ctx context.Context, collection string, entities []interface{}, stopOnError bool) ([]BulkResult, error)
+	// PatchMany applies every patch, like Patch, and returns a result per patch in the same order
+	PatchMany(ctx context.Context, collection string, patches []BulkPatch, stopOnError bool) ([]BulkResult, error)
+	// DeleteMany removes every item, like Delete, and returns a result per id in the same order
+	DeleteMany(ctx context.Context, collection string, ids []string, stopOnError bool) ([]BulkResult, error)
+	// WithTransaction calls fn with a repository whose writes are applied all together when fn
+	// returns nil and discarded when it returns an error, which is returned by WithTransaction.
+	// fn must only use tx, the guarantees of each implementation are described in its documentation
+	WithTransaction(ctx context.Context, fn func(tx Repository) error) error
 }
 
 // OrganizationRepo is the commong interface for repository providers for the Organization model
@@ -1,55 +1,118 @@
 package ports
 
-import "github.com/sy-software/minerva-owl/internal/core/domain"
+import (
+	"context"
+
+	"github.com/sy-software/minerva-owl/internal/core/domain"
+)
 
 // OrganizationService is a common interface for a service provider for organization entity
 type OrganizationService interface {
 	// List returns a single page of items
-	List(page *int, pageSize *int) ([]domain.Organization, error)
+	List(ctx context.Context, page *int, pageSize *int, sort ...Sort) ([]domain.Organization, error)
+	// Search returns a single page of items matching all the filters
+	Search(ctx context.Context, filters []Filter, page *int, pageSize *int, sort ...Sort) ([]domain.Organization, error)
+	// ListPage returns up to first items after the cursor matching all the filters
+	ListPage(ctx context.Context, first *int, after *string, filters ...Filter) ([]domain.Organization, PageInfo, error)
+	// Count returns the number of items matching all the filters
+	Count(ctx context.Context, filters ...Filter) (int, error)
 	// Get returns a single item filter by id
-	Get(id string) (domain.Organization, error)
-	// Create saves a new organization item into the repository
-	Create(name string, Description string, logo string) (domain.Organization, error)
+	Get(ctx context.Context, id string) (domain.Organization, error)
+	// GetMany returns the items with any of the ids, missing ids are skipped
+	GetMany(ctx context.Context, ids []string) ([]domain.Organization, error)
+	// Create saves a new organization item into the repository with its default areas
+	// and, unless owner is empty, the membership of the owner user
+	Create(ctx context.Context, name string, Description string, logo string, owner string) (domain.Organization, error)
 	// Update looks for an existing item and update the values
-	Update(entity domain.Organization) (domain.Organization, error)
+	Update(ctx context.Context, entity domain.Organization) (domain.Organization, error)
+	// Patch changes only the fields in mask of the item with id
+	Patch(ctx context.Context, id string, mask FieldMask) (domain.Organization, error)
+	// CreateMany saves new organization items like Create, owners has the owner of each item.
+	// Returns a result per item
+	CreateMany(ctx context.Context, orgs []domain.Organization, owners []string, stopOnError bool) ([]BulkResult, error)
+	// PatchMany changes only the fields in the mask of each patch, returns a result per patch
+	PatchMany(ctx context.Context, patches []BulkPatch, stopOnError bool) ([]BulkResult, error)
+	// DeleteMany removes the items with the ids, returns a result per id
+	DeleteMany(ctx context.Context, ids []string, hard bool, stopOnError bool) ([]BulkResult, error)
 	// Delete removes the item with the specified id from the repo.
 	//
 	// If the hard parameter is false the value is only soft deleted
 	// and can be later restored.
-	Delete(id string, hard bool) error
+	Delete(ctx context.Context, id string, hard bool) error
 }
 
 // AreaService is a common interface for a service provider for Area entity
 type AreaService interface {
 	// List returns a single page of items
-	List(page *int, pageSize *int) ([]domain.Area, error)
+	List(ctx context.Context, page *int, pageSize *int) ([]domain.Area, error)
 	// List returns a single page of items filtered by Organization Id
-	ListByOrg(org string, page *int, pageSize *int) ([]domain.Area, error)
+	ListByOrg(ctx context.Context, org string, page *int, pageSize *int) ([]domain.Area, error)
 	// Get returns a single item filter by id
-	Get(id string) (domain.Area, error)
+	Get(ctx context.Context, id string) (domain.Area, error)
 	// Create saves a new organization item into the repository
-	Create(name string, Description string, logo string) (domain.Area, error)
+	Create(ctx context.Context, name string, Description string, logo string) (domain.Area, error)
 	// Update looks for an existing item and update the values
-	Update(entity domain.Area) (domain.Area, error)
+	Update(ctx context.Context, entity domain.Area) (domain.Area, error)
+	// Delete removes the item with the specified id from the repo.
+	//
+	// If the hard parameter is false the value is only soft deleted
+	// and can be later restored.
+	Delete(ctx context.Context, id string, hard bool) error
+}
+
+// TeamService is a common interface for a service provider for Team entity
+type TeamService interface {
+	// Get returns a single item filter by id
+	Get(ctx context.Context, id string) (domain.Team, error)
+	// GetMany returns the items with any of the ids, missing ids are skipped
+	GetMany(ctx context.Context, ids []string) ([]domain.Team, error)
+	// Create saves a new team item into an existing organization
+	Create(
+		ctx context.Context,
+		organization string,
+		name string,
+		description string,
+		leader string,
+		color string,
+		icon string,
+		techs []string,
+	) (domain.Team, error)
+	// AddTechs adds the techs missing from the team, it's applied atomically
+	AddTechs(ctx context.Context, id string, techs ...string) (domain.Team, error)
+	// RemoveTechs removes the techs from the team, it's applied atomically
+	RemoveTechs(ctx context.Context, id string, techs ...string) (domain.Team, error)
 	// Delete removes the item with the specified id from the repo.
 	//
 	// If the hard parameter is false the value is only soft deleted
 	// and can be later restored.
-	Delete(id string, hard bool) error
+	Delete(ctx context.Context, id string, hard bool) error
 }
 
 // AuthService is a common interface for a service provider for User entity
 type UserService interface {
 	// List returns a single page of items
-	List(page *int, pageSize *int) ([]domain.User, error)
+	List(ctx context.Context, page *int, pageSize *int, sort ...Sort) ([]domain.User, error)
 	// List returns a single page of items filtered by their role
-	ListByRole(role string, page *int, pageSize *int) ([]domain.User, error)
+	ListByRole(ctx context.Context, role string, page *int, pageSize *int, sort ...Sort) ([]domain.User, error)
+	// Search returns a single page of items matching all the filters
+	Search(ctx context.Context, filters []Filter, page *int, pageSize *int, sort ...Sort) ([]domain.User, error)
+	// ListPage returns up to first items after the cursor matching all the filters
+	ListPage(ctx context.Context, first *int, after *string, filters ...Filter) ([]domain.User, PageInfo, error)
+	// ListPageByRole returns up to first items after the cursor filtered by their role
+	ListPageByRole(ctx context.Context, role string, first *int, after *string) ([]domain.User, PageInfo, error)
+	// Count returns the number of items matching all the filters
+	Count(ctx context.Context, filters ...Filter) (int, error)
+	// CountByRole returns the number of items with the given role
+	CountByRole(ctx context.Context, role string) (int, error)
 	// Get returns a single item filter by id
-	Get(id string) (domain.User, error)
+	Get(ctx context.Context, id string) (domain.User, error)
+	// GetMany returns the items with any of the ids, missing ids are skipped
+	GetMany(ctx context.Context, ids []string) ([]domain.User, error)
 	// Get returns a single item filter by their username
-	GetByUsername(username string) (domain.User, error)
+	GetByUsername(ctx context.Context, username string) (domain.User, error)
 	// Create saves a new organization item into the repository
 	Create(
+		ctx context.Context,
 		name string,
 		username string,
 		picture string,
@@ -58,11 +121,31 @@ type UserService interface {
 		tokenID string,
 		status string,
 	) (domain.User, error)
+	// UpsertByUsername creates the user if the username doesn't exist, otherwise updates it.
+	// Returns true when the user was created
+	UpsertByUsername(
+		ctx context.Context,
+		name string,
+		username string,
+		picture string,
+		role string,
+		provider string,
+		tokenID string,
+		status string,
+	) (domain.User, bool, error)
 	// Update looks for an existing item and update the values
-	Update(entity domain.User) (domain.User, error)
+	Update(ctx context.Context, entity domain.User) (domain.User, error)
+	// Patch changes only the fields in mask of the item with id
+	Patch(ctx context.Context, id string, mask FieldMask) (domain.User, error)
+	// CreateMany saves new user items, returns a result per item
+	CreateMany(ctx context.Context, users []domain.User, stopOnError bool) ([]BulkResult, error)
+	// PatchMany changes only the fields in the mask of each patch, returns a result per patch
+	PatchMany(ctx context.Context, patches []BulkPatch, stopOnError bool) ([]BulkResult, error)
+	// DeleteMany removes the items with the ids, returns a result per id
+	DeleteMany(ctx context.Context, ids []string, hard bool, stopOnError bool) ([]BulkResult, error)
 	// Delete removes the item with the specified id from the repo.
 	//
 	// If the hard parameter is false the value is only soft deleted
 	// and can be later restored.
-	Delete(id string, hard bool) error
+	Delete(ctx context.Context, id string, hard bool) error
 }
@@ -0,0 +1,174 @@
+package ports
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Update operators supported by FieldMask
+const (
+	// UPDATE_SET replaces the value of a field
+	UPDATE_SET = "set"
+	// UPDATE_UNSET removes a field
+	UPDATE_UNSET = "unset"
+	// UPDATE_ADD_TO_SET appends values to an array field unless it already contains them
+	UPDATE_ADD_TO_SET = "addToSet"
+	// UPDATE_PULL removes every element equal to any of the values from an array field
+	UPDATE_PULL = "pull"
+	// UPDATE_PUSH inserts values into an array field
+	UPDATE_PUSH = "push"
+	// UPDATE_INC adds an amount to a numeric field
+	UPDATE_INC = "inc"
+)
+
+// ErrInvalidFieldMask must be thrown when a repository can't apply a field mask
+type ErrInvalidFieldMask struct {
+	// The field that can't be changed
+	Field string
+	// Why the field can't be changed
+	Reason string
+}
+
+func (err ErrInvalidFieldMask) Error() string {
+	return fmt.Sprintf("can't update %q: %v", err.Field, err.Reason)
+}
+
+// FieldMask describes a partial update, only the fields in the mask are changed
+// and every other field keeps its stored value. The whole mask is applied atomically.
+//
+// Field names use the same names as filters (E.G.: the bson tag of the domain model),
+// nested fields are separated by dots
+type FieldMask struct {
+	// Set replaces the value of each field
+	Set map[string]interface{}
+	// Unset clears each field, it's removed from the stored item
+	Unset []string
+	// AddToSet appends the values missing from each array field, missing fields are created
+	AddToSet map[string][]interface{}
+	// Pull removes from each array field every element equal to any of the values
+	Pull map[string][]interface{}
+	// Push inserts the values into each array field, missing fields are created
+	Push map[string]PushValues
+	// Inc adds the amount to each numeric field, missing fields start at zero
+	Inc map[string]int64
+}
+
+// PushValues are the values inserted into an array field by FieldMask.Push
+type PushValues struct {
+	Values []interface{}
+	// Position is the index where the values are inserted, nil appends them at the end.
+	// Negative positions count from the end of the array
+	Position *int
+}
+
+// Push creates PushValues appending values at the end of an array
+func Push(values ...interface{}) PushValues {
+	return PushValues{Values: values}
+}
+
+// PushAt creates PushValues inserting values at position of an array
+func PushAt(position int, values ...interface{}) PushValues {
+	return PushValues{Values: values, Position: &position}
+}
+
+// IsEmpty tells if the mask doesn't change any field
+func (mask FieldMask) IsEmpty() bool {
+	return len(mask.Operators()) == 0
+}
+
+// Operators returns the operator used for each field changed by the mask,
+// a field changed by two operators is reported with ErrInvalidFieldMask by Validate
+func (mask FieldMask) Operators() map[string]string {
+	output := map[string]string{}
+	for field := range mask.Set {
+		output[field] = UPDATE_SET
+	}
+
+	for _, field := range mask.Unset {
+		output[field] = UPDATE_UNSET
+	}
+
+	for field := range mask.AddToSet {
+		output[field] = UPDATE_ADD_TO_SET
+	}
+
+	for field := range mask.Pull {
+		output[field] = UPDATE_PULL
+	}
+
+	for field := range mask.Push {
+		output[field] = UPDATE_PUSH
+	}
+
+	for field := range mask.Inc {
+		output[field] = UPDATE_INC
+	}
+
+	return output
+}
+
+// Fields returns the sorted names of every field changed by the mask
+func (mask FieldMask) Fields() []string {
+	fields := make([]string, 0, len(mask.Set)+len(mask.Unset))
+	for field := range mask.Set {
+		fields = append(fields, field)
+	}
+
+	fields = append(fields, mask.Unset...)
+	for field := range mask.AddToSet {
+		fields = append(fields, field)
+	}
+
+	for field := range mask.Pull {
+		fields = append(fields, field)
+	}
+
+	for field := range mask.Push {
+		fields = append(fields, field)
+	}
+
+	for field := range mask.Inc {
+		fields = append(fields, field)
+	}
+
+	sort.Strings(fields)
+	return fields
+}
+
+// Validate checks the mask can be applied by any repository: field paths are valid,
+// ids can't be changed and a field, or its parent document, can't be changed twice
+func (mask FieldMask) Validate() error {
+	fields := mask.Fields()
+	changed := map[string]bool{}
+	for _, field := range fields {
+		if changed[field] {
+			return ErrInvalidFieldMask{Field: field, Reason: "field changed twice"}
+		}
+
+		changed[field] = true
+	}
+
+	for _, field := range fields {
+		if field == "" {
+			return ErrInvalidFieldMask{Reason: "missing field name"}
+		}
+
+		names := strings.Split(field, ".")
+		for index, name := range names {
+			if name == "" || strings.HasPrefix(name, "$") {
+				return ErrInvalidFieldMask{Field: field, Reason: "invalid field path"}
+			}
+
+			if parent := strings.Join(names[:index], "."); index > 0 && changed[parent] {
+				return ErrInvalidFieldMask{Field: field, Reason: fmt.Sprintf("conflicts with %q", parent)}
+			}
+		}
+
+		if field == "_id" || field == "id" {
+			return ErrInvalidFieldMask{Field: field, Reason: "ids can't be changed"}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,49 @@
+package service
+
+import (
+	"github.com/sy-software/minerva-owl/internal/core/ports"
+)
+
+// validatedBulk validates every item in order and calls run with the indexes of the valid ones,
+// run must return a result per index. validate returns the id reported for the item and its error.
+//
+// When stopOnError is true only the items before the first invalid one are processed
+// and the following items are aborted
+func validatedBulk(
+	size int,
+	stopOnError bool,
+	validate func(index int) (string, error),
+	run func(indexes []int) ([]ports.BulkResult, error),
+) ([]ports.BulkResult, error) {
+	results := make([]ports.BulkResult, size)
+	indexes := []int{}
+	for index := range results {
+		id, err := validate(index)
+		results[index].Id = id
+		if err == nil {
+			indexes = append(indexes, index)
+			continue
+		}
+
+		results[index].Err = err
+		if stopOnError {
+			ports.AbortBulk(results[index+1:])
+			break
+		}
+	}
+
+	if len(indexes) == 0 {
+		return results, nil
+	}
+
+	processed, err := run(indexes)
+	if err != nil {
+		return nil, err
+	}
+
+	for position, index := range indexes {
+		results[index] = processed[position]
+	}
+
+	return results, nil
+}
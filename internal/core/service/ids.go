@@ -0,0 +1,122 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/oklog/ulid/v2"
+	"github.com/rs/zerolog/log"
+	"github.com/sy-software/minerva-owl/internal/core/domain"
+)
+
+// ID generation strategies available in domain.Config.IDGenerator
+const (
+	// OBJECTID_GENERATOR creates 24 hex characters ids compatible with MongoDB ObjectIDs
+	OBJECTID_GENERATOR = "objectid"
+	// UUID_GENERATOR creates random V4 UUIDs
+	UUID_GENERATOR = "uuid"
+	// ULID_GENERATOR creates lexicographically sortable ULIDs
+	ULID_GENERATOR = "ulid"
+)
+
+// IDGenerator creates the ids of new entities, ids are opaque strings
+// for the repositories so data can be moved between storage backends
+type IDGenerator interface {
+	NewID() string
+}
+
+// NewIDGenerator creates the IDGenerator of a strategy
+func NewIDGenerator(strategy string) (IDGenerator, error) {
+	switch strategy {
+	case OBJECTID_GENERATOR:
+		return newObjectIDGenerator(), nil
+	case UUID_GENERATOR:
+		return uuidGenerator{}, nil
+	case ULID_GENERATOR:
+		return &ulidGenerator{
+			entropy: ulid.Monotonic(rand.Reader, 0),
+		}, nil
+	}
+
+	return nil, fmt.Errorf(
+		"unknown id generator %q, available generators: %s, %s, %s",
+		strategy,
+		OBJECTID_GENERATOR,
+		UUID_GENERATOR,
+		ULID_GENERATOR,
+	)
+}
+
+// idGenerator returns the IDGenerator configured in config, the ObjectID generator is used
+// if the configuration is invalid, use NewIDGenerator to validate it at startup
+func idGenerator(config domain.Config) IDGenerator {
+	generator, err := NewIDGenerator(config.IDGenerator)
+	if err != nil {
+		log.Error().Err(err).Msgf("Using the %s id generator", OBJECTID_GENERATOR)
+		return newObjectIDGenerator()
+	}
+
+	return generator
+}
+
+// objectIDGenerator creates ids with the MongoDB ObjectID layout: a 4 bytes timestamp,
+// 5 random bytes unique to the process and a 3 bytes counter
+type objectIDGenerator struct {
+	process [5]byte
+	counter uint32
+}
+
+func newObjectIDGenerator() *objectIDGenerator {
+	generator := &objectIDGenerator{}
+	var counter [4]byte
+	if _, err := io.ReadFull(rand.Reader, generator.process[:]); err != nil {
+		panic(fmt.Errorf("can't read random bytes: %w", err))
+	}
+
+	if _, err := io.ReadFull(rand.Reader, counter[:]); err != nil {
+		panic(fmt.Errorf("can't read random bytes: %w", err))
+	}
+
+	generator.counter = binary.BigEndian.Uint32(counter[:])
+	return generator
+}
+
+func (generator *objectIDGenerator) NewID() string {
+	var id [12]byte
+	binary.BigEndian.PutUint32(id[0:4], uint32(time.Now().Unix()))
+	copy(id[4:9], generator.process[:])
+
+	counter := atomic.AddUint32(&generator.counter, 1)
+	id[9] = byte(counter >> 16)
+	id[10] = byte(counter >> 8)
+	id[11] = byte(counter)
+
+	return hex.EncodeToString(id[:])
+}
+
+// uuidGenerator creates random V4 UUIDs
+type uuidGenerator struct{}
+
+func (uuidGenerator) NewID() string {
+	return uuid.New().String()
+}
+
+// ulidGenerator creates ULIDs which are sorted by creation time even within the same millisecond
+type ulidGenerator struct {
+	entropy io.Reader
+	mutex   sync.Mutex
+}
+
+func (generator *ulidGenerator) NewID() string {
+	generator.mutex.Lock()
+	defer generator.mutex.Unlock()
+
+	return ulid.MustNew(ulid.Timestamp(time.Now()), generator.entropy).String()
+}
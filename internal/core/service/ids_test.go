@@ -0,0 +1,69 @@
+package service
+
+import (
+	"regexp"
+	"sort"
+	"testing"
+
+	"github.com/sy-software/minerva-owl/internal/core/domain"
+)
+
+func TestIDGenerators(t *testing.T) {
+	tests := []struct {
+		strategy string
+		regex    string
+	}{
+		{OBJECTID_GENERATOR, "^[0-9a-f]{24}$"},
+		{UUID_GENERATOR, "^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$"},
+		{ULID_GENERATOR, "^[0-9A-HJKMNP-TV-Z]{26}$"},
+	}
+
+	for _, test := range tests {
+		t.Run("Test "+test.strategy+" ids", func(t *testing.T) {
+			generator, err := NewIDGenerator(test.strategy)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			regex := regexp.MustCompile(test.regex)
+			ids := map[string]bool{}
+			for i := 0; i < 1000; i++ {
+				id := generator.NewID()
+				if !regex.MatchString(id) {
+					t.Fatalf("Expected id matching: %q got: %q", test.regex, id)
+				}
+
+				if ids[id] {
+					t.Fatalf("Expected unique ids got %q twice", id)
+				}
+
+				ids[id] = true
+			}
+		})
+	}
+
+	t.Run("Test ULID ids are sorted by creation", func(t *testing.T) {
+		generator, _ := NewIDGenerator(ULID_GENERATOR)
+		ids := []string{}
+		for i := 0; i < 1000; i++ {
+			ids = append(ids, generator.NewID())
+		}
+
+		if !sort.StringsAreSorted(ids) {
+			t.Errorf("Expected sorted ids got: %v", ids)
+		}
+	})
+
+	t.Run("Test unknown generator", func(t *testing.T) {
+		if _, err := NewIDGenerator("sequence"); err == nil {
+			t.Errorf("Expected error for unknown generator got nil")
+		}
+
+		config := domain.DefaultConfig()
+		config.IDGenerator = "sequence"
+		id := idGenerator(config).NewID()
+		if !regexp.MustCompile("^[0-9a-f]{24}$").MatchString(id) {
+			t.Errorf("Expected ObjectID fallback got: %q", id)
+		}
+	})
+}
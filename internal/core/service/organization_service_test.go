@@ -1,16 +1,19 @@
 package service
 
 import (
+	"context"
 	"regexp"
 	"strconv"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/sy-software/minerva-owl/internal/core/domain"
+	"github.com/sy-software/minerva-owl/internal/core/ports"
 	"github.com/sy-software/minerva-owl/mocks"
 )
 
 func TestOrganizationIsCreated(t *testing.T) {
+	ctx := context.Background()
 	expected := domain.Organization{
 		Name:        "name",
 		Description: "description",
@@ -25,12 +28,11 @@ func TestOrganizationIsCreated(t *testing.T) {
 		Data: data,
 	}
 
-	service := OrganizationService{
-		repository: &repo,
-		config:     domain.DefaultConfig(),
-	}
+	config := domain.DefaultConfig()
+	config.IDGenerator = UUID_GENERATOR
+	service := NewOrgService(&repo, config)
 
-	created, err := service.Create(expected.Name, expected.Description, expected.Logo)
+	created, err := service.Create(ctx, expected.Name, expected.Description, expected.Logo, "")
 
 	if err != nil {
 		t.Errorf("Item should be created without errors: %v", err)
@@ -66,7 +68,67 @@ func TestOrganizationIsCreated(t *testing.T) {
 	}
 }
 
+func TestOrganizationIsCreatedWithAreasAndOwner(t *testing.T) {
+	ctx := context.Background()
+	repo := mocks.MemRepo{
+		Data: map[string][]map[string]interface{}{
+			"users": {{"id": "owner", "username": "tstark"}},
+		},
+	}
+
+	config := domain.DefaultConfig()
+	config.DefaultAreas = []string{"Engineering", "Design", "Sales"}
+	service := NewOrgService(&repo, config)
+
+	t.Run("Areas and membership are saved with the organization", func(t *testing.T) {
+		created, err := service.Create(ctx, "Stark Industries", "description", "", "owner")
+		if err != nil {
+			t.Fatalf("Item should be created without errors: %v", err)
+		}
+
+		areas := repo.Data["areas"]
+		if len(areas) != len(config.DefaultAreas) {
+			t.Errorf("Expected %d areas got: %+v", len(config.DefaultAreas), areas)
+		}
+
+		for index, area := range areas {
+			if area["name"] != config.DefaultAreas[index] || area["organization"] != created.Id {
+				t.Errorf("Expected area %q of the organization got: %+v", config.DefaultAreas[index], area)
+			}
+		}
+
+		memberships := repo.Data["memberships"]
+		if len(memberships) != 1 || memberships[0]["user"] != "owner" || memberships[0]["organization"] != created.Id || memberships[0]["role"] != domain.ORG_ROLE_OWNER {
+			t.Errorf("Expected owner membership got: %+v", memberships)
+		}
+	})
+
+	t.Run("Nothing is saved when the owner doesn't exist", func(t *testing.T) {
+		_, err := service.Create(ctx, "Oscorp", "description", "", "missing")
+		if _, ok := err.(ports.ErrItemNotFound); !ok {
+			t.Errorf("Expected error of type ErrItemNotFound got: %v", err)
+		}
+
+		if len(repo.Data["organizations"]) != 1 || len(repo.Data["areas"]) != len(config.DefaultAreas) {
+			t.Errorf("Expected no new items got: %+v", repo.Data)
+		}
+	})
+
+	t.Run("Bulk creation uses a transaction per organization", func(t *testing.T) {
+		orgs := []domain.Organization{{Name: "Avengers"}, {Name: "X-Men"}}
+		results, err := service.CreateMany(ctx, orgs, []string{"missing", "owner"}, false)
+		if err != nil || results[0].Err == nil || results[1].Err != nil {
+			t.Fatalf("Expected only the organization with a missing owner to fail got: %+v with error: %v", results, err)
+		}
+
+		if len(repo.Data["organizations"]) != 2 || len(repo.Data["areas"]) != 2*len(config.DefaultAreas) || len(repo.Data["memberships"]) != 2 {
+			t.Errorf("Expected the second organization to be saved with its areas and owner got: %+v", repo.Data)
+		}
+	})
+}
+
 func TestOrganizationIsRead(t *testing.T) {
+	ctx := context.Background()
 	expected := []domain.Organization{
 		{
 			Id:          "1",
@@ -116,7 +178,7 @@ func TestOrganizationIsRead(t *testing.T) {
 	}
 
 	t.Run("Get a list of Organizations", func(t *testing.T) {
-		got, err := service.List(nil, nil)
+		got, err := service.List(ctx, nil, nil)
 
 		if err != nil {
 			t.Errorf("Got error while getting all organizations: %v", err)
@@ -133,7 +195,7 @@ func TestOrganizationIsRead(t *testing.T) {
 
 	t.Run("Get an Organization by id", func(t *testing.T) {
 		for _, expect := range expected {
-			got, err := service.Get(expect.Id)
+			got, err := service.Get(ctx, expect.Id)
 			if err != nil {
 				t.Errorf(
 					"Got error while getting organization by id: %v, Error: %v",
@@ -150,7 +212,7 @@ func TestOrganizationIsRead(t *testing.T) {
 
 	t.Run("Get an Organization by a not existing Id", func(t *testing.T) {
 		expectedError := "can't find organizations with Id: not_exists_id"
-		_, err := service.Get("not_exists_id")
+		_, err := service.Get(ctx, "not_exists_id")
 		if err == nil {
 			t.Errorf("Expected 'ErrItemNotFound' error got: %v", err)
 		}
@@ -162,6 +224,7 @@ func TestOrganizationIsRead(t *testing.T) {
 }
 
 func TestPagination(t *testing.T) {
+	ctx := context.Background()
 	dummydata := make([]domain.Organization, 20)
 	dummyDict := make([]map[string]interface{}, 20)
 
@@ -204,7 +267,7 @@ func TestPagination(t *testing.T) {
 			},
 		}
 
-		got, err := service.List(nil, &pageSize)
+		got, err := service.List(ctx, nil, &pageSize)
 
 		if err != nil {
 			t.Errorf("Got error while getting all organizations: %v", err)
@@ -234,7 +297,7 @@ func TestPagination(t *testing.T) {
 			},
 		}
 
-		got, err := service.List(&page, &pageSize)
+		got, err := service.List(ctx, &page, &pageSize)
 
 		if err != nil {
 			t.Errorf("Got error while getting all organizations: %v", err)
@@ -265,7 +328,7 @@ func TestPagination(t *testing.T) {
 			},
 		}
 
-		got, err := service.List(&page, &pageSize)
+		got, err := service.List(ctx, &page, &pageSize)
 
 		if err != nil {
 			t.Errorf("Got error while getting all organizations: %v", err)
@@ -297,7 +360,7 @@ func TestPagination(t *testing.T) {
 			},
 		}
 
-		got, err := service.List(&page, &pageSize)
+		got, err := service.List(ctx, &page, &pageSize)
 
 		if err != nil {
 			t.Errorf("Got error while getting all organizations: %v", err)
@@ -324,7 +387,7 @@ func TestPagination(t *testing.T) {
 			},
 		}
 
-		got, err := service.List(&page, &pageSize)
+		got, err := service.List(ctx, &page, &pageSize)
 
 		if err != nil {
 			t.Errorf("Got error while getting all organizations: %v", err)
@@ -341,6 +404,7 @@ func TestPagination(t *testing.T) {
 }
 
 func TestOrganizationIsUpdated(t *testing.T) {
+	ctx := context.Background()
 	base := []map[string]interface{}{
 		{
 			"id":          "1",
@@ -384,7 +448,7 @@ func TestOrganizationIsUpdated(t *testing.T) {
 		config:     domain.DefaultConfig(),
 	}
 
-	got, err := service.Update(expected[0])
+	got, err := service.Update(ctx, expected[0])
 
 	if err != nil {
 		t.Errorf("Got error while getting updating organizations: %v", err)
@@ -395,7 +459,7 @@ func TestOrganizationIsUpdated(t *testing.T) {
 	}
 
 	for _, expect := range expected {
-		got, _ := service.Get(expect.Id)
+		got, _ := service.Get(ctx, expect.Id)
 
 		if !cmp.Equal(got, expect) {
 			t.Errorf("Expected item to be: %v got: %v", expect, got)
@@ -403,7 +467,71 @@ func TestOrganizationIsUpdated(t *testing.T) {
 	}
 }
 
+func TestOrganizationIsPatched(t *testing.T) {
+	ctx := context.Background()
+	base := []map[string]interface{}{
+		{
+			"id":          "1",
+			"name":        "name 1",
+			"description": "description 1",
+			"logo":        "logo 1",
+		},
+	}
+
+	repo := mocks.MemRepo{
+		Data: map[string][]map[string]interface{}{
+			"organizations": base,
+		},
+	}
+
+	service := NewOrgService(&repo, domain.DefaultConfig())
+
+	t.Run("Set and unset fields", func(t *testing.T) {
+		expected := domain.Organization{
+			Id:          "1",
+			Name:        "name updated",
+			Description: "description 1",
+		}
+
+		got, err := service.Patch(ctx, "1", ports.FieldMask{
+			Set:   map[string]interface{}{"name": "name updated"},
+			Unset: []string{"logo"},
+		})
+
+		if err != nil || !cmp.Equal(got, expected) {
+			t.Errorf("Expected item to be: %v got: %v with error: %v", expected, got, err)
+		}
+	})
+
+	t.Run("Invalid field masks", func(t *testing.T) {
+		masks := []ports.FieldMask{
+			{Set: map[string]interface{}{"owner": "me"}},
+			{Unset: []string{"name"}},
+			{Set: map[string]interface{}{"_id": "2"}},
+			{AddToSet: map[string][]interface{}{"owner": {"me"}}},
+			{Pull: map[string][]interface{}{"_id": {"1"}}},
+			{Push: map[string]ports.PushValues{"createDate": ports.Push("now")}},
+			{Inc: map[string]int64{"status": 1}},
+		}
+
+		for _, mask := range masks {
+			_, err := service.Patch(ctx, "1", mask)
+			if _, ok := err.(ports.ErrInvalidFieldMask); !ok {
+				t.Errorf("Expected error of type ErrInvalidFieldMask for %+v got: %v", mask, err)
+			}
+		}
+	})
+
+	t.Run("Missing item", func(t *testing.T) {
+		_, err := service.Patch(ctx, "2", ports.FieldMask{Set: map[string]interface{}{"name": "name"}})
+		if _, ok := err.(ports.ErrItemNotFound); !ok {
+			t.Errorf("Expected error of type ErrItemNotFound got: %v", err)
+		}
+	})
+}
+
 func TestOrganizationIsDeleted(t *testing.T) {
+	ctx := context.Background()
 	base := []map[string]interface{}{
 		{
 			"id":          "1",
@@ -441,23 +569,64 @@ func TestOrganizationIsDeleted(t *testing.T) {
 		config:     domain.DefaultConfig(),
 	}
 
-	err := service.Delete("1", false)
+	err := service.Delete(ctx, "1", false)
 
 	if err != nil {
 		t.Errorf("Got error while getting updating organizations: %v", err)
 	}
 
-	all, err := service.List(nil, nil)
+	all, err := service.List(ctx, nil, nil)
 
 	if len(all) != len(expected) {
 		t.Errorf("Excted to have %d items got %d", len(expected), len(all))
 	}
 
 	for _, expect := range expected {
-		got, _ := service.Get(expect.Id)
+		got, _ := service.Get(ctx, expect.Id)
 
 		if !cmp.Equal(got, expect) {
 			t.Errorf("Expected item to be: %v got: %v", expect, got)
 		}
 	}
 }
+
+func TestOrganizationBulkOperations(t *testing.T) {
+	ctx := context.Background()
+	repo := mocks.MemRepo{
+		Data: map[string][]map[string]interface{}{
+			"organizations": {},
+		},
+	}
+
+	service := NewOrgService(&repo, domain.DefaultConfig())
+	orgs := []domain.Organization{{Name: "Avengers"}, {Name: "X-Men"}}
+
+	results, err := service.CreateMany(ctx, orgs, nil, true)
+	if err != nil || len(results) != 2 || results[0].Err != nil || results[1].Err != nil {
+		t.Fatalf("Expected organizations to be created got: %+v with error: %v", results, err)
+	}
+
+	t.Run("Invalid masks stop the following patches", func(t *testing.T) {
+		patches := []ports.BulkPatch{
+			{Id: results[0].Id, Mask: ports.FieldMask{Set: map[string]interface{}{"logo": "shield"}}},
+			{Id: results[1].Id, Mask: ports.FieldMask{Unset: []string{"name"}}},
+			{Id: results[1].Id, Mask: ports.FieldMask{Set: map[string]interface{}{"logo": "x"}}},
+		}
+
+		got, err := service.PatchMany(ctx, patches, true)
+		if _, ok := got[1].Err.(ports.ErrInvalidFieldMask); err != nil || got[0].Err != nil || !ok || got[2].Err != ports.ErrBulkAborted {
+			t.Errorf("Expected the patches after the invalid mask to be aborted got: %+v with error: %v", got, err)
+		}
+
+		if org, _ := service.Get(ctx, results[1].Id); org.Logo != "" {
+			t.Errorf("Expected aborted patch to not be applied got: %+v", org)
+		}
+	})
+
+	t.Run("Delete many", func(t *testing.T) {
+		got, err := service.DeleteMany(ctx, []string{results[0].Id, results[1].Id}, false, true)
+		if err != nil || got[0].Err != nil || got[1].Err != nil || len(repo.Data["organizations"]) != 0 {
+			t.Errorf("Expected organizations to be deleted got: %+v with error: %v", got, err)
+		}
+	})
+}
@@ -1,54 +1,220 @@
 package service
 
 import (
+	"context"
+
 	"github.com/sy-software/minerva-owl/internal/core/domain"
 	"github.com/sy-software/minerva-owl/internal/core/ports"
+	"github.com/sy-software/minerva-owl/internal/utils"
 )
 
-const orgCollectionName = "organizations"
+const orgCollectionName = domain.ORG_COL_NAME
+const areaCollectionName = "areas"
+const membershipCollectionName = "memberships"
+
+// ORG_SORT_FIELDS are the fields organizations can be sorted by
+var ORG_SORT_FIELDS = map[string]bool{
+	"name":        true,
+	"description": true,
+}
+
+// ORG_UPDATE_FIELDS are the fields organizations can be patched by, true when the field can be cleared
+var ORG_UPDATE_FIELDS = map[string]bool{
+	"name":        false,
+	"description": false,
+	"logo":        true,
+}
 
 type OrganizationService struct {
 	repository ports.Repository
 	config     domain.Config
+	ids        IDGenerator
 }
 
 func NewOrgService(repo ports.Repository, config domain.Config) *OrganizationService {
 	return &OrganizationService{
 		repository: repo,
 		config:     config,
+		ids:        idGenerator(config),
+	}
+}
+
+// List returns a page of organizations sorted by the ORG_SORT_FIELDS in sort
+func (srv *OrganizationService) List(ctx context.Context, page *int, pageSize *int, sort ...ports.Sort) ([]domain.Organization, error) {
+	results := []domain.Organization{}
+	if err := validateSort(sort, ORG_SORT_FIELDS); err != nil {
+		return results, err
 	}
+
+	_, pageSizeVal, skip := pagination(page, pageSize, srv.config)
+	err := srv.repository.List(ctx, orgCollectionName, &results, skip, pageSizeVal, sort)
+
+	return results, err
 }
 
-func (srv *OrganizationService) List(page *int, pageSize *int) ([]domain.Organization, error) {
+// Search returns a page of the organizations matching all the filters
+func (srv *OrganizationService) Search(ctx context.Context, filters []ports.Filter, page *int, pageSize *int, sort ...ports.Sort) ([]domain.Organization, error) {
 	results := []domain.Organization{}
+	if err := validateSort(sort, ORG_SORT_FIELDS); err != nil {
+		return results, err
+	}
+
 	_, pageSizeVal, skip := pagination(page, pageSize, srv.config)
-	err := srv.repository.List(orgCollectionName, &results, skip, pageSizeVal)
+	err := srv.repository.List(ctx, orgCollectionName, &results, skip, pageSizeVal, sort, filters...)
 
 	return results, err
 }
 
-func (srv *OrganizationService) Get(id string) (domain.Organization, error) {
+// ListPage returns up to first organizations after the cursor matching all the filters,
+// first is normalized with the pagination settings
+func (srv *OrganizationService) ListPage(ctx context.Context, first *int, after *string, filters ...ports.Filter) ([]domain.Organization, ports.PageInfo, error) {
+	results := []domain.Organization{}
+	_, pageSizeVal, _ := pagination(nil, first, srv.config)
+	pageInfo, err := srv.repository.ListPage(ctx, orgCollectionName, &results, utils.CoalesceStr(after, ""), pageSizeVal, filters...)
+
+	return results, pageInfo, err
+}
+
+// Count returns the number of organizations matching all the filters
+func (srv *OrganizationService) Count(ctx context.Context, filters ...ports.Filter) (int, error) {
+	return srv.repository.Count(ctx, orgCollectionName, filters...)
+}
+
+func (srv *OrganizationService) Get(ctx context.Context, id string) (domain.Organization, error) {
 	result := domain.Organization{}
-	err := srv.repository.Get(orgCollectionName, id, &result)
+	err := srv.repository.Get(ctx, orgCollectionName, id, &result)
 	return result, err
 }
 
-func (srv *OrganizationService) Create(name string, description string, logo string) (domain.Organization, error) {
+// GetMany returns the organizations with any of the ids in no particular order, missing ids are skipped
+func (srv *OrganizationService) GetMany(ctx context.Context, ids []string) ([]domain.Organization, error) {
+	results := []domain.Organization{}
+	err := srv.repository.GetMany(ctx, orgCollectionName, ids, &results)
+	return results, err
+}
+
+// Create saves a new organization with the configured default areas and, unless owner is empty,
+// the membership of the owner user. Everything is saved in a single transaction
+func (srv *OrganizationService) Create(ctx context.Context, name string, description string, logo string, owner string) (domain.Organization, error) {
 	entity := domain.Organization{
+		Id:          srv.ids.NewID(),
 		Name:        name,
 		Description: description,
 		Logo:        logo,
 	}
 
-	newId, err := srv.repository.Create(orgCollectionName, &entity)
-	entity.Id = newId
+	err := srv.repository.WithTransaction(ctx, func(tx ports.Repository) error {
+		return srv.create(ctx, tx, entity, owner)
+	})
+
 	return entity, err
 }
 
-func (srv *OrganizationService) Update(entity domain.Organization) (domain.Organization, error) {
-	return entity, srv.repository.Update(orgCollectionName, entity.Id, &entity)
+// create saves org, its default areas and the owner membership using tx
+func (srv *OrganizationService) create(ctx context.Context, tx ports.Repository, org domain.Organization, owner string) error {
+	if owner != "" {
+		if err := tx.Get(ctx, userCollectionName, owner, &domain.User{}); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.Create(ctx, orgCollectionName, &org); err != nil {
+		return err
+	}
+
+	for _, name := range srv.config.DefaultAreas {
+		area := domain.Area{
+			Id:           srv.ids.NewID(),
+			Name:         name,
+			Organization: org.Id,
+		}
+
+		if _, err := tx.Create(ctx, areaCollectionName, &area); err != nil {
+			return err
+		}
+	}
+
+	if owner == "" {
+		return nil
+	}
+
+	_, err := tx.Create(ctx, membershipCollectionName, &domain.Membership{
+		Id:           srv.ids.NewID(),
+		Organization: org.Id,
+		User:         owner,
+		Role:         domain.ORG_ROLE_OWNER,
+	})
+
+	return err
+}
+
+func (srv *OrganizationService) Update(ctx context.Context, entity domain.Organization) (domain.Organization, error) {
+	return entity, srv.repository.Update(ctx, orgCollectionName, entity.Id, &entity)
+}
+
+// Patch changes only the fields in mask of the organization with id, the ORG_UPDATE_FIELDS,
+// and returns the updated organization
+func (srv *OrganizationService) Patch(ctx context.Context, id string, mask ports.FieldMask) (domain.Organization, error) {
+	if err := validateFieldMask(mask, ORG_UPDATE_FIELDS); err != nil {
+		return domain.Organization{}, err
+	}
+
+	if err := srv.repository.Patch(ctx, orgCollectionName, id, mask); err != nil {
+		return domain.Organization{}, err
+	}
+
+	return srv.Get(ctx, id)
+}
+
+func (srv *OrganizationService) Delete(ctx context.Context, id string, hard bool) error {
+	return srv.repository.Delete(ctx, orgCollectionName, id)
+}
+
+// CreateMany saves new organizations like Create, each one in its own transaction, and returns
+// a result per organization. owners has the owner of each organization, it can be nil
+func (srv *OrganizationService) CreateMany(ctx context.Context, orgs []domain.Organization, owners []string, stopOnError bool) ([]ports.BulkResult, error) {
+	return ports.RunBulk(len(orgs), stopOnError, func(index int) (string, error) {
+		org := orgs[index]
+		org.Id = srv.ids.NewID()
+
+		owner := ""
+		if index < len(owners) {
+			owner = owners[index]
+		}
+
+		err := srv.repository.WithTransaction(ctx, func(tx ports.Repository) error {
+			return srv.create(ctx, tx, org, owner)
+		})
+
+		if err != nil {
+			return "", err
+		}
+
+		return org.Id, nil
+	}), nil
+}
+
+// PatchMany changes only the fields in the mask of each patch, the ORG_UPDATE_FIELDS,
+// patches with an invalid mask are not sent to the repository
+func (srv *OrganizationService) PatchMany(ctx context.Context, patches []ports.BulkPatch, stopOnError bool) ([]ports.BulkResult, error) {
+	return validatedBulk(
+		len(patches),
+		stopOnError,
+		func(index int) (string, error) {
+			return patches[index].Id, validateFieldMask(patches[index].Mask, ORG_UPDATE_FIELDS)
+		},
+		func(indexes []int) ([]ports.BulkResult, error) {
+			valid := make([]ports.BulkPatch, len(indexes))
+			for position, index := range indexes {
+				valid[position] = patches[index]
+			}
+
+			return srv.repository.PatchMany(ctx, orgCollectionName, valid, stopOnError)
+		},
+	)
 }
 
-func (srv *OrganizationService) Delete(id string, hard bool) error {
-	return srv.repository.Delete(orgCollectionName, id)
+// DeleteMany removes the organizations with the ids, returns a result per id
+func (srv *OrganizationService) DeleteMany(ctx context.Context, ids []string, hard bool, stopOnError bool) ([]ports.BulkResult, error) {
+	return srv.repository.DeleteMany(ctx, orgCollectionName, ids, stopOnError)
 }
@@ -2,6 +2,7 @@ package service
 
 import (
 	"github.com/sy-software/minerva-owl/internal/core/domain"
+	"github.com/sy-software/minerva-owl/internal/core/ports"
 	"github.com/sy-software/minerva-owl/internal/utils"
 )
 
@@ -26,3 +27,17 @@ func pagination(reqPage *int, reqPageSize *int, config domain.Config) (page int,
 
 	return
 }
+
+// validateSort checks every sort field is in the allowed list
+func validateSort(sort []ports.Sort, allowed map[string]bool) error {
+	for _, s := range sort {
+		if !allowed[s.Field] {
+			return ports.ErrInvalidSort{
+				Field:  s.Field,
+				Reason: "field is not sortable",
+			}
+		}
+	}
+
+	return nil
+}
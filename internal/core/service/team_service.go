@@ -0,0 +1,117 @@
+package service
+
+import (
+	"context"
+
+	"github.com/sy-software/minerva-owl/internal/core/domain"
+	"github.com/sy-software/minerva-owl/internal/core/ports"
+)
+
+const teamCollectionName = domain.TEAM_COL_NAME
+
+type TeamService struct {
+	repository ports.Repository
+	config     domain.Config
+	ids        IDGenerator
+}
+
+func NewTeamService(repo ports.Repository, config domain.Config) *TeamService {
+	return &TeamService{
+		repository: repo,
+		config:     config,
+		ids:        idGenerator(config),
+	}
+}
+
+func (srv *TeamService) Get(ctx context.Context, id string) (domain.Team, error) {
+	result := domain.Team{}
+	err := srv.repository.Get(ctx, teamCollectionName, id, &result)
+	return result, err
+}
+
+// GetMany returns the teams with any of the ids in no particular order, missing ids are skipped
+func (srv *TeamService) GetMany(ctx context.Context, ids []string) ([]domain.Team, error) {
+	results := []domain.Team{}
+	err := srv.repository.GetMany(ctx, teamCollectionName, ids, &results)
+	return results, err
+}
+
+// Create saves a new team into an existing organization
+func (srv *TeamService) Create(
+	ctx context.Context,
+	organization string,
+	name string,
+	description string,
+	leader string,
+	color string,
+	icon string,
+	techs []string,
+) (domain.Team, error) {
+	if err := srv.repository.Get(ctx, orgCollectionName, organization, &domain.Organization{}); err != nil {
+		return domain.Team{}, err
+	}
+
+	entity := domain.Team{
+		Id:           srv.ids.NewID(),
+		Name:         name,
+		Description:  description,
+		Organization: organization,
+		Leader:       leader,
+		Color:        color,
+		Icon:         icon,
+		Techs:        uniqueStrings(techs),
+	}
+
+	_, err := srv.repository.Create(ctx, teamCollectionName, &entity)
+	return entity, err
+}
+
+// AddTechs adds the techs missing from the team with id, concurrent changes are not lost
+func (srv *TeamService) AddTechs(ctx context.Context, id string, techs ...string) (domain.Team, error) {
+	return srv.patchTechs(ctx, id, ports.FieldMask{
+		AddToSet: map[string][]interface{}{"techs": stringValues(techs)},
+	})
+}
+
+// RemoveTechs removes the techs from the team with id, concurrent changes are not lost
+func (srv *TeamService) RemoveTechs(ctx context.Context, id string, techs ...string) (domain.Team, error) {
+	return srv.patchTechs(ctx, id, ports.FieldMask{
+		Pull: map[string][]interface{}{"techs": stringValues(techs)},
+	})
+}
+
+func (srv *TeamService) patchTechs(ctx context.Context, id string, mask ports.FieldMask) (domain.Team, error) {
+	if err := srv.repository.Patch(ctx, teamCollectionName, id, mask); err != nil {
+		return domain.Team{}, err
+	}
+
+	return srv.Get(ctx, id)
+}
+
+func (srv *TeamService) Delete(ctx context.Context, id string, hard bool) error {
+	return srv.repository.Delete(ctx, teamCollectionName, id)
+}
+
+// stringValues converts values into the type used by the field mask operators
+func stringValues(values []string) []interface{} {
+	output := make([]interface{}, len(values))
+	for index, value := range values {
+		output[index] = value
+	}
+
+	return output
+}
+
+// uniqueStrings returns values without duplicates keeping the first occurrence
+func uniqueStrings(values []string) []string {
+	seen := map[string]bool{}
+	output := []string{}
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			output = append(output, value)
+		}
+	}
+
+	return output
+}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sy-software/minerva-owl/internal/core/domain"
+	"github.com/sy-software/minerva-owl/internal/core/ports"
+	"github.com/sy-software/minerva-owl/mocks"
+)
+
+func TestTeamIsCreated(t *testing.T) {
+	ctx := context.Background()
+	repo := mocks.MemRepo{
+		Data: map[string][]map[string]interface{}{
+			"organizations": {{"id": "1", "name": "Avengers"}},
+			"teams":         {},
+		},
+	}
+
+	service := NewTeamService(&repo, domain.DefaultConfig())
+
+	t.Run("Create a team", func(t *testing.T) {
+		created, err := service.Create(ctx, "1", "Engineering", "Builders", "IronMan", "red", "hammer", []string{"go", "go", "rust"})
+		if err != nil {
+			t.Fatalf("Item should be created without errors: %v", err)
+		}
+
+		expected := []string{"go", "rust"}
+		if !cmp.Equal(created.Techs, expected) {
+			t.Errorf("Expected techs without duplicates: %v got: %v", expected, created.Techs)
+		}
+
+		got, err := service.Get(ctx, created.Id)
+		if err != nil || !cmp.Equal(got, created) {
+			t.Errorf("Expected item to be: %+v got: %+v with error: %v", created, got, err)
+		}
+	})
+
+	t.Run("Missing organization", func(t *testing.T) {
+		_, err := service.Create(ctx, "2", "Engineering", "Builders", "IronMan", "red", "hammer", nil)
+		if _, ok := err.(ports.ErrItemNotFound); !ok {
+			t.Errorf("Expected error of type ErrItemNotFound got: %v", err)
+		}
+	})
+}
+
+func TestTeamTechsArePatched(t *testing.T) {
+	ctx := context.Background()
+	repo := mocks.MemRepo{
+		Data: map[string][]map[string]interface{}{
+			"teams": {{"id": "1", "name": "Engineering", "techs": []interface{}{"go"}}},
+		},
+	}
+
+	service := NewTeamService(&repo, domain.DefaultConfig())
+
+	t.Run("Add and remove techs", func(t *testing.T) {
+		got, err := service.AddTechs(ctx, "1", "rust", "go", "java")
+		expected := []string{"go", "rust", "java"}
+		if err != nil || !cmp.Equal(got.Techs, expected) {
+			t.Errorf("Expected techs: %v got: %v with error: %v", expected, got.Techs, err)
+		}
+
+		got, err = service.RemoveTechs(ctx, "1", "go", "java", "cobol")
+		expected = []string{"rust"}
+		if err != nil || !cmp.Equal(got.Techs, expected) {
+			t.Errorf("Expected techs: %v got: %v with error: %v", expected, got.Techs, err)
+		}
+	})
+
+	t.Run("Concurrent changes are not lost", func(t *testing.T) {
+		techs := []string{"a", "b", "c", "d", "e", "f", "g", "h"}
+		wg := sync.WaitGroup{}
+		for _, tech := range techs {
+			wg.Add(1)
+			go func(tech string) {
+				defer wg.Done()
+				service.AddTechs(ctx, "1", tech)
+			}(tech)
+		}
+
+		wg.Wait()
+		got, _ := service.Get(ctx, "1")
+		if len(got.Techs) != len(techs)+1 {
+			t.Errorf("Expected %d techs got: %v", len(techs)+1, got.Techs)
+		}
+	})
+
+	t.Run("Missing item", func(t *testing.T) {
+		_, err := service.AddTechs(ctx, "2", "go")
+		if _, ok := err.(ports.ErrItemNotFound); !ok {
+			t.Errorf("Expected error of type ErrItemNotFound got: %v", err)
+		}
+	})
+}
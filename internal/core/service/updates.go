@@ -0,0 +1,77 @@
+package service
+
+import (
+	"github.com/sy-software/minerva-owl/internal/core/ports"
+)
+
+// validateFieldMask checks every field changed by any operator of mask is in the allowed list,
+// unset fields must also be allowed to be cleared
+func validateFieldMask(mask ports.FieldMask, allowed map[string]bool) error {
+	if err := mask.Validate(); err != nil {
+		return err
+	}
+
+	for field, operator := range mask.Operators() {
+		clearable, exists := allowed[field]
+		if !exists {
+			return ports.ErrInvalidFieldMask{
+				Field:  field,
+				Reason: "field can't be updated",
+			}
+		}
+
+		if operator == ports.UPDATE_UNSET && !clearable {
+			return ports.ErrInvalidFieldMask{
+				Field:  field,
+				Reason: "field can't be cleared",
+			}
+		}
+	}
+
+	return nil
+}
+
+// copyFieldMask returns a copy of mask which can be modified without changing mask
+func copyFieldMask(mask ports.FieldMask) ports.FieldMask {
+	output := ports.FieldMask{
+		Set:   make(map[string]interface{}, len(mask.Set)),
+		Unset: append([]string{}, mask.Unset...),
+	}
+
+	for field, value := range mask.Set {
+		output.Set[field] = value
+	}
+
+	if mask.AddToSet != nil {
+		output.AddToSet = make(map[string][]interface{}, len(mask.AddToSet))
+		for field, values := range mask.AddToSet {
+			output.AddToSet[field] = append([]interface{}{}, values...)
+		}
+	}
+
+	if mask.Pull != nil {
+		output.Pull = make(map[string][]interface{}, len(mask.Pull))
+		for field, values := range mask.Pull {
+			output.Pull[field] = append([]interface{}{}, values...)
+		}
+	}
+
+	if mask.Push != nil {
+		output.Push = make(map[string]ports.PushValues, len(mask.Push))
+		for field, push := range mask.Push {
+			output.Push[field] = ports.PushValues{
+				Values:   append([]interface{}{}, push.Values...),
+				Position: push.Position,
+			}
+		}
+	}
+
+	if mask.Inc != nil {
+		output.Inc = make(map[string]int64, len(mask.Inc))
+		for field, amount := range mask.Inc {
+			output.Inc[field] = amount
+		}
+	}
+
+	return output
+}
@@ -0,0 +1,33 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sy-software/minerva-owl/internal/core/ports"
+)
+
+func TestCopyFieldMask(t *testing.T) {
+	mask := ports.FieldMask{
+		Set:      map[string]interface{}{"name": "Avengers"},
+		Unset:    []string{"picture"},
+		AddToSet: map[string][]interface{}{"tags": {"hero"}},
+		Pull:     map[string][]interface{}{"aliases": {"Cap"}},
+		Push:     map[string]ports.PushValues{"history": ports.PushAt(0, "joined")},
+		Inc:      map[string]int64{"missions": 1},
+	}
+
+	got := copyFieldMask(mask)
+
+	if !cmp.Equal(mask, got) {
+		t.Errorf("Expected copy to keep every operator: %+v got: %+v", mask, got)
+	}
+
+	got.AddToSet["tags"][0] = "villain"
+	got.Inc["missions"] = 2
+	got.Push["history"].Values[0] = "left"
+
+	if mask.AddToSet["tags"][0] != "hero" || mask.Inc["missions"] != 1 || mask.Push["history"].Values[0] != "joined" {
+		t.Errorf("Expected the original mask to be kept got: %+v", mask)
+	}
+}
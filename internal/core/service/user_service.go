@@ -1,6 +1,7 @@
 package service
 
 import (
+	"context"
 	"errors"
 	"fmt"
 
@@ -11,10 +12,35 @@ import (
 
 const userCollectionName = domain.USER_COL_NAME
 
+// USER_SORT_FIELDS are the fields users can be sorted by
+var USER_SORT_FIELDS = map[string]bool{
+	"username":   true,
+	"name":       true,
+	"role":       true,
+	"status":     true,
+	"createDate": true,
+	"updateDate": true,
+}
+
+// USER_UPDATE_FIELDS are the fields users can be patched by, true when the field can be cleared
+var USER_UPDATE_FIELDS = map[string]bool{
+	"username": false,
+	"name":     false,
+	"picture":  true,
+	"role":     false,
+	"provider": false,
+	"tokenID":  false,
+	"status":   false,
+}
+
+// USER_INSERT_ONLY_FIELDS are kept when UpsertByUsername updates an existing user
+var USER_INSERT_ONLY_FIELDS = []string{"role", "status", "createDate"}
+
 // UserService is an implementation for ports.UserService interface
 type UserService struct {
 	repository ports.Repository
 	config     domain.Config
+	ids        IDGenerator
 }
 
 // NewUserService creates a new instance of the UserService implementation
@@ -22,42 +48,109 @@ func NewUserService(repo ports.Repository, config domain.Config) *UserService {
 	return &UserService{
 		repository: repo,
 		config:     config,
+		ids:        idGenerator(config),
 	}
 }
 
-// List search for a paginated list of all users in our repository
-func (srv *UserService) List(page *int, pageSize *int) ([]domain.User, error) {
+// List search for a paginated list of all users in our repository sorted by the USER_SORT_FIELDS in sort
+func (srv *UserService) List(ctx context.Context, page *int, pageSize *int, sort ...ports.Sort) ([]domain.User, error) {
 	_, pageSizeVal, skip := pagination(page, pageSize, srv.config)
 
 	results := []domain.User{}
-	err := srv.repository.List(userCollectionName, &results, skip, pageSizeVal)
+	if err := validateSort(sort, USER_SORT_FIELDS); err != nil {
+		return results, err
+	}
+
+	err := srv.repository.List(ctx, userCollectionName, &results, skip, pageSizeVal, sort)
 
 	return results, err
 }
 
 // ListByRole search for a paginated list of all users in our repository filtered by the role field
-func (srv *UserService) ListByRole(role string, page *int, pageSize *int) ([]domain.User, error) {
+func (srv *UserService) ListByRole(ctx context.Context, role string, page *int, pageSize *int, sort ...ports.Sort) ([]domain.User, error) {
 	_, pageSizeVal, skip := pagination(page, pageSize, srv.config)
 
 	results := []domain.User{}
-	err := srv.repository.List(userCollectionName, &results, skip, pageSizeVal, ports.Filter{
+	if err := validateSort(sort, USER_SORT_FIELDS); err != nil {
+		return results, err
+	}
+
+	err := srv.repository.List(ctx, userCollectionName, &results, skip, pageSizeVal, sort, ports.Filter{
 		Name:  "role",
 		Value: role,
 	})
 	return results, err
 }
 
+// Search looks for a paginated list of the users matching all the filters
+func (srv *UserService) Search(ctx context.Context, filters []ports.Filter, page *int, pageSize *int, sort ...ports.Sort) ([]domain.User, error) {
+	_, pageSizeVal, skip := pagination(page, pageSize, srv.config)
+
+	results := []domain.User{}
+	if err := validateSort(sort, USER_SORT_FIELDS); err != nil {
+		return results, err
+	}
+
+	err := srv.repository.List(ctx, userCollectionName, &results, skip, pageSizeVal, sort, filters...)
+
+	return results, err
+}
+
+// ListPage search for up to first users after the cursor matching all the filters,
+// first is normalized with the pagination settings
+func (srv *UserService) ListPage(ctx context.Context, first *int, after *string, filters ...ports.Filter) ([]domain.User, ports.PageInfo, error) {
+	_, pageSizeVal, _ := pagination(nil, first, srv.config)
+
+	results := []domain.User{}
+	pageInfo, err := srv.repository.ListPage(ctx, userCollectionName, &results, utils.CoalesceStr(after, ""), pageSizeVal, filters...)
+
+	return results, pageInfo, err
+}
+
+// ListPageByRole search for up to first users after the cursor filtered by the role field
+func (srv *UserService) ListPageByRole(ctx context.Context, role string, first *int, after *string) ([]domain.User, ports.PageInfo, error) {
+	_, pageSizeVal, _ := pagination(nil, first, srv.config)
+
+	results := []domain.User{}
+	pageInfo, err := srv.repository.ListPage(ctx, userCollectionName, &results, utils.CoalesceStr(after, ""), pageSizeVal, ports.Filter{
+		Name:  "role",
+		Value: role,
+	})
+
+	return results, pageInfo, err
+}
+
+// Count returns the number of users matching all the filters
+func (srv *UserService) Count(ctx context.Context, filters ...ports.Filter) (int, error) {
+	return srv.repository.Count(ctx, userCollectionName, filters...)
+}
+
+// CountByRole returns the number of users with the given role
+func (srv *UserService) CountByRole(ctx context.Context, role string) (int, error) {
+	return srv.repository.Count(ctx, userCollectionName, ports.Filter{
+		Name:  "role",
+		Value: role,
+	})
+}
+
 // Get looks for the information of an specific user by they id
-func (srv *UserService) Get(id string) (domain.User, error) {
+func (srv *UserService) Get(ctx context.Context, id string) (domain.User, error) {
 	result := domain.User{}
-	err := srv.repository.Get(userCollectionName, id, &result)
+	err := srv.repository.Get(ctx, userCollectionName, id, &result)
 	return result, err
 }
 
+// GetMany returns the users with any of the ids in no particular order, missing ids are skipped
+func (srv *UserService) GetMany(ctx context.Context, ids []string) ([]domain.User, error) {
+	results := []domain.User{}
+	err := srv.repository.GetMany(ctx, userCollectionName, ids, &results)
+	return results, err
+}
+
 // GetByUsername looks for the information of an specific user by they username
-func (srv *UserService) GetByUsername(username string) (domain.User, error) {
+func (srv *UserService) GetByUsername(ctx context.Context, username string) (domain.User, error) {
 	result := domain.User{}
-	err := srv.repository.GetOne(userCollectionName, &result, ports.Filter{
+	err := srv.repository.GetOne(ctx, userCollectionName, &result, ports.Filter{
 		Name:  "username",
 		Value: username,
 	})
@@ -66,6 +159,7 @@ func (srv *UserService) GetByUsername(username string) (domain.User, error) {
 
 // Create saves a new user into our repository ensuring the username is unique
 func (srv *UserService) Create(
+	ctx context.Context,
 	name string,
 	username string,
 	picture string,
@@ -75,7 +169,7 @@ func (srv *UserService) Create(
 	status string,
 ) (domain.User, error) {
 
-	current, err := srv.GetByUsername(username)
+	current, err := srv.GetByUsername(ctx, username)
 
 	if err == nil && current.Username == username {
 		return domain.User{}, errors.New(fmt.Sprintf("duplicated Username: %s", username))
@@ -92,6 +186,7 @@ func (srv *UserService) Create(
 	}
 	now := utils.UnixNow()
 	entity := domain.User{
+		Id:         srv.ids.NewID(),
 		Name:       name,
 		Username:   username,
 		Picture:    picture,
@@ -103,16 +198,64 @@ func (srv *UserService) Create(
 		UpdateDate: now,
 	}
 
-	newId, err := srv.repository.Create(userCollectionName, &entity)
-	entity.Id = newId
+	_, err = srv.repository.Create(ctx, userCollectionName, &entity)
 	return entity, err
 }
 
+// UpsertByUsername creates the user when the username doesn't exist, otherwise updates its name,
+// picture, provider and tokenID keeping the USER_INSERT_ONLY_FIELDS. It's done with a single
+// repository call, the returned bool is true when the user was created
+func (srv *UserService) UpsertByUsername(
+	ctx context.Context,
+	name string,
+	username string,
+	picture string,
+	role string,
+	provider string,
+	tokenID string,
+	status string,
+) (domain.User, bool, error) {
+	encryptedToken, err := utils.AES256Encrypt(srv.config.Keys.Auth, tokenID)
+
+	if err != nil {
+		return domain.User{}, false, err
+	}
+
+	now := utils.UnixUTCNow()
+	entity := domain.User{
+		Id:         srv.ids.NewID(),
+		Name:       name,
+		Username:   username,
+		Picture:    picture,
+		Role:       role,
+		Provider:   provider,
+		TokenID:    encryptedToken,
+		Status:     status,
+		CreateDate: now,
+		UpdateDate: now,
+	}
+
+	id, created, err := srv.repository.Upsert(
+		ctx,
+		userCollectionName,
+		[]ports.Filter{ports.Eq("username", username)},
+		&entity,
+		USER_INSERT_ONLY_FIELDS...,
+	)
+
+	if err != nil {
+		return domain.User{}, false, err
+	}
+
+	user, err := srv.Get(ctx, id)
+	return user, created, err
+}
+
 // Update the given user information
-func (srv *UserService) Update(entity domain.User) (domain.User, error) {
+func (srv *UserService) Update(ctx context.Context, entity domain.User) (domain.User, error) {
 	entity.UpdateDate = utils.UnixUTCNow()
 
-	current, err := srv.Get(entity.Id)
+	current, err := srv.Get(ctx, entity.Id)
 
 	if err != nil {
 		return entity, err
@@ -129,11 +272,139 @@ func (srv *UserService) Update(entity domain.User) (domain.User, error) {
 		entity.TokenID = encryptedToken
 	}
 
-	return entity, srv.repository.Update(userCollectionName, entity.Id, &entity, "createDate")
+	return entity, srv.repository.Update(ctx, userCollectionName, entity.Id, &entity, "createDate")
+}
+
+// Patch changes only the fields in mask of the user with id, the USER_UPDATE_FIELDS,
+// and returns the updated user. The username must stay unique and the tokenID is encrypted
+func (srv *UserService) Patch(ctx context.Context, id string, mask ports.FieldMask) (domain.User, error) {
+	mask, err := srv.prepareMask(ctx, id, mask)
+	if err != nil {
+		return domain.User{}, err
+	}
+
+	if err := srv.repository.Patch(ctx, userCollectionName, id, mask); err != nil {
+		return domain.User{}, err
+	}
+
+	return srv.Get(ctx, id)
+}
+
+// prepareMask validates mask for the user with id and returns a copy with the changes applied
+// by the service: the username must stay unique, the tokenID is encrypted and updateDate is set
+func (srv *UserService) prepareMask(ctx context.Context, id string, mask ports.FieldMask) (ports.FieldMask, error) {
+	if err := validateFieldMask(mask, USER_UPDATE_FIELDS); err != nil {
+		return mask, err
+	}
+
+	mask = copyFieldMask(mask)
+	if username, exists := mask.Set["username"]; exists {
+		current := domain.User{}
+		err := srv.repository.GetOne(ctx, userCollectionName, &current, ports.Eq("username", username))
+
+		if err == nil && current.Id != id {
+			return mask, fmt.Errorf("duplicated Username: %v", username)
+		}
+
+		if _, ok := err.(ports.ErrItemNotFound); err != nil && !ok {
+			return mask, err
+		}
+	}
+
+	if tokenID, exists := mask.Set["tokenID"]; exists {
+		encryptedToken, err := utils.AES256Encrypt(srv.config.Keys.Auth, fmt.Sprintf("%v", tokenID))
+
+		if err != nil {
+			return mask, err
+		}
+
+		mask.Set["tokenID"] = encryptedToken
+	}
+
+	mask.Set["updateDate"] = utils.UnixUTCNow()
+
+	return mask, nil
 }
 
 // Delete the user with the specified id from the repository.
 // The hard false flag for soft deletion is pending implementation
-func (srv *UserService) Delete(id string, hard bool) error {
-	return srv.repository.Delete(userCollectionName, id)
+func (srv *UserService) Delete(ctx context.Context, id string, hard bool) error {
+	return srv.repository.Delete(ctx, userCollectionName, id)
+}
+
+// CreateMany saves new users into the repository, returns a result per user.
+// Usernames must be unique, also within users, and tokenIDs are encrypted
+func (srv *UserService) CreateMany(ctx context.Context, users []domain.User, stopOnError bool) ([]ports.BulkResult, error) {
+	entities := make([]interface{}, len(users))
+	usernames := map[string]bool{}
+	now := utils.UnixNow()
+
+	return validatedBulk(
+		len(users),
+		stopOnError,
+		func(index int) (string, error) {
+			entity := users[index]
+			if usernames[entity.Username] {
+				return "", fmt.Errorf("duplicated Username: %s", entity.Username)
+			}
+
+			current, err := srv.GetByUsername(ctx, entity.Username)
+			if err == nil && current.Username == entity.Username {
+				return "", fmt.Errorf("duplicated Username: %s", entity.Username)
+			}
+
+			if _, ok := err.(ports.ErrItemNotFound); err != nil && !ok {
+				return "", err
+			}
+
+			entity.TokenID, err = utils.AES256Encrypt(srv.config.Keys.Auth, entity.TokenID)
+			if err != nil {
+				return "", err
+			}
+
+			usernames[entity.Username] = true
+			entity.Id = srv.ids.NewID()
+			entity.CreateDate = now
+			entity.UpdateDate = now
+			entities[index] = entity
+			return "", nil
+		},
+		func(indexes []int) ([]ports.BulkResult, error) {
+			valid := make([]interface{}, len(indexes))
+			for position, index := range indexes {
+				valid[position] = entities[index]
+			}
+
+			return srv.repository.CreateMany(ctx, userCollectionName, valid, stopOnError)
+		},
+	)
+}
+
+// PatchMany changes only the fields in the mask of each patch, just like Patch.
+// Patches with an invalid mask are not sent to the repository
+func (srv *UserService) PatchMany(ctx context.Context, patches []ports.BulkPatch, stopOnError bool) ([]ports.BulkResult, error) {
+	prepared := make([]ports.BulkPatch, len(patches))
+
+	return validatedBulk(
+		len(patches),
+		stopOnError,
+		func(index int) (string, error) {
+			mask, err := srv.prepareMask(ctx, patches[index].Id, patches[index].Mask)
+			prepared[index] = ports.BulkPatch{Id: patches[index].Id, Mask: mask}
+			return patches[index].Id, err
+		},
+		func(indexes []int) ([]ports.BulkResult, error) {
+			valid := make([]ports.BulkPatch, len(indexes))
+			for position, index := range indexes {
+				valid[position] = prepared[index]
+			}
+
+			return srv.repository.PatchMany(ctx, userCollectionName, valid, stopOnError)
+		},
+	)
+}
+
+// DeleteMany removes the users with the ids, returns a result per id
+func (srv *UserService) DeleteMany(ctx context.Context, ids []string, hard bool, stopOnError bool) ([]ports.BulkResult, error) {
+	return srv.repository.DeleteMany(ctx, userCollectionName, ids, stopOnError)
 }
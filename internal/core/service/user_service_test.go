@@ -1,6 +1,7 @@
 package service
 
 import (
+	"context"
 	"reflect"
 	"testing"
 	"time"
@@ -14,6 +15,7 @@ import (
 const authKey = "2b7e151628aed2a6abf71589a12b4da32"
 
 func TestCreateOperations(t *testing.T) {
+	ctx := context.Background()
 	config := domain.DefaultConfig()
 	config.Keys = domain.KeyList{
 		Auth: authKey,
@@ -46,6 +48,7 @@ func TestCreateOperations(t *testing.T) {
 		service = NewUserService(&repo, config)
 
 		created, err := service.Create(
+			ctx,
 			expected.Name,
 			expected.Username,
 			expected.Picture,
@@ -158,7 +161,7 @@ func TestCreateOperations(t *testing.T) {
 
 		repo := mocks.MemRepo{
 			Data: data,
-			GetOneInterceptor: func(collection string, result interface{}, filters ...ports.Filter) error {
+			GetOneInterceptor: func(ctx context.Context, collection string, result interface{}, filters ...ports.Filter) error {
 				elementPtr := reflect.ValueOf(result)
 				elementVal := elementPtr.Elem()
 
@@ -172,6 +175,7 @@ func TestCreateOperations(t *testing.T) {
 		service = NewUserService(&repo, config)
 
 		_, err := service.Create(
+			ctx,
 			expected.Name,
 			expected.Username,
 			expected.Picture,
@@ -193,6 +197,7 @@ func TestCreateOperations(t *testing.T) {
 }
 
 func TestReadOperations(t *testing.T) {
+	ctx := context.Background()
 	config := domain.DefaultConfig()
 	config.Keys = domain.KeyList{
 		Auth: authKey,
@@ -227,7 +232,7 @@ func TestReadOperations(t *testing.T) {
 
 		service := NewUserService(&repo, config)
 
-		got, err := service.List(nil, nil)
+		got, err := service.List(ctx, nil, nil)
 
 		if err != nil {
 			t.Errorf("Got error while getting all users: %v", err)
@@ -244,6 +249,47 @@ func TestReadOperations(t *testing.T) {
 		}
 	})
 
+	t.Run("Test list users sorted", func(t *testing.T) {
+		repo := mocks.MemRepo{
+			Data: map[string][]map[string]interface{}{
+				domain.USER_COL_NAME: {
+					{"id": "1", "username": "b"},
+					{"id": "2", "username": "c"},
+					{"id": "3", "username": "a"},
+				},
+			},
+		}
+
+		service := NewUserService(&repo, config)
+
+		got, err := service.List(ctx, nil, nil, ports.Sort{Field: "username", Descending: true})
+
+		if err != nil {
+			t.Errorf("Got error while getting all users: %v", err)
+		}
+
+		expected := []string{"2", "1", "3"}
+		for i := 0; i < len(got); i++ {
+			if got[i].Id != expected[i] {
+				t.Errorf("Expected item %d id to be: %v got: %v", i, expected[i], got[i].Id)
+			}
+		}
+	})
+
+	t.Run("Test list users with a not sortable field", func(t *testing.T) {
+		repo := mocks.MemRepo{
+			Data: map[string][]map[string]interface{}{},
+		}
+
+		service := NewUserService(&repo, config)
+
+		_, err := service.List(ctx, nil, nil, ports.Sort{Field: "tokenID"})
+
+		if _, ok := err.(ports.ErrInvalidSort); !ok {
+			t.Errorf("Expected error of type ErrInvalidSort got: %v", err)
+		}
+	})
+
 	t.Run("Test getting user by id", func(t *testing.T) {
 		dummyData := []map[string]interface{}{
 			{
@@ -264,7 +310,7 @@ func TestReadOperations(t *testing.T) {
 
 		service := NewUserService(&repo, config)
 
-		got, err := service.Get("1")
+		got, err := service.Get(ctx, "1")
 
 		if err != nil {
 			t.Errorf("Got error while getting user by id: %v", err)
@@ -294,7 +340,7 @@ func TestReadOperations(t *testing.T) {
 		called := false
 		repo := mocks.MemRepo{
 			Data: data,
-			GetOneInterceptor: func(collection string, result interface{}, filters ...ports.Filter) error {
+			GetOneInterceptor: func(ctx context.Context, collection string, result interface{}, filters ...ports.Filter) error {
 				called = true
 				if len(filters) != 1 {
 					t.Errorf("Expected 1 filter got %d", len(filters))
@@ -314,7 +360,7 @@ func TestReadOperations(t *testing.T) {
 
 		service := NewUserService(&repo, config)
 
-		_, err := service.GetByUsername("IronMan")
+		_, err := service.GetByUsername(ctx, "IronMan")
 
 		if err != nil {
 			t.Errorf("Got error while getting user by id: %v", err)
@@ -349,7 +395,7 @@ func TestReadOperations(t *testing.T) {
 		called := false
 		repo := mocks.MemRepo{
 			Data: data,
-			ListInterceptor: func(collection string, results interface{}, skip, limit int, filters ...ports.Filter) error {
+			ListInterceptor: func(ctx context.Context, collection string, results interface{}, skip, limit int, sort []ports.Sort, filters ...ports.Filter) error {
 				called = true
 				if skip != 0 {
 					t.Errorf("Expect skip to be 0 got %d", skip)
@@ -377,7 +423,7 @@ func TestReadOperations(t *testing.T) {
 
 		service := NewUserService(&repo, config)
 
-		_, err := service.ListByRole("genius", &page, &size)
+		_, err := service.ListByRole(ctx, "genius", &page, &size)
 
 		if err != nil {
 			t.Errorf("Got error while getting user by id: %v", err)
@@ -390,6 +436,7 @@ func TestReadOperations(t *testing.T) {
 }
 
 func TestUpdateOperations(t *testing.T) {
+	ctx := context.Background()
 	config := domain.DefaultConfig()
 	config.Keys = domain.KeyList{
 		Auth: authKey,
@@ -440,13 +487,13 @@ func TestUpdateOperations(t *testing.T) {
 			Status:     "active",
 		}
 
-		_, err := service.Update(expected)
+		_, err := service.Update(ctx, expected)
 
 		if err != nil {
 			t.Errorf("Item should be updated without errors: %v", err)
 		}
 
-		got, _ := service.Get("1")
+		got, _ := service.Get(ctx, "1")
 
 		if got.Name != expected.Name {
 			t.Errorf(
@@ -546,7 +593,7 @@ func TestUpdateOperations(t *testing.T) {
 			Id: "3",
 		}
 
-		_, err := service.Update(expected)
+		_, err := service.Update(ctx, expected)
 
 		if err == nil {
 			t.Errorf("Expected error got nil")
@@ -560,6 +607,7 @@ func TestUpdateOperations(t *testing.T) {
 }
 
 func TestDeleteOperations(t *testing.T) {
+	ctx := context.Background()
 	config := domain.DefaultConfig()
 	config.Keys = domain.KeyList{
 		Auth: authKey,
@@ -589,13 +637,13 @@ func TestDeleteOperations(t *testing.T) {
 
 		service := NewUserService(&repo, config)
 
-		err := service.Delete("1", false)
+		err := service.Delete(ctx, "1", false)
 
 		if err != nil {
 			t.Errorf("Unexpected error: %v", err)
 		}
 
-		_, err = service.Get("1")
+		_, err = service.Get(ctx, "1")
 
 		if err == nil {
 			t.Errorf("Expected error got nil")
@@ -631,9 +679,9 @@ func TestDeleteOperations(t *testing.T) {
 
 		service := NewUserService(&repo, config)
 
-		_ = service.Delete("3", false)
+		_ = service.Delete(ctx, "3", false)
 
-		got, err := service.Get("1")
+		got, err := service.Get(ctx, "1")
 
 		if err != nil {
 			t.Errorf("Unexpected error: %v", err)
@@ -648,3 +696,204 @@ func TestDeleteOperations(t *testing.T) {
 		}
 	})
 }
+
+func TestPatchOperations(t *testing.T) {
+	ctx := context.Background()
+	config := domain.DefaultConfig()
+	config.Keys = domain.KeyList{
+		Auth: authKey,
+	}
+
+	now := utils.UnixUTCNow()
+	yesterday := now.Add(-24 * time.Hour)
+	newRepo := func() *mocks.MemRepo {
+		return &mocks.MemRepo{
+			Data: map[string][]map[string]interface{}{
+				domain.USER_COL_NAME: {
+					{
+						"id":         "1",
+						"username":   "CapAmerica",
+						"name":       "Steve Rogers",
+						"picture":    "picture",
+						"createDate": yesterday,
+						"updateDate": yesterday,
+						"status":     "active",
+					},
+				},
+			},
+		}
+	}
+
+	t.Run("Test only the masked fields are changed", func(t *testing.T) {
+		service := NewUserService(newRepo(), config)
+		mask := ports.FieldMask{
+			Set:   map[string]interface{}{"name": "Sam Wilson", "tokenID": "newTokenId"},
+			Unset: []string{"picture"},
+		}
+
+		got, err := service.Patch(ctx, "1", mask)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		if got.Name != "Sam Wilson" || got.Username != "CapAmerica" || got.Status != "active" || got.Picture != "" {
+			t.Errorf("Expected only name and picture to change got: %+v", got)
+		}
+
+		decrypted, err := utils.AES256Decrypt(authKey, got.TokenID)
+		if err != nil || decrypted != "newTokenId" {
+			t.Errorf("Expected encrypted TokenID: %q got: %q with error: %v", "newTokenId", decrypted, err)
+		}
+
+		if !got.CreateDate.Equal(yesterday) || got.UpdateDate.Before(now) {
+			t.Errorf("Expected only UpdateDate to change got: %v and %v", got.CreateDate, got.UpdateDate)
+		}
+
+		if mask.Set["tokenID"] != "newTokenId" || len(mask.Set) != 2 {
+			t.Errorf("Expected the mask to be kept got: %+v", mask)
+		}
+	})
+
+	t.Run("Test fields which can't be patched", func(t *testing.T) {
+		service := NewUserService(newRepo(), config)
+		masks := []ports.FieldMask{
+			{Set: map[string]interface{}{"createDate": now}},
+			{Unset: []string{"username"}},
+			{AddToSet: map[string][]interface{}{"createDate": {now}}},
+			{Inc: map[string]int64{"_id": 1}},
+		}
+
+		for _, mask := range masks {
+			_, err := service.Patch(ctx, "1", mask)
+			if _, ok := err.(ports.ErrInvalidFieldMask); !ok {
+				t.Errorf("Expected error of type ErrInvalidFieldMask for %+v got: %v", mask, err)
+			}
+		}
+	})
+}
+
+func TestUpsertOperations(t *testing.T) {
+	ctx := context.Background()
+	config := domain.DefaultConfig()
+	config.Keys = domain.KeyList{
+		Auth: authKey,
+	}
+
+	repo := mocks.MemRepo{
+		Data: map[string][]map[string]interface{}{
+			domain.USER_COL_NAME: {},
+		},
+	}
+
+	var service ports.UserService
+	service = NewUserService(&repo, config)
+
+	created, isNew, err := service.UpsertByUsername(ctx, "Tony Stark", "ironman", "picture1", "hero", "marvel", "token1", "active")
+
+	t.Run("Test User is created", func(t *testing.T) {
+		if err != nil || !isNew {
+			t.Fatalf("Expected user to be created got: %v with error: %v", isNew, err)
+		}
+
+		if created.Username != "ironman" || created.Role != "hero" || created.TokenID == "token1" {
+			t.Errorf("Expected user with an encrypted token got: %+v", created)
+		}
+	})
+
+	t.Run("Test User is updated", func(t *testing.T) {
+		updated, isNew, err := service.UpsertByUsername(ctx, "Anthony Stark", "ironman", "picture2", "villain", "marvel", "token2", "deceased")
+		if err != nil || isNew {
+			t.Fatalf("Expected user to be updated got: %v with error: %v", isNew, err)
+		}
+
+		if updated.Id != created.Id || updated.Name != "Anthony Stark" || updated.Picture != "picture2" {
+			t.Errorf("Expected name and picture of %q to be updated got: %+v", created.Id, updated)
+		}
+
+		if updated.Role != "hero" || updated.Status != "active" || !updated.CreateDate.Equal(created.CreateDate) {
+			t.Errorf("Expected insert only fields to be kept got: %+v", updated)
+		}
+
+		token, _ := utils.AES256Decrypt(authKey, updated.TokenID)
+		if token != "token2" {
+			t.Errorf("Expected token: %q got: %q", "token2", token)
+		}
+
+		if len(repo.Data[domain.USER_COL_NAME]) != 1 {
+			t.Errorf("Expected a single user got: %v", repo.Data[domain.USER_COL_NAME])
+		}
+	})
+}
+
+func TestBulkOperations(t *testing.T) {
+	ctx := context.Background()
+	config := domain.DefaultConfig()
+	config.Keys = domain.KeyList{
+		Auth: authKey,
+	}
+
+	repo := mocks.MemRepo{
+		Data: map[string][]map[string]interface{}{
+			domain.USER_COL_NAME: {{"id": "1", "username": "thor"}},
+		},
+	}
+
+	var service ports.UserService
+	service = NewUserService(&repo, config)
+
+	users := []domain.User{
+		{Username: "ironman", Name: "Tony Stark", TokenID: "token1"},
+		{Username: "thor", Name: "Thor"},
+		{Username: "ironman", Name: "Anthony Stark"},
+		{Username: "hulk", Name: "Bruce Banner"},
+	}
+
+	t.Run("Test duplicated usernames fail without stopping", func(t *testing.T) {
+		results, err := service.CreateMany(ctx, users, false)
+		if err != nil || len(results) != len(users) {
+			t.Fatalf("Expected %d results got: %+v with error: %v", len(users), results, err)
+		}
+
+		if results[0].Err != nil || results[1].Err == nil || results[2].Err == nil || results[3].Err != nil {
+			t.Errorf("Expected only the duplicated usernames to fail got: %+v", results)
+		}
+
+		created, err := service.Get(ctx, results[0].Id)
+		if err != nil || created.TokenID == "token1" || created.CreateDate.IsZero() {
+			t.Errorf("Expected user with an encrypted token and create date got: %+v with error: %v", created, err)
+		}
+	})
+
+	t.Run("Test stop on error", func(t *testing.T) {
+		results, err := service.CreateMany(ctx, []domain.User{{Username: "spiderman"}, {Username: "thor"}, {Username: "vision"}}, true)
+		if err != nil || results[0].Err != nil || results[1].Err == nil || results[2].Err != ports.ErrBulkAborted {
+			t.Errorf("Expected the users after thor to be aborted got: %+v with error: %v", results, err)
+		}
+
+		if _, err := service.GetByUsername(ctx, "vision"); err == nil {
+			t.Errorf("Expected aborted user to not be created")
+		}
+	})
+
+	t.Run("Test patch and delete", func(t *testing.T) {
+		patches := []ports.BulkPatch{
+			{Id: "1", Mask: ports.FieldMask{Set: map[string]interface{}{"name": "Thor Odinson"}}},
+			{Id: "1", Mask: ports.FieldMask{Set: map[string]interface{}{"username": "ironman"}}},
+			{Id: "1", Mask: ports.FieldMask{Unset: []string{"name"}}},
+		}
+
+		results, err := service.PatchMany(ctx, patches, false)
+		if err != nil || results[0].Err != nil || results[1].Err == nil || results[2].Err == nil {
+			t.Errorf("Expected only the first patch to succeed got: %+v with error: %v", results, err)
+		}
+
+		if got, _ := service.Get(ctx, "1"); got.Name != "Thor Odinson" || got.UpdateDate.IsZero() {
+			t.Errorf("Expected user to be patched got: %+v", got)
+		}
+
+		results, err = service.DeleteMany(ctx, []string{"1", "missing"}, false, false)
+		if _, ok := results[1].Err.(ports.ErrItemNotFound); err != nil || results[0].Err != nil || !ok {
+			t.Errorf("Expected only the missing user to fail got: %+v with error: %v", results, err)
+		}
+	})
+}
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/pprof"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sy-software/minerva-owl/internal/core/domain"
+)
+
+// MASKED_VALUE replaces the secrets in the configuration dump
+const MASKED_VALUE = "********"
+
+// NewAdminRouter creates the router of the admin listener serving the metrics, the health
+// endpoints, pprof under /debug/pprof/ and the masked configuration under /config
+func NewAdminRouter(config *domain.Config, health *Health) *gin.Engine {
+	r := gin.New()
+	r.GET("/metrics", MetricsHandler())
+	r.GET("/healthz", health.LivenessHandler())
+	r.GET("/readyz", health.ReadinessHandler())
+	r.GET("/health", health.DetailsHandler())
+	r.GET("/config", ConfigHandler(config))
+	r.Any("/debug/pprof/*profile", PprofHandler())
+
+	return r
+}
+
+// ConfigHandler serves the effective configuration with its secrets masked
+func ConfigHandler(config *domain.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, MaskConfig(*config))
+	}
+}
+
+// PprofHandler serves the net/http/pprof profiles, it must be routed under /debug/pprof/
+// since the profile index links are relative to it
+func PprofHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch strings.TrimPrefix(c.Param("profile"), "/") {
+		case "cmdline":
+			pprof.Cmdline(c.Writer, c.Request)
+		case "profile":
+			pprof.Profile(c.Writer, c.Request)
+		case "symbol":
+			pprof.Symbol(c.Writer, c.Request)
+		case "trace":
+			pprof.Trace(c.Writer, c.Request)
+		default:
+			pprof.Index(c.Writer, c.Request)
+		}
+	}
+}
+
+// MaskConfig returns a copy of config with passwords, keys and credentials in DSNs masked
+func MaskConfig(config domain.Config) domain.Config {
+	config.CassandraDB = maskCassandra(config.CassandraDB)
+	config.MongoDBConfig = maskMongo(config.MongoDBConfig)
+	config.SQLDB = maskSQL(config.SQLDB)
+	config.Keys.Auth = mask(config.Keys.Auth)
+
+	backends := make(map[string]domain.BackendConfig, len(config.Storage.Backends))
+	for name, backend := range config.Storage.Backends {
+		if backend.CassandraDB != nil {
+			cassandra := maskCassandra(*backend.CassandraDB)
+			backend.CassandraDB = &cassandra
+		}
+
+		if backend.MongoDB != nil {
+			mongo := maskMongo(*backend.MongoDB)
+			backend.MongoDB = &mongo
+		}
+
+		if backend.SQLDB != nil {
+			sql := maskSQL(*backend.SQLDB)
+			backend.SQLDB = &sql
+		}
+
+		backends[name] = backend
+	}
+	config.Storage.Backends = backends
+
+	return config
+}
+
+// mask hides a secret, empty values are kept so missing secrets are visible
+func mask(secret string) string {
+	if secret == "" {
+		return secret
+	}
+
+	return MASKED_VALUE
+}
+
+func maskCassandra(config domain.CDBConfig) domain.CDBConfig {
+	config.Password = mask(config.Password)
+	return config
+}
+
+func maskMongo(config domain.MDBConfig) domain.MDBConfig {
+	config.Password = mask(config.Password)
+	return config
+}
+
+// maskSQL hides the whole DSN, each driver has its own way to pass credentials on it
+func maskSQL(config domain.SQLConfig) domain.SQLConfig {
+	config.DSN = mask(config.DSN)
+	return config
+}
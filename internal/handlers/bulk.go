@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"context"
+
+	"github.com/sy-software/minerva-owl/cmd/graphql/graph/model"
+	"github.com/sy-software/minerva-owl/internal/core/ports"
+)
+
+// inputsToPatches converts a list of GraphQL update inputs into bulk patches
+func inputsToPatches(inputs []*model.UpdateInput, fields map[string]string) []ports.BulkPatch {
+	patches := make([]ports.BulkPatch, len(inputs))
+	for index, input := range inputs {
+		patches[index].Id, patches[index].Mask = inputToFieldMask(input.Fields, fields)
+	}
+
+	return patches
+}
+
+// bulkToGraphQL converts the results of a bulk operation into a BulkPayload,
+// the error of each item is converted with mapErr, which can be nil, and classified
+// like the errors sent by the error presenter: unexpected errors are logged and their
+// message is replaced by INTERNAL_ERROR_MESSAGE
+func bulkToGraphQL(ctx context.Context, results []ports.BulkResult, mapErr func(err error) error) *model.BulkPayload {
+	payload := &model.BulkPayload{
+		Results: make([]*model.BulkItemResult, len(results)),
+	}
+
+	for index, result := range results {
+		item := &model.BulkItemResult{
+			Index:   index,
+			Success: result.Err == nil,
+		}
+
+		if result.Id != "" {
+			id := result.Id
+			item.ID = &id
+		}
+
+		if result.Err != nil {
+			message := bulkErrorMessage(ctx, result.Err, mapErr)
+			item.Error = &message
+			payload.Failed++
+		} else {
+			payload.Succeeded++
+		}
+
+		payload.Results[index] = item
+	}
+
+	return payload
+}
+
+// bulkErrorMessage returns the message reported to clients for the error of a bulk item
+func bulkErrorMessage(ctx context.Context, err error, mapErr func(err error) error) string {
+	if mapErr != nil {
+		err = mapErr(err)
+	}
+
+	if _, expected := errorCode(err); !expected {
+		LogErrorReporter(ctx, err)
+		return INTERNAL_ERROR_MESSAGE
+	}
+
+	return err.Error()
+}
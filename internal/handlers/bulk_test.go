@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/sy-software/minerva-owl/internal/core/ports"
+)
+
+func TestBulkToGraphQL(t *testing.T) {
+	ctx := context.Background()
+	id := "1"
+	results := []ports.BulkResult{
+		{Id: "1"},
+		{Id: "1", Err: ports.ErrItemNotFound{Id: &id, Model: "users"}},
+		{Err: errors.New("dial tcp 10.0.0.5:27017: connection refused")},
+		{Err: errors.New("duplicated key")},
+	}
+
+	mapErr := func(err error) error {
+		if err.Error() == "duplicated key" {
+			return ErrDuplicatedValue
+		}
+
+		return err
+	}
+
+	got := bulkToGraphQL(ctx, results, mapErr)
+	if got.Succeeded != 1 || got.Failed != 3 {
+		t.Fatalf("Expected 1 success and 3 failures got: %+v", got)
+	}
+
+	expected := []string{
+		ports.ErrItemNotFound{Id: &id, Model: "users"}.Error(),
+		INTERNAL_ERROR_MESSAGE,
+		ErrDuplicatedValue.Error(),
+	}
+
+	for index, message := range expected {
+		item := got.Results[index+1]
+		if item.Error == nil || *item.Error != message {
+			t.Errorf("Expected item %d error: %q got: %v", index+1, message, item.Error)
+		}
+	}
+}
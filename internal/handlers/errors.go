@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/sy-software/minerva-owl/internal/core/ports"
+	"github.com/sy-software/minerva-owl/internal/utils"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// Values of extensions.code in the GraphQL errors, clients can rely on them
+const (
+	ERR_CODE_NOT_FOUND        = "NOT_FOUND"
+	ERR_CODE_DUPLICATED_VALUE = "DUPLICATED_VALUE"
+	ERR_CODE_BAD_USER_INPUT   = "BAD_USER_INPUT"
+	ERR_CODE_TIMEOUT          = "TIMEOUT"
+	ERR_CODE_CANCELLED        = "CANCELLED"
+	ERR_CODE_INTERNAL         = "INTERNAL_SERVER_ERROR"
+)
+
+// INTERNAL_ERROR_MESSAGE replaces the message of unexpected errors in production
+const INTERNAL_ERROR_MESSAGE = "internal server error"
+
+// ErrNotFound is returned to clients asking for an item that doesn't exist
+var ErrNotFound = errors.New("not_found")
+
+// ErrDuplicatedValue is returned to clients writing a value that must be unique
+var ErrDuplicatedValue = errors.New("duplicated_value")
+
+// ErrPanic wraps the value recovered from a panicking resolver
+type ErrPanic struct {
+	Value interface{}
+}
+
+func (err ErrPanic) Error() string {
+	return fmt.Sprintf("panic: %v", err.Value)
+}
+
+// ErrorReporter is called with the unexpected errors sent to GraphQL clients
+type ErrorReporter func(ctx context.Context, err error)
+
+// LogErrorReporter reports unexpected errors to the request logger
+func LogErrorReporter(ctx context.Context, err error) {
+	utils.Logger(ctx).Error().Err(err).Str("path", graphql.GetPath(ctx).String()).Msg("Unexpected GraphQL error")
+}
+
+// RecoverFunc can be passed into Gqlgen server as recover function,
+// it logs the request and returns an ErrPanic for the error presenter
+func RecoverFunc(ctx context.Context, err interface{}) error {
+	ErrorLogger(ctx)
+	return ErrPanic{Value: err}
+}
+
+// NewErrorPresenter creates a Gqlgen error presenter adding a stable code and the request id to
+// the extensions of every error. Unexpected errors are sent to report, which can be nil,
+// and their message is replaced by INTERNAL_ERROR_MESSAGE in production
+func NewErrorPresenter(production bool, report ErrorReporter) graphql.ErrorPresenterFunc {
+	return func(ctx context.Context, err error) *gqlerror.Error {
+		var gqlErr *gqlerror.Error
+		if !errors.As(err, &gqlErr) {
+			gqlErr = gqlerror.WrapPath(graphql.GetPath(ctx), err)
+		}
+
+		if gqlErr.Extensions == nil {
+			gqlErr.Extensions = map[string]interface{}{}
+		}
+
+		cause := gqlErr.Unwrap()
+		code, expected := errorCode(cause)
+		// Keep the codes set by gqlgen, like GRAPHQL_VALIDATION_FAILED
+		if _, exists := gqlErr.Extensions["code"]; !exists {
+			gqlErr.Extensions["code"] = code
+		}
+		gqlErr.Extensions["request_id"] = RequestIDFromCtx(ctx)
+
+		if !expected {
+			if report != nil {
+				report(ctx, cause)
+			}
+
+			if production {
+				gqlErr.Message = INTERNAL_ERROR_MESSAGE
+			}
+		}
+
+		return gqlErr
+	}
+}
+
+// errorCode returns the extensions code of err and if it's an expected error,
+// a nil err comes from gqlgen rejecting the request
+func errorCode(err error) (string, bool) {
+	switch {
+	case err == nil:
+		return ERR_CODE_BAD_USER_INPUT, true
+	case errors.Is(err, ErrNotFound), errors.As(err, &ports.ErrItemNotFound{}):
+		return ERR_CODE_NOT_FOUND, true
+	case errors.Is(err, ErrDuplicatedValue), errors.As(err, &ports.ErrDuplicatedId{}):
+		return ERR_CODE_DUPLICATED_VALUE, true
+	case errors.As(err, &ports.ErrInvalidFilter{}),
+		errors.As(err, &ports.ErrInvalidSort{}),
+		errors.As(err, &ports.ErrInvalidCursor{}),
+		errors.As(err, &ports.ErrInvalidFieldMask{}):
+		return ERR_CODE_BAD_USER_INPUT, true
+	case errors.Is(err, context.DeadlineExceeded):
+		return ERR_CODE_TIMEOUT, true
+	case errors.Is(err, context.Canceled):
+		return ERR_CODE_CANCELLED, true
+	}
+
+	return ERR_CODE_INTERNAL, false
+}
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/sy-software/minerva-owl/internal/core/ports"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+func TestErrorPresenter(t *testing.T) {
+	ctx := context.WithValue(context.Background(), REQUEST_ID_KEY, "abc")
+
+	t.Run("Errors are mapped to stable codes", func(t *testing.T) {
+		id := "1"
+		expected := []struct {
+			err  error
+			code string
+		}{
+			{ErrNotFound, ERR_CODE_NOT_FOUND},
+			{fmt.Errorf("get user: %w", ports.ErrItemNotFound{Id: &id, Model: "users"}), ERR_CODE_NOT_FOUND},
+			{ErrDuplicatedValue, ERR_CODE_DUPLICATED_VALUE},
+			{fmt.Errorf("create user: %w", ports.ErrDuplicatedId{Id: "1", Model: "users"}), ERR_CODE_DUPLICATED_VALUE},
+			{ports.ErrInvalidCursor{Cursor: "?"}, ERR_CODE_BAD_USER_INPUT},
+			{ports.ErrInvalidFilter{Reason: "unknown"}, ERR_CODE_BAD_USER_INPUT},
+			{context.DeadlineExceeded, ERR_CODE_TIMEOUT},
+			{errors.New("connection refused"), ERR_CODE_INTERNAL},
+			{ErrPanic{Value: "nil map"}, ERR_CODE_INTERNAL},
+		}
+
+		presenter := NewErrorPresenter(false, nil)
+		for _, e := range expected {
+			got := presenter(ctx, graphql.ErrorOnPath(ctx, e.err))
+
+			if got.Extensions["code"] != e.code || got.Extensions["request_id"] != "abc" {
+				t.Errorf("Expected code: %q and request id: %q for: %v got: %v", e.code, "abc", e.err, got.Extensions)
+			}
+
+			if got.Message != e.err.Error() {
+				t.Errorf("Expected message: %q got: %q", e.err.Error(), got.Message)
+			}
+		}
+	})
+
+	t.Run("Unexpected errors are reported and masked in production", func(t *testing.T) {
+		reported := []error{}
+		presenter := NewErrorPresenter(true, func(ctx context.Context, err error) {
+			reported = append(reported, err)
+		})
+
+		internal := errors.New("the provided hex string is not a valid ObjectID")
+		got := presenter(ctx, graphql.ErrorOnPath(ctx, internal))
+		if got.Message != INTERNAL_ERROR_MESSAGE {
+			t.Errorf("Expected message: %q got: %q", INTERNAL_ERROR_MESSAGE, got.Message)
+		}
+
+		got = presenter(ctx, graphql.ErrorOnPath(ctx, ErrNotFound))
+		if got.Message != ErrNotFound.Error() {
+			t.Errorf("Expected message: %q got: %q", ErrNotFound.Error(), got.Message)
+		}
+
+		if len(reported) != 1 || reported[0] != internal {
+			t.Errorf("Expected only the unexpected error to be reported got: %v", reported)
+		}
+	})
+
+	t.Run("Codes set by gqlgen are kept", func(t *testing.T) {
+		validation := gqlerror.Errorf("Cannot query field")
+		validation.Extensions = map[string]interface{}{"code": "GRAPHQL_VALIDATION_FAILED"}
+
+		got := NewErrorPresenter(true, nil)(ctx, validation)
+
+		if got != validation || got.Extensions["code"] != "GRAPHQL_VALIDATION_FAILED" || got.Message != "Cannot query field" {
+			t.Errorf("Expected validation error to keep its code and message got: %+v", got)
+		}
+
+		if got.Extensions["request_id"] != "abc" {
+			t.Errorf("Expected request id: %q got: %v", "abc", got.Extensions)
+		}
+	})
+}
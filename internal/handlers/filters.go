@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"reflect"
+
+	"github.com/sy-software/minerva-owl/cmd/graphql/graph/model"
+	"github.com/sy-software/minerva-owl/internal/core/ports"
+)
+
+// orgWhereToFilters converts a GraphQL organization where input into repository filters
+func orgWhereToFilters(where *model.OrganizationWhere) []ports.Filter {
+	if where == nil {
+		return nil
+	}
+
+	filters := []ports.Filter{}
+	for _, nested := range where.And {
+		filters = append(filters, ports.And(orgWhereToFilters(nested)...))
+	}
+
+	if len(where.Or) > 0 {
+		anyOf := make([]ports.Filter, len(where.Or))
+		for index, nested := range where.Or {
+			anyOf[index] = ports.And(orgWhereToFilters(nested)...)
+		}
+		filters = append(filters, ports.Or(anyOf...))
+	}
+
+	if where.Not != nil {
+		filters = append(filters, ports.Not(ports.And(orgWhereToFilters(where.Not)...)))
+	}
+
+	filters = append(filters, idFilters(where.ID)...)
+	filters = append(filters, stringFilters("name", where.Name)...)
+	filters = append(filters, stringFilters("description", where.Description)...)
+	filters = append(filters, stringFilters("logo", where.Logo)...)
+
+	return filters
+}
+
+// userWhereToFilters converts a GraphQL user where input into repository filters
+func userWhereToFilters(where *model.UserWhere) []ports.Filter {
+	if where == nil {
+		return nil
+	}
+
+	filters := []ports.Filter{}
+	for _, nested := range where.And {
+		filters = append(filters, ports.And(userWhereToFilters(nested)...))
+	}
+
+	if len(where.Or) > 0 {
+		anyOf := make([]ports.Filter, len(where.Or))
+		for index, nested := range where.Or {
+			anyOf[index] = ports.And(userWhereToFilters(nested)...)
+		}
+		filters = append(filters, ports.Or(anyOf...))
+	}
+
+	if where.Not != nil {
+		filters = append(filters, ports.Not(ports.And(userWhereToFilters(where.Not)...)))
+	}
+
+	filters = append(filters, idFilters(where.ID)...)
+	filters = append(filters, stringFilters("username", where.Username)...)
+	filters = append(filters, stringFilters("name", where.Name)...)
+	filters = append(filters, stringFilters("role", where.Role)...)
+	filters = append(filters, stringFilters("provider", where.Provider)...)
+	filters = append(filters, stringFilters("status", where.Status)...)
+	filters = append(filters, timeFilters("createDate", where.CreateDate)...)
+	filters = append(filters, timeFilters("updateDate", where.UpdateDate)...)
+
+	return filters
+}
+
+// idFilters converts a GraphQL ID filter into repository filters for the _id field
+func idFilters(filter *model.IDFilter) []ports.Filter {
+	filters := []ports.Filter{}
+	if filter == nil {
+		return filters
+	}
+
+	filters = appendFilter(filters, "_id", ports.OP_EQ, filter.Eq)
+	filters = appendFilter(filters, "_id", ports.OP_NE, filter.Ne)
+	filters = appendFilter(filters, "_id", ports.OP_IN, filter.In)
+	filters = appendFilter(filters, "_id", ports.OP_NIN, filter.Nin)
+
+	return filters
+}
+
+// stringFilters converts a GraphQL String filter into repository filters for the field name
+func stringFilters(name string, filter *model.StringFilter) []ports.Filter {
+	filters := []ports.Filter{}
+	if filter == nil {
+		return filters
+	}
+
+	filters = appendFilter(filters, name, ports.OP_EQ, filter.Eq)
+	filters = appendFilter(filters, name, ports.OP_NE, filter.Ne)
+	filters = appendFilter(filters, name, ports.OP_IN, filter.In)
+	filters = appendFilter(filters, name, ports.OP_NIN, filter.Nin)
+	filters = appendFilter(filters, name, ports.OP_GT, filter.Gt)
+	filters = appendFilter(filters, name, ports.OP_GTE, filter.Gte)
+	filters = appendFilter(filters, name, ports.OP_LT, filter.Lt)
+	filters = appendFilter(filters, name, ports.OP_LTE, filter.Lte)
+	filters = appendFilter(filters, name, ports.OP_PREFIX, filter.Prefix)
+	filters = appendFilter(filters, name, ports.OP_CONTAINS, filter.Contains)
+	filters = appendFilter(filters, name, ports.OP_EXISTS, filter.Exists)
+
+	return filters
+}
+
+// timeFilters converts a GraphQL Time filter into repository filters for the field name
+func timeFilters(name string, filter *model.TimeFilter) []ports.Filter {
+	filters := []ports.Filter{}
+	if filter == nil {
+		return filters
+	}
+
+	filters = appendFilter(filters, name, ports.OP_EQ, filter.Eq)
+	filters = appendFilter(filters, name, ports.OP_NE, filter.Ne)
+	filters = appendFilter(filters, name, ports.OP_IN, filter.In)
+	filters = appendFilter(filters, name, ports.OP_NIN, filter.Nin)
+	filters = appendFilter(filters, name, ports.OP_GT, filter.Gt)
+	filters = appendFilter(filters, name, ports.OP_GTE, filter.Gte)
+	filters = appendFilter(filters, name, ports.OP_LT, filter.Lt)
+	filters = appendFilter(filters, name, ports.OP_LTE, filter.Lte)
+	filters = appendFilter(filters, name, ports.OP_EXISTS, filter.Exists)
+
+	return filters
+}
+
+// appendFilter adds a filter to filters if value was provided, pointers are
+// dereferenced and lists converted into a []interface{}
+func appendFilter(filters []ports.Filter, name string, operator string, value interface{}) []ports.Filter {
+	val := reflect.ValueOf(value)
+	switch val.Kind() {
+	case reflect.Ptr:
+		if val.IsNil() {
+			return filters
+		}
+		value = val.Elem().Interface()
+	case reflect.Slice:
+		if val.IsNil() {
+			return filters
+		}
+
+		values := make([]interface{}, val.Len())
+		for i := range values {
+			values[i] = reflect.Indirect(val.Index(i)).Interface()
+		}
+		value = values
+	}
+
+	return append(filters, ports.Filter{
+		Name:     name,
+		Operator: operator,
+		Value:    value,
+	})
+}
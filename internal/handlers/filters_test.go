@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sy-software/minerva-owl/cmd/graphql/graph/model"
+	"github.com/sy-software/minerva-owl/internal/core/domain"
+	"github.com/sy-software/minerva-owl/internal/core/ports"
+	"github.com/sy-software/minerva-owl/internal/core/service"
+	"github.com/sy-software/minerva-owl/mocks"
+)
+
+func TestWhereToFilters(t *testing.T) {
+	t.Run("Test field filters", func(t *testing.T) {
+		prefix := "Iron"
+		exists := true
+		date := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+
+		got := userWhereToFilters(&model.UserWhere{
+			ID: &model.IDFilter{
+				In: []string{"1", "2"},
+			},
+			Username: &model.StringFilter{
+				Prefix: &prefix,
+			},
+			CreateDate: &model.TimeFilter{
+				Gte:    &date,
+				Exists: &exists,
+			},
+		})
+
+		expected := []ports.Filter{
+			{Name: "_id", Operator: ports.OP_IN, Value: []interface{}{"1", "2"}},
+			{Name: "username", Operator: ports.OP_PREFIX, Value: "Iron"},
+			{Name: "createDate", Operator: ports.OP_GTE, Value: date},
+			{Name: "createDate", Operator: ports.OP_EXISTS, Value: true},
+		}
+
+		if !cmp.Equal(expected, got) {
+			t.Errorf("Expected filters: %+v got: %+v", expected, got)
+		}
+	})
+
+	t.Run("Test logical filters", func(t *testing.T) {
+		admin := "admin"
+		guest := "guest"
+
+		got := orgWhereToFilters(&model.OrganizationWhere{
+			Or: []*model.OrganizationWhere{
+				{Name: &model.StringFilter{Eq: &admin}},
+				{Name: &model.StringFilter{Eq: &guest}},
+			},
+			Not: &model.OrganizationWhere{
+				Logo: &model.StringFilter{Ne: &admin},
+			},
+		})
+
+		expected := []ports.Filter{
+			ports.Or(
+				ports.And(ports.Eq("name", "admin")),
+				ports.And(ports.Eq("name", "guest")),
+			),
+			ports.Not(ports.And(ports.Ne("logo", "admin"))),
+		}
+
+		if !cmp.Equal(expected, got) {
+			t.Errorf("Expected filters: %+v got: %+v", expected, got)
+		}
+	})
+
+	t.Run("Test nil where", func(t *testing.T) {
+		if got := userWhereToFilters(nil); len(got) != 0 {
+			t.Errorf("Expected no filters got: %+v", got)
+		}
+	})
+}
+
+func TestUserQueryWhere(t *testing.T) {
+	ctx := context.Background()
+	repo := mocks.MemRepo{
+		Data: map[string][]map[string]interface{}{
+			domain.USER_COL_NAME: {
+				{"id": "1", "username": "CapAmerica", "role": "user"},
+				{"id": "2", "username": "IronMan", "role": "admin"},
+				{"id": "3", "username": "IronHeart", "role": "user"},
+			},
+		},
+	}
+	config := domain.DefaultConfig()
+	config.Keys.Auth = authKey
+	service := service.NewUserService(&repo, config)
+	handlerInstance := NewUserGraphqlHandler(*service)
+
+	prefix := "Iron"
+	role := "user"
+	where := &model.UserWhere{
+		Username: &model.StringFilter{
+			Prefix: &prefix,
+		},
+	}
+
+	got, err := handlerInstance.Query(ctx, &role, where, nil, nil)
+
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	if len(got) != 1 || got[0].ID != "3" {
+		t.Errorf("Expected only user with id 3 got: %+v", got)
+	}
+
+	connection, err := handlerInstance.QueryConnection(ctx, nil, where, nil, nil)
+
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	total, err := handlerInstance.TotalCount(ctx, connection)
+
+	if err != nil || total != 2 {
+		t.Errorf("Expected total count to be: 2 got: %d with error: %v", total, err)
+	}
+}
@@ -7,8 +7,10 @@ import (
 
 	"github.com/99designs/gqlgen/graphql"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type ServerCtxKeys string
@@ -20,11 +22,20 @@ const (
 	OP_TYPE_KEY    ServerCtxKeys = "operation_type_key"
 	OP_NAME_KEY    ServerCtxKeys = "operation_name_key"
 	OP_RAW         ServerCtxKeys = "operation_raw_key"
+	LOADERS_KEY    ServerCtxKeys = "loaders_key"
 )
 
+// REQUEST_ID_HEADER carries the id of a request, incoming ids are kept and new ones are generated
+const REQUEST_ID_HEADER = "X-Request-ID"
+
+// Max length of an incoming request id, longer ids are replaced
+const maxRequestIdLength = 128
+
 // LogValues represents the values we want to include in server logs
 type LogValues struct {
 	ReqId      string
+	TraceId    string
+	SpanId     string
 	SerName    string
 	Path       string
 	Latency    time.Duration
@@ -53,12 +64,21 @@ func logValuesFromCtx(serName string, c *gin.Context) *LogValues {
 		msg = "Request"
 	}
 
-	reqId := c.Request.Context().Value(REQUEST_ID_KEY).(string)
+	reqId := RequestIDFromCtx(c.Request.Context())
 	actionType := c.Request.Context().Value(OP_TYPE_KEY)
 	action := c.Request.Context().Value(OP_NAME_KEY)
 	body := c.Request.Context().Value(OP_RAW)
+	spanContext := trace.SpanContextFromContext(c.Request.Context())
+	traceId, spanId := "", ""
+	if spanContext.IsValid() {
+		traceId = spanContext.TraceID().String()
+		spanId = spanContext.SpanID().String()
+	}
+
 	return &LogValues{
 		ReqId:      reqId,
+		TraceId:    traceId,
+		SpanId:     spanId,
 		SerName:    serName,
 		Latency:    time.Since(t),
 		StatusCode: c.Writer.Status(),
@@ -85,6 +105,8 @@ func logSwitch(data *LogValues) {
 
 	logger.
 		Str("req_id", data.ReqId).
+		Str("trace_id", data.TraceId).
+		Str("span_id", data.SpanId).
 		Interface("req_body", data.Body).
 		Str("ser_name", data.SerName).
 		Dur("resp_time", data.Latency).
@@ -115,11 +137,59 @@ func ErrorLogger(ctx context.Context) {
 	}
 }
 
-// GinCtxToCtxMiddleware stores Gin request context into a generic context
+// GinCtxToCtxMiddleware stores Gin request context into a generic context along with the request id
+// and a logger tagged with it. The id comes from the X-Request-ID header or it's generated,
+// either way it's echoed in the response headers
 func GinCtxToCtxMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
+		reqId := c.GetHeader(REQUEST_ID_HEADER)
+		if !validRequestId(reqId) {
+			reqId = uuid.NewString()
+		}
+		c.Header(REQUEST_ID_HEADER, reqId)
+
 		ctx := context.WithValue(c.Request.Context(), GIN_CTX_KEY, c)
-		ctx = context.WithValue(ctx, REQUEST_ID_KEY, "Franklin was here")
+		ctx = context.WithValue(ctx, REQUEST_ID_KEY, reqId)
+		logger := log.With().Str("req_id", reqId).Logger()
+		ctx = logger.WithContext(ctx)
+
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// validRequestId tells if an incoming request id is short and only has printable ASCII characters
+func validRequestId(reqId string) bool {
+	if reqId == "" || len(reqId) > maxRequestIdLength {
+		return false
+	}
+
+	for _, char := range reqId {
+		if char <= ' ' || char > '~' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// RequestIDFromCtx returns the id of the request or an empty string when there is none
+func RequestIDFromCtx(ctx context.Context) string {
+	reqId, _ := ctx.Value(REQUEST_ID_KEY).(string)
+	return reqId
+}
+
+// TimeoutMiddleware cancels the request context after timeout, stopping any storage
+// operation still running. A timeout <= 0 disables the deadline
+func TimeoutMiddleware(timeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if timeout <= 0 {
+			c.Next()
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
 
 		c.Request = c.Request.WithContext(ctx)
 		c.Next()
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/sy-software/minerva-owl/internal/utils"
+)
+
+func TestGinCtxToCtxMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(GinCtxToCtxMiddleware())
+
+	var reqId string
+	router.GET("/", func(c *gin.Context) {
+		reqId = RequestIDFromCtx(c.Request.Context())
+		utils.Logger(c.Request.Context()).Info().Msg("handled")
+	})
+
+	serve := func(header string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodGet, "/", nil)
+		if header != "" {
+			request.Header.Set(REQUEST_ID_HEADER, header)
+		}
+
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	t.Run("Incoming request ids are kept", func(t *testing.T) {
+		recorder := serve("upstream-42")
+
+		if reqId != "upstream-42" || recorder.Header().Get(REQUEST_ID_HEADER) != "upstream-42" {
+			t.Errorf("Expected request id: %q got: %q and header: %q", "upstream-42", reqId, recorder.Header().Get(REQUEST_ID_HEADER))
+		}
+	})
+
+	t.Run("Missing or invalid request ids are generated", func(t *testing.T) {
+		seen := map[string]bool{}
+		for _, header := range []string{"", "has spaces", strings.Repeat("x", maxRequestIdLength+1)} {
+			recorder := serve(header)
+
+			if reqId == "" || reqId == header || seen[reqId] {
+				t.Errorf("Expected a new unique request id for: %q got: %q", header, reqId)
+			}
+
+			if recorder.Header().Get(REQUEST_ID_HEADER) != reqId {
+				t.Errorf("Expected header: %q got: %q", reqId, recorder.Header().Get(REQUEST_ID_HEADER))
+			}
+			seen[reqId] = true
+		}
+	})
+
+	t.Run("Request logger carries the request id", func(t *testing.T) {
+		out := &bytes.Buffer{}
+		previous := log.Logger
+		log.Logger = zerolog.New(out)
+		defer func() { log.Logger = previous }()
+
+		serve("logged-id")
+
+		if !strings.Contains(out.String(), `"req_id":"logged-id"`) {
+			t.Errorf("Expected log to contain the request id got: %q", out.String())
+		}
+	})
+}
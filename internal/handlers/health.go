@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sy-software/minerva-owl/internal/core/domain"
+	"github.com/sy-software/minerva-owl/internal/repositories/storage"
+)
+
+// CONFIG_DEPENDENCY is the name and type of the dependency reporting the configuration is loaded
+const CONFIG_DEPENDENCY = "config"
+
+// HealthReport is the detailed status served by Health.DetailsHandler
+type HealthReport struct {
+	// STATUS_UP when the service is ready to serve requests
+	Status string `json:"status"`
+	// Whether the server is shutting down
+	ShuttingDown bool `json:"shuttingDown"`
+	// Status and latency of the configuration and each storage backend
+	Dependencies []storage.BackendHealth `json:"dependencies"`
+}
+
+// Health serves the liveness, readiness and detailed health endpoints
+type Health struct {
+	config       *domain.Config
+	store        *storage.Storage
+	shuttingDown int32
+}
+
+// NewHealth creates the health endpoints of a service using config and checking the backends of store
+func NewHealth(config *domain.Config, store *storage.Storage) *Health {
+	return &Health{
+		config: config,
+		store:  store,
+	}
+}
+
+// ShuttingDown makes readiness fail from now on, call it before the server stops listening
+func (health *Health) ShuttingDown() {
+	atomic.StoreInt32(&health.shuttingDown, 1)
+}
+
+// Report checks every dependency until ctx is done and tells if the service is ready
+func (health *Health) Report(ctx context.Context) HealthReport {
+	report := HealthReport{
+		ShuttingDown: atomic.LoadInt32(&health.shuttingDown) == 1,
+		Dependencies: []storage.BackendHealth{health.configHealth()},
+	}
+
+	if health.store != nil && health.config != nil {
+		backends := health.store.Health(ctx, health.config.Health.Timeout*time.Second)
+		report.Dependencies = append(report.Dependencies, backends...)
+	}
+
+	report.Status = storage.STATUS_DOWN
+	if !report.ShuttingDown && storage.Healthy(report.Dependencies) {
+		report.Status = storage.STATUS_UP
+	}
+
+	return report
+}
+
+// configHealth reports if the configuration was loaded
+func (health *Health) configHealth() storage.BackendHealth {
+	result := storage.BackendHealth{
+		Name:   CONFIG_DEPENDENCY,
+		Type:   CONFIG_DEPENDENCY,
+		Status: storage.STATUS_UP,
+	}
+
+	if health.config == nil {
+		result.Status = storage.STATUS_DOWN
+		result.Error = "configuration not loaded"
+	}
+
+	return result
+}
+
+// LivenessHandler answers while the process is up, it doesn't check any dependency
+func (health *Health) LivenessHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"status": storage.STATUS_UP})
+	}
+}
+
+// ReadinessHandler answers 200 when the service is ready and 503 otherwise,
+// with the status of each dependency by name
+func (health *Health) ReadinessHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		report := health.Report(c.Request.Context())
+		dependencies := make(map[string]string, len(report.Dependencies))
+		for _, dependency := range report.Dependencies {
+			dependencies[dependency.Name] = dependency.Status
+		}
+
+		c.JSON(statusCode(report), gin.H{"status": report.Status, "dependencies": dependencies})
+	}
+}
+
+// DetailsHandler serves the HealthReport with the same status code as ReadinessHandler
+func (health *Health) DetailsHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		report := health.Report(c.Request.Context())
+		c.JSON(statusCode(report), report)
+	}
+}
+
+// statusCode returns the HTTP status of a report
+func statusCode(report HealthReport) int {
+	if report.Status != storage.STATUS_UP {
+		return http.StatusServiceUnavailable
+	}
+
+	return http.StatusOK
+}
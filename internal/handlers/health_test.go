@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sy-software/minerva-owl/internal/core/domain"
+	"github.com/sy-software/minerva-owl/internal/repositories/storage"
+	"github.com/sy-software/minerva-owl/mocks"
+)
+
+func TestHealth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	config := domain.DefaultConfig()
+
+	var pingErr error
+	backend := storage.NewBackend(&mocks.MemRepo{}, func(ctx context.Context) error {
+		return pingErr
+	}, nil)
+	backend.Name = "primary"
+	store := &storage.Storage{Backends: []*storage.Backend{backend}}
+
+	health := NewHealth(&config, store)
+	router := gin.New()
+	router.GET("/healthz", health.LivenessHandler())
+	router.GET("/readyz", health.ReadinessHandler())
+	router.GET("/health", health.DetailsHandler())
+
+	serve := func(path string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+		return recorder
+	}
+
+	t.Run("Ready when every backend answers", func(t *testing.T) {
+		recorder := serve("/readyz")
+		if recorder.Code != http.StatusOK {
+			t.Errorf("Expected status: %d got: %d", http.StatusOK, recorder.Code)
+		}
+
+		body := struct {
+			Status       string
+			Dependencies map[string]string
+		}{}
+		if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		if body.Dependencies[CONFIG_DEPENDENCY] != storage.STATUS_UP || body.Dependencies["primary"] != storage.STATUS_UP {
+			t.Errorf("Expected config and primary to be up got: %+v", body.Dependencies)
+		}
+	})
+
+	t.Run("Details list each backend", func(t *testing.T) {
+		pingErr = errors.New("connection refused")
+		defer func() { pingErr = nil }()
+
+		recorder := serve("/health")
+		if recorder.Code != http.StatusServiceUnavailable {
+			t.Errorf("Expected status: %d got: %d", http.StatusServiceUnavailable, recorder.Code)
+		}
+
+		report := HealthReport{}
+		if err := json.Unmarshal(recorder.Body.Bytes(), &report); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		if len(report.Dependencies) != 2 {
+			t.Fatalf("Expected config and primary dependencies got: %+v", report.Dependencies)
+		}
+
+		if report.Dependencies[0].Name != CONFIG_DEPENDENCY || report.Dependencies[0].Status != storage.STATUS_UP {
+			t.Errorf("Expected config to be up got: %+v", report.Dependencies[0])
+		}
+
+		if report.Dependencies[1].Name != "primary" || report.Dependencies[1].Error != "connection refused" {
+			t.Errorf("Expected failing primary backend got: %+v", report.Dependencies[1])
+		}
+	})
+
+	t.Run("Readiness fails while shutting down but liveness doesn't", func(t *testing.T) {
+		health.ShuttingDown()
+
+		if got := serve("/readyz").Code; got != http.StatusServiceUnavailable {
+			t.Errorf("Expected status: %d got: %d", http.StatusServiceUnavailable, got)
+		}
+
+		if got := serve("/healthz").Code; got != http.StatusOK {
+			t.Errorf("Expected status: %d got: %d", http.StatusOK, got)
+		}
+	})
+}
+
+func TestHealthWithoutConfig(t *testing.T) {
+	report := NewHealth(nil, nil).Report(context.Background())
+
+	if report.Status != storage.STATUS_DOWN {
+		t.Errorf("Expected status: %v got: %v", storage.STATUS_DOWN, report.Status)
+	}
+
+	if len(report.Dependencies) != 1 || report.Dependencies[0].Name != CONFIG_DEPENDENCY || report.Dependencies[0].Status != storage.STATUS_DOWN {
+		t.Errorf("Expected config to be down got: %+v", report.Dependencies)
+	}
+}
@@ -0,0 +1,213 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/sy-software/minerva-owl/internal/core/domain"
+	"github.com/sy-software/minerva-owl/internal/core/ports"
+	"github.com/sy-software/minerva-owl/internal/core/service"
+)
+
+// How long a loader waits for more ids before fetching a batch
+const loaderWait = 2 * time.Millisecond
+
+// Max number of ids fetched by a single batch, a full batch is fetched without waiting
+const loaderMaxBatch = 100
+
+var loaderBatchSize = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace: "owl",
+	Subsystem: "dataloader",
+	Name:      "batch_size",
+	Help:      "Number of distinct ids fetched by each DataLoader batch",
+	Buckets:   []float64{1, 2, 5, 10, 25, 50, 100},
+}, []string{"collection"})
+
+// FetchFunc returns the items with any of the ids mapped by their id, missing ids are skipped
+type FetchFunc func(ctx context.Context, ids []string) (map[string]interface{}, error)
+
+// Loader batches and de-duplicates the lookups by id of a single collection, so
+// resolving a list issues one GetMany instead of a Get per row.
+//
+// Results are cached for the lifetime of the loader, which must not outlive a request,
+// except the context errors so a cancelled lookup can be retried
+type Loader struct {
+	// ctx is the context of the request, used to fetch every batch
+	ctx        context.Context
+	collection string
+	fetch      FetchFunc
+	wait       time.Duration
+	mutex      sync.Mutex
+	cache      map[string]*loaderCall
+	batch      *loaderBatch
+}
+
+// loaderCall is the pending result of an id
+type loaderCall struct {
+	done  chan struct{}
+	value interface{}
+	err   error
+}
+
+// loaderBatch holds the ids waiting to be fetched together
+type loaderBatch struct {
+	ids   []string
+	calls []*loaderCall
+	full  chan struct{}
+}
+
+// NewLoader creates a Loader for collection fetching the batches with fetch and the request ctx
+func NewLoader(ctx context.Context, collection string, fetch FetchFunc) *Loader {
+	return &Loader{
+		ctx:        ctx,
+		collection: collection,
+		fetch:      fetch,
+		wait:       loaderWait,
+		cache:      map[string]*loaderCall{},
+	}
+}
+
+// Load returns the item with id, ids without an item return ports.ErrItemNotFound.
+//
+// The batch is fetched with the request context, ctx only limits how long Load waits for it
+func (loader *Loader) Load(ctx context.Context, id string) (interface{}, error) {
+	loader.mutex.Lock()
+	call, cached := loader.cache[id]
+	if !cached {
+		call = &loaderCall{done: make(chan struct{})}
+		loader.cache[id] = call
+		loader.enqueue(id, call)
+	}
+	loader.mutex.Unlock()
+
+	select {
+	case <-call.done:
+		return call.value, call.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// enqueue adds id to the current batch, it must be called with the mutex locked
+func (loader *Loader) enqueue(id string, call *loaderCall) {
+	if loader.batch == nil {
+		loader.batch = &loaderBatch{full: make(chan struct{})}
+		go loader.run(loader.batch)
+	}
+
+	batch := loader.batch
+	batch.ids = append(batch.ids, id)
+	batch.calls = append(batch.calls, call)
+
+	if len(batch.ids) == loaderMaxBatch {
+		loader.batch = nil
+		close(batch.full)
+	}
+}
+
+// run fetches batch once it's full or the loader wait has passed
+func (loader *Loader) run(batch *loaderBatch) {
+	timer := time.NewTimer(loader.wait)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		loader.mutex.Lock()
+		if loader.batch == batch {
+			loader.batch = nil
+		}
+		loader.mutex.Unlock()
+	case <-batch.full:
+	}
+
+	loaderBatchSize.WithLabelValues(loader.collection).Observe(float64(len(batch.ids)))
+	found, err := loader.fetch(loader.ctx, batch.ids)
+
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		loader.mutex.Lock()
+		for index, call := range batch.calls {
+			if loader.cache[batch.ids[index]] == call {
+				delete(loader.cache, batch.ids[index])
+			}
+		}
+		loader.mutex.Unlock()
+	}
+
+	for index, call := range batch.calls {
+		id := batch.ids[index]
+		value, exists := found[id]
+
+		switch {
+		case err != nil:
+			call.err = err
+		case !exists:
+			call.err = ports.ErrItemNotFound{Id: &id, Model: loader.collection}
+		default:
+			call.value = value
+		}
+
+		close(call.done)
+	}
+}
+
+// Loaders holds the DataLoaders of a single GraphQL request
+type Loaders struct {
+	Organizations *Loader
+	Users         *Loader
+	Teams         *Loader
+}
+
+// NewLoaders creates the DataLoaders of the request with ctx fetching the items with the services
+func NewLoaders(ctx context.Context, orgs service.OrganizationService, users service.UserService, teams service.TeamService) *Loaders {
+	return &Loaders{
+		Organizations: NewLoader(ctx, domain.ORG_COL_NAME, func(ctx context.Context, ids []string) (map[string]interface{}, error) {
+			results, err := orgs.GetMany(ctx, ids)
+			found := make(map[string]interface{}, len(results))
+			for _, org := range results {
+				found[org.Id] = org
+			}
+
+			return found, err
+		}),
+		Users: NewLoader(ctx, domain.USER_COL_NAME, func(ctx context.Context, ids []string) (map[string]interface{}, error) {
+			results, err := users.GetMany(ctx, ids)
+			found := make(map[string]interface{}, len(results))
+			for _, user := range results {
+				found[user.Id] = user
+			}
+
+			return found, err
+		}),
+		Teams: NewLoader(ctx, domain.TEAM_COL_NAME, func(ctx context.Context, ids []string) (map[string]interface{}, error) {
+			results, err := teams.GetMany(ctx, ids)
+			found := make(map[string]interface{}, len(results))
+			for _, team := range results {
+				found[team.Id] = team
+			}
+
+			return found, err
+		}),
+	}
+}
+
+// LoadersMiddleware stores new Loaders into the context of every request,
+// so lookups are only batched and cached while resolving a single operation
+func LoadersMiddleware(orgs service.OrganizationService, users service.UserService, teams service.TeamService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		ctx = context.WithValue(ctx, LOADERS_KEY, NewLoaders(ctx, orgs, users, teams))
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// LoadersFromCtx returns the Loaders of the request or nil when there are none
+func LoadersFromCtx(ctx context.Context) *Loaders {
+	loaders, _ := ctx.Value(LOADERS_KEY).(*Loaders)
+	return loaders
+}
@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sy-software/minerva-owl/internal/core/domain"
+	"github.com/sy-software/minerva-owl/internal/core/service"
+	"github.com/sy-software/minerva-owl/mocks"
+)
+
+func TestLoaders(t *testing.T) {
+	ctx := context.Background()
+	data := map[string][]map[string]interface{}{
+		domain.USER_COL_NAME: {
+			{"id": "1", "username": "CapAmerica"},
+			{"id": "2", "username": "IronMan"},
+			{"id": "3", "username": "Hulk"},
+		},
+	}
+
+	t.Run("Concurrent lookups are batched and de-duplicated", func(t *testing.T) {
+		batches := [][]string{}
+		stored := mocks.MemRepo{Data: data}
+		repo := mocks.MemRepo{
+			GetManyInterceptor: func(ctx context.Context, collection string, ids []string, results interface{}) error {
+				batches = append(batches, ids)
+				return stored.GetMany(ctx, collection, ids, results)
+			},
+		}
+
+		config := domain.DefaultConfig()
+		handlerInstance := NewUserGraphqlHandler(*service.NewUserService(&repo, config))
+		loaders := NewLoaders(
+			ctx,
+			*service.NewOrgService(&repo, config),
+			*service.NewUserService(&repo, config),
+			*service.NewTeamService(&repo, config),
+		)
+		// Wait long enough for every goroutine to join the batch
+		loaders.Users.wait = 50 * time.Millisecond
+		ctx := context.WithValue(ctx, LOADERS_KEY, loaders)
+
+		ids := []string{"1", "3", "1", "2", "3"}
+		got := make([]string, len(ids))
+		var wg sync.WaitGroup
+		for index, id := range ids {
+			wg.Add(1)
+			go func(index int, id string) {
+				defer wg.Done()
+				user, err := handlerInstance.QueryById(ctx, id)
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+					return
+				}
+
+				got[index] = user.Username
+			}(index, id)
+		}
+		wg.Wait()
+
+		expected := []string{"CapAmerica", "Hulk", "CapAmerica", "IronMan", "Hulk"}
+		if !cmp.Equal(expected, got) {
+			t.Errorf("Expected users: %v got: %v", expected, got)
+		}
+
+		if len(batches) != 1 {
+			t.Fatalf("Expected a single batch got: %v", batches)
+		}
+
+		sort.Strings(batches[0])
+		if !cmp.Equal([]string{"1", "2", "3"}, batches[0]) {
+			t.Errorf("Expected batch: [1 2 3] got: %v", batches[0])
+		}
+	})
+
+	t.Run("Missing ids are not found", func(t *testing.T) {
+		repo := mocks.MemRepo{Data: data}
+		config := domain.DefaultConfig()
+		handlerInstance := NewUserGraphqlHandler(*service.NewUserService(&repo, config))
+		ctx := context.WithValue(ctx, LOADERS_KEY, NewLoaders(
+			ctx,
+			*service.NewOrgService(&repo, config),
+			*service.NewUserService(&repo, config),
+			*service.NewTeamService(&repo, config),
+		))
+
+		_, err := handlerInstance.QueryById(ctx, "missing")
+		if err == nil || err.Error() != "not_found" {
+			t.Errorf("Expected error: not_found got: %v", err)
+		}
+	})
+
+	t.Run("Batches don't use the context of their first lookup", func(t *testing.T) {
+		loader := NewLoader(ctx, domain.USER_COL_NAME, func(ctx context.Context, ids []string) (map[string]interface{}, error) {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+
+			return map[string]interface{}{"1": "CapAmerica"}, nil
+		})
+		loader.wait = 20 * time.Millisecond
+
+		cancelled, cancelFn := context.WithCancel(ctx)
+		first := make(chan error, 1)
+		go func() {
+			_, err := loader.Load(cancelled, "1")
+			first <- err
+		}()
+
+		time.Sleep(5 * time.Millisecond)
+		cancelFn()
+
+		got, err := loader.Load(ctx, "1")
+		if err != nil || got != "CapAmerica" {
+			t.Errorf("Expected user: CapAmerica got: %v with error: %v", got, err)
+		}
+
+		if err := <-first; err != context.Canceled {
+			t.Errorf("Expected the cancelled lookup to fail with: %v got: %v", context.Canceled, err)
+		}
+	})
+
+	t.Run("Context errors are not cached", func(t *testing.T) {
+		calls := 0
+		loader := NewLoader(ctx, domain.USER_COL_NAME, func(ctx context.Context, ids []string) (map[string]interface{}, error) {
+			calls++
+			if calls == 1 {
+				return nil, context.DeadlineExceeded
+			}
+
+			return map[string]interface{}{"1": "CapAmerica"}, nil
+		})
+		loader.wait = 0
+
+		if _, err := loader.Load(ctx, "1"); err != context.DeadlineExceeded {
+			t.Errorf("Expected error: %v got: %v", context.DeadlineExceeded, err)
+		}
+
+		got, err := loader.Load(ctx, "1")
+		if err != nil || got != "CapAmerica" || calls != 2 {
+			t.Errorf("Expected user: CapAmerica after %d fetches got: %v after %d with error: %v", 2, got, calls, err)
+		}
+	})
+}
@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// anonymousOperation labels the GraphQL operations without name
+const anonymousOperation = "anonymous"
+
+var (
+	httpRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "owl",
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "HTTP requests by method, route and status code",
+	}, []string{"method", "route", "status"})
+	httpDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "owl",
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "Latency of the HTTP requests by method and route",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+	gqlOperations = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "owl",
+		Subsystem: "graphql",
+		Name:      "operations_total",
+		Help:      "GraphQL operations by name, type and outcome",
+	}, []string{"operation_name", "operation_type", "outcome"})
+	gqlDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "owl",
+		Subsystem: "graphql",
+		Name:      "operation_duration_seconds",
+		Help:      "Latency of the GraphQL operations by name and type",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"operation_name", "operation_type"})
+	gqlResolverErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "owl",
+		Subsystem: "graphql",
+		Name:      "resolver_errors_total",
+		Help:      "Errors returned by the GraphQL resolvers by object and field",
+	}, []string{"object", "field"})
+)
+
+// MetricsHandler serves the Prometheus metrics in text format
+func MetricsHandler() gin.HandlerFunc {
+	return gin.WrapH(promhttp.Handler())
+}
+
+// MetricsMiddleware is a Gin middleware counting the requests and observing their latency,
+// requests without a matching route are reported with an empty route
+func MetricsMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		httpRequests.WithLabelValues(c.Request.Method, route, strconv.Itoa(c.Writer.Status())).Inc()
+		httpDuration.WithLabelValues(c.Request.Method, route).Observe(time.Since(start).Seconds())
+	}
+}
+
+// operationLabels returns the name and type of a GraphQL operation
+func operationLabels(oc *graphql.OperationContext) (string, string) {
+	name, opType := anonymousOperation, ""
+	if oc.OperationName != "" {
+		name = oc.OperationName
+	}
+
+	if oc.Operation != nil {
+		opType = string(oc.Operation.Operation)
+	}
+
+	return name, opType
+}
+
+// GQLMetricsMiddleware can be passed into Gqlgen server as AroundOperation middleware,
+// it counts the operations and observes their latency by operation name and type
+func GQLMetricsMiddleware(ctx context.Context, next graphql.OperationHandler) graphql.ResponseHandler {
+	name, opType := operationLabels(graphql.GetOperationContext(ctx))
+	start := time.Now()
+	handler := next(ctx)
+
+	return func(ctx context.Context) *graphql.Response {
+		response := handler(ctx)
+		if response == nil {
+			return response
+		}
+
+		outcome := "ok"
+		if len(response.Errors) > 0 {
+			outcome = "error"
+		}
+
+		gqlOperations.WithLabelValues(name, opType, outcome).Inc()
+		gqlDuration.WithLabelValues(name, opType).Observe(time.Since(start).Seconds())
+		return response
+	}
+}
+
+// GQLResolverMetricsMiddleware can be passed into Gqlgen server as AroundFields middleware,
+// it counts the errors returned by each resolver
+func GQLResolverMetricsMiddleware(ctx context.Context, next graphql.Resolver) (interface{}, error) {
+	result, err := next(ctx)
+
+	if err != nil {
+		fc := graphql.GetFieldContext(ctx)
+		gqlResolverErrors.WithLabelValues(fc.Object, fc.Field.Name).Inc()
+	}
+
+	return result, err
+}
@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMetricsMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(MetricsMiddleware())
+	router.GET("/teapot/:id", func(c *gin.Context) {
+		c.Status(http.StatusTeapot)
+	})
+	router.GET("/metrics", MetricsHandler())
+
+	for i := 0; i < 2; i++ {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/teapot/1", nil))
+	}
+
+	got := testutil.ToFloat64(httpRequests.WithLabelValues(http.MethodGet, "/teapot/:id", "418"))
+	if got != 2 {
+		t.Errorf("Expected 2 requests got: %v", got)
+	}
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	expected := `owl_http_requests_total{method="GET",route="/teapot/:id",status="418"} 2`
+	if !strings.Contains(recorder.Body.String(), expected) {
+		t.Errorf("Expected metrics to contain: %q got: %q", expected, recorder.Body.String())
+	}
+}
@@ -1,8 +1,11 @@
 package handlers
 
 import (
+	"context"
+
 	"github.com/sy-software/minerva-owl/cmd/graphql/graph/model"
 	"github.com/sy-software/minerva-owl/internal/core/domain"
+	"github.com/sy-software/minerva-owl/internal/core/ports"
 	"github.com/sy-software/minerva-owl/internal/core/service"
 	"github.com/sy-software/minerva-owl/internal/utils"
 )
@@ -17,10 +20,10 @@ func NewOrgGraphqlHandler(service service.OrganizationService) *OrganizationGrap
 	}
 }
 
-func (handler *OrganizationGraphqlHandler) Create(name string, description string, logo *string) (*model.Organization, error) {
+func (handler *OrganizationGraphqlHandler) Create(ctx context.Context, name string, description string, logo *string, owner *string) (*model.Organization, error) {
 	validatedLogo := utils.CoalesceStr(logo, "")
 
-	org, err := handler.service.Create(name, description, validatedLogo)
+	org, err := handler.service.Create(ctx, name, description, validatedLogo, utils.CoalesceStr(owner, ""))
 
 	if err != nil {
 		return nil, err
@@ -36,32 +39,36 @@ func (handler *OrganizationGraphqlHandler) Create(name string, description strin
 	return &graphModel, err
 }
 
-func (handler *OrganizationGraphqlHandler) Update(id string, name *string, description *string, logo *string) (*model.Organization, error) {
-	// TODO: Avoid get to save but for now is required to support PATCH
-	current, err := handler.service.Get(id)
+// Update changes only the fields provided in the UpdateOrganization input,
+// fields with an explicit null are cleared
+func (handler *OrganizationGraphqlHandler) Update(ctx context.Context, input map[string]interface{}) (*model.Organization, error) {
+	id, mask := inputToFieldMask(input, orgUpdateFields)
+	output, err := handler.service.Patch(ctx, id, mask)
 
 	if err != nil {
 		return nil, err
 	}
 
-	new := domain.Organization{
-		Id:          id,
-		Name:        utils.CoalesceStr(name, current.Name),
-		Description: utils.CoalesceStr(description, current.Description),
-		Logo:        utils.CoalesceStr(logo, current.Logo),
-	}
+	return orgToGraphQLModel(&output), nil
+}
 
-	output, err := handler.service.Update(new)
+// orgSortFields maps the GraphQL sort fields to the Organization fields
+var orgSortFields = map[model.OrganizationSortField]string{
+	model.OrganizationSortFieldName:        "name",
+	model.OrganizationSortFieldDescription: "description",
+}
 
-	if err != nil {
-		return nil, err
+// Query returns a paginated list of organizations matching where and sorted with orderBy
+func (handler *OrganizationGraphqlHandler) Query(ctx context.Context, where *model.OrganizationWhere, page *int, pageSize *int, orderBy ...*model.OrganizationOrderBy) ([]*model.Organization, error) {
+	sort := make([]ports.Sort, len(orderBy))
+	for index, order := range orderBy {
+		sort[index] = ports.Sort{
+			Field:      orgSortFields[order.Field],
+			Descending: isDescending(order.Direction),
+		}
 	}
 
-	return orgToGraphQLModel(&output), nil
-}
-
-func (handler *OrganizationGraphqlHandler) Query(page *int, pageSize *int) ([]*model.Organization, error) {
-	all, err := handler.service.List(page, pageSize)
+	all, err := handler.service.Search(ctx, orgWhereToFilters(where), page, pageSize, sort...)
 
 	if err != nil {
 		return []*model.Organization{}, err
@@ -76,8 +83,38 @@ func (handler *OrganizationGraphqlHandler) Query(page *int, pageSize *int) ([]*m
 	return out, nil
 }
 
-func (handler *OrganizationGraphqlHandler) QueryById(id string) (*model.Organization, error) {
-	out, err := handler.service.Get(id)
+// QueryConnection returns a Relay connection with up to first organizations after the cursor matching where
+func (handler *OrganizationGraphqlHandler) QueryConnection(ctx context.Context, where *model.OrganizationWhere, first *int, after *string) (*model.OrganizationConnection, error) {
+	filters := orgWhereToFilters(where)
+	all, pageInfo, err := handler.service.ListPage(ctx, first, after, filters...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	edges := make([]*model.OrganizationEdge, len(all))
+
+	for index := range all {
+		edges[index] = &model.OrganizationEdge{
+			Cursor: pageInfo.Cursors[index],
+			Node:   orgToGraphQLModel(&all[index]),
+		}
+	}
+
+	return &model.OrganizationConnection{
+		Edges:    edges,
+		PageInfo: pageInfoToGraphQL(&pageInfo),
+		Filters:  filters,
+	}, nil
+}
+
+// TotalCount returns how many organizations are available through the connection
+func (handler *OrganizationGraphqlHandler) TotalCount(ctx context.Context, connection *model.OrganizationConnection) (int, error) {
+	return handler.service.Count(ctx, connection.Filters...)
+}
+
+func (handler *OrganizationGraphqlHandler) QueryById(ctx context.Context, id string) (*model.Organization, error) {
+	out, err := handler.get(ctx, id)
 
 	if err != nil {
 		return nil, err
@@ -86,14 +123,29 @@ func (handler *OrganizationGraphqlHandler) QueryById(id string) (*model.Organiza
 	return orgToGraphQLModel(&out), nil
 }
 
-func (handler *OrganizationGraphqlHandler) Delete(id string) (*model.Organization, error) {
-	out, err := handler.service.Get(id)
+// get returns the organization with id through the request DataLoader when there is one
+func (handler *OrganizationGraphqlHandler) get(ctx context.Context, id string) (domain.Organization, error) {
+	loaders := LoadersFromCtx(ctx)
+	if loaders == nil {
+		return handler.service.Get(ctx, id)
+	}
+
+	value, err := loaders.Organizations.Load(ctx, id)
+	if err != nil {
+		return domain.Organization{}, err
+	}
+
+	return value.(domain.Organization), nil
+}
+
+func (handler *OrganizationGraphqlHandler) Delete(ctx context.Context, id string) (*model.Organization, error) {
+	out, err := handler.service.Get(ctx, id)
 
 	if err != nil {
 		return nil, err
 	}
 
-	err = handler.service.Delete(id, false)
+	err = handler.service.Delete(ctx, id, false)
 
 	if err != nil {
 		return nil, err
@@ -102,6 +154,48 @@ func (handler *OrganizationGraphqlHandler) Delete(id string) (*model.Organizatio
 	return orgToGraphQLModel(&out), nil
 }
 
+// CreateMany saves new organizations, the result of each organization is reported
+func (handler *OrganizationGraphqlHandler) CreateMany(ctx context.Context, inputs []*model.NewOrganization, stopOnError *bool) (*model.BulkPayload, error) {
+	orgs := make([]domain.Organization, len(inputs))
+	owners := make([]string, len(inputs))
+	for index, input := range inputs {
+		orgs[index] = domain.Organization{
+			Name:        input.Name,
+			Description: input.Description,
+			Logo:        utils.CoalesceStr(input.Logo, ""),
+		}
+		owners[index] = utils.CoalesceStr(input.Owner, "")
+	}
+
+	results, err := handler.service.CreateMany(ctx, orgs, owners, utils.CoalesceBool(stopOnError, false))
+	if err != nil {
+		return nil, err
+	}
+
+	return bulkToGraphQL(ctx, results, nil), nil
+}
+
+// UpdateMany changes only the fields provided in each UpdateOrganization input,
+// the result of each organization is reported
+func (handler *OrganizationGraphqlHandler) UpdateMany(ctx context.Context, inputs []*model.UpdateInput, stopOnError *bool) (*model.BulkPayload, error) {
+	results, err := handler.service.PatchMany(ctx, inputsToPatches(inputs, orgUpdateFields), utils.CoalesceBool(stopOnError, false))
+	if err != nil {
+		return nil, err
+	}
+
+	return bulkToGraphQL(ctx, results, nil), nil
+}
+
+// DeleteMany removes the organizations with the ids, the result of each organization is reported
+func (handler *OrganizationGraphqlHandler) DeleteMany(ctx context.Context, ids []string, stopOnError *bool) (*model.BulkPayload, error) {
+	results, err := handler.service.DeleteMany(ctx, ids, false, utils.CoalesceBool(stopOnError, false))
+	if err != nil {
+		return nil, err
+	}
+
+	return bulkToGraphQL(ctx, results, nil), nil
+}
+
 func orgToGraphQLModel(source *domain.Organization) *model.Organization {
 	return &model.Organization{
 		ID:          source.Id,
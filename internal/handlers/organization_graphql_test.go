@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"context"
 	"regexp"
 	"strconv"
 	"testing"
@@ -48,6 +49,7 @@ func TestOrgToGraphQLModel(t *testing.T) {
 }
 
 func TestOrgCreateOperation(t *testing.T) {
+	ctx := context.Background()
 	t.Run("Create an Organization without logo", func(t *testing.T) {
 		data := map[string][]map[string]interface{}{
 			"organizations": {},
@@ -66,15 +68,15 @@ func TestOrgCreateOperation(t *testing.T) {
 			Description: "Description",
 			Logo:        nil,
 		}
-		got, err := handlerInstance.Create(expected.Name, expected.Description, expected.Logo)
+		got, err := handlerInstance.Create(ctx, expected.Name, expected.Description, expected.Logo, nil)
 
 		if err != nil {
 			t.Errorf("Unexpected error: %v", err)
 		}
 
-		match, err := regexp.MatchString(mocks.ID_REGEX, got.ID)
+		match, err := regexp.MatchString(mocks.OBJECTID_REGEX, got.ID)
 		if !match || err != nil {
-			t.Errorf("ID is not V4 UUID got: %q with error: %v", got.ID, err)
+			t.Errorf("ID is not an ObjectID got: %q with error: %v", got.ID, err)
 		}
 
 		if got.Name != expected.Name {
@@ -113,15 +115,15 @@ func TestOrgCreateOperation(t *testing.T) {
 			Description: "Description",
 			Logo:        &logo,
 		}
-		got, err := handlerInstance.Create(expected.Name, expected.Description, expected.Logo)
+		got, err := handlerInstance.Create(ctx, expected.Name, expected.Description, expected.Logo, nil)
 
 		if err != nil {
 			t.Errorf("Unexpected error: %v", err)
 		}
 
-		match, err := regexp.MatchString(mocks.ID_REGEX, got.ID)
+		match, err := regexp.MatchString(mocks.OBJECTID_REGEX, got.ID)
 		if !match || err != nil {
-			t.Errorf("ID is not V4 UUID got: %q with error: %v", got.ID, err)
+			t.Errorf("ID is not an ObjectID got: %q with error: %v", got.ID, err)
 		}
 
 		if got.Name != expected.Name {
@@ -143,6 +145,7 @@ func TestOrgCreateOperation(t *testing.T) {
 }
 
 func TestOrgQueryOperations(t *testing.T) {
+	ctx := context.Background()
 	t.Run("Query all items", func(t *testing.T) {
 		base := []map[string]interface{}{
 			{
@@ -175,7 +178,7 @@ func TestOrgQueryOperations(t *testing.T) {
 		orgService := service.NewOrgService(&repo, domain.DefaultConfig())
 		handlerInstance := NewOrgGraphqlHandler(*orgService)
 
-		got, err := handlerInstance.Query(nil, nil)
+		got, err := handlerInstance.Query(ctx, nil, nil, nil)
 
 		if err != nil {
 			t.Errorf("Unexpected error: %v", err)
@@ -225,7 +228,7 @@ func TestOrgQueryOperations(t *testing.T) {
 		orgService := service.NewOrgService(&repo, domain.DefaultConfig())
 		handlerInstance := NewOrgGraphqlHandler(*orgService)
 
-		got, err := handlerInstance.QueryById(expected.ID)
+		got, err := handlerInstance.QueryById(ctx, expected.ID)
 
 		if err != nil {
 			t.Errorf("Unexpected error: %v", err)
@@ -256,7 +259,7 @@ func TestOrgQueryOperations(t *testing.T) {
 		orgService := service.NewOrgService(&repo, domain.DefaultConfig())
 		handlerInstance := NewOrgGraphqlHandler(*orgService)
 
-		_, err := handlerInstance.QueryById("myid")
+		_, err := handlerInstance.QueryById(ctx, "myid")
 
 		if err == nil {
 			t.Errorf("Expected error got nil")
@@ -270,6 +273,7 @@ func TestOrgQueryOperations(t *testing.T) {
 }
 
 func TestOrgQueryPagination(t *testing.T) {
+	ctx := context.Background()
 	dummydata := make([]domain.Organization, 20)
 	dummyDict := make([]map[string]interface{}, 20)
 
@@ -311,7 +315,7 @@ func TestOrgQueryPagination(t *testing.T) {
 
 		handlerInstance := NewOrgGraphqlHandler(*orgService)
 
-		got, err := handlerInstance.Query(nil, &pageSize)
+		got, err := handlerInstance.Query(ctx, nil, nil, &pageSize)
 
 		if err != nil {
 			t.Errorf("Got error while getting all organizations: %v", err)
@@ -340,7 +344,7 @@ func TestOrgQueryPagination(t *testing.T) {
 
 		handlerInstance := NewOrgGraphqlHandler(*orgService)
 
-		got, err := handlerInstance.Query(&page, &pageSize)
+		got, err := handlerInstance.Query(ctx, nil, &page, &pageSize)
 
 		if err != nil {
 			t.Errorf("Got error while getting all organizations: %v", err)
@@ -370,7 +374,7 @@ func TestOrgQueryPagination(t *testing.T) {
 
 		handlerInstance := NewOrgGraphqlHandler(*orgService)
 
-		got, err := handlerInstance.Query(&page, &pageSize)
+		got, err := handlerInstance.Query(ctx, nil, &page, &pageSize)
 
 		if err != nil {
 			t.Errorf("Got error while getting all organizations: %v", err)
@@ -401,7 +405,7 @@ func TestOrgQueryPagination(t *testing.T) {
 
 		handlerInstance := NewOrgGraphqlHandler(*orgService)
 
-		got, err := handlerInstance.Query(&page, &pageSize)
+		got, err := handlerInstance.Query(ctx, nil, &page, &pageSize)
 
 		if err != nil {
 			t.Errorf("Got error while getting all organizations: %v", err)
@@ -427,7 +431,7 @@ func TestOrgQueryPagination(t *testing.T) {
 
 		handlerInstance := NewOrgGraphqlHandler(*orgService)
 
-		got, err := handlerInstance.Query(&page, &pageSize)
+		got, err := handlerInstance.Query(ctx, nil, &page, &pageSize)
 
 		if err != nil {
 			t.Errorf("Got error while getting all organizations: %v", err)
@@ -443,7 +447,105 @@ func TestOrgQueryPagination(t *testing.T) {
 	})
 }
 
+func TestOrgQueryConnection(t *testing.T) {
+	ctx := context.Background()
+	dummyDict := make([]map[string]interface{}, 7)
+
+	for i := 0; i < 7; i++ {
+		str := strconv.Itoa(i)
+		dummyDict[i] = map[string]interface{}{
+			"id":          str,
+			"name":        "name " + str,
+			"description": "description " + str,
+		}
+	}
+
+	repo := mocks.MemRepo{
+		Data: map[string][]map[string]interface{}{
+			"organizations": dummyDict,
+		},
+	}
+
+	orgService := service.NewOrgService(&repo, domain.Config{
+		Pagination: domain.Pagination{
+			PageSize:    3,
+			MaxPageSize: 5,
+		},
+	})
+	handlerInstance := NewOrgGraphqlHandler(*orgService)
+
+	t.Run("Walk all pages with cursors", func(t *testing.T) {
+		ids := []string{}
+		var after *string
+
+		for pages := 0; pages < 5; pages++ {
+			got, err := handlerInstance.QueryConnection(ctx, nil, nil, after)
+
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			for _, edge := range got.Edges {
+				ids = append(ids, edge.Node.ID)
+			}
+
+			if got.PageInfo.HasPreviousPage != (after != nil) {
+				t.Errorf("Expected HasPreviousPage to be: %v got: %v", after != nil, got.PageInfo.HasPreviousPage)
+			}
+
+			if !got.PageInfo.HasNextPage {
+				break
+			}
+
+			if *got.PageInfo.EndCursor != got.Edges[len(got.Edges)-1].Cursor {
+				t.Errorf("Expected EndCursor to be the last edge cursor got: %q", *got.PageInfo.EndCursor)
+			}
+
+			after = got.PageInfo.EndCursor
+		}
+
+		if len(ids) != len(dummyDict) {
+			t.Errorf("Expected %d organizations got: %v", len(dummyDict), ids)
+		}
+
+		for index, id := range ids {
+			if id != strconv.Itoa(index) {
+				t.Errorf("Expected organization %d to have id: %d got: %q", index, index, id)
+			}
+		}
+	})
+
+	t.Run("First is limited by max page size", func(t *testing.T) {
+		first := 100
+		got, err := handlerInstance.QueryConnection(ctx, nil, &first, nil)
+
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+
+		if len(got.Edges) != 5 {
+			t.Errorf("Expected 5 edges got: %d", len(got.Edges))
+		}
+
+		total, err := handlerInstance.TotalCount(ctx, got)
+
+		if err != nil || total != len(dummyDict) {
+			t.Errorf("Expected total count to be: %d got: %d with error: %v", len(dummyDict), total, err)
+		}
+	})
+
+	t.Run("Invalid cursor returns an error", func(t *testing.T) {
+		after := "not-a-cursor"
+		_, err := handlerInstance.QueryConnection(ctx, nil, nil, &after)
+
+		if _, ok := err.(ports.ErrInvalidCursor); !ok {
+			t.Errorf("Expected error of type ErrInvalidCursor got: %v", err)
+		}
+	})
+}
+
 func TestOrgUpdateOperation(t *testing.T) {
+	ctx := context.Background()
 	t.Run("Partial Update", func(t *testing.T) {
 		base := []map[string]interface{}{
 			{
@@ -472,7 +574,11 @@ func TestOrgUpdateOperation(t *testing.T) {
 			Description: "originalDescription",
 			Logo:        &logo,
 		}
-		got, err := handlerInstance.Update(expected.ID, &expected.Name, nil, &logo)
+		got, err := handlerInstance.Update(ctx, map[string]interface{}{
+			"id":   expected.ID,
+			"name": expected.Name,
+			"logo": logo,
+		})
 
 		if err != nil {
 			t.Errorf("Unexpected error: %v", err)
@@ -523,7 +629,12 @@ func TestOrgUpdateOperation(t *testing.T) {
 			Description: "newDescription",
 			Logo:        &logo,
 		}
-		got, err := handlerInstance.Update(expected.ID, &expected.Name, &expected.Description, &logo)
+		got, err := handlerInstance.Update(ctx, map[string]interface{}{
+			"id":          expected.ID,
+			"name":        expected.Name,
+			"description": expected.Description,
+			"logo":        logo,
+		})
 
 		if err != nil {
 			t.Errorf("Unexpected error: %v", err)
@@ -566,7 +677,11 @@ func TestOrgUpdateOperation(t *testing.T) {
 			Description: "originalDescription",
 			Logo:        &logo,
 		}
-		_, err := handlerInstance.Update(expected.ID, &expected.Name, nil, &logo)
+		_, err := handlerInstance.Update(ctx, map[string]interface{}{
+			"id":   expected.ID,
+			"name": expected.Name,
+			"logo": logo,
+		})
 
 		if err == nil {
 			t.Errorf("Expected error got nil")
@@ -579,7 +694,92 @@ func TestOrgUpdateOperation(t *testing.T) {
 	})
 }
 
+func TestOrgUpdateClearsFields(t *testing.T) {
+	ctx := context.Background()
+	newRepo := func() *mocks.MemRepo {
+		return &mocks.MemRepo{
+			Data: map[string][]map[string]interface{}{
+				"organizations": {
+					{
+						"id":          "myid",
+						"name":        "originalName",
+						"description": "originalDescription",
+						"logo":        "originalLogo",
+					},
+				},
+			},
+		}
+	}
+
+	t.Run("Null clears the field", func(t *testing.T) {
+		repo := newRepo()
+		orgService := service.NewOrgService(repo, domain.DefaultConfig())
+		handlerInstance := NewOrgGraphqlHandler(*orgService)
+
+		got, err := handlerInstance.Update(ctx, map[string]interface{}{
+			"id":   "myid",
+			"logo": nil,
+		})
+
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		if *got.Logo != "" {
+			t.Errorf("Expected Logo to be cleared got: %q", *got.Logo)
+		}
+
+		if got.Name != "originalName" || got.Description != "originalDescription" {
+			t.Errorf("Expected other fields to be kept got: %+v", got)
+		}
+
+		if _, exists := repo.Data["organizations"][0]["logo"]; exists {
+			t.Errorf("Expected logo to be removed from the stored item")
+		}
+	})
+
+	t.Run("Required fields can't be cleared", func(t *testing.T) {
+		repo := newRepo()
+		orgService := service.NewOrgService(repo, domain.DefaultConfig())
+		handlerInstance := NewOrgGraphqlHandler(*orgService)
+
+		_, err := handlerInstance.Update(ctx, map[string]interface{}{
+			"id":   "myid",
+			"name": nil,
+		})
+
+		if _, ok := err.(ports.ErrInvalidFieldMask); !ok {
+			t.Errorf("Expected error of type ErrInvalidFieldMask got: %v", err)
+		}
+	})
+
+	t.Run("The item is not read before writing", func(t *testing.T) {
+		repo := newRepo()
+		gets := 0
+		repo.GetInterceptor = func(ctx context.Context, collection string, id string, result interface{}) error {
+			gets++
+			if gets == 1 {
+				t.Errorf("Expected Patch to be called before Get")
+			}
+			return ports.ErrItemNotFound{Id: &id, Model: collection}
+		}
+		repo.PatchInterceptor = func(ctx context.Context, collection string, id string, mask ports.FieldMask) error {
+			gets++
+			return nil
+		}
+
+		orgService := service.NewOrgService(repo, domain.DefaultConfig())
+		handlerInstance := NewOrgGraphqlHandler(*orgService)
+		handlerInstance.Update(ctx, map[string]interface{}{"id": "myid", "name": "newName"})
+
+		if gets != 2 {
+			t.Errorf("Expected a Patch and a Get got: %d calls", gets)
+		}
+	})
+}
+
 func TestOrgDeleteOperaton(t *testing.T) {
+	ctx := context.Background()
 	t.Run("Delete an item", func(t *testing.T) {
 		base := []map[string]interface{}{
 			{
@@ -600,7 +800,7 @@ func TestOrgDeleteOperaton(t *testing.T) {
 		orgService := service.NewOrgService(&repo, domain.DefaultConfig())
 		handlerInstance := NewOrgGraphqlHandler(*orgService)
 
-		_, err := handlerInstance.Delete("myid")
+		_, err := handlerInstance.Delete(ctx, "myid")
 
 		if err != nil {
 			t.Errorf("Unexpected error: %v", err)
@@ -623,7 +823,7 @@ func TestOrgDeleteOperaton(t *testing.T) {
 		orgService := service.NewOrgService(&repo, domain.DefaultConfig())
 		handlerInstance := NewOrgGraphqlHandler(*orgService)
 
-		_, err := handlerInstance.Delete("id")
+		_, err := handlerInstance.Delete(ctx, "id")
 
 		if err == nil {
 			t.Errorf("Expected error got nil")
@@ -635,3 +835,39 @@ func TestOrgDeleteOperaton(t *testing.T) {
 		}
 	})
 }
+
+func TestOrgBulkOperations(t *testing.T) {
+	ctx := context.Background()
+	repo := mocks.MemRepo{
+		Data: map[string][]map[string]interface{}{
+			"organizations": {},
+		},
+	}
+
+	service := service.NewOrgService(&repo, domain.DefaultConfig())
+	handlerInstance := NewOrgGraphqlHandler(*service)
+
+	created, err := handlerInstance.CreateMany(ctx, []*model.NewOrganization{{Name: "Avengers"}, {Name: "X-Men"}}, nil)
+	if err != nil || created.Succeeded != 2 {
+		t.Fatalf("Expected 2 organizations created got: %+v with error: %v", created, err)
+	}
+
+	t.Run("Invalid updates are reported", func(t *testing.T) {
+		inputs := []*model.UpdateInput{
+			{Fields: map[string]interface{}{"id": *created.Results[0].ID, "logo": "shield"}},
+			{Fields: map[string]interface{}{"id": *created.Results[1].ID, "name": nil}},
+		}
+
+		got, err := handlerInstance.UpdateMany(ctx, inputs, nil)
+		if err != nil || !got.Results[0].Success || got.Results[1].Success || got.Results[1].Error == nil {
+			t.Errorf("Expected only the second update to fail got: %+v with error: %v", got, err)
+		}
+	})
+
+	t.Run("Delete organizations", func(t *testing.T) {
+		got, err := handlerInstance.DeleteMany(ctx, []string{*created.Results[0].ID, "missing"}, nil)
+		if err != nil || got.Succeeded != 1 || got.Failed != 1 || len(repo.Data["organizations"]) != 1 {
+			t.Errorf("Expected 1 organization deleted and 1 failure got: %+v with error: %v", got, err)
+		}
+	})
+}
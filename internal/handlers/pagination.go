@@ -0,0 +1,31 @@
+package handlers
+
+import (
+	"github.com/sy-software/minerva-owl/cmd/graphql/graph/model"
+	"github.com/sy-software/minerva-owl/internal/core/ports"
+)
+
+// pageInfoToGraphQL converts the repository page info into the GraphQL version
+func pageInfoToGraphQL(source *ports.PageInfo) *model.PageInfo {
+	output := &model.PageInfo{
+		HasNextPage:     source.HasNextPage,
+		HasPreviousPage: source.HasPreviousPage,
+	}
+
+	if len(source.Cursors) > 0 {
+		startCursor := source.Cursors[0]
+		output.StartCursor = &startCursor
+	}
+
+	if source.EndCursor != "" {
+		endCursor := source.EndCursor
+		output.EndCursor = &endCursor
+	}
+
+	return output
+}
+
+// isDescending tells if a GraphQL sort direction is descending
+func isDescending(direction model.SortDirection) bool {
+	return direction == model.SortDirectionDesc
+}
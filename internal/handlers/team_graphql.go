@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"context"
+
+	"github.com/sy-software/minerva-owl/cmd/graphql/graph/model"
+	"github.com/sy-software/minerva-owl/internal/core/domain"
+	"github.com/sy-software/minerva-owl/internal/core/service"
+	"github.com/sy-software/minerva-owl/internal/utils"
+)
+
+// TeamGraphqlHandler works as adapter between GraphQL endpoints and a TeamService
+type TeamGraphqlHandler struct {
+	service service.TeamService
+}
+
+// NewTeamGraphqlHandler creates an instance of TeamGraphqlHandler
+func NewTeamGraphqlHandler(service service.TeamService) *TeamGraphqlHandler {
+	return &TeamGraphqlHandler{
+		service: service,
+	}
+}
+
+// Create saves a new team into an existing organization
+func (handler *TeamGraphqlHandler) Create(ctx context.Context, input model.NewTeam) (*model.Team, error) {
+	team, err := handler.service.Create(
+		ctx,
+		input.Organization,
+		input.Name,
+		input.Description,
+		utils.CoalesceStr(input.Leader, ""),
+		utils.CoalesceStr(input.Color, ""),
+		utils.CoalesceStr(input.Icon, ""),
+		input.Techs,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return teamToGraphQL(&team), nil
+}
+
+// QueryById returns the team with id
+func (handler *TeamGraphqlHandler) QueryById(ctx context.Context, id string) (*model.Team, error) {
+	team, err := handler.get(ctx, id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return teamToGraphQL(&team), nil
+}
+
+// get returns the team with id through the request DataLoader when there is one
+func (handler *TeamGraphqlHandler) get(ctx context.Context, id string) (domain.Team, error) {
+	loaders := LoadersFromCtx(ctx)
+	if loaders == nil {
+		return handler.service.Get(ctx, id)
+	}
+
+	value, err := loaders.Teams.Load(ctx, id)
+	if err != nil {
+		return domain.Team{}, err
+	}
+
+	return value.(domain.Team), nil
+}
+
+// AddTech adds tech to the team with id unless the team already has it
+func (handler *TeamGraphqlHandler) AddTech(ctx context.Context, id string, tech string) (*model.Team, error) {
+	team, err := handler.service.AddTechs(ctx, id, tech)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return teamToGraphQL(&team), nil
+}
+
+// RemoveTech removes tech from the team with id
+func (handler *TeamGraphqlHandler) RemoveTech(ctx context.Context, id string, tech string) (*model.Team, error) {
+	team, err := handler.service.RemoveTechs(ctx, id, tech)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return teamToGraphQL(&team), nil
+}
+
+func teamToGraphQL(source *domain.Team) *model.Team {
+	techs := source.Techs
+	if techs == nil {
+		techs = []string{}
+	}
+
+	return &model.Team{
+		ID:           source.Id,
+		Name:         source.Name,
+		Description:  source.Description,
+		Organization: source.Organization,
+		Leader:       &source.Leader,
+		Color:        &source.Color,
+		Icon:         &source.Icon,
+		Techs:        techs,
+	}
+}
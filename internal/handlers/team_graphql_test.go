@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sy-software/minerva-owl/cmd/graphql/graph/model"
+	"github.com/sy-software/minerva-owl/internal/core/domain"
+	"github.com/sy-software/minerva-owl/internal/core/ports"
+	"github.com/sy-software/minerva-owl/internal/core/service"
+	"github.com/sy-software/minerva-owl/mocks"
+)
+
+func TestTeamOperations(t *testing.T) {
+	ctx := context.Background()
+	repo := mocks.MemRepo{
+		Data: map[string][]map[string]interface{}{
+			"organizations": {{"id": "1", "name": "Avengers"}},
+			"teams":         {},
+		},
+	}
+
+	service := service.NewTeamService(&repo, domain.DefaultConfig())
+	handlerInstance := NewTeamGraphqlHandler(*service)
+
+	leader := "IronMan"
+	created, err := handlerInstance.Create(ctx, model.NewTeam{
+		Organization: "1",
+		Name:         "Engineering",
+		Description:  "Builders",
+		Leader:       &leader,
+	})
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	t.Run("Create a team", func(t *testing.T) {
+		if created.Name != "Engineering" || *created.Leader != leader || created.Techs == nil {
+			t.Errorf("Expected a team named Engineering led by %q with techs got: %+v", leader, created)
+		}
+
+		got, err := handlerInstance.QueryById(ctx, created.ID)
+		if err != nil || !cmp.Equal(got, created) {
+			t.Errorf("Expected team: %+v got: %+v with error: %v", created, got, err)
+		}
+	})
+
+	t.Run("Add and remove techs", func(t *testing.T) {
+		handlerInstance.AddTech(ctx, created.ID, "go")
+		handlerInstance.AddTech(ctx, created.ID, "rust")
+		got, err := handlerInstance.AddTech(ctx, created.ID, "go")
+
+		expected := []string{"go", "rust"}
+		if err != nil || !cmp.Equal(got.Techs, expected) {
+			t.Errorf("Expected techs: %v got: %+v with error: %v", expected, got, err)
+		}
+
+		got, err = handlerInstance.RemoveTech(ctx, created.ID, "go")
+		expected = []string{"rust"}
+		if err != nil || !cmp.Equal(got.Techs, expected) {
+			t.Errorf("Expected techs: %v got: %+v with error: %v", expected, got, err)
+		}
+	})
+
+	t.Run("Missing team", func(t *testing.T) {
+		_, err := handlerInstance.AddTech(ctx, "missing", "go")
+		if _, ok := err.(ports.ErrItemNotFound); !ok {
+			t.Errorf("Expected error of type ErrItemNotFound got: %v", err)
+		}
+	})
+}
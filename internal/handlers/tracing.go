@@ -0,0 +1,195 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+	"github.com/sy-software/minerva-owl/internal/core/domain"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TRACER_NAME identifies the spans started by the handlers
+const TRACER_NAME = "github.com/sy-software/minerva-owl/internal/handlers"
+
+// Span exporters available in domain.TracingConfig.Exporter
+const (
+	// TRACING_NONE doesn't export spans, incoming trace ids are still propagated and logged
+	TRACING_NONE = "none"
+	// TRACING_STDOUT prints the spans as JSON to the standard output
+	TRACING_STDOUT = "stdout"
+	// TRACING_OTLP sends the spans to an OpenTelemetry collector over OTLP/HTTP
+	TRACING_OTLP = "otlp"
+)
+
+// Attributes of the GraphQL spans
+const (
+	GQL_OPERATION_NAME_KEY = attribute.Key("graphql.operation.name")
+	GQL_OPERATION_TYPE_KEY = attribute.Key("graphql.operation.type")
+	GQL_FIELD_OBJECT_KEY   = attribute.Key("graphql.field.object")
+	GQL_FIELD_NAME_KEY     = attribute.Key("graphql.field.name")
+	GQL_FIELD_PATH_KEY     = attribute.Key("graphql.field.path")
+	REQUEST_ID_ATTR_KEY    = attribute.Key("owl.request_id")
+)
+
+// NewTracerProvider creates a tracer provider exporting the spans as configured,
+// the stdout exporter writes to out. It returns nil with the none exporter
+func NewTracerProvider(config domain.TracingConfig, out io.Writer) (*sdktrace.TracerProvider, error) {
+	var exporter sdktrace.SpanExporter
+	var err error
+
+	switch strings.ToLower(strings.TrimSpace(config.Exporter)) {
+	case TRACING_NONE, "":
+		return nil, nil
+	case TRACING_STDOUT:
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(out))
+	case TRACING_OTLP:
+		options := []otlptracehttp.Option{otlptracehttp.WithEndpoint(config.Endpoint)}
+		if config.Insecure {
+			options = append(options, otlptracehttp.WithInsecure())
+		}
+		exporter, err = otlptracehttp.New(context.Background(), options...)
+	default:
+		return nil, fmt.Errorf(
+			"unknown tracing exporter %q, available exporters: %s, %s, %s",
+			config.Exporter,
+			TRACING_NONE,
+			TRACING_STDOUT,
+			TRACING_OTLP,
+		)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("can't create %s tracing exporter: %w", config.Exporter, err)
+	}
+
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(config.SampleRatio))),
+		sdktrace.WithResource(resource.NewSchemaless(semconv.ServiceNameKey.String(config.ServiceName))),
+	), nil
+}
+
+// SetupTracing installs the configured tracer provider and the W3C trace context propagator
+// as OpenTelemetry globals. The returned function flushes the pending spans on shutdown
+func SetupTracing(config domain.TracingConfig) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	provider, err := NewTracerProvider(config, os.Stdout)
+	if err != nil || provider == nil {
+		return func(context.Context) error { return nil }, err
+	}
+
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// TracingMiddleware is a Gin middleware starting the server span of every request,
+// incoming W3C traceparent headers make it a child of the caller span.
+// The request id and the trace id are added to the span and the request logger
+func TracingMiddleware(serName string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := otel.GetTextMapPropagator().Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
+		route := c.FullPath()
+		ctx, span := otel.Tracer(TRACER_NAME).Start(
+			ctx,
+			strings.TrimSpace(c.Request.Method+" "+route),
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(semconv.HTTPServerAttributesFromHTTPRequest(serName, route, c.Request)...),
+		)
+		defer span.End()
+
+		if reqId := RequestIDFromCtx(ctx); reqId != "" {
+			span.SetAttributes(REQUEST_ID_ATTR_KEY.String(reqId))
+		}
+
+		if spanContext := span.SpanContext(); spanContext.IsValid() {
+			logger := zerolog.Ctx(ctx).With().Str("trace_id", spanContext.TraceID().String()).Logger()
+			ctx = logger.WithContext(ctx)
+		}
+
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+
+		status := c.Writer.Status()
+		span.SetAttributes(semconv.HTTPAttributesFromHTTPStatusCode(status)...)
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+	}
+}
+
+// GQLTracingMiddleware can be passed into Gqlgen server as AroundOperation middleware,
+// it starts a span for each operation named after its type and name
+func GQLTracingMiddleware(ctx context.Context, next graphql.OperationHandler) graphql.ResponseHandler {
+	name, opType := operationLabels(graphql.GetOperationContext(ctx))
+	ctx, span := otel.Tracer(TRACER_NAME).Start(
+		ctx,
+		strings.TrimSpace(opType+" "+name),
+		trace.WithAttributes(GQL_OPERATION_NAME_KEY.String(name), GQL_OPERATION_TYPE_KEY.String(opType)),
+	)
+
+	handler := next(ctx)
+	ended := false
+
+	return func(ctx context.Context) *graphql.Response {
+		response := handler(ctx)
+		if ended {
+			return response
+		}
+
+		if response != nil && len(response.Errors) > 0 {
+			span.SetStatus(codes.Error, response.Errors.Error())
+		}
+
+		ended = true
+		span.End()
+		return response
+	}
+}
+
+// GQLResolverTracingMiddleware can be passed into Gqlgen server as AroundFields middleware,
+// it starts a span for each field with a resolver, plain struct fields aren't traced
+func GQLResolverTracingMiddleware(ctx context.Context, next graphql.Resolver) (interface{}, error) {
+	fc := graphql.GetFieldContext(ctx)
+	if fc == nil || !fc.IsResolver {
+		return next(ctx)
+	}
+
+	ctx, span := otel.Tracer(TRACER_NAME).Start(
+		ctx,
+		fc.Object+"."+fc.Field.Name,
+		trace.WithAttributes(
+			GQL_FIELD_OBJECT_KEY.String(fc.Object),
+			GQL_FIELD_NAME_KEY.String(fc.Field.Name),
+			GQL_FIELD_PATH_KEY.String(fc.Path().String()),
+		),
+	)
+	defer span.End()
+
+	result, err := next(ctx)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	return result, err
+}
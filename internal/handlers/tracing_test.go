@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/gin-gonic/gin"
+	"github.com/sy-software/minerva-owl/internal/core/domain"
+	"github.com/vektah/gqlparser/v2/ast"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// recordSpans installs a global tracer provider recording the ended spans until the test ends
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	previousProvider := otel.GetTracerProvider()
+	previousPropagator := otel.GetTextMapPropagator()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previousProvider)
+		otel.SetTextMapPropagator(previousPropagator)
+	})
+
+	return recorder
+}
+
+func TestTracingMiddleware(t *testing.T) {
+	recorder := recordSpans(t)
+	traceId := "4bf92f3577b34da6a3ce929d0e0e4736"
+	parentId := "00f067aa0ba902b7"
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(GinCtxToCtxMiddleware())
+	router.Use(TracingMiddleware("test"))
+
+	var logged *LogValues
+	router.GET("/teams/:id", func(c *gin.Context) {
+		logged = logValuesFromCtx("test", c)
+		c.Status(http.StatusInternalServerError)
+	})
+
+	request := httptest.NewRequest(http.MethodGet, "/teams/1", nil)
+	request.Header.Set("traceparent", "00-"+traceId+"-"+parentId+"-01")
+	router.ServeHTTP(httptest.NewRecorder(), request)
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("Expected 1 span got: %d", len(spans))
+	}
+
+	span := spans[0]
+	if span.Name() != "GET /teams/:id" {
+		t.Errorf("Expected span name: %q got: %q", "GET /teams/:id", span.Name())
+	}
+
+	if span.SpanContext().TraceID().String() != traceId || span.Parent().SpanID().String() != parentId {
+		t.Errorf("Expected span to continue the incoming trace got: %v", span.SpanContext())
+	}
+
+	if span.Status().Code != codes.Error {
+		t.Errorf("Expected error status got: %v", span.Status())
+	}
+
+	if logged == nil || logged.TraceId != traceId || logged.SpanId != span.SpanContext().SpanID().String() {
+		t.Errorf("Expected trace id: %q to be logged got: %+v", traceId, logged)
+	}
+}
+
+func TestGQLTracingMiddleware(t *testing.T) {
+	recorder := recordSpans(t)
+	ctx := graphql.WithOperationContext(context.Background(), &graphql.OperationContext{
+		OperationName: "GetTeams",
+		Operation:     &ast.OperationDefinition{Operation: ast.Query},
+	})
+
+	// gqlgen calls the response handler with the context passed down to the operation handler
+	var innerCtx context.Context
+	expected := errors.New("can't read teams")
+	handler := GQLTracingMiddleware(ctx, func(ctx context.Context) graphql.ResponseHandler {
+		innerCtx = ctx
+		return func(ctx context.Context) *graphql.Response {
+			fc := &graphql.FieldContext{
+				Object:     "Query",
+				Field:      graphql.CollectedField{Field: &ast.Field{Name: "teams", Alias: "teams"}},
+				IsResolver: true,
+			}
+			GQLResolverTracingMiddleware(graphql.WithFieldContext(ctx, fc), func(ctx context.Context) (interface{}, error) {
+				return nil, expected
+			})
+
+			return graphql.ErrorResponse(ctx, expected.Error())
+		}
+	})
+	handler(innerCtx)
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("Expected 2 spans got: %d", len(spans))
+	}
+
+	resolver, operation := spans[0], spans[1]
+	if resolver.Name() != "Query.teams" || resolver.Status().Code != codes.Error {
+		t.Errorf("Expected failed resolver span: %q got: %q with status: %v", "Query.teams", resolver.Name(), resolver.Status())
+	}
+
+	if operation.Name() != "query GetTeams" || operation.Status().Code != codes.Error {
+		t.Errorf("Expected failed operation span: %q got: %q with status: %v", "query GetTeams", operation.Name(), operation.Status())
+	}
+
+	if resolver.Parent().SpanID() != operation.SpanContext().SpanID() {
+		t.Errorf("Expected resolver span to be a child of the operation span")
+	}
+}
+
+func TestNewTracerProvider(t *testing.T) {
+	t.Run("Stdout exporter writes the spans", func(t *testing.T) {
+		out := &bytes.Buffer{}
+		config := domain.DefaultConfig().Tracing
+		config.Exporter = TRACING_STDOUT
+
+		provider, err := NewTracerProvider(config, out)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		_, span := provider.Tracer("test").Start(context.Background(), "exported")
+		span.End()
+		provider.Shutdown(context.Background())
+
+		if !strings.Contains(out.String(), span.SpanContext().TraceID().String()) {
+			t.Errorf("Expected output to contain the trace id got: %q", out.String())
+		}
+	})
+
+	t.Run("None exporter returns no provider", func(t *testing.T) {
+		provider, err := NewTracerProvider(domain.DefaultConfig().Tracing, nil)
+		if provider != nil || err != nil {
+			t.Errorf("Expected nil provider and error got: %v, %v", provider, err)
+		}
+	})
+
+	t.Run("Unknown exporter returns an error", func(t *testing.T) {
+		config := domain.DefaultConfig().Tracing
+		config.Exporter = "carrier pigeon"
+
+		if _, err := NewTracerProvider(config, nil); err == nil {
+			t.Errorf("Expected error got nil")
+		}
+	})
+}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/sy-software/minerva-owl/internal/core/ports"
+)
+
+// orgUpdateFields maps the UpdateOrganization input fields to the Organization fields
+var orgUpdateFields = map[string]string{
+	"name":        "name",
+	"description": "description",
+	"logo":        "logo",
+}
+
+// userUpdateFields maps the UpdateUser input fields to the User fields
+var userUpdateFields = map[string]string{
+	"username": "username",
+	"name":     "name",
+	"picture":  "picture",
+	"role":     "role",
+	"provider": "provider",
+	"tokenID":  "tokenID",
+	"status":   "status",
+}
+
+// inputToFieldMask converts a GraphQL update input into the id of the item and a field mask,
+// fields with an explicit null are cleared and missing fields are not changed
+func inputToFieldMask(input map[string]interface{}, fields map[string]string) (string, ports.FieldMask) {
+	mask := ports.FieldMask{
+		Set:   map[string]interface{}{},
+		Unset: []string{},
+	}
+
+	for name, value := range input {
+		field, exists := fields[name]
+		if !exists {
+			continue
+		}
+
+		if value == nil {
+			mask.Unset = append(mask.Unset, field)
+		} else {
+			mask.Set[field] = value
+		}
+	}
+
+	id := ""
+	if value, exists := input["id"]; exists && value != nil {
+		id = fmt.Sprintf("%v", value)
+	}
+
+	return id, mask
+}
@@ -1,7 +1,7 @@
 package handlers
 
 import (
-	"errors"
+	"context"
 	"strings"
 
 	"github.com/sy-software/minerva-owl/cmd/graphql/graph/model"
@@ -24,8 +24,9 @@ func NewUserGraphqlHandler(service service.UserService) *UserGraphqlHandler {
 }
 
 // Create saves a new user into a repository
-func (handler *UserGraphqlHandler) Create(input model.NewUser) (*model.User, error) {
+func (handler *UserGraphqlHandler) Create(ctx context.Context, input model.NewUser) (*model.User, error) {
 	domainUser, err := handler.service.Create(
+		ctx,
 		input.Name,
 		input.Username,
 		utils.CoalesceStr(input.Picture, ""),
@@ -36,35 +37,57 @@ func (handler *UserGraphqlHandler) Create(input model.NewUser) (*model.User, err
 	)
 
 	if err != nil {
-		if strings.HasPrefix(err.Error(), "duplicated") {
-			return nil, errors.New("duplicated_value")
-		}
-		return nil, err
+		return nil, userError(err)
 	}
 
 	return userToGraphQL(&domainUser), err
 }
 
-// Update saves changes into an exiting User
-func (handler *UserGraphqlHandler) Update(input model.UpdateUser) (*model.User, error) {
-	domainUser, err := handler.service.Update(*graphQLUpdateToUser(&input))
+// Upsert creates the user or updates the existing user with the same username
+func (handler *UserGraphqlHandler) Upsert(ctx context.Context, input model.NewUser) (*model.UpsertUserPayload, error) {
+	domainUser, created, err := handler.service.UpsertByUsername(
+		ctx,
+		input.Name,
+		input.Username,
+		utils.CoalesceStr(input.Picture, ""),
+		input.Role,
+		input.Provider,
+		input.TokenID,
+		input.Status,
+	)
 
 	if err != nil {
-		return nil, err
+		return nil, userError(err)
+	}
+
+	return &model.UpsertUserPayload{
+		User:    userToGraphQL(&domainUser),
+		Created: created,
+	}, nil
+}
+
+// Update changes only the fields provided in the UpdateUser input of an existing User,
+// fields with an explicit null are cleared
+func (handler *UserGraphqlHandler) Update(ctx context.Context, input map[string]interface{}) (*model.User, error) {
+	id, mask := inputToFieldMask(input, userUpdateFields)
+	domainUser, err := handler.service.Patch(ctx, id, mask)
+
+	if err != nil {
+		return nil, userError(err)
 	}
 
 	return userToGraphQL(&domainUser), err
 }
 
 // Delete removes a User with the provided id
-func (handler *UserGraphqlHandler) Delete(id string) (*model.User, error) {
-	out, err := handler.service.Get(id)
+func (handler *UserGraphqlHandler) Delete(ctx context.Context, id string) (*model.User, error) {
+	out, err := handler.service.Get(ctx, id)
 
 	if err != nil {
 		return nil, err
 	}
 
-	err = handler.service.Delete(id, false)
+	err = handler.service.Delete(ctx, id, false)
 
 	if err != nil {
 		return nil, err
@@ -73,23 +96,32 @@ func (handler *UserGraphqlHandler) Delete(id string) (*model.User, error) {
 	return userToGraphQL(&out), nil
 }
 
-// Query returns a paginated list of Users that can be filtered by role
-func (handler *UserGraphqlHandler) Query(role *string, page *int, pageSize *int) ([]*model.User, error) {
-	output := []*model.User{}
-	var users []domain.User
-	var err error
-	if role != nil {
-		users, err = handler.service.ListByRole(*role, page, pageSize)
-		if err != nil {
-			return output, err
-		}
-	} else {
-		users, err = handler.service.List(page, pageSize)
-		if err != nil {
-			return output, err
+// userSortFields maps the GraphQL sort fields to the User fields
+var userSortFields = map[model.UserSortField]string{
+	model.UserSortFieldUsername:   "username",
+	model.UserSortFieldName:       "name",
+	model.UserSortFieldRole:       "role",
+	model.UserSortFieldStatus:     "status",
+	model.UserSortFieldCreateDate: "createDate",
+	model.UserSortFieldUpdateDate: "updateDate",
+}
+
+// Query returns a paginated list of Users matching the role and where, sorted with orderBy
+func (handler *UserGraphqlHandler) Query(ctx context.Context, role *string, where *model.UserWhere, page *int, pageSize *int, orderBy ...*model.UserOrderBy) ([]*model.User, error) {
+	sort := make([]ports.Sort, len(orderBy))
+	for index, order := range orderBy {
+		sort[index] = ports.Sort{
+			Field:      userSortFields[order.Field],
+			Descending: isDescending(order.Direction),
 		}
 	}
 
+	output := []*model.User{}
+	users, err := handler.service.Search(ctx, userFilters(role, where), page, pageSize, sort...)
+	if err != nil {
+		return output, err
+	}
+
 	for _, u := range users {
 		output = append(output, userToGraphQL(&u))
 	}
@@ -97,12 +129,52 @@ func (handler *UserGraphqlHandler) Query(role *string, page *int, pageSize *int)
 	return output, nil
 }
 
+// QueryConnection returns a Relay connection with up to first Users after the cursor matching the role and where
+func (handler *UserGraphqlHandler) QueryConnection(ctx context.Context, role *string, where *model.UserWhere, first *int, after *string) (*model.UserConnection, error) {
+	filters := userFilters(role, where)
+	users, pageInfo, err := handler.service.ListPage(ctx, first, after, filters...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	edges := make([]*model.UserEdge, len(users))
+
+	for index := range users {
+		edges[index] = &model.UserEdge{
+			Cursor: pageInfo.Cursors[index],
+			Node:   userToGraphQL(&users[index]),
+		}
+	}
+
+	return &model.UserConnection{
+		Edges:    edges,
+		PageInfo: pageInfoToGraphQL(&pageInfo),
+		Filters:  filters,
+	}, nil
+}
+
+// TotalCount returns how many Users are available through the connection
+func (handler *UserGraphqlHandler) TotalCount(ctx context.Context, connection *model.UserConnection) (int, error) {
+	return handler.service.Count(ctx, connection.Filters...)
+}
+
+// userFilters combines the role argument with the where filters
+func userFilters(role *string, where *model.UserWhere) []ports.Filter {
+	filters := userWhereToFilters(where)
+	if role != nil {
+		filters = append(filters, ports.Eq("role", *role))
+	}
+
+	return filters
+}
+
 // QueryById returns the User with the provided id
-func (handler *UserGraphqlHandler) QueryById(id string) (*model.User, error) {
-	domainUser, err := handler.service.Get(id)
+func (handler *UserGraphqlHandler) QueryById(ctx context.Context, id string) (*model.User, error) {
+	domainUser, err := handler.get(ctx, id)
 
 	if _, ok := err.(ports.ErrItemNotFound); ok {
-		return nil, errors.New("not_found")
+		return nil, ErrNotFound
 	}
 
 	if err != nil {
@@ -112,12 +184,27 @@ func (handler *UserGraphqlHandler) QueryById(id string) (*model.User, error) {
 	return userToGraphQL(&domainUser), nil
 }
 
+// get returns the user with id through the request DataLoader when there is one
+func (handler *UserGraphqlHandler) get(ctx context.Context, id string) (domain.User, error) {
+	loaders := LoadersFromCtx(ctx)
+	if loaders == nil {
+		return handler.service.Get(ctx, id)
+	}
+
+	value, err := loaders.Users.Load(ctx, id)
+	if err != nil {
+		return domain.User{}, err
+	}
+
+	return value.(domain.User), nil
+}
+
 // QueryById returns the User with the provided username
-func (handler *UserGraphqlHandler) QueryByUsername(username string) (*model.User, error) {
-	domainUser, err := handler.service.GetByUsername(username)
+func (handler *UserGraphqlHandler) QueryByUsername(ctx context.Context, username string) (*model.User, error) {
+	domainUser, err := handler.service.GetByUsername(ctx, username)
 
 	if _, ok := err.(ports.ErrItemNotFound); ok {
-		return nil, errors.New("not_found")
+		return nil, ErrNotFound
 	}
 
 	if err != nil {
@@ -143,16 +230,54 @@ func userToGraphQL(source *domain.User) *model.User {
 	}
 }
 
-// graphQLUpdateToUser converts the GraphQL user model into our internal model
-func graphQLUpdateToUser(source *model.UpdateUser) *domain.User {
-	return &domain.User{
-		Id:       source.ID,
-		Name:     source.Name,
-		Username: source.Username,
-		Picture:  utils.CoalesceStr(source.Picture, ""),
-		Role:     source.Role,
-		Provider: source.Provider,
-		TokenID:  source.TokenID,
-		Status:   source.Status,
+// CreateMany saves new users into a repository, the result of each user is reported
+func (handler *UserGraphqlHandler) CreateMany(ctx context.Context, inputs []*model.NewUser, stopOnError *bool) (*model.BulkPayload, error) {
+	users := make([]domain.User, len(inputs))
+	for index, input := range inputs {
+		users[index] = domain.User{
+			Name:     input.Name,
+			Username: input.Username,
+			Picture:  utils.CoalesceStr(input.Picture, ""),
+			Role:     input.Role,
+			Provider: input.Provider,
+			TokenID:  input.TokenID,
+			Status:   input.Status,
+		}
+	}
+
+	results, err := handler.service.CreateMany(ctx, users, utils.CoalesceBool(stopOnError, false))
+	if err != nil {
+		return nil, err
+	}
+
+	return bulkToGraphQL(ctx, results, userError), nil
+}
+
+// UpdateMany changes only the fields provided in each UpdateUser input, the result of each user is reported
+func (handler *UserGraphqlHandler) UpdateMany(ctx context.Context, inputs []*model.UpdateInput, stopOnError *bool) (*model.BulkPayload, error) {
+	results, err := handler.service.PatchMany(ctx, inputsToPatches(inputs, userUpdateFields), utils.CoalesceBool(stopOnError, false))
+	if err != nil {
+		return nil, err
+	}
+
+	return bulkToGraphQL(ctx, results, userError), nil
+}
+
+// DeleteMany removes the users with the ids, the result of each user is reported
+func (handler *UserGraphqlHandler) DeleteMany(ctx context.Context, ids []string, stopOnError *bool) (*model.BulkPayload, error) {
+	results, err := handler.service.DeleteMany(ctx, ids, false, utils.CoalesceBool(stopOnError, false))
+	if err != nil {
+		return nil, err
 	}
+
+	return bulkToGraphQL(ctx, results, userError), nil
+}
+
+// userError hides the details of duplicated values
+func userError(err error) error {
+	if strings.HasPrefix(err.Error(), "duplicated") {
+		return ErrDuplicatedValue
+	}
+
+	return err
 }
@@ -1,6 +1,8 @@
 package handlers
 
 import (
+	"context"
+	"errors"
 	"regexp"
 	"testing"
 	"time"
@@ -16,6 +18,7 @@ import (
 const authKey = "2b7e151628aed2a6abf71589a12b4da32"
 
 func TestUserCreateOperation(t *testing.T) {
+	ctx := context.Background()
 	t.Run("Create an User", func(t *testing.T) {
 		data := map[string][]map[string]interface{}{
 			domain.USER_COL_NAME: {},
@@ -38,15 +41,15 @@ func TestUserCreateOperation(t *testing.T) {
 			TokenID:  "mytoken",
 			Status:   "deceased",
 		}
-		got, err := handlerInstance.Create(input)
+		got, err := handlerInstance.Create(ctx, input)
 
 		if err != nil {
 			t.Errorf("Unexpected error: %v", err)
 		}
 
-		match, err := regexp.MatchString(mocks.ID_REGEX, got.ID)
+		match, err := regexp.MatchString(mocks.OBJECTID_REGEX, got.ID)
 		if !match || err != nil {
-			t.Errorf("ID is not V4 UUID got: %q with error: %v", got.ID, err)
+			t.Errorf("ID is not an ObjectID got: %q with error: %v", got.ID, err)
 		}
 
 		if got.Name != input.Name {
@@ -81,6 +84,7 @@ func TestUserCreateOperation(t *testing.T) {
 }
 
 func TestReadOperations(t *testing.T) {
+	ctx := context.Background()
 	t.Run("List Users", func(t *testing.T) {
 		dummyData := []map[string]interface{}{
 			{
@@ -107,7 +111,7 @@ func TestReadOperations(t *testing.T) {
 
 		page := 1
 		size := 10
-		got, err := handlerInstance.Query(nil, &page, &size)
+		got, err := handlerInstance.Query(ctx, nil, nil, &page, &size)
 
 		if err != nil {
 			t.Errorf("Unexpected error: %v", err)
@@ -118,6 +122,76 @@ func TestReadOperations(t *testing.T) {
 		}
 	})
 
+	t.Run("Expired request deadline stops the query", func(t *testing.T) {
+		repo := mocks.MemRepo{
+			Data: map[string][]map[string]interface{}{
+				domain.USER_COL_NAME: {{"id": "1", "username": "CapAmerica"}},
+			},
+		}
+		config := domain.DefaultConfig()
+		config.Keys.Auth = authKey
+		service := service.NewUserService(&repo, config)
+		handlerInstance := NewUserGraphqlHandler(*service)
+
+		expired, cancel := context.WithTimeout(ctx, -time.Second)
+		defer cancel()
+
+		_, err := handlerInstance.QueryById(expired, "1")
+
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Expected error: %v got: %v", context.DeadlineExceeded, err)
+		}
+	})
+
+	t.Run("List Users Sorted", func(t *testing.T) {
+		dummyData := []map[string]interface{}{
+			{
+				"id":       "1",
+				"username": "CapAmerica",
+				"role":     "user",
+			},
+			{
+				"id":       "2",
+				"username": "IronMan",
+				"role":     "admin",
+			},
+			{
+				"id":       "3",
+				"username": "Hulk",
+				"role":     "user",
+			},
+		}
+
+		repo := mocks.MemRepo{
+			Data: map[string][]map[string]interface{}{
+				domain.USER_COL_NAME: dummyData,
+			},
+		}
+		config := domain.DefaultConfig()
+		config.Keys.Auth = authKey
+		service := service.NewUserService(&repo, config)
+		handlerInstance := NewUserGraphqlHandler(*service)
+
+		got, err := handlerInstance.Query(ctx, nil, nil, nil, nil, &model.UserOrderBy{
+			Field:     model.UserSortFieldRole,
+			Direction: model.SortDirectionDesc,
+		}, &model.UserOrderBy{
+			Field:     model.UserSortFieldUsername,
+			Direction: model.SortDirectionAsc,
+		})
+
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+
+		expected := []string{"CapAmerica", "Hulk", "IronMan"}
+		for index, user := range got {
+			if user.Username != expected[index] {
+				t.Errorf("Expected user %d to be: %q got: %q", index, expected[index], user.Username)
+			}
+		}
+	})
+
 	t.Run("List Users By Role", func(t *testing.T) {
 		dummyData := []map[string]interface{}{
 			{
@@ -137,7 +211,7 @@ func TestReadOperations(t *testing.T) {
 		called := false
 		repo := mocks.MemRepo{
 			Data: data,
-			ListInterceptor: func(collection string, results interface{}, skip, limit int, filters ...ports.Filter) error {
+			ListInterceptor: func(ctx context.Context, collection string, results interface{}, skip, limit int, sort []ports.Sort, filters ...ports.Filter) error {
 				called = true
 
 				if len(filters) != 1 {
@@ -163,7 +237,7 @@ func TestReadOperations(t *testing.T) {
 		page := 1
 		size := 10
 		role := "genius"
-		_, err := handlerInstance.Query(&role, &page, &size)
+		_, err := handlerInstance.Query(ctx, &role, nil, &page, &size)
 
 		if err != nil {
 			t.Errorf("Unexpected error: %v", err)
@@ -198,7 +272,7 @@ func TestReadOperations(t *testing.T) {
 		service := service.NewUserService(&repo, config)
 		handlerInstance := NewUserGraphqlHandler(*service)
 
-		got, err := handlerInstance.QueryById("1")
+		got, err := handlerInstance.QueryById(ctx, "1")
 
 		if err != nil {
 			t.Errorf("Unexpected error: %v", err)
@@ -228,7 +302,7 @@ func TestReadOperations(t *testing.T) {
 		called := false
 		repo := mocks.MemRepo{
 			Data: data,
-			GetOneInterceptor: func(collection string, result interface{}, filters ...ports.Filter) error {
+			GetOneInterceptor: func(ctx context.Context, collection string, result interface{}, filters ...ports.Filter) error {
 				called = true
 				if len(filters) != 1 {
 					t.Errorf("Expected 1 filter got %d", len(filters))
@@ -249,7 +323,7 @@ func TestReadOperations(t *testing.T) {
 		service := service.NewUserService(&repo, config)
 		handlerInstance := NewUserGraphqlHandler(*service)
 
-		_, err := handlerInstance.QueryByUsername("IronMan")
+		_, err := handlerInstance.QueryByUsername(ctx, "IronMan")
 
 		if err != nil {
 			t.Errorf("Unexpected error: %v", err)
@@ -261,7 +335,60 @@ func TestReadOperations(t *testing.T) {
 	})
 }
 
+func TestUserUpsertOperation(t *testing.T) {
+	ctx := context.Background()
+	repo := mocks.MemRepo{
+		Data: map[string][]map[string]interface{}{
+			domain.USER_COL_NAME: {},
+		},
+	}
+
+	config := domain.DefaultConfig()
+	config.Keys.Auth = authKey
+	service := service.NewUserService(&repo, config)
+	handlerInstance := NewUserGraphqlHandler(*service)
+
+	input := model.NewUser{
+		Name:     "Tony Stark",
+		Username: "IronMan",
+		Role:     "hero",
+		Provider: "avengers",
+		TokenID:  "mytoken",
+		Status:   "active",
+	}
+
+	t.Run("Upsert reports created and updated users", func(t *testing.T) {
+		got, err := handlerInstance.Upsert(ctx, input)
+		if err != nil || !got.Created {
+			t.Fatalf("Expected user to be created got: %+v with error: %v", got, err)
+		}
+
+		input.Name = "Anthony Stark"
+		updated, err := handlerInstance.Upsert(ctx, input)
+		if err != nil || updated.Created {
+			t.Fatalf("Expected user to be updated got: %+v with error: %v", updated, err)
+		}
+
+		if updated.User.ID != got.User.ID || updated.User.Name != "Anthony Stark" {
+			t.Errorf("Expected user %q to be renamed got: %+v", got.User.ID, updated.User)
+		}
+	})
+
+	t.Run("Upsert errors are mapped like create errors", func(t *testing.T) {
+		repo.UpsertInterceptor = func(ctx context.Context, collection string, filters []ports.Filter, entity interface{}, onInsertOnly ...string) (string, bool, error) {
+			return "", false, errors.New("duplicated Username: IronMan")
+		}
+		defer func() { repo.UpsertInterceptor = nil }()
+
+		_, err := handlerInstance.Upsert(ctx, input)
+		if err != ErrDuplicatedValue {
+			t.Errorf("Expected duplicated_value error got: %v", err)
+		}
+	})
+}
+
 func TestUserUpdateOperation(t *testing.T) {
+	ctx := context.Background()
 	tokenId := "myTokenId"
 	encrypted, _ := utils.AES256Encrypt(authKey, tokenId)
 	now := utils.UnixUTCNow()
@@ -296,31 +423,35 @@ func TestUserUpdateOperation(t *testing.T) {
 		service := service.NewUserService(&repo, config)
 		handlerInstance := NewUserGraphqlHandler(*service)
 
-		input := model.UpdateUser{
-			ID:       "1",
-			Name:     "Sam Wilson",
-			Username: "CapAmerica",
-			Role:     "hero",
-			Provider: "avengers",
-			TokenID:  "newTokenId",
-			Status:   "active",
+		input := map[string]interface{}{
+			"id":       "1",
+			"name":     "Sam Wilson",
+			"username": "CapAmerica",
+			"role":     "hero",
+			"provider": "avengers",
+			"tokenID":  "newTokenId",
+			"picture":  nil,
 		}
-		got, err := handlerInstance.Update(input)
+		got, err := handlerInstance.Update(ctx, input)
 
 		if err != nil {
-			t.Errorf("Unexpected error: %v", err)
+			t.Fatalf("Unexpected error: %v", err)
 		}
 
-		if got.ID != input.ID {
-			t.Errorf("Expected ID to be: %q got: %q", input.Name, got.Name)
+		if got.ID != input["id"] {
+			t.Errorf("Expected ID to be: %q got: %q", input["id"], got.ID)
 		}
 
-		if got.Name != input.Name {
-			t.Errorf("Expected Name to be: %q got: %q", input.Username, got.Username)
+		if got.Name != input["name"] {
+			t.Errorf("Expected Name to be: %q got: %q", input["name"], got.Name)
 		}
 
-		if got.Username != input.Username {
-			t.Errorf("Expected Username to be: %q got: %q", input.Username, got.Username)
+		if got.Username != input["username"] {
+			t.Errorf("Expected Username to be: %q got: %q", input["username"], got.Username)
+		}
+
+		if got.Status != "active" {
+			t.Errorf("Expected Status to be kept got: %q", got.Status)
 		}
 
 		if *got.Picture != "" {
@@ -332,21 +463,48 @@ func TestUserUpdateOperation(t *testing.T) {
 			t.Errorf("Unexpected error: %v", err)
 		}
 
-		if decrypted != input.TokenID {
-			t.Errorf("Expected TokenID to be: %q got: %q", input.TokenID, decrypted)
+		if decrypted != input["tokenID"] {
+			t.Errorf("Expected TokenID to be: %q got: %q", input["tokenID"], decrypted)
 		}
 
-		if got.CreateDate.Equal(yesterday) {
-			t.Errorf("Expected CreatedDate to be approximately equals: %q got: %q", now, got.CreateDate)
+		if !got.CreateDate.Equal(yesterday) {
+			t.Errorf("Expected CreateDate to be kept: %q got: %q", yesterday, got.CreateDate)
 		}
 
-		if got.UpdateDate.After(now) {
-			t.Errorf("Expected UpdateDate to be approximately equals: %q got: %q", now, got.CreateDate)
+		if got.UpdateDate.Before(now) {
+			t.Errorf("Expected UpdateDate to be after: %q got: %q", now, got.UpdateDate)
+		}
+	})
+
+	t.Run("Update to a duplicated username", func(t *testing.T) {
+		repo := mocks.MemRepo{
+			Data: map[string][]map[string]interface{}{
+				domain.USER_COL_NAME: {
+					{"id": "1", "username": "CapAmerica"},
+					{"id": "2", "username": "other"},
+				},
+			},
+		}
+
+		config := domain.DefaultConfig()
+		config.Keys.Auth = authKey
+		service := service.NewUserService(&repo, config)
+		handlerInstance := NewUserGraphqlHandler(*service)
+
+		_, err := handlerInstance.Update(ctx, map[string]interface{}{"id": "1", "username": "other"})
+		if err == nil || err.Error() != "duplicated_value" {
+			t.Errorf("Expected duplicated_value error got: %v", err)
+		}
+
+		got, err := handlerInstance.Update(ctx, map[string]interface{}{"id": "1", "username": "CapAmerica"})
+		if err != nil || got.Username != "CapAmerica" {
+			t.Errorf("Expected the username of the same user to be accepted got: %+v with error: %v", got, err)
 		}
 	})
 }
 
 func TestUserDeleteOperation(t *testing.T) {
+	ctx := context.Background()
 	tokenId := "myTokenId"
 	encrypted, _ := utils.AES256Encrypt(authKey, tokenId)
 	now := utils.UnixUTCNow()
@@ -381,7 +539,7 @@ func TestUserDeleteOperation(t *testing.T) {
 		service := service.NewUserService(&repo, config)
 		handlerInstance := NewUserGraphqlHandler(*service)
 
-		got, err := handlerInstance.Delete("1")
+		got, err := handlerInstance.Delete(ctx, "1")
 
 		if err != nil {
 			t.Errorf("Unexpected error: %v", err)
@@ -391,7 +549,7 @@ func TestUserDeleteOperation(t *testing.T) {
 			t.Errorf("Expected ID to be: \"1\" got: %q", got.ID)
 		}
 
-		_, err = service.Get("1")
+		_, err = service.Get(ctx, "1")
 
 		if err == nil {
 			t.Errorf("Expected error got nil")
@@ -403,3 +561,62 @@ func TestUserDeleteOperation(t *testing.T) {
 		}
 	})
 }
+
+func TestUserBulkOperations(t *testing.T) {
+	ctx := context.Background()
+	repo := mocks.MemRepo{
+		Data: map[string][]map[string]interface{}{
+			domain.USER_COL_NAME: {{"id": "1", "username": "thor"}},
+		},
+	}
+
+	config := domain.DefaultConfig()
+	config.Keys.Auth = authKey
+	service := service.NewUserService(&repo, config)
+	handlerInstance := NewUserGraphqlHandler(*service)
+
+	t.Run("Create users reports each result", func(t *testing.T) {
+		inputs := []*model.NewUser{
+			{Username: "ironman", Name: "Tony Stark", TokenID: "token"},
+			{Username: "thor", Name: "Thor", TokenID: "token"},
+			{Username: "hulk", Name: "Bruce Banner", TokenID: "token"},
+		}
+
+		got, err := handlerInstance.CreateMany(ctx, inputs, nil)
+		if err != nil || got.Succeeded != 2 || got.Failed != 1 {
+			t.Fatalf("Expected 2 users created and 1 failure got: %+v with error: %v", got, err)
+		}
+
+		failed := got.Results[1]
+		if failed.Success || failed.ID != nil || failed.Error == nil || *failed.Error != "duplicated_value" {
+			t.Errorf("Expected duplicated_value error got: %+v", failed)
+		}
+
+		if got.Results[2].Index != 2 || got.Results[2].ID == nil {
+			t.Errorf("Expected an id for the third user got: %+v", got.Results[2])
+		}
+	})
+
+	t.Run("Update and delete users stop on error", func(t *testing.T) {
+		stop := true
+		inputs := []*model.UpdateInput{
+			{Fields: map[string]interface{}{"id": "1", "name": "Thor Odinson"}},
+			{Fields: map[string]interface{}{"id": "missing", "name": "Loki"}},
+			{Fields: map[string]interface{}{"id": "1", "name": "Thor"}},
+		}
+
+		got, err := handlerInstance.UpdateMany(ctx, inputs, &stop)
+		if err != nil || got.Succeeded != 1 || got.Failed != 2 {
+			t.Fatalf("Expected 1 user updated and 2 failures got: %+v with error: %v", got, err)
+		}
+
+		if user, _ := service.Get(ctx, "1"); user.Name != "Thor Odinson" {
+			t.Errorf("Expected the aborted update to not be applied got: %+v", user)
+		}
+
+		got, err = handlerInstance.DeleteMany(ctx, []string{"1"}, &stop)
+		if err != nil || got.Succeeded != 1 || *got.Results[0].ID != "1" {
+			t.Errorf("Expected user 1 to be deleted got: %+v with error: %v", got, err)
+		}
+	})
+}
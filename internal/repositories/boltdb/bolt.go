@@ -0,0 +1,94 @@
+package boltdb
+
+import (
+	"context"
+	"io"
+	"os"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/sy-software/minerva-owl/internal/core/domain"
+	bolt "go.etcd.io/bbolt"
+)
+
+type BoltDB struct {
+	db     *bolt.DB
+	config domain.BoltConfig
+}
+
+// Open opens or creates the database file in config.Path
+//
+// Only one process can open the file at a time, Open waits up to config.Timeout
+// seconds for the lock held by other process
+func Open(config domain.BoltConfig) (*BoltDB, error) {
+	log.Info().Msgf("Opening Bolt DB file: %s", config.Path)
+	db, err := bolt.Open(config.Path, 0600, &bolt.Options{
+		Timeout: config.Timeout * time.Second,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &BoltDB{
+		db:     db,
+		config: config,
+	}, nil
+}
+
+// Ping checks the database file is still open
+func (bdb *BoltDB) Ping(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return bdb.db.View(func(tx *bolt.Tx) error {
+		return nil
+	})
+}
+
+// Close releases the database file
+func (bdb *BoltDB) Close() {
+	if err := bdb.db.Close(); err != nil {
+		log.Error().Err(err).Msgf("Error closing Bolt DB file: %s", bdb.config.Path)
+	}
+}
+
+// Backup writes a consistent copy of the database into w without blocking writers,
+// it returns the number of bytes written
+func (bdb *BoltDB) Backup(w io.Writer) (int64, error) {
+	var size int64
+	err := bdb.db.View(func(tx *bolt.Tx) error {
+		var err error
+		size, err = tx.WriteTo(w)
+		return err
+	})
+
+	return size, err
+}
+
+// BackupFile writes a consistent copy of the database into the file in path,
+// the file is only replaced once the copy is complete
+func (bdb *BoltDB) BackupFile(path string) (int64, error) {
+	tmpPath := path + ".tmp"
+	file, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return 0, err
+	}
+
+	size, err := bdb.Backup(file)
+	if err == nil {
+		err = file.Sync()
+	}
+
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		os.Remove(tmpPath)
+		return 0, err
+	}
+
+	return size, os.Rename(tmpPath, path)
+}
@@ -0,0 +1,19 @@
+package boltdb
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/sy-software/minerva-owl/internal/core/ports"
+	"github.com/sy-software/minerva-owl/internal/repositories/repotest"
+)
+
+func TestContract(t *testing.T) {
+	repotest.Run(t, repotest.Suite{
+		New: func(t *testing.T) ports.Repository {
+			db, repo := openRepo(t, filepath.Join(t.TempDir(), "owl.db"), nil)
+			t.Cleanup(db.Close)
+			return repo
+		},
+	})
+}
@@ -0,0 +1,3 @@
+// Package boltdb implements a ports.Repository stored in a single file using bbolt,
+// useful to run without a database server while keeping the data between restarts
+package boltdb
@@ -0,0 +1,1032 @@
+package boltdb
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"github.com/sy-software/minerva-owl/internal/core/ports"
+	"github.com/sy-software/minerva-owl/internal/repositories/memory"
+	"github.com/sy-software/minerva-owl/internal/utils"
+	bolt "go.etcd.io/bbolt"
+)
+
+// idField is the document field holding the item id
+const idField = "id"
+
+// Names of the buckets inside each collection bucket
+var (
+	// itemsBucket maps the insertion sequence of each item to its document
+	itemsBucket = []byte("items")
+	// idsBucket maps each item id to its insertion sequence
+	idsBucket = []byte("ids")
+	// indexPrefix is the prefix of the buckets mapping index keys to insertion sequences
+	indexPrefix = []byte("index:")
+)
+
+// Type markers used as the first byte of index keys
+const (
+	stringKey = 's'
+	numberKey = 'n'
+	boolKey   = 'b'
+)
+
+// BoltRepo is an implementation of ports.Repository stored in a single bbolt file
+//
+// Items are stored as documents serialized with the json tags of the domain models,
+// filters and sorts by "_id" are applied to the "id" field. Every write runs in its own
+// transaction so a crash never leaves a partially written item or index, unless it's
+// part of WithTransaction
+//
+// Filters by eq, in and prefix on indexed fields only read the matching items,
+// any other filter reads the whole collection
+type BoltRepo struct {
+	db *BoltDB
+	// indexes has the indexed fields of each collection
+	indexes map[string][]string
+	// tx is the transaction used by every operation of the repository returned by WithTransaction
+	tx *bolt.Tx
+}
+
+// NewBoltRepo creates an instance of BoltRepo, indexes of existing collections
+// are built or dropped to match the configuration
+func NewBoltRepo(db *BoltDB) (*BoltRepo, error) {
+	repo := &BoltRepo{
+		db:      db,
+		indexes: map[string][]string{},
+	}
+
+	for collection, fields := range db.config.Indexes {
+		for _, field := range fields {
+			if field == "_id" || field == idField {
+				continue
+			}
+
+			repo.indexes[collection] = append(repo.indexes[collection], field)
+		}
+	}
+
+	err := db.db.Update(func(tx *bolt.Tx) error {
+		return tx.ForEach(func(name []byte, bucket *bolt.Bucket) error {
+			return repo.syncIndexes(string(name), bucket)
+		})
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return repo, nil
+}
+
+// syncIndexes builds the configured indexes missing in a collection and drops the ones no longer configured
+func (repo *BoltRepo) syncIndexes(collection string, bucket *bolt.Bucket) error {
+	configured := map[string]bool{}
+	for _, field := range repo.indexes[collection] {
+		configured[field] = true
+	}
+
+	stale := [][]byte{}
+	err := bucket.ForEach(func(name []byte, value []byte) error {
+		if value == nil && bytes.HasPrefix(name, indexPrefix) && !configured[string(name[len(indexPrefix):])] {
+			stale = append(stale, append([]byte{}, name...))
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return err
+	}
+
+	for _, name := range stale {
+		log.Info().Msgf("Dropping index %s of %s", name[len(indexPrefix):], collection)
+		if err := bucket.DeleteBucket(name); err != nil {
+			return err
+		}
+	}
+
+	for _, field := range repo.indexes[collection] {
+		if bucket.Bucket(indexName(field)) != nil {
+			continue
+		}
+
+		log.Info().Msgf("Building index %s of %s", field, collection)
+		index, err := bucket.CreateBucket(indexName(field))
+		if err != nil {
+			return err
+		}
+
+		err = bucket.Bucket(itemsBucket).ForEach(func(seq []byte, data []byte) error {
+			doc, err := decodeDoc(data)
+			if err != nil {
+				return err
+			}
+
+			return putIndex(index, field, doc, seq)
+		})
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// getCollection returns the bucket of a collection creating it with its indexes if it doesn't exist
+func (repo *BoltRepo) getCollection(tx *bolt.Tx, collection string) (*bolt.Bucket, error) {
+	if bucket := tx.Bucket([]byte(collection)); bucket != nil {
+		return bucket, nil
+	}
+
+	bucket, err := tx.CreateBucket([]byte(collection))
+	if err != nil {
+		return nil, err
+	}
+
+	names := [][]byte{itemsBucket, idsBucket}
+	for _, field := range repo.indexes[collection] {
+		names = append(names, indexName(field))
+	}
+
+	for _, name := range names {
+		if _, err := bucket.CreateBucket(name); err != nil {
+			return nil, err
+		}
+	}
+
+	return bucket, nil
+}
+
+// item is a stored document with its insertion sequence
+type item struct {
+	seq  []byte
+	data []byte
+	doc  map[string]interface{}
+}
+
+// find returns the items from a collection matching the filters in insertion order,
+// starting after the sequence in after when it's not nil
+func (repo *BoltRepo) find(tx *bolt.Tx, collection string, after []byte, filters []ports.Filter, fn func(item item) bool) error {
+	for _, filter := range filters {
+		if err := filter.Validate(); err != nil {
+			return err
+		}
+	}
+
+	bucket := tx.Bucket([]byte(collection))
+	if bucket == nil {
+		return nil
+	}
+
+	items := bucket.Bucket(itemsBucket)
+	visit := func(seq []byte, data []byte) (bool, error) {
+		doc, err := decodeDoc(data)
+		if err != nil {
+			return false, err
+		}
+
+		if !memory.MatchAll(doc, filters) {
+			return true, nil
+		}
+
+		return fn(item{seq: seq, data: data, doc: doc}), nil
+	}
+
+	if candidates, ok := repo.candidates(bucket, collection, filters); ok {
+		for _, seq := range candidates {
+			if after != nil && bytes.Compare(seq, after) <= 0 {
+				continue
+			}
+
+			data := items.Get(seq)
+			if data == nil {
+				continue
+			}
+
+			next, err := visit(seq, data)
+			if err != nil || !next {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	cursor := items.Cursor()
+	seq, data := cursor.First()
+	if after != nil {
+		seq, data = cursor.Seek(after)
+		if seq != nil && bytes.Equal(seq, after) {
+			seq, data = cursor.Next()
+		}
+	}
+
+	for ; seq != nil; seq, data = cursor.Next() {
+		next, err := visit(seq, data)
+		if err != nil || !next {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// candidates returns the sorted sequences of the items which can match the filters using
+// the ids bucket or an index, the second value is false when no filter can use them
+func (repo *BoltRepo) candidates(bucket *bolt.Bucket, collection string, filters []ports.Filter) ([][]byte, bool) {
+	var best map[string]bool
+	for _, filter := range filters {
+		var found map[string]bool
+		var ok bool
+
+		if filter.Name == "_id" || filter.Name == idField {
+			found, ok = lookupIds(bucket.Bucket(idsBucket), filter)
+		} else if index := bucket.Bucket(indexName(filter.Name)); index != nil && repo.isIndexed(collection, filter.Name) {
+			found, ok = lookupIndex(index, filter)
+		}
+
+		if ok && (best == nil || len(found) < len(best)) {
+			best = found
+		}
+	}
+
+	if best == nil {
+		return nil, false
+	}
+
+	sequences := make([][]byte, 0, len(best))
+	for seq := range best {
+		sequences = append(sequences, []byte(seq))
+	}
+
+	sort.Slice(sequences, func(i, j int) bool {
+		return bytes.Compare(sequences[i], sequences[j]) < 0
+	})
+
+	return sequences, true
+}
+
+// isIndexed tells if field is indexed in collection
+func (repo *BoltRepo) isIndexed(collection string, field string) bool {
+	for _, indexed := range repo.indexes[collection] {
+		if indexed == field {
+			return true
+		}
+	}
+
+	return false
+}
+
+// lookupIds returns the sequences of the items with the ids in an eq or in filter
+func lookupIds(ids *bolt.Bucket, filter ports.Filter) (map[string]bool, bool) {
+	values, ok := filterValues(filter)
+	if !ok {
+		return nil, false
+	}
+
+	found := map[string]bool{}
+	for _, value := range values {
+		id, ok := value.(string)
+		if !ok {
+			return nil, false
+		}
+
+		if seq := ids.Get([]byte(id)); seq != nil {
+			found[string(seq)] = true
+		}
+	}
+
+	return found, true
+}
+
+// lookupIndex returns the sequences of the items matching an eq, in or prefix filter from an index
+func lookupIndex(index *bolt.Bucket, filter ports.Filter) (map[string]bool, bool) {
+	exact := true
+	keys := [][]byte{}
+
+	if filter.Op() == ports.OP_PREFIX {
+		prefix, ok := filter.Value.(string)
+		if !ok {
+			return nil, false
+		}
+
+		exact = false
+		keys = append(keys, append([]byte{stringKey}, prefix...))
+	} else {
+		values, ok := filterValues(filter)
+		if !ok {
+			return nil, false
+		}
+
+		for _, value := range values {
+			// Dates are compared by instant, the same instant can be stored with different text
+			if str, ok := value.(string); ok && isTime(str) {
+				return nil, false
+			}
+
+			key, ok := indexKey(value)
+			if !ok {
+				return nil, false
+			}
+
+			keys = append(keys, key)
+		}
+	}
+
+	found := map[string]bool{}
+	cursor := index.Cursor()
+	for _, key := range keys {
+		for k, _ := cursor.Seek(key); k != nil && bytes.HasPrefix(k, key); k, _ = cursor.Next() {
+			if exact && len(k) != len(key)+8 {
+				continue
+			}
+
+			found[string(k[len(k)-8:])] = true
+		}
+	}
+
+	return found, true
+}
+
+// filterValues returns the values of an eq or in filter normalized as stored in the documents
+func filterValues(filter ports.Filter) ([]interface{}, bool) {
+	switch filter.Op() {
+	case ports.OP_EQ:
+		return []interface{}{normalize(filter.Value)}, true
+	case ports.OP_IN:
+		values, ok := normalize(filter.Value).([]interface{})
+		return values, ok
+	}
+
+	return nil, false
+}
+
+// List stores into results a list of items from the given collection applying the filters
+// results must be a pointer to an Slice of an struct with json tags for serialization
+//
+// Items without sort are returned in insertion order
+func (repo *BoltRepo) List(ctx context.Context, collection string, results interface{}, skip int, limit int, sortBy []ports.Sort, filters ...ports.Filter) error {
+	docs := []map[string]interface{}{}
+	err := repo.view(ctx, func(tx *bolt.Tx) error {
+		return repo.find(tx, collection, nil, filters, func(item item) bool {
+			docs = append(docs, item.doc)
+			// Without sort there is no need to read more than needed
+			return len(sortBy) > 0 || len(docs) < skip+limit
+		})
+	})
+
+	if err != nil {
+		utils.Logger(ctx).Debug().Err(err).Msgf("%v - List error", collection)
+		return err
+	}
+
+	docs = memory.SortItems(docs, sortBy)
+
+	if skip >= len(docs) {
+		return nil
+	}
+
+	end := skip + limit
+	if end > len(docs) {
+		end = len(docs)
+	}
+
+	resultsVal := reflect.ValueOf(results).Elem()
+	for _, doc := range docs[skip:end] {
+		element := reflect.New(resultsVal.Type().Elem())
+		if err := decodeInto(doc, element.Interface()); err != nil {
+			utils.Logger(ctx).Debug().Err(err).Msgf("%v - List error", collection)
+			return err
+		}
+
+		resultsVal.Set(reflect.Append(resultsVal, element.Elem()))
+	}
+
+	return nil
+}
+
+// ListPage stores into results up to limit items from the given collection after the cursor.
+// results must be a pointer to an Slice of an struct with json tags for serialization
+//
+// Items are returned in insertion order, a cursor keeps working even if its item is deleted
+func (repo *BoltRepo) ListPage(ctx context.Context, collection string, results interface{}, after string, limit int, filters ...ports.Filter) (ports.PageInfo, error) {
+	pageInfo := ports.PageInfo{
+		Cursors:         []string{},
+		HasPreviousPage: after != "",
+	}
+
+	var afterSeq []byte
+	if after != "" {
+		var err error
+		afterSeq, err = decodeCursor(after)
+		if err != nil {
+			return pageInfo, err
+		}
+	}
+
+	resultsVal := reflect.ValueOf(results).Elem()
+	err := repo.view(ctx, func(tx *bolt.Tx) error {
+		var decodeErr error
+		err := repo.find(tx, collection, afterSeq, filters, func(item item) bool {
+			if len(pageInfo.Cursors) == limit {
+				pageInfo.HasNextPage = true
+				return false
+			}
+
+			element := reflect.New(resultsVal.Type().Elem())
+			if decodeErr = json.Unmarshal(item.data, element.Interface()); decodeErr != nil {
+				return false
+			}
+
+			resultsVal.Set(reflect.Append(resultsVal, element.Elem()))
+			pageInfo.EndCursor = encodeCursor(item.seq)
+			pageInfo.Cursors = append(pageInfo.Cursors, pageInfo.EndCursor)
+			return true
+		})
+
+		if err != nil {
+			return err
+		}
+
+		return decodeErr
+	})
+
+	if err != nil {
+		utils.Logger(ctx).Debug().Err(err).Msgf("%v - List page error", collection)
+	}
+
+	return pageInfo, err
+}
+
+// Count returns how many items from collection match the filters
+func (repo *BoltRepo) Count(ctx context.Context, collection string, filters ...ports.Filter) (int, error) {
+	count := 0
+	err := repo.view(ctx, func(tx *bolt.Tx) error {
+		return repo.find(tx, collection, nil, filters, func(item item) bool {
+			count++
+			return true
+		})
+	})
+
+	if err != nil {
+		utils.Logger(ctx).Debug().Err(err).Msgf("%v - Count error", collection)
+	}
+
+	return count, err
+}
+
+// Get stores into result an item from collection with id equals to id
+// result must be a pointer to an instance of a struct with json tags for serialization
+func (repo *BoltRepo) Get(ctx context.Context, collection string, id string, result interface{}) error {
+	return repo.view(ctx, func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(collection))
+		if bucket != nil {
+			if seq := bucket.Bucket(idsBucket).Get([]byte(id)); seq != nil {
+				return json.Unmarshal(bucket.Bucket(itemsBucket).Get(seq), result)
+			}
+		}
+
+		return ports.ErrItemNotFound{
+			Id:    &id,
+			Model: collection,
+		}
+	})
+}
+
+// GetMany appends to results the items from collection with any of the ids
+// results must be a pointer to an Slice of an struct with json tags for serialization
+func (repo *BoltRepo) GetMany(ctx context.Context, collection string, ids []string, results interface{}) error {
+	if len(ids) == 0 {
+		return ctx.Err()
+	}
+
+	return repo.List(ctx, collection, results, 0, len(ids), nil, ports.InIds(ids...))
+}
+
+// GetOne stores into result the first inserted item from collection matching the filters
+// result must be a pointer to an instance of a struct with json tags for serialization
+func (repo *BoltRepo) GetOne(ctx context.Context, collection string, result interface{}, filters ...ports.Filter) error {
+	var data []byte
+	err := repo.view(ctx, func(tx *bolt.Tx) error {
+		return repo.find(tx, collection, nil, filters, func(item item) bool {
+			// Data is only valid during the transaction
+			data = append([]byte{}, item.data...)
+			return false
+		})
+	})
+
+	if err != nil {
+		utils.Logger(ctx).Debug().Err(err).Msgf("%v - Get error", collection)
+		return err
+	}
+
+	if data == nil {
+		return ports.ErrItemNotFound{
+			Model: collection,
+		}
+	}
+
+	return json.Unmarshal(data, result)
+}
+
+// Create saves the serialized version of entity into the collection
+// entity must be an instance of a struct with json tags for serialization
+//
+// If the entity has no id a new V4 UUID is assigned
+func (repo *BoltRepo) Create(ctx context.Context, collection string, entity interface{}) (string, error) {
+	doc, err := encode(entity)
+	if err != nil {
+		return "", err
+	}
+
+	id, _ := doc[idField].(string)
+	if id == "" {
+		id = uuid.New().String()
+		doc[idField] = id
+	}
+
+	err = repo.update(ctx, func(tx *bolt.Tx) error {
+		return repo.insertItem(tx, collection, id, doc)
+	})
+
+	if err != nil {
+		utils.Logger(ctx).Debug().Err(err).Msgf("%v - Create error", collection)
+		return "", err
+	}
+
+	return id, nil
+}
+
+// insertItem stores doc with id as the newest item of collection
+func (repo *BoltRepo) insertItem(tx *bolt.Tx, collection string, id string, doc map[string]interface{}) error {
+	bucket, err := repo.getCollection(tx, collection)
+	if err != nil {
+		return err
+	}
+
+	ids := bucket.Bucket(idsBucket)
+	if ids.Get([]byte(id)) != nil {
+		return ports.ErrDuplicatedId{Id: id, Model: collection}
+	}
+
+	items := bucket.Bucket(itemsBucket)
+	next, err := items.NextSequence()
+	if err != nil {
+		return err
+	}
+
+	seq := make([]byte, 8)
+	binary.BigEndian.PutUint64(seq, next)
+
+	if err := ids.Put([]byte(id), seq); err != nil {
+		return err
+	}
+
+	return repo.putItem(bucket, collection, seq, doc)
+}
+
+// Update saves the values of entity to the item with id from the collection
+// entity must be an instance of a struct with json tags for serialization
+//
+// Fields without value in entity and the field names in omit keep their stored value
+func (repo *BoltRepo) Update(ctx context.Context, collection string, id string, entity interface{}, omit ...string) error {
+	values, err := encode(entity)
+	if err != nil {
+		return err
+	}
+
+	err = repo.update(ctx, func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(collection))
+		var seq []byte
+		if bucket != nil {
+			seq = bucket.Bucket(idsBucket).Get([]byte(id))
+		}
+
+		if seq == nil {
+			return ports.ErrItemNotFound{
+				Id:    &id,
+				Model: collection,
+			}
+		}
+
+		doc, err := repo.deleteItem(bucket, collection, seq)
+		if err != nil {
+			return err
+		}
+
+		mergeFields(doc, values, omit)
+		return repo.putItem(bucket, collection, seq, doc)
+	})
+
+	if err != nil {
+		utils.Logger(ctx).Debug().Err(err).Msgf("%v - Update error", collection)
+	}
+
+	return err
+}
+
+// Upsert saves the values of entity to the first inserted item from collection matching the filters,
+// except the fields in onInsertOnly, or creates entity if none matches. Both happen in the same transaction
+func (repo *BoltRepo) Upsert(ctx context.Context, collection string, filters []ports.Filter, entity interface{}, onInsertOnly ...string) (string, bool, error) {
+	values, err := encode(entity)
+	if err != nil {
+		return "", false, err
+	}
+
+	id := ""
+	created := false
+	err = repo.update(ctx, func(tx *bolt.Tx) error {
+		var seq []byte
+		err := repo.find(tx, collection, nil, filters, func(item item) bool {
+			seq = item.seq
+			id, _ = item.doc[idField].(string)
+			return false
+		})
+
+		if err != nil {
+			return err
+		}
+
+		if seq == nil {
+			id, _ = values[idField].(string)
+			if id == "" {
+				id = uuid.New().String()
+				values[idField] = id
+			}
+
+			created = true
+			return repo.insertItem(tx, collection, id, values)
+		}
+
+		bucket := tx.Bucket([]byte(collection))
+		doc, err := repo.deleteItem(bucket, collection, seq)
+		if err != nil {
+			return err
+		}
+
+		mergeFields(doc, values, onInsertOnly)
+		return repo.putItem(bucket, collection, seq, doc)
+	})
+
+	if err != nil {
+		utils.Logger(ctx).Debug().Err(err).Msgf("%v - Upsert error", collection)
+		return "", false, err
+	}
+
+	return id, created, nil
+}
+
+// Patch changes the fields in mask of the item with id from the collection
+func (repo *BoltRepo) Patch(ctx context.Context, collection string, id string, mask ports.FieldMask) error {
+	if err := mask.Validate(); err != nil {
+		utils.Logger(ctx).Debug().Err(err).Msgf("%v - Patch error", collection)
+		return err
+	}
+
+	err := repo.update(ctx, func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(collection))
+		var seq []byte
+		if bucket != nil {
+			seq = bucket.Bucket(idsBucket).Get([]byte(id))
+		}
+
+		if seq == nil {
+			return ports.ErrItemNotFound{
+				Id:    &id,
+				Model: collection,
+			}
+		}
+
+		current, err := repo.deleteItem(bucket, collection, seq)
+		if err != nil {
+			return err
+		}
+
+		doc, err := memory.ApplyFieldMask(current, mask)
+		if err != nil {
+			return err
+		}
+
+		return repo.putItem(bucket, collection, seq, doc)
+	})
+
+	if err != nil {
+		utils.Logger(ctx).Debug().Err(err).Msgf("%v - Patch error", collection)
+	}
+
+	return err
+}
+
+// Delete removes the item with id from collection
+func (repo *BoltRepo) Delete(ctx context.Context, collection string, id string) error {
+	err := repo.update(ctx, func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(collection))
+		var seq []byte
+		if bucket != nil {
+			seq = bucket.Bucket(idsBucket).Get([]byte(id))
+		}
+
+		if seq == nil {
+			return ports.ErrItemNotFound{
+				Id:    &id,
+				Model: collection,
+			}
+		}
+
+		// The sequence is only valid until the key is deleted
+		seq = append([]byte{}, seq...)
+		if _, err := repo.deleteItem(bucket, collection, seq); err != nil {
+			return err
+		}
+
+		return bucket.Bucket(idsBucket).Delete([]byte(id))
+	})
+
+	if err != nil {
+		utils.Logger(ctx).Debug().Err(err).Msgf("%v - Delete error", collection)
+	}
+
+	return err
+}
+
+// view runs fn in a read only transaction, or in the current one inside WithTransaction.
+// bbolt can't interrupt a running transaction so ctx is only checked before starting
+func (repo *BoltRepo) view(ctx context.Context, fn func(tx *bolt.Tx) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if repo.tx != nil {
+		return fn(repo.tx)
+	}
+
+	return repo.db.db.View(fn)
+}
+
+// update runs fn in a read-write transaction, or in the current one inside WithTransaction.
+// bbolt can't interrupt a running transaction so ctx is only checked before starting
+func (repo *BoltRepo) update(ctx context.Context, fn func(tx *bolt.Tx) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if repo.tx != nil {
+		return fn(repo.tx)
+	}
+
+	return repo.db.db.Update(fn)
+}
+
+// WithTransaction runs every operation of fn in a single read-write transaction,
+// committed when fn returns nil and rolled back otherwise.
+//
+// bbolt allows a single writer, other writes wait until the transaction ends so fn
+// must only use tx. Nested calls run in the same transaction
+func (repo *BoltRepo) WithTransaction(ctx context.Context, fn func(tx ports.Repository) error) error {
+	if repo.tx != nil {
+		return fn(repo)
+	}
+
+	return repo.update(ctx, func(tx *bolt.Tx) error {
+		err := fn(&BoltRepo{
+			db:      repo.db,
+			indexes: repo.indexes,
+			tx:      tx,
+		})
+
+		// A transaction cancelled while fn was running is rolled back
+		if err == nil {
+			err = ctx.Err()
+		}
+
+		return err
+	})
+}
+
+// CreateMany saves every entity into the collection in order, each one like Create in its own transaction
+func (repo *BoltRepo) CreateMany(ctx context.Context, collection string, entities []interface{}, stopOnError bool) ([]ports.BulkResult, error) {
+	return ports.RunBulk(len(entities), stopOnError, func(index int) (string, error) {
+		return repo.Create(ctx, collection, entities[index])
+	}), nil
+}
+
+// PatchMany applies every patch to the collection in order, each one like Patch in its own transaction
+func (repo *BoltRepo) PatchMany(ctx context.Context, collection string, patches []ports.BulkPatch, stopOnError bool) ([]ports.BulkResult, error) {
+	return ports.RunBulk(len(patches), stopOnError, func(index int) (string, error) {
+		return patches[index].Id, repo.Patch(ctx, collection, patches[index].Id, patches[index].Mask)
+	}), nil
+}
+
+// DeleteMany removes every item with the ids from the collection in order, each one like Delete in its own transaction
+func (repo *BoltRepo) DeleteMany(ctx context.Context, collection string, ids []string, stopOnError bool) ([]ports.BulkResult, error) {
+	return ports.RunBulk(len(ids), stopOnError, func(index int) (string, error) {
+		return ids[index], repo.Delete(ctx, collection, ids[index])
+	}), nil
+}
+
+// putItem stores doc with seq and adds it to the indexes
+func (repo *BoltRepo) putItem(bucket *bolt.Bucket, collection string, seq []byte, doc map[string]interface{}) error {
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+
+	if err := bucket.Bucket(itemsBucket).Put(seq, data); err != nil {
+		return err
+	}
+
+	for _, field := range repo.indexes[collection] {
+		if err := putIndex(bucket.Bucket(indexName(field)), field, doc, seq); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// deleteItem removes the item with seq and its index entries, it returns the removed document
+func (repo *BoltRepo) deleteItem(bucket *bolt.Bucket, collection string, seq []byte) (map[string]interface{}, error) {
+	items := bucket.Bucket(itemsBucket)
+	doc, err := decodeDoc(items.Get(seq))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, field := range repo.indexes[collection] {
+		index := bucket.Bucket(indexName(field))
+		for _, key := range indexKeys(field, doc) {
+			if err := index.Delete(append(key, seq...)); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return doc, items.Delete(seq)
+}
+
+// putIndex adds the index entries of a document field
+func putIndex(index *bolt.Bucket, field string, doc map[string]interface{}, seq []byte) error {
+	for _, key := range indexKeys(field, doc) {
+		if err := index.Put(append(key, seq...), []byte{}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// indexKeys returns the index keys of a document field, each element of an array has its own key
+func indexKeys(field string, doc map[string]interface{}) [][]byte {
+	value, exists := memory.FieldValue(doc, field)
+	if !exists {
+		return nil
+	}
+
+	values, isArray := value.([]interface{})
+	if !isArray {
+		values = []interface{}{value}
+	}
+
+	keys := [][]byte{}
+	for _, element := range values {
+		if key, ok := indexKey(element); ok {
+			keys = append(keys, key)
+		}
+	}
+
+	return keys
+}
+
+// indexKey encodes a scalar value into an index key, the second value is false for other types
+func indexKey(value interface{}) ([]byte, bool) {
+	switch val := value.(type) {
+	case string:
+		return append([]byte{stringKey}, val...), true
+	case float64:
+		if val == 0 {
+			// Avoid a different key for -0
+			val = 0
+		}
+
+		bits := math.Float64bits(val)
+		if val >= 0 {
+			bits ^= 1 << 63
+		} else {
+			bits = ^bits
+		}
+
+		key := make([]byte, 9)
+		key[0] = numberKey
+		binary.BigEndian.PutUint64(key[1:], bits)
+		return key, true
+	case bool:
+		if val {
+			return []byte{boolKey, 1}, true
+		}
+
+		return []byte{boolKey, 0}, true
+	}
+
+	return nil, false
+}
+
+// indexName returns the bucket name of the index of field
+func indexName(field string) []byte {
+	return append(append([]byte{}, indexPrefix...), field...)
+}
+
+// isTime tells if str is a serialized date
+func isTime(str string) bool {
+	_, err := time.Parse(time.RFC3339Nano, str)
+	return err == nil
+}
+
+// mergeFields copies into doc the fields of values, except the id and the fields in omit
+func mergeFields(doc map[string]interface{}, values map[string]interface{}, omit []string) {
+	omitMap := map[string]bool{idField: true}
+	for _, field := range omit {
+		omitMap[field] = true
+	}
+
+	for field, value := range values {
+		if !omitMap[field] {
+			doc[field] = value
+		}
+	}
+}
+
+// encode serializes entity into a document
+func encode(entity interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(entity)
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeDoc(data)
+}
+
+// decodeDoc deserializes stored data into a document
+func decodeDoc(data []byte) (map[string]interface{}, error) {
+	doc := map[string]interface{}{}
+	err := json.Unmarshal(data, &doc)
+	return doc, err
+}
+
+// decodeInto deserializes a document into result
+func decodeInto(doc map[string]interface{}, result interface{}) error {
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, result)
+}
+
+// normalize converts value into the same types used by the stored documents
+func normalize(value interface{}) interface{} {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return value
+	}
+
+	var output interface{}
+	if err := json.Unmarshal(data, &output); err != nil {
+		return value
+	}
+
+	return output
+}
+
+// encodeCursor converts an insertion sequence into an opaque cursor
+func encodeCursor(seq []byte) string {
+	value := strconv.FormatUint(binary.BigEndian.Uint64(seq), 10)
+	return base64.RawURLEncoding.EncodeToString([]byte(value))
+}
+
+// decodeCursor converts an opaque cursor back into an insertion sequence
+func decodeCursor(cursor string) ([]byte, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, ports.ErrInvalidCursor{Cursor: cursor}
+	}
+
+	value, err := strconv.ParseUint(string(raw), 10, 64)
+	if err != nil {
+		return nil, ports.ErrInvalidCursor{Cursor: cursor}
+	}
+
+	seq := make([]byte, 8)
+	binary.BigEndian.PutUint64(seq, value)
+	return seq, nil
+}
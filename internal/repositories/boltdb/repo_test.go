@@ -0,0 +1,278 @@
+package boltdb
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sy-software/minerva-owl/internal/core/domain"
+	"github.com/sy-software/minerva-owl/internal/core/ports"
+	bolt "go.etcd.io/bbolt"
+)
+
+// openRepo creates a BoltRepo stored in a temporary file
+func openRepo(t *testing.T, path string, indexes map[string][]string) (*BoltDB, *BoltRepo) {
+	db, err := Open(domain.BoltConfig{
+		Path:    path,
+		Timeout: 1,
+		Indexes: indexes,
+	})
+
+	if err != nil {
+		t.Fatalf("Unexpected error opening file: %v", err)
+	}
+
+	repo, err := NewBoltRepo(db)
+	if err != nil {
+		db.Close()
+		t.Fatalf("Unexpected error creating repo: %v", err)
+	}
+
+	return db, repo
+}
+
+func TestBoltRepo(t *testing.T) {
+	ctx := context.Background()
+	indexes := map[string][]string{"users": {"role", "username"}}
+
+	t.Run("Test create and get", func(t *testing.T) {
+		db, repo := openRepo(t, filepath.Join(t.TempDir(), "owl.db"), indexes)
+		defer db.Close()
+
+		expected := domain.User{
+			Username:   "IronMan",
+			Name:       "Tony Stark",
+			CreateDate: time.Date(2021, 6, 1, 10, 0, 0, 0, time.UTC),
+		}
+
+		id, err := repo.Create(ctx, "users", expected)
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+
+		expected.Id = id
+		got := domain.User{}
+		err = repo.Get(ctx, "users", id, &got)
+
+		if err != nil || !cmp.Equal(expected, got) {
+			t.Errorf("Expected user: %+v got: %+v with error: %v", expected, got, err)
+		}
+
+		_, err = repo.Create(ctx, "users", domain.User{Id: id})
+		if _, ok := err.(ports.ErrDuplicatedId); !ok {
+			t.Errorf("Expected error of type ErrDuplicatedId got: %v", err)
+		}
+
+		err = repo.Get(ctx, "users", "missing", &got)
+		if _, ok := err.(ports.ErrItemNotFound); !ok {
+			t.Errorf("Expected error of type ErrItemNotFound got: %v", err)
+		}
+	})
+
+	t.Run("Test update and delete", func(t *testing.T) {
+		db, repo := openRepo(t, filepath.Join(t.TempDir(), "owl.db"), indexes)
+		defer db.Close()
+
+		id, _ := repo.Create(ctx, "users", domain.User{Username: "IronMan", Name: "Tony Stark", Role: "admin"})
+
+		err := repo.Update(ctx, "users", id, domain.User{Id: "other", Name: "Anthony Stark", Role: "user"}, "name")
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+
+		got := domain.User{}
+		repo.Get(ctx, "users", id, &got)
+		expected := domain.User{Id: id, Username: "IronMan", Name: "Tony Stark", Role: "user"}
+		if !cmp.Equal(expected, got) {
+			t.Errorf("Expected user: %+v got: %+v", expected, got)
+		}
+
+		// The old index entry must be removed
+		count, _ := repo.Count(ctx, "users", ports.Eq("role", "admin"))
+		if count != 0 {
+			t.Errorf("Expected 0 admins got: %d", count)
+		}
+
+		count, _ = repo.Count(ctx, "users", ports.Eq("role", "user"))
+		if count != 1 {
+			t.Errorf("Expected 1 user got: %d", count)
+		}
+
+		if err := repo.Delete(ctx, "users", id); err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+
+		count, _ = repo.Count(ctx, "users", ports.Eq("role", "user"))
+		if count != 0 {
+			t.Errorf("Expected 0 users after delete got: %d", count)
+		}
+
+		if _, ok := repo.Delete(ctx, "users", id).(ports.ErrItemNotFound); !ok {
+			t.Errorf("Expected error of type ErrItemNotFound deleting twice")
+		}
+
+		if _, ok := repo.Update(ctx, "users", id, domain.User{}).(ports.ErrItemNotFound); !ok {
+			t.Errorf("Expected error of type ErrItemNotFound updating a deleted item")
+		}
+	})
+
+	t.Run("Test filters with and without indexes", func(t *testing.T) {
+		db, repo := openRepo(t, filepath.Join(t.TempDir(), "owl.db"), indexes)
+		defer db.Close()
+
+		for i := 0; i < 10; i++ {
+			role := "user"
+			if i%3 == 0 {
+				role = "admin"
+			}
+
+			repo.Create(ctx, "users", domain.User{
+				Id:       fmt.Sprintf("%d", i),
+				Username: fmt.Sprintf("user%d", i),
+				Name:     fmt.Sprintf("Name %d", i%2),
+				Role:     role,
+			})
+		}
+
+		tests := []struct {
+			name     string
+			filters  []ports.Filter
+			expected []string
+		}{
+			{"indexed eq", []ports.Filter{ports.Eq("role", "admin")}, []string{"0", "3", "6", "9"}},
+			{"indexed in", []ports.Filter{ports.In("username", "user2", "user7", "nobody")}, []string{"2", "7"}},
+			{"indexed prefix", []ports.Filter{ports.Prefix("username", "user1")}, []string{"1"}},
+			{"ids", []ports.Filter{ports.In("_id", "4", "5")}, []string{"4", "5"}},
+			{"not indexed", []ports.Filter{ports.Eq("name", "Name 1")}, []string{"1", "3", "5", "7", "9"}},
+			{"mixed", []ports.Filter{ports.Eq("role", "admin"), ports.Eq("name", "Name 0")}, []string{"0", "6"}},
+			{"logical", []ports.Filter{ports.Or(ports.Eq("role", "admin"), ports.Eq("_id", "1"))}, []string{"0", "1", "3", "6", "9"}},
+		}
+
+		for _, test := range tests {
+			got := []domain.User{}
+			err := repo.List(ctx, "users", &got, 0, 100, nil, test.filters...)
+
+			ids := []string{}
+			for _, user := range got {
+				ids = append(ids, user.Id)
+			}
+
+			if err != nil || !cmp.Equal(test.expected, ids) {
+				t.Errorf("%s: Expected ids: %v got: %v with error: %v", test.name, test.expected, ids, err)
+			}
+		}
+
+		got := []domain.User{}
+		repo.List(ctx, "users", &got, 1, 2, []ports.Sort{{Field: "_id", Descending: true}}, ports.Eq("role", "admin"))
+		if len(got) != 2 || got[0].Id != "6" || got[1].Id != "3" {
+			t.Errorf("Expected sorted users 6 and 3 got: %+v", got)
+		}
+
+		err := repo.List(ctx, "users", &got, 0, 10, nil, ports.Filter{Name: "role", Operator: "regex", Value: "a"})
+		if _, ok := err.(ports.ErrInvalidFilter); !ok {
+			t.Errorf("Expected error of type ErrInvalidFilter got: %v", err)
+		}
+	})
+
+	t.Run("Test list page", func(t *testing.T) {
+		db, repo := openRepo(t, filepath.Join(t.TempDir(), "owl.db"), indexes)
+		defer db.Close()
+
+		for i := 0; i < 5; i++ {
+			repo.Create(ctx, "users", domain.User{Id: fmt.Sprintf("%d", i), Role: "user"})
+		}
+
+		ids := []string{}
+		after := ""
+		for pages := 0; pages < 5; pages++ {
+			got := []domain.User{}
+			pageInfo, err := repo.ListPage(ctx, "users", &got, after, 2, ports.Eq("role", "user"))
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			for _, user := range got {
+				ids = append(ids, user.Id)
+			}
+
+			if !pageInfo.HasNextPage {
+				break
+			}
+
+			after = pageInfo.EndCursor
+			// A cursor keeps working after its item is deleted
+			repo.Delete(ctx, "users", got[len(got)-1].Id)
+		}
+
+		expected := []string{"0", "1", "2", "3", "4"}
+		if !cmp.Equal(expected, ids) {
+			t.Errorf("Expected ids: %v got: %v", expected, ids)
+		}
+
+		_, err := repo.ListPage(ctx, "users", &[]domain.User{}, "invalid", 2)
+		if _, ok := err.(ports.ErrInvalidCursor); !ok {
+			t.Errorf("Expected error of type ErrInvalidCursor got: %v", err)
+		}
+	})
+
+	t.Run("Test indexes are synced on open", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "owl.db")
+		db, repo := openRepo(t, path, nil)
+		repo.Create(ctx, "users", domain.User{Id: "1", Username: "IronMan", Role: "admin"})
+		db.Close()
+
+		db, repo = openRepo(t, path, indexes)
+		count, err := repo.Count(ctx, "users", ports.Eq("role", "admin"))
+		if err != nil || count != 1 {
+			t.Errorf("Expected 1 admin from the built index got: %d with error: %v", count, err)
+		}
+
+		db.db.View(func(tx *bolt.Tx) error {
+			if tx.Bucket([]byte("users")).Bucket(indexName("role")) == nil {
+				t.Errorf("Expected role index to exist")
+			}
+			return nil
+		})
+		db.Close()
+
+		db, _ = openRepo(t, path, map[string][]string{"users": {"username"}})
+		defer db.Close()
+		db.db.View(func(tx *bolt.Tx) error {
+			if tx.Bucket([]byte("users")).Bucket(indexName("role")) != nil {
+				t.Errorf("Expected role index to be dropped")
+			}
+			return nil
+		})
+	})
+
+	t.Run("Test backup", func(t *testing.T) {
+		dir := t.TempDir()
+		db, repo := openRepo(t, filepath.Join(dir, "owl.db"), indexes)
+		repo.Create(ctx, "users", domain.User{Id: "1", Username: "IronMan"})
+
+		buffer := bytes.Buffer{}
+		size, err := db.Backup(&buffer)
+		if err != nil || size != int64(buffer.Len()) {
+			t.Errorf("Expected backup of %d bytes got: %d with error: %v", buffer.Len(), size, err)
+		}
+
+		backupPath := filepath.Join(dir, "backup.db")
+		if _, err := db.BackupFile(backupPath); err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+		db.Close()
+
+		backup, repo := openRepo(t, backupPath, indexes)
+		defer backup.Close()
+
+		got := domain.User{}
+		err = repo.Get(ctx, "users", "1", &got)
+		if err != nil || got.Username != "IronMan" {
+			t.Errorf("Expected user from backup got: %+v with error: %v", got, err)
+		}
+	})
+}
@@ -0,0 +1,21 @@
+package cache
+
+import (
+	"testing"
+
+	"github.com/sy-software/minerva-owl/internal/core/domain"
+	"github.com/sy-software/minerva-owl/internal/core/ports"
+	"github.com/sy-software/minerva-owl/internal/repositories/memory"
+	"github.com/sy-software/minerva-owl/internal/repositories/repotest"
+)
+
+func TestContract(t *testing.T) {
+	config := domain.DefaultConfig().Cache
+	config.Default.Enabled = true
+
+	repotest.Run(t, repotest.Suite{
+		New: func(t *testing.T) ports.Repository {
+			return NewCachedRepo(memory.NewMemoryRepo(), config, NewLRU(0))
+		},
+	})
+}
@@ -0,0 +1,3 @@
+// Package cache implements a read-through cache decorator for any ports.Repository
+// and the in-process LRU used by default
+package cache
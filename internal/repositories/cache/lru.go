@@ -0,0 +1,111 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// LRU is an in-process implementation of ports.Cache which keeps up to a max number
+// of values, evicting the least recently used one when it's full.
+// Expired values are removed when they are read or evicted
+type LRU struct {
+	mutex    sync.Mutex
+	maxItems int
+	// order has the entries from the most to the least recently used
+	order   *list.List
+	entries map[string]*list.Element
+	// now is replaced by the tests to control the expiration
+	now func() time.Time
+}
+
+// lruEntry is a single cached value
+type lruEntry struct {
+	key       string
+	value     []byte
+	expiresAt time.Time
+}
+
+// NewLRU creates an empty LRU keeping up to maxItems values, maxItems <= 0 means no limit
+func NewLRU(maxItems int) *LRU {
+	return &LRU{
+		maxItems: maxItems,
+		order:    list.New(),
+		entries:  map[string]*list.Element{},
+		now:      time.Now,
+	}
+}
+
+// Get returns the value stored with key and true, or false when there is none or it expired
+func (lru *LRU) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	lru.mutex.Lock()
+	defer lru.mutex.Unlock()
+
+	element, exists := lru.entries[key]
+	if !exists {
+		return nil, false, nil
+	}
+
+	entry := element.Value.(*lruEntry)
+	if !lru.now().Before(entry.expiresAt) {
+		lru.remove(element)
+		return nil, false, nil
+	}
+
+	lru.order.MoveToFront(element)
+	return entry.value, true, nil
+}
+
+// Set stores value with key for ttl
+func (lru *LRU) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	lru.mutex.Lock()
+	defer lru.mutex.Unlock()
+
+	expiresAt := lru.now().Add(ttl)
+	if element, exists := lru.entries[key]; exists {
+		entry := element.Value.(*lruEntry)
+		entry.value, entry.expiresAt = value, expiresAt
+		lru.order.MoveToFront(element)
+		return nil
+	}
+
+	lru.entries[key] = lru.order.PushFront(&lruEntry{
+		key:       key,
+		value:     value,
+		expiresAt: expiresAt,
+	})
+
+	if lru.maxItems > 0 && lru.order.Len() > lru.maxItems {
+		lru.remove(lru.order.Back())
+	}
+
+	return nil
+}
+
+// Delete removes the values stored with any of the keys
+func (lru *LRU) Delete(ctx context.Context, keys ...string) error {
+	lru.mutex.Lock()
+	defer lru.mutex.Unlock()
+
+	for _, key := range keys {
+		if element, exists := lru.entries[key]; exists {
+			lru.remove(element)
+		}
+	}
+
+	return nil
+}
+
+// Len returns how many values are stored, including the expired ones not removed yet
+func (lru *LRU) Len() int {
+	lru.mutex.Lock()
+	defer lru.mutex.Unlock()
+	return lru.order.Len()
+}
+
+// remove deletes element, it must be called with the mutex locked
+func (lru *LRU) remove(element *list.Element) {
+	lru.order.Remove(element)
+	delete(lru.entries, element.Value.(*lruEntry).key)
+}
@@ -0,0 +1,69 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestLRU(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Least recently used values are evicted", func(t *testing.T) {
+		lru := NewLRU(2)
+		lru.Set(ctx, "a", []byte("1"), time.Minute)
+		lru.Set(ctx, "b", []byte("2"), time.Minute)
+		lru.Get(ctx, "a")
+		lru.Set(ctx, "c", []byte("3"), time.Minute)
+
+		if _, found, _ := lru.Get(ctx, "b"); found {
+			t.Errorf("Expected %q to be evicted", "b")
+		}
+
+		for _, key := range []string{"a", "c"} {
+			if _, found, _ := lru.Get(ctx, key); !found {
+				t.Errorf("Expected %q to be cached", key)
+			}
+		}
+
+		if lru.Len() != 2 {
+			t.Errorf("Expected 2 values got: %d", lru.Len())
+		}
+	})
+
+	t.Run("Expired values are not returned", func(t *testing.T) {
+		now := time.Now()
+		lru := NewLRU(0)
+		lru.now = func() time.Time { return now }
+		lru.Set(ctx, "a", []byte("1"), time.Minute)
+
+		now = now.Add(59 * time.Second)
+		if value, found, _ := lru.Get(ctx, "a"); !found || string(value) != "1" {
+			t.Errorf("Expected value: %q got: %q found: %v", "1", value, found)
+		}
+
+		now = now.Add(time.Second)
+		if _, found, _ := lru.Get(ctx, "a"); found {
+			t.Errorf("Expected value to be expired")
+		}
+
+		if lru.Len() != 0 {
+			t.Errorf("Expected expired value to be removed got: %d values", lru.Len())
+		}
+	})
+
+	t.Run("Deleted values are not returned", func(t *testing.T) {
+		lru := NewLRU(0)
+		lru.Set(ctx, "a", []byte("1"), time.Minute)
+		lru.Set(ctx, "b", []byte("2"), time.Minute)
+		lru.Delete(ctx, "a", "missing")
+
+		if _, found, _ := lru.Get(ctx, "a"); found {
+			t.Errorf("Expected %q to be deleted", "a")
+		}
+
+		if _, found, _ := lru.Get(ctx, "b"); !found {
+			t.Errorf("Expected %q to be cached", "b")
+		}
+	})
+}
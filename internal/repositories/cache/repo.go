@@ -0,0 +1,422 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/sy-software/minerva-owl/internal/core/domain"
+	"github.com/sy-software/minerva-owl/internal/core/ports"
+	"github.com/sy-software/minerva-owl/internal/utils"
+)
+
+// defaultTTL is used when neither the collection nor the default settings have a TTL
+const defaultTTL = 60 * time.Second
+
+// CachedRepo is a ports.Repository decorator caching the items read by id with Get and GetMany.
+//
+// Items are looked up in the in-process cache, then in the shared cache when there is one,
+// and finally in the decorated repository. Writes by id remove the item from both caches,
+// so other instances sharing the backend may read a stale item until its TTL expires.
+// An item read from the decorated repository is not cached when it was written while it
+// was being read, since the value read may be older than the write.
+//
+// Items are stored serialized with their json tags, reads inside a transaction skip the cache
+type CachedRepo struct {
+	ports.Repository
+	local  ports.Cache
+	shared ports.Cache
+	config domain.CacheConfig
+	// reads tracks the keys being read from the decorated repository
+	reads *pendingReads
+	// tx holds the keys written inside a transaction, it's nil outside of them
+	tx *txKeys
+}
+
+// txKeys are the keys to invalidate once a transaction ends
+type txKeys struct {
+	mutex sync.Mutex
+	keys  []string
+}
+
+// pendingReads are the keys being read from the decorated repository by Get and GetMany
+type pendingReads struct {
+	mutex sync.Mutex
+	keys  map[string]*pendingRead
+}
+
+// pendingRead tracks the reads of a key, invalidated tells if the key was
+// written since the oldest of them started
+type pendingRead struct {
+	mutex       sync.Mutex
+	readers     int
+	invalidated bool
+}
+
+// start registers a read of key from the decorated repository
+func (reads *pendingReads) start(key string) *pendingRead {
+	reads.mutex.Lock()
+	defer reads.mutex.Unlock()
+
+	read, exists := reads.keys[key]
+	if !exists {
+		read = &pendingRead{}
+		reads.keys[key] = read
+	}
+	read.readers++
+
+	return read
+}
+
+// finish ends a read of key calling fill, which can be nil, only if key
+// wasn't invalidated while it was read
+func (reads *pendingReads) finish(key string, read *pendingRead, fill func()) {
+	read.mutex.Lock()
+	if !read.invalidated && fill != nil {
+		fill()
+	}
+	read.mutex.Unlock()
+
+	reads.mutex.Lock()
+	defer reads.mutex.Unlock()
+
+	read.readers--
+	if read.readers == 0 {
+		delete(reads.keys, key)
+	}
+}
+
+// invalidate marks the reads of keys in progress, it waits for the reads
+// filling the cache so they can't store a value after the keys are removed
+func (reads *pendingReads) invalidate(keys ...string) {
+	reads.mutex.Lock()
+	pending := []*pendingRead{}
+	for _, key := range keys {
+		if read, exists := reads.keys[key]; exists {
+			pending = append(pending, read)
+		}
+	}
+	reads.mutex.Unlock()
+
+	for _, read := range pending {
+		read.mutex.Lock()
+		read.invalidated = true
+		read.mutex.Unlock()
+	}
+}
+
+// NewCachedRepo creates a CachedRepo decorating repository with an in-process LRU,
+// shared is optional and can be nil
+func NewCachedRepo(repository ports.Repository, config domain.CacheConfig, shared ports.Cache) *CachedRepo {
+	return &CachedRepo{
+		Repository: repository,
+		local:      NewLRU(config.MaxItems),
+		shared:     shared,
+		config:     config,
+		reads:      &pendingReads{keys: map[string]*pendingRead{}},
+	}
+}
+
+// Enabled tells if config enables the cache for any collection
+func Enabled(config domain.CacheConfig) bool {
+	if config.Default.Enabled {
+		return true
+	}
+
+	for _, settings := range config.Collections {
+		if settings.Enabled {
+			return true
+		}
+	}
+
+	return false
+}
+
+// settings returns the TTL of collection and if it's cached
+func (repo *CachedRepo) settings(collection string) (time.Duration, bool) {
+	settings, exists := repo.config.Collections[collection]
+	if !exists {
+		settings = repo.config.Default
+	}
+
+	ttl := settings.TTL
+	if ttl <= 0 {
+		ttl = repo.config.Default.TTL
+	}
+
+	if ttl <= 0 {
+		return defaultTTL, settings.Enabled
+	}
+
+	return ttl * time.Second, settings.Enabled
+}
+
+// cacheKey returns the key of the item with id
+func cacheKey(collection string, id string) string {
+	return collection + "/" + id
+}
+
+// Get stores into result the item with id, see CachedRepo for the lookup order
+func (repo *CachedRepo) Get(ctx context.Context, collection string, id string, result interface{}) error {
+	ttl, enabled := repo.settings(collection)
+	if !enabled || repo.tx != nil {
+		return repo.Repository.Get(ctx, collection, id, result)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	key := cacheKey(collection, id)
+	if value, found := repo.lookup(ctx, key, ttl); found {
+		if err := json.Unmarshal(value, result); err == nil {
+			return nil
+		}
+	}
+
+	read := repo.reads.start(key)
+	err := repo.Repository.Get(ctx, collection, id, result)
+	repo.reads.finish(key, read, func() {
+		if err != nil {
+			return
+		}
+
+		if value, err := json.Marshal(result); err == nil {
+			repo.set(ctx, key, value, ttl)
+		}
+	})
+
+	return err
+}
+
+// GetMany appends to results the items with any of the ids, only the ids missing
+// from the cache are read from the decorated repository
+func (repo *CachedRepo) GetMany(ctx context.Context, collection string, ids []string, results interface{}) error {
+	ttl, enabled := repo.settings(collection)
+	if !enabled || repo.tx != nil {
+		return repo.Repository.GetMany(ctx, collection, ids, results)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	resultsVal := reflect.ValueOf(results).Elem()
+	elementType := resultsVal.Type().Elem()
+	missing := []string{}
+	seen := map[string]bool{}
+	for _, id := range ids {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		if value, found := repo.lookup(ctx, cacheKey(collection, id), ttl); found {
+			element := reflect.New(elementType)
+			if err := json.Unmarshal(value, element.Interface()); err == nil {
+				resultsVal.Set(reflect.Append(resultsVal, element.Elem()))
+				continue
+			}
+		}
+
+		missing = append(missing, id)
+	}
+
+	if len(missing) == 0 {
+		return nil
+	}
+
+	reads := make([]*pendingRead, len(missing))
+	for index, id := range missing {
+		reads[index] = repo.reads.start(cacheKey(collection, id))
+	}
+
+	fetched := reflect.New(resultsVal.Type())
+	err := repo.Repository.GetMany(ctx, collection, missing, fetched.Interface())
+
+	items := fetched.Elem()
+	values := map[string][]byte{}
+	for index := 0; err == nil && index < items.Len(); index++ {
+		value, err := json.Marshal(items.Index(index).Interface())
+		if err != nil {
+			continue
+		}
+
+		var item struct {
+			Id string `json:"id"`
+		}
+		if err := json.Unmarshal(value, &item); err == nil && item.Id != "" {
+			values[item.Id] = value
+		}
+	}
+
+	for index, id := range missing {
+		key := cacheKey(collection, id)
+		value, exists := values[id]
+		repo.reads.finish(key, reads[index], func() {
+			if exists {
+				repo.set(ctx, key, value, ttl)
+			}
+		})
+	}
+
+	if err != nil {
+		return err
+	}
+
+	resultsVal.Set(reflect.AppendSlice(resultsVal, items))
+	return nil
+}
+
+// lookup returns the cached value of key, values found in the shared cache are
+// copied into the in-process cache. Cache errors are logged and treated as misses
+func (repo *CachedRepo) lookup(ctx context.Context, key string, ttl time.Duration) ([]byte, bool) {
+	value, found, err := repo.local.Get(ctx, key)
+	if err == nil && found {
+		return value, true
+	}
+
+	if repo.shared == nil {
+		return nil, false
+	}
+
+	value, found, err = repo.shared.Get(ctx, key)
+	if err != nil {
+		utils.Logger(ctx).Debug().Err(err).Msgf("%v - Shared cache get error", key)
+		return nil, false
+	}
+
+	if found {
+		repo.local.Set(ctx, key, value, ttl)
+	}
+
+	return value, found
+}
+
+// set stores value in every cache
+func (repo *CachedRepo) set(ctx context.Context, key string, value []byte, ttl time.Duration) {
+	repo.local.Set(ctx, key, value, ttl)
+
+	if repo.shared != nil {
+		if err := repo.shared.Set(ctx, key, value, ttl); err != nil {
+			utils.Logger(ctx).Debug().Err(err).Msgf("%v - Shared cache set error", key)
+		}
+	}
+}
+
+// invalidate removes the items with the ids from every cache, inside a transaction
+// they are removed once the transaction ends
+func (repo *CachedRepo) invalidate(ctx context.Context, collection string, ids ...string) {
+	if _, enabled := repo.settings(collection); !enabled {
+		return
+	}
+
+	keys := make([]string, len(ids))
+	for index, id := range ids {
+		keys[index] = cacheKey(collection, id)
+	}
+
+	if repo.tx != nil {
+		repo.tx.mutex.Lock()
+		repo.tx.keys = append(repo.tx.keys, keys...)
+		repo.tx.mutex.Unlock()
+		return
+	}
+
+	repo.delete(ctx, keys...)
+}
+
+// delete removes keys from every cache, the reads of keys in progress won't cache their values
+func (repo *CachedRepo) delete(ctx context.Context, keys ...string) {
+	if len(keys) == 0 {
+		return
+	}
+
+	repo.reads.invalidate(keys...)
+	repo.local.Delete(ctx, keys...)
+
+	if repo.shared != nil {
+		if err := repo.shared.Delete(ctx, keys...); err != nil {
+			utils.Logger(ctx).Debug().Err(err).Msgf("%v - Shared cache delete error", keys)
+		}
+	}
+}
+
+// Update saves the values of an existing item and removes it from the cache
+func (repo *CachedRepo) Update(ctx context.Context, collection string, id string, entity interface{}, omit ...string) error {
+	err := repo.Repository.Update(ctx, collection, id, entity, omit...)
+	repo.invalidate(ctx, collection, id)
+	return err
+}
+
+// Upsert updates or creates an item and removes it from the cache
+func (repo *CachedRepo) Upsert(ctx context.Context, collection string, filters []ports.Filter, entity interface{}, onInsertOnly ...string) (string, bool, error) {
+	id, created, err := repo.Repository.Upsert(ctx, collection, filters, entity, onInsertOnly...)
+	if id != "" {
+		repo.invalidate(ctx, collection, id)
+	}
+
+	return id, created, err
+}
+
+// Patch changes the fields in mask of an item and removes it from the cache
+func (repo *CachedRepo) Patch(ctx context.Context, collection string, id string, mask ports.FieldMask) error {
+	err := repo.Repository.Patch(ctx, collection, id, mask)
+	repo.invalidate(ctx, collection, id)
+	return err
+}
+
+// Delete removes an item from the decorated repository and the cache
+func (repo *CachedRepo) Delete(ctx context.Context, collection string, id string) error {
+	err := repo.Repository.Delete(ctx, collection, id)
+	repo.invalidate(ctx, collection, id)
+	return err
+}
+
+// PatchMany applies every patch and removes the patched items from the cache
+func (repo *CachedRepo) PatchMany(ctx context.Context, collection string, patches []ports.BulkPatch, stopOnError bool) ([]ports.BulkResult, error) {
+	results, err := repo.Repository.PatchMany(ctx, collection, patches, stopOnError)
+
+	ids := make([]string, len(patches))
+	for index, patch := range patches {
+		ids[index] = patch.Id
+	}
+	repo.invalidate(ctx, collection, ids...)
+
+	return results, err
+}
+
+// DeleteMany removes every item from the decorated repository and the cache
+func (repo *CachedRepo) DeleteMany(ctx context.Context, collection string, ids []string, stopOnError bool) ([]ports.BulkResult, error) {
+	results, err := repo.Repository.DeleteMany(ctx, collection, ids, stopOnError)
+	repo.invalidate(ctx, collection, ids...)
+	return results, err
+}
+
+// WithTransaction calls fn with a transaction of the decorated repository, the items
+// written by fn are removed from the cache once the transaction ends
+func (repo *CachedRepo) WithTransaction(ctx context.Context, fn func(tx ports.Repository) error) error {
+	keys := repo.tx
+	if keys == nil {
+		keys = &txKeys{}
+	}
+
+	err := repo.Repository.WithTransaction(ctx, func(tx ports.Repository) error {
+		return fn(&CachedRepo{
+			Repository: tx,
+			local:      repo.local,
+			shared:     repo.shared,
+			config:     repo.config,
+			reads:      repo.reads,
+			tx:         keys,
+		})
+	})
+
+	if repo.tx == nil {
+		repo.delete(ctx, keys.keys...)
+	}
+
+	return err
+}
@@ -0,0 +1,241 @@
+package cache
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sy-software/minerva-owl/internal/core/domain"
+	"github.com/sy-software/minerva-owl/internal/core/ports"
+	"github.com/sy-software/minerva-owl/mocks"
+)
+
+// countingRepo returns a repository counting the items read by Get and GetMany
+func countingRepo(reads *int) *mocks.MemRepo {
+	stored := &mocks.MemRepo{
+		Data: map[string][]map[string]interface{}{
+			"users": {
+				{"id": "1", "username": "CapAmerica"},
+				{"id": "2", "username": "IronMan"},
+				{"id": "3", "username": "Hulk"},
+			},
+			"teams": {
+				{"id": "1", "name": "Avengers"},
+			},
+		},
+	}
+
+	repo := &mocks.MemRepo{}
+	repo.GetInterceptor = func(ctx context.Context, collection string, id string, result interface{}) error {
+		*reads++
+		return stored.Get(ctx, collection, id, result)
+	}
+	repo.GetManyInterceptor = func(ctx context.Context, collection string, ids []string, results interface{}) error {
+		*reads += len(ids)
+		return stored.GetMany(ctx, collection, ids, results)
+	}
+	repo.PatchInterceptor = func(ctx context.Context, collection string, id string, mask ports.FieldMask) error {
+		return stored.Patch(ctx, collection, id, mask)
+	}
+	repo.DeleteInterceptor = func(ctx context.Context, collection string, id string) error {
+		return stored.Delete(ctx, collection, id)
+	}
+	repo.TransactionInterceptor = func(ctx context.Context, fn func(tx ports.Repository) error) error {
+		return fn(repo)
+	}
+
+	return repo
+}
+
+func TestCachedRepo(t *testing.T) {
+	ctx := context.Background()
+	config := domain.DefaultConfig().Cache
+	config.Collections = map[string]domain.CollectionCacheConfig{
+		"users": {Enabled: true},
+	}
+
+	t.Run("Items are read once until they are written", func(t *testing.T) {
+		reads := 0
+		repo := NewCachedRepo(countingRepo(&reads), config, nil)
+
+		for i := 0; i < 3; i++ {
+			got := domain.User{}
+			if err := repo.Get(ctx, "users", "1", &got); err != nil || got.Username != "CapAmerica" {
+				t.Errorf("Expected user: %q got: %+v with error: %v", "CapAmerica", got, err)
+			}
+		}
+
+		if reads != 1 {
+			t.Errorf("Expected 1 read got: %d", reads)
+		}
+
+		mask := ports.FieldMask{Set: map[string]interface{}{"username": "Cap"}}
+		if err := repo.Patch(ctx, "users", "1", mask); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		got := domain.User{}
+		if err := repo.Get(ctx, "users", "1", &got); err != nil || got.Username != "Cap" {
+			t.Errorf("Expected user: %q got: %+v with error: %v", "Cap", got, err)
+		}
+
+		if reads != 2 {
+			t.Errorf("Expected 2 reads got: %d", reads)
+		}
+	})
+
+	t.Run("Disabled collections are not cached", func(t *testing.T) {
+		reads := 0
+		repo := NewCachedRepo(countingRepo(&reads), config, nil)
+
+		repo.Get(ctx, "teams", "1", &domain.Team{})
+		repo.Get(ctx, "teams", "1", &domain.Team{})
+
+		if reads != 2 {
+			t.Errorf("Expected 2 reads got: %d", reads)
+		}
+	})
+
+	t.Run("GetMany only reads the missing ids", func(t *testing.T) {
+		reads := 0
+		repo := NewCachedRepo(countingRepo(&reads), config, nil)
+		repo.Get(ctx, "users", "1", &domain.User{})
+
+		got := []domain.User{}
+		if err := repo.GetMany(ctx, "users", []string{"1", "2", "missing", "2"}, &got); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		usernames := []string{}
+		for _, user := range got {
+			usernames = append(usernames, user.Username)
+		}
+		sort.Strings(usernames)
+
+		if !cmp.Equal([]string{"CapAmerica", "IronMan"}, usernames) {
+			t.Errorf("Expected users: [CapAmerica IronMan] got: %v", usernames)
+		}
+
+		// 1 for the Get and 2 for the ids missing from the cache
+		if reads != 3 {
+			t.Errorf("Expected 3 reads got: %d", reads)
+		}
+
+		repo.GetMany(ctx, "users", []string{"1", "2"}, &[]domain.User{})
+		if reads != 3 {
+			t.Errorf("Expected cached items to not be read got: %d reads", reads)
+		}
+	})
+
+	t.Run("Instances share the items through the shared cache", func(t *testing.T) {
+		reads := 0
+		backend := countingRepo(&reads)
+		shared := NewLRU(0)
+		first := NewCachedRepo(backend, config, shared)
+		second := NewCachedRepo(backend, config, shared)
+
+		first.Get(ctx, "users", "2", &domain.User{})
+		second.Get(ctx, "users", "2", &domain.User{})
+
+		if reads != 1 {
+			t.Errorf("Expected 1 read got: %d", reads)
+		}
+
+		if err := first.Delete(ctx, "users", "2"); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		if _, found, _ := shared.Get(ctx, cacheKey("users", "2")); found {
+			t.Errorf("Expected deleted item to be removed from the shared cache")
+		}
+	})
+
+	t.Run("Items written in a transaction are removed once it ends", func(t *testing.T) {
+		reads := 0
+		repo := NewCachedRepo(countingRepo(&reads), config, nil)
+		repo.Get(ctx, "users", "3", &domain.User{})
+
+		err := repo.WithTransaction(ctx, func(tx ports.Repository) error {
+			mask := ports.FieldMask{Set: map[string]interface{}{"username": "Banner"}}
+			if err := tx.Patch(ctx, "users", "3", mask); err != nil {
+				return err
+			}
+
+			if _, found, _ := repo.local.Get(ctx, cacheKey("users", "3")); !found {
+				t.Errorf("Expected item to be removed after the transaction")
+			}
+
+			return nil
+		})
+
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		got := domain.User{}
+		if err := repo.Get(ctx, "users", "3", &got); err != nil || got.Username != "Banner" {
+			t.Errorf("Expected user: %q got: %+v with error: %v", "Banner", got, err)
+		}
+	})
+
+	t.Run("Items written while they are read are not cached", func(t *testing.T) {
+		reads := 0
+		backend := countingRepo(&reads)
+		repo := NewCachedRepo(backend, config, nil)
+
+		get := backend.GetInterceptor
+		backend.GetInterceptor = func(ctx context.Context, collection string, id string, result interface{}) error {
+			err := get(ctx, collection, id, result)
+			// Simulate a concurrent write landing after the backend read
+			mask := ports.FieldMask{Set: map[string]interface{}{"username": "Tony"}}
+			repo.Patch(ctx, collection, id, mask)
+			return err
+		}
+
+		got := domain.User{}
+		if err := repo.Get(ctx, "users", "2", &got); err != nil || got.Username != "IronMan" {
+			t.Errorf("Expected user: %q got: %+v with error: %v", "IronMan", got, err)
+		}
+
+		if _, found, _ := repo.local.Get(ctx, cacheKey("users", "2")); found {
+			t.Errorf("Expected item written during the read to not be cached")
+		}
+
+		backend.GetInterceptor = get
+		got = domain.User{}
+		if err := repo.Get(ctx, "users", "2", &got); err != nil || got.Username != "Tony" {
+			t.Errorf("Expected user: %q got: %+v with error: %v", "Tony", got, err)
+		}
+
+		if _, found, _ := repo.local.Get(ctx, cacheKey("users", "2")); !found {
+			t.Errorf("Expected item to be cached once the writes end")
+		}
+	})
+
+	t.Run("GetMany doesn't cache items written while they are read", func(t *testing.T) {
+		reads := 0
+		backend := countingRepo(&reads)
+		repo := NewCachedRepo(backend, config, nil)
+
+		getMany := backend.GetManyInterceptor
+		backend.GetManyInterceptor = func(ctx context.Context, collection string, ids []string, results interface{}) error {
+			err := getMany(ctx, collection, ids, results)
+			repo.Delete(ctx, collection, "1")
+			return err
+		}
+
+		got := []domain.User{}
+		if err := repo.GetMany(ctx, "users", []string{"1", "2"}, &got); err != nil || len(got) != 2 {
+			t.Fatalf("Expected 2 users got: %+v with error: %v", got, err)
+		}
+
+		if _, found, _ := repo.local.Get(ctx, cacheKey("users", "1")); found {
+			t.Errorf("Expected deleted item to not be cached")
+		}
+
+		if _, found, _ := repo.local.Get(ctx, cacheKey("users", "2")); !found {
+			t.Errorf("Expected item not written to be cached")
+		}
+	})
+}
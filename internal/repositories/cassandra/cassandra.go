@@ -1,6 +1,8 @@
 package cassandra
 
 import (
+	"context"
+	"sort"
 	"sync"
 	"time"
 
@@ -10,59 +12,135 @@ import (
 	"github.com/sy-software/minerva-owl/internal/core/domain"
 )
 
+// DEFAULT_CONNECTION is the name of the connection returned by GetCassandra
+const DEFAULT_CONNECTION = "default"
+
 // Cassandra holds Cassandra DB related objects
 type Cassandra struct {
 	cluster *gocql.ClusterConfig
 	session *gocqlx.Session
 }
 
-var instance *Cassandra
-var once sync.Once
+// Connections keeps a set of named connections to Cassandra clusters
+type Connections struct {
+	instances map[string]*Cassandra
+	mutex     sync.Mutex
+}
+
+// NewConnections creates an empty instance of Connections
+func NewConnections() *Connections {
+	return &Connections{
+		instances: map[string]*Cassandra{},
+	}
+}
+
+var defaultConnections = NewConnections()
+
+// DefaultConnections returns the process wide connection manager
+func DefaultConnections() *Connections {
+	return defaultConnections
+}
 
-// GetCassandra Gets a singleton connection with Cassandra DB
+// GetCassandra Gets the default connection with Cassandra DB
 func GetCassandra(config domain.CDBConfig) (*Cassandra, error) {
-	var dbErr error
-	once.Do(func() {
-		log.Info().Msg("Initializing Cassandra DB connection")
-		cluster := gocql.NewCluster(config.Host)
-		cluster.Port = config.Port
-		// TODO: Set the right value for the server
-		cluster.Consistency = gocql.One
-		cluster.ProtoVersion = 4
-		cluster.ConnectTimeout = time.Second * config.ConnectTimeout
-		cluster.Timeout = time.Second * config.ConnectTimeout
-		cluster.NumConns = config.Connections
-
-		// TODO: Pass a logger with our standard format
-		// cluster.Logger = ....
-
-		// TODO: Add authentication
-		// cluster.Authenticator = gocql.PasswordAuthenticator{Username: "Username", Password: "Password"} //replace the username and password fields with their real settings.
-		session, err := gocqlx.WrapSession(cluster.CreateSession())
-
-		if err != nil {
-			dbErr = err
-			return
-		}
-		log.Info().Msg("Cassandra DB Session created")
-		log.Info().Msg("Creating minerva Keyspace")
-		// create keyspaces
-		err = session.ExecStmt("CREATE KEYSPACE IF NOT EXISTS minerva WITH replication = {'class':'SimpleStrategy', 'replication_factor' : 3};")
-		if err != nil {
-			log.Debug().Err(err).Msg("Error")
-			dbErr = err
-			return
-		}
-
-		log.Info().Msg("Keyspace created")
-
-		instance = &Cassandra{
-			cluster: cluster,
-			session: &session,
-		}
-	})
-
-	return instance, dbErr
+	return defaultConnections.Get(DEFAULT_CONNECTION, config)
+}
+
+// Get returns the connection registered with name, if it doesn't exist a new
+// connection is created using config
+func (conns *Connections) Get(name string, config domain.CDBConfig) (*Cassandra, error) {
+	conns.mutex.Lock()
+	defer conns.mutex.Unlock()
+
+	if instance, exists := conns.instances[name]; exists {
+		return instance, nil
+	}
+
+	instance, err := connect(name, config)
+	if err != nil {
+		return nil, err
+	}
+
+	conns.instances[name] = instance
+	return instance, nil
+}
+
+// Names returns the sorted names of all open connections
+func (conns *Connections) Names() []string {
+	conns.mutex.Lock()
+	defer conns.mutex.Unlock()
+
+	names := make([]string, 0, len(conns.instances))
+	for name := range conns.instances {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+// Close closes and forgets the connection registered with name
+func (conns *Connections) Close(name string) {
+	conns.mutex.Lock()
+	instance, exists := conns.instances[name]
+	delete(conns.instances, name)
+	conns.mutex.Unlock()
+
+	if exists {
+		instance.Close()
+	}
+}
+
+// CloseAll closes every open connection
+func (conns *Connections) CloseAll() {
+	for _, name := range conns.Names() {
+		conns.Close(name)
+	}
+}
+
+// connect creates a new session with a Cassandra cluster
+func connect(name string, config domain.CDBConfig) (*Cassandra, error) {
+	log.Info().Msgf("Initializing Cassandra DB connection: %s", name)
+	cluster := gocql.NewCluster(config.Host)
+	cluster.Port = config.Port
+	// TODO: Set the right value for the server
+	cluster.Consistency = gocql.One
+	cluster.ProtoVersion = 4
+	cluster.ConnectTimeout = time.Second * config.ConnectTimeout
+	cluster.Timeout = time.Second * config.Timeout
+	cluster.NumConns = config.Connections
+
+	// TODO: Pass a logger with our standard format
+	// cluster.Logger = ....
+
+	// TODO: Add authentication
+	// cluster.Authenticator = gocql.PasswordAuthenticator{Username: "Username", Password: "Password"} //replace the username and password fields with their real settings.
+	session, err := gocqlx.WrapSession(cluster.CreateSession())
+
+	if err != nil {
+		return nil, err
+	}
+	log.Info().Msg("Cassandra DB Session created")
+	log.Info().Msg("Creating minerva Keyspace")
+	// create keyspaces
+	err = session.ExecStmt("CREATE KEYSPACE IF NOT EXISTS minerva WITH replication = {'class':'SimpleStrategy', 'replication_factor' : 3};")
+	if err != nil {
+		log.Debug().Err(err).Msg("Error")
+		session.Close()
+		return nil, err
+	}
+
+	log.Info().Msg("Keyspace created")
+
+	return &Cassandra{
+		cluster: cluster,
+		session: &session,
+	}, nil
+}
+
+// Ping checks the cluster answers a query within the configured timeout or the deadline of ctx
+func (cassandra *Cassandra) Ping(ctx context.Context) error {
+	return cassandra.session.ContextQuery(ctx, "SELECT now() FROM system.local", nil).ExecRelease()
 }
 
 func (cassandra *Cassandra) Close() {
@@ -0,0 +1,56 @@
+package cassandra
+
+import (
+	"os"
+	"testing"
+
+	"github.com/sy-software/minerva-owl/internal/core/domain"
+	"github.com/sy-software/minerva-owl/internal/core/ports"
+	"github.com/sy-software/minerva-owl/internal/repositories/repotest"
+)
+
+// TestContract runs against the Cassandra server in OWL_TEST_CASSANDRA_HOST,
+// every run creates new tables which are not dropped
+func TestContract(t *testing.T) {
+	host := os.Getenv("OWL_TEST_CASSANDRA_HOST")
+	if host == "" {
+		t.Skip("Set OWL_TEST_CASSANDRA_HOST to run the repository contract tests against Cassandra")
+	}
+
+	config := domain.DefaultConfig()
+	config.CassandraDB.Host = host
+
+	connections := NewConnections()
+	defer connections.CloseAll()
+
+	db, err := connections.Get(DEFAULT_CONNECTION, config.CassandraDB)
+	if err != nil {
+		t.Fatalf("Can't connect to Cassandra: %v", err)
+	}
+
+	repotest.Run(t, repotest.Suite{
+		New: func(t *testing.T) ports.Repository {
+			repo, _ := NewCassandraRepo(db, &config)
+			return repo
+		},
+		UnsupportedOperators: []string{
+			ports.OP_NE,
+			ports.OP_NIN,
+			ports.OP_PREFIX,
+			ports.OP_CONTAINS,
+			ports.OP_EXISTS,
+			ports.OP_OR,
+			ports.OP_NOT,
+		},
+		// Rows are only sorted inside a partition and every item has its own
+		NoSort: true,
+		// The contract items store tags in a list and counters need counter tables
+		UnsupportedUpdates: []string{
+			ports.UPDATE_ADD_TO_SET,
+			ports.UPDATE_INC,
+		},
+		NoNestedFields: true,
+		// Items are looked up and then written, there are no multi partition transactions
+		NoAtomicUpsert: true,
+	})
+}
@@ -56,9 +56,10 @@ func (repo *OrgRepo) List(skip int, limit int) ([]domain.Organization, error) {
 	}
 
 	log.Debug().Msgf("Statement: %v Names: %v", stmt, names)
+	// Cassandra has no offset support so the skipped rows are read and discarded
 	q := repo.cassandra.session.
 		Query(stmt, names).BindMap(qb.M{
-		"limit": repo.config.Pagination.PageSize,
+		"limit": skip + limit,
 	})
 
 	log.Debug().Msgf("Query: %v", q)
@@ -69,7 +70,11 @@ func (repo *OrgRepo) List(skip int, limit int) ([]domain.Organization, error) {
 	}
 
 	log.Debug().Msgf("Quering values: %d", len(orgs))
-	return orgs, nil
+	if skip >= len(orgs) {
+		return []domain.Organization{}, nil
+	}
+
+	return orgs[skip:], nil
 }
 
 func (repo *OrgRepo) Get(id string) (domain.Organization, error) {
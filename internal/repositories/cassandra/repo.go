@@ -0,0 +1,1088 @@
+package cassandra
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"github.com/scylladb/go-reflectx"
+	"github.com/scylladb/gocqlx/v2/qb"
+	"github.com/scylladb/gocqlx/v2/table"
+	"github.com/sy-software/minerva-owl/internal/core/domain"
+	"github.com/sy-software/minerva-owl/internal/core/ports"
+	"github.com/sy-software/minerva-owl/internal/utils"
+)
+
+// keyspace where all minerva tables are created
+const keyspace = "minerva"
+
+// idColumn is the partition key used for every generic table
+const idColumn = "id"
+
+// columnRegex matches the field names which can be used as column names in the statements
+var columnRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// batchSize is the maximum number of inserts sent in a single logged batch,
+// large batches are rejected by the coordinator
+const batchSize = 50
+
+// mapper reads the column names from the bson tags used by our domain models
+var mapper = reflectx.NewMapperTagFunc("bson", strings.ToLower, columnName)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// CassandraRepo is an implementation of ports.Repository interface with Cassandra as datasource
+//
+// Tables are created on demand using the metadata of the struct passed to each operation,
+// every table uses the "id" column as partition key
+type CassandraRepo struct {
+	cassandra *Cassandra
+	// schema creates the tables and reads their metadata
+	schema schemaSession
+	config *domain.Config
+	tables map[string]*collectionTable
+	mutex  sync.Mutex
+	// batch collects the writes of the repository returned by WithTransaction
+	batch *gocql.Batch
+	// parent is the repository which started the transaction, it owns the table definitions
+	parent *CassandraRepo
+}
+
+// schemaSession is the part of the session used to manage the tables
+type schemaSession interface {
+	ExecStmt(stmt string) error
+	KeyspaceMetadata(keyspace string) (*gocql.KeyspaceMetadata, error)
+}
+
+// collectionTable holds the table definition for a collection and the column options
+type collectionTable struct {
+	*table.Table
+	// omitEmpty tells which columns are skipped on update when they hold a zero value
+	omitEmpty map[string]bool
+}
+
+// NewCassandraRepo creates an instance of CassandraRepo
+func NewCassandraRepo(cassandra *Cassandra, config *domain.Config) (*CassandraRepo, error) {
+	return &CassandraRepo{
+		cassandra: cassandra,
+		schema:    cassandra.session,
+		config:    config,
+		tables:    map[string]*collectionTable{},
+	}, nil
+}
+
+// getTable checks if we already know the table of a given collection, if no creates it
+// using the metadata of the entityType struct
+func (repo *CassandraRepo) getTable(collection string, entityType reflect.Type) (*collectionTable, error) {
+	if repo.parent != nil {
+		return repo.parent.getTable(collection, entityType)
+	}
+
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+
+	if value, exists := repo.tables[collection]; exists {
+		return value, nil
+	}
+
+	metadata, columnTypes, err := tableMetadata(collection, entityType)
+	if err != nil {
+		return nil, err
+	}
+
+	stmt := createTableStmt(metadata, columnTypes)
+	log.Debug().Msgf("%v - Creating table: %v", collection, stmt)
+	if err := repo.schema.ExecStmt(stmt); err != nil {
+		return nil, err
+	}
+
+	value := &collectionTable{
+		Table:     table.New(metadata),
+		omitEmpty: omitEmptyColumns(entityType),
+	}
+	repo.tables[collection] = value
+	return value, nil
+}
+
+// List stores into results a list of items from the given collection applying the filters
+// results must be a pointer to an Slice of an struct with bson tags for serialization
+//
+// Cassandra has no offset support so the skipped rows are read and discarded.
+// Sorting is not supported, see validateSort
+func (repo *CassandraRepo) List(ctx context.Context, collection string, results interface{}, skip int, limit int, sort []ports.Sort, filters ...ports.Filter) error {
+	resultsVal := reflect.ValueOf(results).Elem()
+	elementType := resultsVal.Type().Elem()
+
+	colTable, err := repo.getTable(collection, elementType)
+	if err != nil {
+		utils.Logger(ctx).Debug().Err(err).Msgf("%v - List error", collection)
+		return err
+	}
+
+	if err := validateSort(sort); err != nil {
+		utils.Logger(ctx).Debug().Err(err).Msgf("%v - List error", collection)
+		return err
+	}
+
+	where, values, err := formatFilters(filters)
+	if err != nil {
+		utils.Logger(ctx).Debug().Err(err).Msgf("%v - List error", collection)
+		return err
+	}
+
+	builder := qb.Select(colTable.Name()).
+		Columns(colTable.Metadata().Columns...).
+		Where(where...).
+		Limit(uint(skip + limit))
+
+	if len(where) > 0 {
+		builder.AllowFiltering()
+	}
+
+	stmt, names := builder.ToCql()
+	utils.Logger(ctx).Debug().Msgf("%v - Listing elements: %v", collection, stmt)
+
+	page := reflect.New(resultsVal.Type())
+	q := repo.cassandra.session.Query(stmt, names).WithContext(ctx).BindMap(values)
+	q.Mapper = mapper
+	if err := q.SelectRelease(page.Interface()); err != nil {
+		utils.Logger(ctx).Debug().Err(err).Msgf("%v - List error", collection)
+		return err
+	}
+
+	rows := page.Elem()
+	if skip >= rows.Len() {
+		return nil
+	}
+
+	resultsVal.Set(reflect.AppendSlice(resultsVal, rows.Slice(skip, rows.Len())))
+	return nil
+}
+
+// ListPage stores into results up to limit items from the given collection after the cursor.
+// results must be a pointer to an Slice of an struct with bson tags for serialization
+//
+// Rows are returned in token order, the end cursor holds the Cassandra paging state
+// while the cursor of each item resumes after the token of its id. Cassandra can
+// return a paging state for an empty next page, so HasNextPage may be true on the last page
+func (repo *CassandraRepo) ListPage(ctx context.Context, collection string, results interface{}, after string, limit int, filters ...ports.Filter) (ports.PageInfo, error) {
+	pageInfo := ports.PageInfo{
+		Cursors:         []string{},
+		HasPreviousPage: after != "",
+	}
+
+	resultsVal := reflect.ValueOf(results).Elem()
+	elementType := resultsVal.Type().Elem()
+
+	colTable, err := repo.getTable(collection, elementType)
+	if err != nil {
+		utils.Logger(ctx).Debug().Err(err).Msgf("%v - List page error", collection)
+		return pageInfo, err
+	}
+
+	where, values, err := formatFilters(filters)
+	if err != nil {
+		utils.Logger(ctx).Debug().Err(err).Msgf("%v - List page error", collection)
+		return pageInfo, err
+	}
+
+	pageState, afterId, err := decodeCursor(after)
+	if err != nil {
+		return pageInfo, err
+	}
+
+	if afterId != "" {
+		where = append(where, qb.Token(idColumn).GtNamed("after_id"))
+		values["after_id"] = afterId
+	}
+
+	builder := qb.Select(colTable.Name()).
+		Columns(colTable.Metadata().Columns...).
+		Where(where...)
+
+	if len(filters) > 0 {
+		builder.AllowFiltering()
+	}
+
+	stmt, names := builder.ToCql()
+	utils.Logger(ctx).Debug().Msgf("%v - Listing page: %v", collection, stmt)
+
+	q := repo.cassandra.session.Query(stmt, names).WithContext(ctx).BindMap(values)
+	q.Mapper = mapper
+	defer q.Release()
+
+	// Setting the page state disables the automatic paging so only one page is read
+	iter := q.PageSize(limit).PageState(pageState).Iter()
+	for {
+		element := reflect.New(elementType)
+		if !iter.StructScan(element.Interface()) {
+			break
+		}
+
+		resultsVal.Set(reflect.Append(resultsVal, element.Elem()))
+		id := toColumnMap(element.Elem())[idColumn]
+		pageInfo.Cursors = append(pageInfo.Cursors, encodeKeyCursor(fmt.Sprintf("%v", id)))
+	}
+
+	nextState := iter.PageState()
+	if err := iter.Close(); err != nil {
+		utils.Logger(ctx).Debug().Err(err).Msgf("%v - List page error", collection)
+		return pageInfo, err
+	}
+
+	if len(pageInfo.Cursors) > 0 {
+		pageInfo.EndCursor = pageInfo.Cursors[len(pageInfo.Cursors)-1]
+	}
+
+	if len(nextState) > 0 {
+		pageInfo.HasNextPage = true
+		pageInfo.EndCursor = encodePageStateCursor(nextState)
+	}
+
+	return pageInfo, nil
+}
+
+// Count returns how many items from collection match the filters
+//
+// Cassandra has to scan the whole table to count the rows, avoid it on big tables
+func (repo *CassandraRepo) Count(ctx context.Context, collection string, filters ...ports.Filter) (int, error) {
+	where, values, err := formatFilters(filters)
+	if err != nil {
+		utils.Logger(ctx).Debug().Err(err).Msgf("%v - Count error", collection)
+		return 0, err
+	}
+
+	builder := qb.Select(repo.tableName(collection)).CountAll().Where(where...)
+	if len(where) > 0 {
+		builder.AllowFiltering()
+	}
+
+	var count int64
+	err = repo.cassandra.session.Query(builder.ToCql()).WithContext(ctx).BindMap(values).GetRelease(&count)
+	if err != nil {
+		utils.Logger(ctx).Debug().Err(err).Msgf("%v - Count error", collection)
+	}
+
+	return int(count), err
+}
+
+// Get stores into result an item from collection with id equals to id
+// result must be a pointer to an instance of a struct with bson tags for serialization
+func (repo *CassandraRepo) Get(ctx context.Context, collection string, id string, result interface{}) error {
+	utils.Logger(ctx).Debug().Msgf("%v - Finding element with id: %q", collection, id)
+	colTable, err := repo.getTable(collection, reflect.TypeOf(result).Elem())
+	if err != nil {
+		utils.Logger(ctx).Debug().Err(err).Msgf("%v - Get error", collection)
+		return err
+	}
+
+	q := repo.cassandra.session.Query(colTable.Get(colTable.Metadata().Columns...)).WithContext(ctx).BindMap(qb.M{
+		idColumn: id,
+	})
+	q.Mapper = mapper
+
+	err = q.GetRelease(result)
+	if err == gocql.ErrNotFound {
+		return ports.ErrItemNotFound{
+			Id:    &id,
+			Model: collection,
+		}
+	}
+
+	if err != nil {
+		utils.Logger(ctx).Debug().Err(err).Msgf("%v - Get error", collection)
+	}
+
+	return err
+}
+
+// GetMany appends to results the items from collection with any of the ids
+// results must be a pointer to an Slice of an struct with bson tags for serialization
+func (repo *CassandraRepo) GetMany(ctx context.Context, collection string, ids []string, results interface{}) error {
+	if len(ids) == 0 {
+		return ctx.Err()
+	}
+
+	return repo.List(ctx, collection, results, 0, len(ids), nil, ports.InIds(ids...))
+}
+
+// GetOne stores into result an item from collection matching the filters
+// result must be a pointer to an instance of a struct with bson tags for serialization
+func (repo *CassandraRepo) GetOne(ctx context.Context, collection string, result interface{}, filters ...ports.Filter) error {
+	utils.Logger(ctx).Debug().Msgf("%v - Finding element with filters: %+v", collection, filters)
+	colTable, err := repo.getTable(collection, reflect.TypeOf(result).Elem())
+	if err != nil {
+		utils.Logger(ctx).Debug().Err(err).Msgf("%v - Get error", collection)
+		return err
+	}
+
+	where, values, err := formatFilters(filters)
+	if err != nil {
+		utils.Logger(ctx).Debug().Err(err).Msgf("%v - Get error", collection)
+		return err
+	}
+
+	builder := qb.Select(colTable.Name()).
+		Columns(colTable.Metadata().Columns...).
+		Where(where...).
+		Limit(1)
+
+	if len(where) > 0 {
+		builder.AllowFiltering()
+	}
+
+	q := repo.cassandra.session.Query(builder.ToCql()).WithContext(ctx).BindMap(values)
+	q.Mapper = mapper
+
+	err = q.GetRelease(result)
+	if err == gocql.ErrNotFound {
+		return ports.ErrItemNotFound{
+			Model: collection,
+		}
+	}
+
+	if err != nil {
+		utils.Logger(ctx).Debug().Err(err).Msgf("%v - Get error", collection)
+	}
+
+	return err
+}
+
+// Create saves the serialized version of entity into the collection
+// entity must be an instance of a struct with bson tags for serialization
+//
+// If the entity has no id a new V4 UUID is assigned
+func (repo *CassandraRepo) Create(ctx context.Context, collection string, entity interface{}) (string, error) {
+	utils.Logger(ctx).Debug().Msgf("%v - Saving: %v", collection, entity)
+	entityVal := reflect.Indirect(reflect.ValueOf(entity))
+	colTable, err := repo.getTable(collection, entityVal.Type())
+	if err != nil {
+		return "", err
+	}
+
+	values := toColumnMap(entityVal)
+	id, _ := values[idColumn].(string)
+	if len(id) == 0 {
+		id = uuid.New().String()
+		values[idColumn] = id
+	}
+
+	stmt, names := colTable.Insert()
+	applied, err := repo.writeNew(ctx, collection, id, stmt, namedArgs(names, values)...)
+	if err != nil {
+		utils.Logger(ctx).Debug().Err(err).Msgf("%v - Create error", collection)
+		return "", err
+	}
+
+	if !applied {
+		return "", ports.ErrDuplicatedId{Id: id, Model: collection}
+	}
+
+	return id, nil
+}
+
+// Update saves the values of entity to the item with id from the collection
+// entity must be an instance of a struct with bson tags for serialization
+//
+// Just like with MongoDB, fields tagged with omitempty are not saved when they have
+// a zero value. If you whish to omit some fields from entity from saving you can pass
+// the field names into the final omit parameter
+func (repo *CassandraRepo) Update(ctx context.Context, collection string, id string, entity interface{}, omit ...string) error {
+	utils.Logger(ctx).Debug().Msgf("%v - Saving: %v", collection, entity)
+	entityVal := reflect.Indirect(reflect.ValueOf(entity))
+	colTable, err := repo.getTable(collection, entityVal.Type())
+	if err != nil {
+		return err
+	}
+
+	values := toColumnMap(entityVal)
+	omitMap := map[string]bool{
+		idColumn: true,
+	}
+	for _, v := range omit {
+		omitMap[columnName(v)] = true
+	}
+
+	columns := []string{}
+	for _, column := range colTable.Metadata().Columns {
+		if omitMap[column] {
+			continue
+		}
+
+		if colTable.omitEmpty[column] && reflect.ValueOf(values[column]).IsZero() {
+			continue
+		}
+
+		columns = append(columns, column)
+	}
+
+	if len(columns) == 0 {
+		return nil
+	}
+
+	values[idColumn] = id
+	stmt, names := qb.Update(colTable.Name()).
+		Set(columns...).
+		Where(qb.Eq(idColumn)).
+		ToCql()
+
+	applied, err := repo.writeExisting(ctx, collection, id, stmt, namedArgs(names, values)...)
+	if err != nil {
+		return err
+	}
+
+	if !applied {
+		return ports.ErrItemNotFound{
+			Id:    &id,
+			Model: collection,
+		}
+	}
+
+	return nil
+}
+
+// Upsert saves the values of entity to the first item from collection matching the filters,
+// except the fields in onInsertOnly, or creates entity if none matches
+//
+// Cassandra has no transactions across partitions, the item is looked up and then updated
+// or created so two concurrent calls with the same filters can both create an item
+func (repo *CassandraRepo) Upsert(ctx context.Context, collection string, filters []ports.Filter, entity interface{}, onInsertOnly ...string) (string, bool, error) {
+	entityType := reflect.Indirect(reflect.ValueOf(entity)).Type()
+	current := reflect.New(entityType)
+	err := repo.GetOne(ctx, collection, current.Interface(), filters...)
+	if _, ok := err.(ports.ErrItemNotFound); ok {
+		id, err := repo.Create(ctx, collection, entity)
+		if err != nil {
+			utils.Logger(ctx).Debug().Err(err).Msgf("%v - Upsert error", collection)
+			return "", false, err
+		}
+
+		return id, true, nil
+	}
+
+	if err != nil {
+		utils.Logger(ctx).Debug().Err(err).Msgf("%v - Upsert error", collection)
+		return "", false, err
+	}
+
+	id, _ := toColumnMap(current.Elem())[idColumn].(string)
+	if err := repo.Update(ctx, collection, id, entity, onInsertOnly...); err != nil {
+		utils.Logger(ctx).Debug().Err(err).Msgf("%v - Upsert error", collection)
+		return "", false, err
+	}
+
+	return id, false, nil
+}
+
+// Patch changes the fields in mask of the item with id from the collection in a single statement
+//
+// Cassandra only supports the operators of its collection types: unset fields are stored
+// as null, push can only append or prepend (position 0) values and add-to-set requires a
+// set column (see the cql:"set" tag). Nested fields and counters are not supported
+func (repo *CassandraRepo) Patch(ctx context.Context, collection string, id string, mask ports.FieldMask) error {
+	utils.Logger(ctx).Debug().Msgf("%v - Patching %q: %+v", collection, id, mask)
+	if err := mask.Validate(); err != nil {
+		utils.Logger(ctx).Debug().Err(err).Msgf("%v - Patch error", collection)
+		return err
+	}
+
+	if mask.IsEmpty() {
+		return nil
+	}
+
+	assignments, args, err := repo.formatFieldMask(collection, mask)
+	if err != nil {
+		utils.Logger(ctx).Debug().Err(err).Msgf("%v - Patch error", collection)
+		return err
+	}
+
+	stmt := fmt.Sprintf(
+		"UPDATE %s SET %s WHERE %s = ?",
+		repo.tableName(collection),
+		strings.Join(assignments, ", "),
+		idColumn,
+	)
+
+	applied, err := repo.writeExisting(ctx, collection, id, stmt, append(args, id)...)
+	if err != nil {
+		utils.Logger(ctx).Debug().Err(err).Msgf("%v - Patch error", collection)
+		return err
+	}
+
+	if !applied {
+		return ports.ErrItemNotFound{
+			Id:    &id,
+			Model: collection,
+		}
+	}
+
+	return nil
+}
+
+// formatFieldMask converts a field mask into the assignments of a CQL update and their arguments
+func (repo *CassandraRepo) formatFieldMask(collection string, mask ports.FieldMask) ([]string, []interface{}, error) {
+	assignments := []string{}
+	args := []interface{}{}
+	operators := mask.Operators()
+	for _, field := range mask.Fields() {
+		if strings.Contains(field, ".") {
+			return nil, nil, ports.ErrInvalidFieldMask{Field: field, Reason: "nested fields are not supported by Cassandra"}
+		}
+
+		if !columnRegex.MatchString(field) {
+			return nil, nil, ports.ErrInvalidFieldMask{Field: field, Reason: "invalid field name"}
+		}
+
+		column := columnName(field)
+		switch operators[field] {
+		case ports.UPDATE_SET:
+			assignments = append(assignments, column+" = ?")
+			args = append(args, mask.Set[field])
+		case ports.UPDATE_UNSET:
+			assignments = append(assignments, column+" = null")
+		case ports.UPDATE_PULL:
+			assignments = append(assignments, column+" = "+column+" - ?")
+			args = append(args, mask.Pull[field])
+		case ports.UPDATE_PUSH:
+			push := mask.Push[field]
+			switch {
+			case push.Position == nil:
+				assignments = append(assignments, column+" = "+column+" + ?")
+			case *push.Position == 0:
+				assignments = append(assignments, column+" = ? + "+column)
+			default:
+				return nil, nil, ports.ErrInvalidFieldMask{Field: field, Reason: "Cassandra can only push at the start or the end of a list"}
+			}
+			args = append(args, push.Values)
+		case ports.UPDATE_ADD_TO_SET:
+			if repo.columnType(collection, column) != gocql.TypeSet {
+				return nil, nil, ports.ErrInvalidFieldMask{Field: field, Reason: "Cassandra requires a set column to add to set"}
+			}
+			assignments = append(assignments, column+" = "+column+" + ?")
+			args = append(args, mask.AddToSet[field])
+		case ports.UPDATE_INC:
+			return nil, nil, ports.ErrInvalidFieldMask{Field: field, Reason: "counters are not supported by Cassandra"}
+		}
+	}
+
+	return assignments, args, nil
+}
+
+// columnType reads the type of a column from the keyspace metadata, gocql.TypeCustom is
+// returned for unknown columns
+func (repo *CassandraRepo) columnType(collection string, column string) gocql.Type {
+	metadata, err := repo.schema.KeyspaceMetadata(keyspace)
+	if err != nil {
+		log.Debug().Err(err).Msgf("%v - Can't read table metadata", collection)
+		return gocql.TypeCustom
+	}
+
+	tableMetadata, exists := metadata.Tables[collection]
+	if !exists {
+		return gocql.TypeCustom
+	}
+
+	columnMetadata, exists := tableMetadata.Columns[column]
+	if !exists || columnMetadata.Type == nil {
+		return gocql.TypeCustom
+	}
+
+	return columnMetadata.Type.Type()
+}
+
+// Delete removes item with id from collection
+func (repo *CassandraRepo) Delete(ctx context.Context, collection string, id string) error {
+	utils.Logger(ctx).Debug().Msgf("%v - Deleting by id: %q", collection, id)
+	stmt, _ := qb.Delete(repo.tableName(collection)).Where(qb.Eq(idColumn)).ToCql()
+	applied, err := repo.writeExisting(ctx, collection, id, stmt, id)
+
+	if err != nil {
+		utils.Logger(ctx).Debug().Err(err).Msgf("%v - Delete error", collection)
+		return err
+	}
+
+	if !applied {
+		return ports.ErrItemNotFound{
+			Id:    &id,
+			Model: collection,
+		}
+	}
+
+	return nil
+}
+
+// CreateMany saves every entity into the collection, like Create, with logged batches of up to
+// batchSize inserts. A batch is applied completely or not at all, so every entity of a failed
+// batch is reported with the same error. Inside WithTransaction the inserts join the transaction batch
+func (repo *CassandraRepo) CreateMany(ctx context.Context, collection string, entities []interface{}, stopOnError bool) ([]ports.BulkResult, error) {
+	if repo.batch != nil {
+		return ports.RunBulk(len(entities), stopOnError, func(index int) (string, error) {
+			return repo.Create(ctx, collection, entities[index])
+		}), nil
+	}
+
+	results := make([]ports.BulkResult, len(entities))
+	batch := repo.cassandra.session.NewBatch(gocql.LoggedBatch)
+	pending := []int{}
+
+	// flush executes the pending inserts and tells if no more entities must be saved
+	flush := func() bool {
+		if len(pending) == 0 {
+			return false
+		}
+
+		err := repo.cassandra.session.ExecuteBatch(batch.WithContext(ctx))
+		if err != nil {
+			utils.Logger(ctx).Debug().Err(err).Msgf("%v - Create error", collection)
+			for _, index := range pending {
+				results[index] = ports.BulkResult{Err: err}
+			}
+
+			if stopOnError {
+				ports.AbortBulk(results[pending[len(pending)-1]+1:])
+			}
+		}
+
+		batch = repo.cassandra.session.NewBatch(gocql.LoggedBatch)
+		pending = []int{}
+		return err != nil && stopOnError
+	}
+
+	for index, entity := range entities {
+		entityVal := reflect.Indirect(reflect.ValueOf(entity))
+		colTable, err := repo.getTable(collection, entityVal.Type())
+		if err != nil {
+			results[index].Err = err
+			if stopOnError {
+				ports.AbortBulk(results[index+1:])
+				flush()
+				return results, nil
+			}
+
+			continue
+		}
+
+		values := toColumnMap(entityVal)
+		id, _ := values[idColumn].(string)
+		if len(id) == 0 {
+			id = uuid.New().String()
+			values[idColumn] = id
+		} else if exists, err := repo.exists(ctx, collection, id); err != nil || exists {
+			// Inserts overwrite existing items, so only the given ids are looked up
+			if err == nil {
+				err = ports.ErrDuplicatedId{Id: id, Model: collection}
+			}
+
+			results[index].Err = err
+			if stopOnError {
+				ports.AbortBulk(results[index+1:])
+				flush()
+				return results, nil
+			}
+
+			continue
+		}
+
+		stmt, names := colTable.Insert()
+		batch.Query(stmt, namedArgs(names, values)...)
+		results[index].Id = id
+		pending = append(pending, index)
+
+		if len(pending) == batchSize && flush() {
+			return results, nil
+		}
+	}
+
+	flush()
+	return results, nil
+}
+
+// PatchMany applies every patch to the collection in order, each one like Patch.
+// Conditional updates can't be batched across partitions so each one is a single statement
+func (repo *CassandraRepo) PatchMany(ctx context.Context, collection string, patches []ports.BulkPatch, stopOnError bool) ([]ports.BulkResult, error) {
+	return ports.RunBulk(len(patches), stopOnError, func(index int) (string, error) {
+		return patches[index].Id, repo.Patch(ctx, collection, patches[index].Id, patches[index].Mask)
+	}), nil
+}
+
+// DeleteMany removes every item with the ids from the collection in order, each one like Delete.
+// Conditional deletes can't be batched across partitions so each one is a single statement
+func (repo *CassandraRepo) DeleteMany(ctx context.Context, collection string, ids []string, stopOnError bool) ([]ports.BulkResult, error) {
+	return ports.RunBulk(len(ids), stopOnError, func(index int) (string, error) {
+		return ids[index], repo.Delete(ctx, collection, ids[index])
+	}), nil
+}
+
+// writeExisting executes stmt only if the item with id exists and tells if it was applied.
+//
+// Conditional statements can't be batched across partitions, inside WithTransaction the item
+// is looked up first and stmt is added to the transaction batch without the condition
+func (repo *CassandraRepo) writeExisting(ctx context.Context, collection string, id string, stmt string, args ...interface{}) (bool, error) {
+	if repo.batch == nil {
+		return repo.cassandra.session.Query(stmt+" IF EXISTS", nil).WithContext(ctx).Bind(args...).ExecCASRelease()
+	}
+
+	exists, err := repo.exists(ctx, collection, id)
+	if err != nil || !exists {
+		return false, err
+	}
+
+	repo.batch.Query(stmt, args...)
+	return true, nil
+}
+
+// writeNew executes the insert stmt only if no item with id exists and tells if it was applied.
+//
+// Conditional statements can't be batched across partitions, inside WithTransaction the item
+// is looked up first and stmt is added to the transaction batch without the condition
+func (repo *CassandraRepo) writeNew(ctx context.Context, collection string, id string, stmt string, args ...interface{}) (bool, error) {
+	if repo.batch == nil {
+		query := repo.cassandra.session.Query(stmt+" IF NOT EXISTS", nil).WithContext(ctx).Bind(args...)
+		defer query.Release()
+
+		// A rejected insert returns the existing item, which is discarded
+		return query.MapScanCAS(map[string]interface{}{})
+	}
+
+	exists, err := repo.exists(ctx, collection, id)
+	if err != nil || exists {
+		return false, err
+	}
+
+	repo.batch.Query(stmt, args...)
+	return true, nil
+}
+
+// exists tells if the item with id is stored in collection
+func (repo *CassandraRepo) exists(ctx context.Context, collection string, id string) (bool, error) {
+	var found string
+	lookup := fmt.Sprintf("SELECT %s FROM %s WHERE %s = ?", idColumn, repo.tableName(collection), idColumn)
+	err := repo.cassandra.session.Query(lookup, nil).WithContext(ctx).Bind(id).GetRelease(&found)
+	if err == gocql.ErrNotFound {
+		return false, nil
+	}
+
+	return err == nil, err
+}
+
+// WithTransaction collects every write of fn into a single logged batch executed when fn returns nil,
+// the batch is discarded when fn returns an error. Nested calls join the same batch
+//
+// Cassandra has no multi-partition transactions, a logged batch guarantees all its writes are
+// eventually applied but has these limits:
+//   - Reads inside fn don't see the writes of the transaction, they are only sent on commit
+//   - Updates, patches and deletes check the item exists when they are called instead of on
+//     commit, so a concurrent delete is not detected
+//   - Writes are not isolated, other clients can read a partially applied batch
+//   - Batches larger than the batch_size_fail_threshold_in_kb of the cluster are rejected
+func (repo *CassandraRepo) WithTransaction(ctx context.Context, fn func(tx ports.Repository) error) error {
+	if repo.batch != nil {
+		return fn(repo)
+	}
+
+	tx := &CassandraRepo{
+		cassandra: repo.cassandra,
+		schema:    repo.schema,
+		config:    repo.config,
+		batch:     repo.cassandra.session.NewBatch(gocql.LoggedBatch),
+		parent:    repo,
+	}
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	if tx.batch.Size() == 0 {
+		return nil
+	}
+
+	err := repo.cassandra.session.ExecuteBatch(tx.batch.WithContext(ctx))
+	if err != nil {
+		utils.Logger(ctx).Debug().Err(err).Msg("Transaction error")
+	}
+
+	return err
+}
+
+// namedArgs returns the values of names in the same order, to bind them as positional arguments
+func namedArgs(names []string, values map[string]interface{}) []interface{} {
+	args := make([]interface{}, len(names))
+	for position, name := range names {
+		args[position] = values[name]
+	}
+
+	return args
+}
+
+// tableName returns the full name of the table storing a collection
+func (repo *CassandraRepo) tableName(collection string) string {
+	return keyspace + "." + collection
+}
+
+// columnName converts a bson field name into a CQL column name.
+//
+// Unquoted CQL identifiers are case insensitive and can't start with "_"
+// so names are lower cased and "_id" is mapped to "id"
+func columnName(field string) string {
+	if field == "_id" || strings.HasPrefix(field, "_id,") {
+		field = idColumn + strings.TrimPrefix(field, "_id")
+	}
+
+	return strings.ToLower(field)
+}
+
+// tableMetadata maps the top level fields of entityType into a table definition
+// and returns the CQL type of each column
+func tableMetadata(collection string, entityType reflect.Type) (table.Metadata, map[string]string, error) {
+	entityType = reflectx.Deref(entityType)
+	if entityType.Kind() != reflect.Struct {
+		return table.Metadata{}, nil, fmt.Errorf("can't map %v into a table, a struct is required", entityType)
+	}
+
+	columns := []string{}
+	columnTypes := map[string]string{}
+	for _, field := range mapper.TypeMap(entityType).Index {
+		// Only top level fields are mapped, nested structs are not supported yet
+		if len(field.Index) != 1 || field.Name == "-" {
+			continue
+		}
+
+		cqlType, err := cqlTypeOf(field.Field.Type)
+		if err != nil {
+			return table.Metadata{}, nil, fmt.Errorf("can't map field %q: %w", field.Field.Name, err)
+		}
+
+		// Slices are stored as lists unless they are tagged with cql:"set"
+		if field.Field.Tag.Get("cql") == "set" && strings.HasPrefix(cqlType, "list<") {
+			cqlType = "set<" + strings.TrimPrefix(cqlType, "list<")
+		}
+
+		columns = append(columns, field.Name)
+		columnTypes[field.Name] = cqlType
+	}
+
+	if _, ok := columnTypes[idColumn]; !ok {
+		return table.Metadata{}, nil, errors.New("entities require an \"_id\" field to be stored in Cassandra")
+	}
+
+	return table.Metadata{
+		Name:    keyspace + "." + collection,
+		Columns: columns,
+		PartKey: []string{idColumn},
+	}, columnTypes, nil
+}
+
+// createTableStmt builds the CQL statement that creates the table described by metadata
+func createTableStmt(metadata table.Metadata, columnTypes map[string]string) string {
+	definitions := make([]string, len(metadata.Columns))
+	for index, column := range metadata.Columns {
+		definitions[index] = column + " " + columnTypes[column]
+	}
+
+	return fmt.Sprintf(
+		"CREATE TABLE IF NOT EXISTS %s (%s, PRIMARY KEY (%s));",
+		metadata.Name,
+		strings.Join(definitions, ", "),
+		strings.Join(metadata.PartKey, ", "),
+	)
+}
+
+// cqlTypeOf returns the CQL type used to store a go type
+func cqlTypeOf(t reflect.Type) (string, error) {
+	if t == timeType {
+		return "timestamp", nil
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return "text", nil
+	case reflect.Bool:
+		return "boolean", nil
+	case reflect.Int8, reflect.Int16, reflect.Int32:
+		return "int", nil
+	case reflect.Int, reflect.Int64:
+		return "bigint", nil
+	case reflect.Float32:
+		return "float", nil
+	case reflect.Float64:
+		return "double", nil
+	case reflect.Ptr:
+		return cqlTypeOf(t.Elem())
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return "blob", nil
+		}
+
+		elemType, err := cqlTypeOf(t.Elem())
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("list<%s>", elemType), nil
+	case reflect.Map:
+		keyType, err := cqlTypeOf(t.Key())
+		if err != nil {
+			return "", err
+		}
+
+		elemType, err := cqlTypeOf(t.Elem())
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("map<%s, %s>", keyType, elemType), nil
+	}
+
+	return "", fmt.Errorf("unsupported type: %v", t)
+}
+
+// omitEmptyColumns returns which columns of entityType are tagged with omitempty
+func omitEmptyColumns(entityType reflect.Type) map[string]bool {
+	result := map[string]bool{}
+	for _, field := range mapper.TypeMap(reflectx.Deref(entityType)).Index {
+		if _, ok := field.Options["omitempty"]; ok {
+			result[field.Name] = true
+		}
+	}
+
+	return result
+}
+
+// toColumnMap converts the top level fields of an struct value into a map of column values
+func toColumnMap(entityVal reflect.Value) map[string]interface{} {
+	values := map[string]interface{}{}
+	for _, field := range mapper.TypeMap(entityVal.Type()).Index {
+		if len(field.Index) != 1 || field.Name == "-" {
+			continue
+		}
+
+		values[field.Name] = entityVal.Field(field.Index[0]).Interface()
+	}
+
+	return values
+}
+
+// formatFilters takes a generic list of filters and converts them into CQL conditions
+//
+// CQL only supports the eq, in, gt, gte, lt and lte operators combined with and
+func formatFilters(filters []ports.Filter) ([]qb.Cmp, qb.M, error) {
+	where := []qb.Cmp{}
+	values := qb.M{}
+
+	for _, filter := range filters {
+		if err := filter.Validate(); err != nil {
+			return where, values, err
+		}
+
+		if err := formatFilter(filter, &where, values); err != nil {
+			return where, values, err
+		}
+	}
+
+	return where, values, nil
+}
+
+// formatFilter appends the condition of a single filter to where and its value to values
+func formatFilter(filter ports.Filter, where *[]qb.Cmp, values qb.M) error {
+	if filter.Op() == ports.OP_AND {
+		for _, nested := range filter.Filters {
+			if err := formatFilter(nested, where, values); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	if !columnRegex.MatchString(filter.Name) {
+		return ports.ErrInvalidFilter{
+			Filter: filter,
+			Reason: "invalid field name",
+		}
+	}
+
+	column := columnName(filter.Name)
+	name := fmt.Sprintf("filter_%d", len(values))
+
+	switch filter.Op() {
+	case ports.OP_EQ:
+		*where = append(*where, qb.EqNamed(column, name))
+	case ports.OP_IN:
+		*where = append(*where, qb.InNamed(column, name))
+	case ports.OP_GT:
+		*where = append(*where, qb.GtNamed(column, name))
+	case ports.OP_GTE:
+		*where = append(*where, qb.GtOrEqNamed(column, name))
+	case ports.OP_LT:
+		*where = append(*where, qb.LtNamed(column, name))
+	case ports.OP_LTE:
+		*where = append(*where, qb.LtOrEqNamed(column, name))
+	default:
+		return ports.ErrInvalidFilter{
+			Filter: filter,
+			Reason: "operator not supported by Cassandra",
+		}
+	}
+
+	values[name] = filter.Value
+	return nil
+}
+
+// Cursor prefixes tell which kind of value is stored in a cursor
+const (
+	pageStateCursor = "p:"
+	keyCursor       = "k:"
+)
+
+// validateSort rejects any sort
+//
+// Cassandra only sorts the rows of a partition by its clustering columns. The tables of this
+// repository are partitioned by id with no clustering columns, so there is nothing to sort
+func validateSort(sort []ports.Sort) error {
+	if len(sort) == 0 {
+		return nil
+	}
+
+	return ports.ErrInvalidSort{
+		Field:  sort[0].Field,
+		Reason: "sorting is not supported by Cassandra",
+	}
+}
+
+// encodePageStateCursor converts a Cassandra paging state into an opaque cursor
+func encodePageStateCursor(state []byte) string {
+	return base64.RawURLEncoding.EncodeToString(append([]byte(pageStateCursor), state...))
+}
+
+// encodeKeyCursor converts an item id into an opaque cursor
+func encodeKeyCursor(id string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(keyCursor + id))
+}
+
+// decodeCursor converts an opaque cursor back into either a paging state or an item id
+func decodeCursor(cursor string) (pageState []byte, id string, err error) {
+	if cursor == "" {
+		return nil, "", nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil || len(raw) < 2 {
+		return nil, "", ports.ErrInvalidCursor{Cursor: cursor}
+	}
+
+	switch string(raw[:2]) {
+	case pageStateCursor:
+		return raw[2:], "", nil
+	case keyCursor:
+		return nil, string(raw[2:]), nil
+	}
+
+	return nil, "", ports.ErrInvalidCursor{Cursor: cursor}
+}
@@ -0,0 +1,246 @@
+package cassandra
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/gocql/gocql"
+	"github.com/google/go-cmp/cmp"
+	"github.com/scylladb/gocqlx/v2/qb"
+	"github.com/sy-software/minerva-owl/internal/core/domain"
+	"github.com/sy-software/minerva-owl/internal/core/ports"
+)
+
+func TestTableMetadata(t *testing.T) {
+	t.Run("Test metadata is mapped from bson tags", func(t *testing.T) {
+		metadata, columnTypes, err := tableMetadata("users", reflect.TypeOf(domain.User{}))
+
+		if err != nil {
+			t.Errorf("Metadata failed to map with error: %v", err)
+		}
+
+		expectedColumns := []string{
+			"id", "username", "name", "picture", "role", "provider",
+			"tokenid", "createdate", "updatedate", "status",
+		}
+
+		if !cmp.Equal(expectedColumns, metadata.Columns) {
+			t.Errorf("Expected columns: %+v. Got: %+v", expectedColumns, metadata.Columns)
+		}
+
+		if metadata.Name != "minerva.users" {
+			t.Errorf("Expected table name: %q. Got: %q", "minerva.users", metadata.Name)
+		}
+
+		if !cmp.Equal([]string{"id"}, metadata.PartKey) {
+			t.Errorf("Expected partition key: [id]. Got: %+v", metadata.PartKey)
+		}
+
+		if columnTypes["createdate"] != "timestamp" {
+			t.Errorf("Expected createdate to be a timestamp. Got: %q", columnTypes["createdate"])
+		}
+	})
+
+	t.Run("Test slices are mapped into lists", func(t *testing.T) {
+		type withList struct {
+			Id   string   `bson:"_id"`
+			Tags []string `bson:"tags"`
+		}
+
+		_, columnTypes, err := tableMetadata("lists", reflect.TypeOf(&withList{}))
+
+		if err != nil {
+			t.Errorf("Metadata failed to map with error: %v", err)
+		}
+
+		if columnTypes["tags"] != "list<text>" {
+			t.Errorf("Expected tags to be a list<text>. Got: %q", columnTypes["tags"])
+		}
+	})
+
+	t.Run("Test slices tagged as set are mapped into sets", func(t *testing.T) {
+		_, columnTypes, err := tableMetadata("teams", reflect.TypeOf(&domain.Team{}))
+
+		if err != nil {
+			t.Errorf("Metadata failed to map with error: %v", err)
+		}
+
+		if columnTypes["techs"] != "set<text>" {
+			t.Errorf("Expected techs to be a set<text>. Got: %q", columnTypes["techs"])
+		}
+	})
+
+	t.Run("Test entities without id are rejected", func(t *testing.T) {
+		type noId struct {
+			Name string `bson:"name"`
+		}
+
+		_, _, err := tableMetadata("invalid", reflect.TypeOf(noId{}))
+
+		if err == nil {
+			t.Errorf("Expected an error for an entity without id")
+		}
+	})
+}
+
+func TestCreateTableStmt(t *testing.T) {
+	metadata, columnTypes, _ := tableMetadata("organizations", reflect.TypeOf(domain.Organization{}))
+
+	expected := "CREATE TABLE IF NOT EXISTS minerva.organizations " +
+		"(id text, name text, description text, logo text, PRIMARY KEY (id));"
+
+	got := createTableStmt(metadata, columnTypes)
+
+	if got != expected {
+		t.Errorf("Expected statement: %q. Got: %q", expected, got)
+	}
+}
+
+func TestFilters(t *testing.T) {
+	t.Run("Test equality and operator filters", func(t *testing.T) {
+		filters := []ports.Filter{
+			{
+				Name:  "_id",
+				Value: "myid",
+			},
+			ports.And(
+				ports.In("role", "v1", "v2"),
+			),
+		}
+
+		where, values, err := formatFilters(filters)
+
+		if err != nil {
+			t.Errorf("Filters failed to format with error: %v", err)
+		}
+
+		stmt, names := qb.Select("minerva.users").Where(where...).ToCql()
+		expectedStmt := "SELECT * FROM minerva.users WHERE id=? AND role IN ? "
+
+		if stmt != expectedStmt {
+			t.Errorf("Expected statement: %q. Got: %q", expectedStmt, stmt)
+		}
+
+		expectedValues := qb.M{
+			"filter_0": "myid",
+			"filter_1": []interface{}{"v1", "v2"},
+		}
+
+		if !cmp.Equal(expectedValues, values) {
+			t.Errorf("Expected values: %+v. Got: %+v", expectedValues, values)
+		}
+
+		if !cmp.Equal([]string{"filter_0", "filter_1"}, names) {
+			t.Errorf("Expected names: [filter_0 filter_1]. Got: %+v", names)
+		}
+	})
+
+	t.Run("Test unsupported operators", func(t *testing.T) {
+		filters := []ports.Filter{
+			ports.Ne("role", "admin"),
+			ports.Prefix("name", "a"),
+			ports.Or(ports.Eq("role", "admin")),
+			ports.Not(ports.Eq("role", "admin")),
+		}
+
+		for _, filter := range filters {
+			_, _, err := formatFilters([]ports.Filter{filter})
+
+			if _, ok := err.(ports.ErrInvalidFilter); !ok {
+				t.Errorf("Expected error of type ErrInvalidFilter for %+v got: %v", filter, err)
+			}
+		}
+	})
+
+	t.Run("Test invalid field names", func(t *testing.T) {
+		for _, name := range []string{"name = 'x' OR id", "role;", "1role", "age)"} {
+			_, _, err := formatFilters([]ports.Filter{ports.Eq(name, "x")})
+
+			if _, ok := err.(ports.ErrInvalidFilter); !ok {
+				t.Errorf("Expected error of type ErrInvalidFilter for %q got: %v", name, err)
+			}
+		}
+	})
+}
+
+func TestFormatFieldMask(t *testing.T) {
+	repo := &CassandraRepo{}
+
+	assignments, args, err := repo.formatFieldMask("users", ports.FieldMask{
+		Set:   map[string]interface{}{"name": "Tony"},
+		Unset: []string{"picture"},
+	})
+
+	expected := []string{"name = ?", "picture = null"}
+	if err != nil || !cmp.Equal(expected, assignments) || !cmp.Equal([]interface{}{"Tony"}, args) {
+		t.Errorf("Expected assignments: %v got: %v %v with error: %v", expected, assignments, args, err)
+	}
+
+	for _, field := range []string{"name = 'x', role", "role;", "1role"} {
+		_, _, err := repo.formatFieldMask("users", ports.FieldMask{Set: map[string]interface{}{field: "admin"}})
+
+		if _, ok := err.(ports.ErrInvalidFieldMask); !ok {
+			t.Errorf("Expected error of type ErrInvalidFieldMask for %q got: %v", field, err)
+		}
+	}
+}
+
+func TestSort(t *testing.T) {
+	if err := validateSort(nil); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	err := validateSort([]ports.Sort{{Field: "createDate", Descending: true}})
+	if _, ok := err.(ports.ErrInvalidSort); !ok {
+		t.Errorf("Expected error of type ErrInvalidSort got: %v", err)
+	}
+}
+
+// fakeSchema records the executed statements and returns fixed keyspace metadata
+type fakeSchema struct {
+	stmts    []string
+	metadata *gocql.KeyspaceMetadata
+}
+
+func (schema *fakeSchema) ExecStmt(stmt string) error {
+	schema.stmts = append(schema.stmts, stmt)
+	return nil
+}
+
+func (schema *fakeSchema) KeyspaceMetadata(keyspace string) (*gocql.KeyspaceMetadata, error) {
+	return schema.metadata, nil
+}
+
+func TestColumnType(t *testing.T) {
+	schema := &fakeSchema{
+		metadata: &gocql.KeyspaceMetadata{
+			Name: keyspace,
+			Tables: map[string]*gocql.TableMetadata{
+				"teams": {
+					Name: "teams",
+					Columns: map[string]*gocql.ColumnMetadata{
+						"techs": {Name: "techs", Type: gocql.CollectionType{NativeType: gocql.NewNativeType(0, gocql.TypeSet, "")}},
+						"name":  {Name: "name", Type: gocql.NewNativeType(0, gocql.TypeText, "")},
+					},
+				},
+			},
+		},
+	}
+	repo := &CassandraRepo{
+		schema: schema,
+		tables: map[string]*collectionTable{},
+	}
+
+	if _, err := repo.getTable("teams", reflect.TypeOf(domain.Team{})); err != nil || len(schema.stmts) != 1 {
+		t.Fatalf("Expected the table to be created got: %v with error: %v", schema.stmts, err)
+	}
+
+	if got := repo.columnType("teams", "techs"); got != gocql.TypeSet {
+		t.Errorf("Expected column type: %v got: %v", gocql.TypeSet, got)
+	}
+
+	_, _, err := repo.formatFieldMask("teams", ports.FieldMask{AddToSet: map[string][]interface{}{"name": {"Avengers"}}})
+	if _, ok := err.(ports.ErrInvalidFieldMask); !ok {
+		t.Errorf("Expected error of type ErrInvalidFieldMask adding to a text column got: %v", err)
+	}
+}
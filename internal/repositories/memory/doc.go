@@ -0,0 +1,3 @@
+// Package memory implements a ports.Repository which keeps all the data in memory,
+// useful for local development and tests without any database
+package memory
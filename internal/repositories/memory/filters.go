@@ -0,0 +1,338 @@
+package memory
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sy-software/minerva-owl/internal/core/ports"
+)
+
+// FilterItems returns the items matching all the filters
+//
+// Items are documents serialized with the json tags of the domain models,
+// filters by "_id" are applied to the "id" field
+func FilterItems(items []map[string]interface{}, filters []ports.Filter) ([]map[string]interface{}, error) {
+	if len(filters) == 0 {
+		return items, nil
+	}
+
+	for _, filter := range filters {
+		if err := filter.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	output := []map[string]interface{}{}
+	for _, item := range items {
+		if MatchAll(item, filters) {
+			output = append(output, item)
+		}
+	}
+
+	return output, nil
+}
+
+// MatchAll tells if item matches all the filters, filters must be already validated with Filter.Validate
+func MatchAll(item map[string]interface{}, filters []ports.Filter) bool {
+	for _, filter := range filters {
+		if !match(item, filter) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// match tells if item matches a single filter, the filter must be already validated
+func match(item map[string]interface{}, filter ports.Filter) bool {
+	switch filter.Op() {
+	case ports.OP_AND:
+		return MatchAll(item, filter.Filters)
+	case ports.OP_OR:
+		for _, nested := range filter.Filters {
+			if match(item, nested) {
+				return true
+			}
+		}
+		return false
+	case ports.OP_NOT:
+		return !match(item, filter.Filters[0])
+	}
+
+	field, exists := FieldValue(item, filter.Name)
+	value := normalize(filter.Value)
+
+	switch filter.Op() {
+	case ports.OP_EQ:
+		return equals(field, value)
+	case ports.OP_NE:
+		return !equals(field, value)
+	case ports.OP_IN:
+		// An empty list is serialized as null
+		values, _ := value.([]interface{})
+		return anyEquals(field, values)
+	case ports.OP_NIN:
+		values, _ := value.([]interface{})
+		return !anyEquals(field, values)
+	case ports.OP_GT:
+		result, ok := compare(field, value)
+		return ok && result > 0
+	case ports.OP_GTE:
+		result, ok := compare(field, value)
+		return ok && result >= 0
+	case ports.OP_LT:
+		result, ok := compare(field, value)
+		return ok && result < 0
+	case ports.OP_LTE:
+		result, ok := compare(field, value)
+		return ok && result <= 0
+	case ports.OP_PREFIX:
+		return anyString(field, func(str string) bool {
+			return strings.HasPrefix(str, value.(string))
+		})
+	case ports.OP_CONTAINS:
+		return anyString(field, func(str string) bool {
+			return strings.Contains(str, value.(string))
+		})
+	case ports.OP_EXISTS:
+		return exists == value.(bool)
+	}
+
+	return false
+}
+
+// FieldValue returns the value of a field, nested fields are separated by dots
+func FieldValue(item map[string]interface{}, name string) (interface{}, bool) {
+	if name == "_id" {
+		name = "id"
+	}
+
+	var current interface{} = item
+	for _, part := range strings.Split(name, ".") {
+		values, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+
+		if current, ok = values[part]; !ok {
+			return nil, false
+		}
+	}
+
+	return current, true
+}
+
+// normalize converts value into the same types used by the stored items
+func normalize(value interface{}) interface{} {
+	doc, err := json.Marshal(value)
+	if err != nil {
+		return value
+	}
+
+	var output interface{}
+	if err := json.Unmarshal(doc, &output); err != nil {
+		return value
+	}
+
+	return output
+}
+
+// equals tells if field is equal to value, when field is an array
+// any of its elements can be equal to value
+func equals(field interface{}, value interface{}) bool {
+	if result, ok := compare(field, value); ok {
+		return result == 0
+	}
+
+	if field == nil || value == nil {
+		return field == value
+	}
+
+	if elements, ok := toSlice(field); ok {
+		for _, element := range elements {
+			if equals(element, value) {
+				return true
+			}
+		}
+	}
+
+	return reflect.DeepEqual(normalize(field), value)
+}
+
+// anyEquals tells if field is equal to any of values
+func anyEquals(field interface{}, values []interface{}) bool {
+	for _, value := range values {
+		if equals(field, value) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// anyString tells if field or any of its elements is a string matching fn
+func anyString(field interface{}, fn func(str string) bool) bool {
+	if str, ok := field.(string); ok {
+		return fn(str)
+	}
+
+	elements, _ := toSlice(field)
+	for _, element := range elements {
+		if str, ok := element.(string); ok && fn(str) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// compare returns -1, 0 or 1 if a is lower, equal or greater than b,
+// the second value is false if the values have different types
+func compare(a interface{}, b interface{}) (int, bool) {
+	if _, ok := toFloat(a); ok {
+		if _, ok := toFloat(b); !ok {
+			return 0, false
+		}
+	} else if reflect.TypeOf(a) != reflect.TypeOf(b) {
+		return 0, false
+	}
+
+	switch a.(type) {
+	case nil, map[string]interface{}, []interface{}:
+		return 0, false
+	}
+
+	return compareValues(a, b), true
+}
+
+// toSlice converts any slice into a []interface{}
+func toSlice(value interface{}) ([]interface{}, bool) {
+	val := reflect.ValueOf(value)
+	if val.Kind() != reflect.Slice && val.Kind() != reflect.Array {
+		return nil, false
+	}
+
+	output := make([]interface{}, val.Len())
+	for i := range output {
+		output[i] = val.Index(i).Interface()
+	}
+
+	return output, true
+}
+
+// SortItems returns a sorted copy of items, without sort the insertion order is kept
+func SortItems(items []map[string]interface{}, sortBy []ports.Sort) []map[string]interface{} {
+	if len(sortBy) == 0 {
+		return items
+	}
+
+	sorted := make([]map[string]interface{}, len(items))
+	copy(sorted, items)
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		for _, s := range sortBy {
+			a, _ := FieldValue(sorted[i], s.Field)
+			b, _ := FieldValue(sorted[j], s.Field)
+
+			result := compareValues(a, b)
+			if result == 0 {
+				continue
+			}
+
+			if s.Descending {
+				return result > 0
+			}
+
+			return result < 0
+		}
+
+		return false
+	})
+
+	return sorted
+}
+
+// compareValues returns -1, 0 or 1 if a is lower, equal or greater than b,
+// missing values are lower than any other value
+func compareValues(a interface{}, b interface{}) int {
+	if a == nil || b == nil {
+		switch {
+		case a == nil && b == nil:
+			return 0
+		case a == nil:
+			return -1
+		default:
+			return 1
+		}
+	}
+
+	aNum, aIsNum := toFloat(a)
+	bNum, bIsNum := toFloat(b)
+	if aIsNum && bIsNum {
+		switch {
+		case aNum < bNum:
+			return -1
+		case aNum > bNum:
+			return 1
+		}
+		return 0
+	}
+
+	switch aVal := a.(type) {
+	case bool:
+		if bVal, ok := b.(bool); ok {
+			switch {
+			case aVal == bVal:
+				return 0
+			case bVal:
+				return -1
+			}
+			return 1
+		}
+	}
+
+	if aStr, ok := a.(string); ok {
+		if bStr, ok := b.(string); ok {
+			return compareStrings(aStr, bStr)
+		}
+	}
+
+	return strings.Compare(fmt.Sprintf("%v", a), fmt.Sprintf("%v", b))
+}
+
+// toFloat converts any numeric value into a float64
+func toFloat(value interface{}) (float64, bool) {
+	val := reflect.ValueOf(value)
+	switch val.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(val.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(val.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return val.Float(), true
+	}
+
+	return 0, false
+}
+
+// compareStrings compares two strings, dates serialized with different
+// time zones are compared by their instant
+func compareStrings(a string, b string) int {
+	aTime, aErr := time.Parse(time.RFC3339Nano, a)
+	bTime, bErr := time.Parse(time.RFC3339Nano, b)
+	if aErr == nil && bErr == nil {
+		switch {
+		case aTime.Before(bTime):
+			return -1
+		case aTime.After(bTime):
+			return 1
+		}
+		return 0
+	}
+
+	return strings.Compare(a, b)
+}
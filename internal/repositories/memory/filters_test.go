@@ -0,0 +1,71 @@
+package memory
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sy-software/minerva-owl/internal/core/ports"
+)
+
+func TestSortItems(t *testing.T) {
+	items := []map[string]interface{}{
+		{"id": "1", "date": "2021-06-01T12:00:00-05:00"},
+		{"id": "2", "date": "2021-06-01T15:00:00Z"},
+		{"id": "3"},
+	}
+
+	got := SortItems(items, []ports.Sort{{Field: "date"}})
+
+	expected := []string{"3", "2", "1"}
+	for index, item := range got {
+		if item["id"] != expected[index] {
+			t.Errorf("Expected item %d to be: %q got: %v", index, expected[index], item["id"])
+		}
+	}
+
+	if items[0]["id"] != "1" {
+		t.Errorf("Expected original items to keep their order")
+	}
+
+	t.Run("Nested fields", func(t *testing.T) {
+		items := []map[string]interface{}{
+			{"id": "1", "owner": map[string]interface{}{"name": "Wanda"}},
+			{"id": "2", "owner": map[string]interface{}{"name": "Peter"}},
+			{"id": "3"},
+		}
+
+		got := SortItems(items, []ports.Sort{{Field: "owner.name"}})
+
+		expected := []string{"3", "2", "1"}
+		for index, item := range got {
+			if item["id"] != expected[index] {
+				t.Errorf("Expected item %d to be: %q got: %v", index, expected[index], item["id"])
+			}
+		}
+	})
+}
+
+func TestFilterItems(t *testing.T) {
+	items := []map[string]interface{}{
+		{"id": "1", "date": "2021-06-01T12:00:00-05:00"},
+		{"id": "2", "date": "2021-06-01T15:00:00Z"},
+	}
+
+	got, err := FilterItems(items, []ports.Filter{
+		ports.Gt("date", time.Date(2021, 6, 1, 16, 0, 0, 0, time.UTC)),
+	})
+
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	if len(got) != 1 || got[0]["id"] != "1" {
+		t.Errorf("Expected only item 1 got: %+v", got)
+	}
+
+	_, err = FilterItems(items, []ports.Filter{{Name: "date", Operator: "after"}})
+
+	if _, ok := err.(ports.ErrInvalidFilter); !ok {
+		t.Errorf("Expected error of type ErrInvalidFilter got: %v", err)
+	}
+}
@@ -0,0 +1,594 @@
+package memory
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/sy-software/minerva-owl/internal/core/ports"
+	"github.com/sy-software/minerva-owl/internal/utils"
+)
+
+// idField is the document field holding the item id
+const idField = "id"
+
+// MemoryRepo is an implementation of ports.Repository which keeps all the items in memory,
+// it's safe for concurrent use and all the data is lost when the process ends
+//
+// Items are stored as documents serialized with the json tags of the domain models,
+// filters and sorts by "_id" are applied to the "id" field
+type MemoryRepo struct {
+	mutex       sync.RWMutex
+	collections map[string]*memCollection
+	// sequence is increased on every insert to keep the insertion order
+	sequence uint64
+}
+
+// memCollection holds the items of a collection sorted by insertion order
+type memCollection struct {
+	records []record
+	// index has the position in records of every id
+	index map[string]int
+}
+
+// record is a single stored item
+type record struct {
+	seq uint64
+	doc map[string]interface{}
+}
+
+// NewMemoryRepo creates an empty instance of MemoryRepo
+func NewMemoryRepo() *MemoryRepo {
+	return &MemoryRepo{
+		collections: map[string]*memCollection{},
+	}
+}
+
+// getCollection returns the collection with the given name, the caller must hold the lock
+func (repo *MemoryRepo) getCollection(collection string) *memCollection {
+	value, exists := repo.collections[collection]
+	if !exists {
+		value = &memCollection{
+			records: []record{},
+			index:   map[string]int{},
+		}
+		repo.collections[collection] = value
+	}
+
+	return value
+}
+
+// snapshot returns a copy of the records of a collection in insertion order,
+// documents are never modified once stored so they can be read without the lock
+func (repo *MemoryRepo) snapshot(collection string) []record {
+	repo.mutex.RLock()
+	defer repo.mutex.RUnlock()
+
+	value, exists := repo.collections[collection]
+	if !exists {
+		return []record{}
+	}
+
+	records := make([]record, len(value.records))
+	copy(records, value.records)
+	return records
+}
+
+// List stores into results a list of items from the given collection applying the filters
+// results must be a pointer to an Slice of an struct with json tags for serialization
+//
+// Items without sort are returned in insertion order
+func (repo *MemoryRepo) List(ctx context.Context, collection string, results interface{}, skip int, limit int, sortBy []ports.Sort, filters ...ports.Filter) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	records := repo.snapshot(collection)
+	docs := make([]map[string]interface{}, len(records))
+	for index, r := range records {
+		docs[index] = r.doc
+	}
+
+	docs, err := FilterItems(docs, filters)
+	if err != nil {
+		utils.Logger(ctx).Debug().Err(err).Msgf("%v - List error", collection)
+		return err
+	}
+
+	docs = SortItems(docs, sortBy)
+
+	if skip >= len(docs) {
+		return nil
+	}
+
+	end := skip + limit
+	if end > len(docs) {
+		end = len(docs)
+	}
+
+	resultsVal := reflect.ValueOf(results).Elem()
+	for _, doc := range docs[skip:end] {
+		element, err := decode(doc, resultsVal.Type().Elem())
+		if err != nil {
+			utils.Logger(ctx).Debug().Err(err).Msgf("%v - List error", collection)
+			return err
+		}
+
+		resultsVal.Set(reflect.Append(resultsVal, element))
+	}
+
+	return nil
+}
+
+// ListPage stores into results up to limit items from the given collection after the cursor.
+// results must be a pointer to an Slice of an struct with json tags for serialization
+//
+// Items are returned in insertion order, a cursor keeps working even if its item is deleted
+func (repo *MemoryRepo) ListPage(ctx context.Context, collection string, results interface{}, after string, limit int, filters ...ports.Filter) (ports.PageInfo, error) {
+	if err := ctx.Err(); err != nil {
+		return ports.PageInfo{}, err
+	}
+
+	pageInfo := ports.PageInfo{
+		Cursors:         []string{},
+		HasPreviousPage: after != "",
+	}
+
+	for _, filter := range filters {
+		if err := filter.Validate(); err != nil {
+			utils.Logger(ctx).Debug().Err(err).Msgf("%v - List page error", collection)
+			return pageInfo, err
+		}
+	}
+
+	records := repo.snapshot(collection)
+	start := 0
+	if after != "" {
+		afterSeq, err := decodeCursor(after)
+		if err != nil {
+			return pageInfo, err
+		}
+
+		start = sort.Search(len(records), func(i int) bool {
+			return records[i].seq > afterSeq
+		})
+	}
+
+	resultsVal := reflect.ValueOf(results).Elem()
+	for _, r := range records[start:] {
+		if !MatchAll(r.doc, filters) {
+			continue
+		}
+
+		if len(pageInfo.Cursors) == limit {
+			pageInfo.HasNextPage = true
+			break
+		}
+
+		element, err := decode(r.doc, resultsVal.Type().Elem())
+		if err != nil {
+			utils.Logger(ctx).Debug().Err(err).Msgf("%v - List page error", collection)
+			return pageInfo, err
+		}
+
+		resultsVal.Set(reflect.Append(resultsVal, element))
+		pageInfo.EndCursor = encodeCursor(r.seq)
+		pageInfo.Cursors = append(pageInfo.Cursors, pageInfo.EndCursor)
+	}
+
+	return pageInfo, nil
+}
+
+// Count returns how many items from collection match the filters
+func (repo *MemoryRepo) Count(ctx context.Context, collection string, filters ...ports.Filter) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	records := repo.snapshot(collection)
+	docs := make([]map[string]interface{}, len(records))
+	for index, r := range records {
+		docs[index] = r.doc
+	}
+
+	docs, err := FilterItems(docs, filters)
+	if err != nil {
+		utils.Logger(ctx).Debug().Err(err).Msgf("%v - Count error", collection)
+	}
+
+	return len(docs), err
+}
+
+// Get stores into result an item from collection with id equals to id
+// result must be a pointer to an instance of a struct with json tags for serialization
+func (repo *MemoryRepo) Get(ctx context.Context, collection string, id string, result interface{}) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	repo.mutex.RLock()
+	var doc map[string]interface{}
+	if value, exists := repo.collections[collection]; exists {
+		if position, exists := value.index[id]; exists {
+			doc = value.records[position].doc
+		}
+	}
+	repo.mutex.RUnlock()
+
+	if doc == nil {
+		return ports.ErrItemNotFound{
+			Id:    &id,
+			Model: collection,
+		}
+	}
+
+	return decodeInto(doc, result)
+}
+
+// GetMany appends to results the items from collection with any of the ids
+// results must be a pointer to an Slice of an struct with json tags for serialization
+func (repo *MemoryRepo) GetMany(ctx context.Context, collection string, ids []string, results interface{}) error {
+	if len(ids) == 0 {
+		return ctx.Err()
+	}
+
+	return repo.List(ctx, collection, results, 0, len(ids), nil, ports.InIds(ids...))
+}
+
+// GetOne stores into result the first inserted item from collection matching the filters
+// result must be a pointer to an instance of a struct with json tags for serialization
+func (repo *MemoryRepo) GetOne(ctx context.Context, collection string, result interface{}, filters ...ports.Filter) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	for _, filter := range filters {
+		if err := filter.Validate(); err != nil {
+			utils.Logger(ctx).Debug().Err(err).Msgf("%v - Get error", collection)
+			return err
+		}
+	}
+
+	for _, r := range repo.snapshot(collection) {
+		if MatchAll(r.doc, filters) {
+			return decodeInto(r.doc, result)
+		}
+	}
+
+	return ports.ErrItemNotFound{
+		Model: collection,
+	}
+}
+
+// Create saves the serialized version of entity into the collection
+// entity must be an instance of a struct with json tags for serialization
+//
+// If the entity has no id a new V4 UUID is assigned
+func (repo *MemoryRepo) Create(ctx context.Context, collection string, entity interface{}) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	doc, err := encode(entity)
+	if err != nil {
+		return "", err
+	}
+
+	id, _ := doc[idField].(string)
+	if id == "" {
+		id = uuid.New().String()
+		doc[idField] = id
+	}
+
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+
+	return id, repo.insert(collection, doc)
+}
+
+// insert stores doc as the newest item of collection, the caller must hold the lock
+func (repo *MemoryRepo) insert(collection string, doc map[string]interface{}) error {
+	id, _ := doc[idField].(string)
+	value := repo.getCollection(collection)
+	if _, exists := value.index[id]; exists {
+		return ports.ErrDuplicatedId{Id: id, Model: collection}
+	}
+
+	repo.sequence++
+	value.index[id] = len(value.records)
+	value.records = append(value.records, record{
+		seq: repo.sequence,
+		doc: doc,
+	})
+
+	return nil
+}
+
+// Update saves the values of entity to the item with id from the collection
+// entity must be an instance of a struct with json tags for serialization
+//
+// Fields without value in entity and the field names in omit keep their stored value
+func (repo *MemoryRepo) Update(ctx context.Context, collection string, id string, entity interface{}, omit ...string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	values, err := encode(entity)
+	if err != nil {
+		return err
+	}
+
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+
+	value := repo.getCollection(collection)
+	position, exists := value.index[id]
+	if !exists {
+		return ports.ErrItemNotFound{
+			Id:    &id,
+			Model: collection,
+		}
+	}
+
+	// Stored documents are replaced instead of modified so readers can use them without locking
+	value.records[position].doc = mergeDoc(value.records[position].doc, values, omit)
+	return nil
+}
+
+// Upsert saves the values of entity to the first inserted item from collection matching the filters,
+// except the fields in onInsertOnly, or creates entity if none matches. Both happen under the same lock
+func (repo *MemoryRepo) Upsert(ctx context.Context, collection string, filters []ports.Filter, entity interface{}, onInsertOnly ...string) (string, bool, error) {
+	if err := ctx.Err(); err != nil {
+		return "", false, err
+	}
+
+	for _, filter := range filters {
+		if err := filter.Validate(); err != nil {
+			utils.Logger(ctx).Debug().Err(err).Msgf("%v - Upsert error", collection)
+			return "", false, err
+		}
+	}
+
+	values, err := encode(entity)
+	if err != nil {
+		return "", false, err
+	}
+
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+
+	value := repo.getCollection(collection)
+	for position, r := range value.records {
+		if MatchAll(r.doc, filters) {
+			value.records[position].doc = mergeDoc(r.doc, values, onInsertOnly)
+			id, _ := r.doc[idField].(string)
+			return id, false, nil
+		}
+	}
+
+	id, _ := values[idField].(string)
+	if id == "" {
+		id = uuid.New().String()
+		values[idField] = id
+	}
+
+	return id, true, repo.insert(collection, values)
+}
+
+// Patch changes the fields in mask of the item with id from the collection
+func (repo *MemoryRepo) Patch(ctx context.Context, collection string, id string, mask ports.FieldMask) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if err := mask.Validate(); err != nil {
+		utils.Logger(ctx).Debug().Err(err).Msgf("%v - Patch error", collection)
+		return err
+	}
+
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+
+	value := repo.getCollection(collection)
+	position, exists := value.index[id]
+	if !exists {
+		return ports.ErrItemNotFound{
+			Id:    &id,
+			Model: collection,
+		}
+	}
+
+	// Stored documents are replaced instead of modified so readers can use them without locking
+	doc, err := ApplyFieldMask(value.records[position].doc, mask)
+	if err != nil {
+		return err
+	}
+
+	value.records[position].doc = doc
+	return nil
+}
+
+// Delete removes the item with id from collection
+func (repo *MemoryRepo) Delete(ctx context.Context, collection string, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+
+	value := repo.getCollection(collection)
+	position, exists := value.index[id]
+	if !exists {
+		return ports.ErrItemNotFound{
+			Id:    &id,
+			Model: collection,
+		}
+	}
+
+	value.records = append(value.records[:position], value.records[position+1:]...)
+
+	delete(value.index, id)
+	for index := position; index < len(value.records); index++ {
+		itemId, _ := value.records[index].doc[idField].(string)
+		value.index[itemId] = index
+	}
+
+	return nil
+}
+
+// WithTransaction runs fn against a copy of the collections which replaces them
+// when fn returns nil and is discarded otherwise.
+//
+// Stored documents are never modified so the copy only duplicates the records and the indexes.
+// Other operations wait until the transaction ends so fn must only use tx
+func (repo *MemoryRepo) WithTransaction(ctx context.Context, fn func(tx ports.Repository) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+
+	tx := &MemoryRepo{
+		collections: make(map[string]*memCollection, len(repo.collections)),
+		sequence:    repo.sequence,
+	}
+
+	for name, value := range repo.collections {
+		records := make([]record, len(value.records))
+		copy(records, value.records)
+
+		index := make(map[string]int, len(value.index))
+		for id, position := range value.index {
+			index[id] = position
+		}
+
+		tx.collections[name] = &memCollection{
+			records: records,
+			index:   index,
+		}
+	}
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	// A transaction cancelled while fn was running is discarded
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	repo.collections = tx.collections
+	repo.sequence = tx.sequence
+	return nil
+}
+
+// CreateMany saves every entity into the collection in order, each one like Create
+func (repo *MemoryRepo) CreateMany(ctx context.Context, collection string, entities []interface{}, stopOnError bool) ([]ports.BulkResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return ports.RunBulk(len(entities), stopOnError, func(index int) (string, error) {
+		return repo.Create(ctx, collection, entities[index])
+	}), nil
+}
+
+// PatchMany applies every patch to the collection in order, each one like Patch
+func (repo *MemoryRepo) PatchMany(ctx context.Context, collection string, patches []ports.BulkPatch, stopOnError bool) ([]ports.BulkResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return ports.RunBulk(len(patches), stopOnError, func(index int) (string, error) {
+		return patches[index].Id, repo.Patch(ctx, collection, patches[index].Id, patches[index].Mask)
+	}), nil
+}
+
+// DeleteMany removes every item with the ids from the collection in order, each one like Delete
+func (repo *MemoryRepo) DeleteMany(ctx context.Context, collection string, ids []string, stopOnError bool) ([]ports.BulkResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return ports.RunBulk(len(ids), stopOnError, func(index int) (string, error) {
+		return ids[index], repo.Delete(ctx, collection, ids[index])
+	}), nil
+}
+
+// mergeDoc returns a copy of current with the fields of values, except the id and the fields in omit
+func mergeDoc(current map[string]interface{}, values map[string]interface{}, omit []string) map[string]interface{} {
+	omitMap := map[string]bool{idField: true}
+	for _, field := range omit {
+		omitMap[field] = true
+	}
+
+	doc := make(map[string]interface{}, len(current))
+	for field, fieldValue := range current {
+		doc[field] = fieldValue
+	}
+
+	for field, fieldValue := range values {
+		if !omitMap[field] {
+			doc[field] = fieldValue
+		}
+	}
+
+	return doc
+}
+
+// encode serializes entity into a document
+func encode(entity interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(entity)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := map[string]interface{}{}
+	err = json.Unmarshal(data, &doc)
+	return doc, err
+}
+
+// decode creates a new value of elementType from a document
+func decode(doc map[string]interface{}, elementType reflect.Type) (reflect.Value, error) {
+	element := reflect.New(elementType)
+	err := decodeInto(doc, element.Interface())
+
+	return element.Elem(), err
+}
+
+// decodeInto deserializes a document into result
+func decodeInto(doc map[string]interface{}, result interface{}) error {
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, result)
+}
+
+// encodeCursor converts an insertion sequence into an opaque cursor
+func encodeCursor(seq uint64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatUint(seq, 10)))
+}
+
+// decodeCursor converts an opaque cursor back into an insertion sequence
+func decodeCursor(cursor string) (uint64, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, ports.ErrInvalidCursor{Cursor: cursor}
+	}
+
+	seq, err := strconv.ParseUint(string(raw), 10, 64)
+	if err != nil {
+		return 0, ports.ErrInvalidCursor{Cursor: cursor}
+	}
+
+	return seq, nil
+}
@@ -0,0 +1,241 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sy-software/minerva-owl/internal/core/domain"
+	"github.com/sy-software/minerva-owl/internal/core/ports"
+)
+
+const idRegex = "^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$"
+
+func TestMemoryRepo(t *testing.T) {
+	ctx := context.Background()
+	t.Run("Test create and get", func(t *testing.T) {
+		repo := NewMemoryRepo()
+		expected := domain.User{
+			Username:   "IronMan",
+			Name:       "Tony Stark",
+			CreateDate: time.Date(2021, 6, 1, 10, 0, 0, 0, time.UTC),
+		}
+
+		id, err := repo.Create(ctx, "users", expected)
+
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+
+		if match, _ := regexp.MatchString(idRegex, id); !match {
+			t.Errorf("ID is not V4 UUID got: %q", id)
+		}
+
+		expected.Id = id
+		got := domain.User{}
+		err = repo.Get(ctx, "users", id, &got)
+
+		if err != nil || !cmp.Equal(expected, got) {
+			t.Errorf("Expected user: %+v got: %+v with error: %v", expected, got, err)
+		}
+	})
+
+	t.Run("Test create with duplicated id", func(t *testing.T) {
+		repo := NewMemoryRepo()
+		repo.Create(ctx, "users", domain.User{Id: "1"})
+
+		_, err := repo.Create(ctx, "users", domain.User{Id: "1"})
+
+		if _, ok := err.(ports.ErrDuplicatedId); !ok {
+			t.Errorf("Expected error of type ErrDuplicatedId got: %v", err)
+		}
+	})
+
+	t.Run("Test get a non-existing id", func(t *testing.T) {
+		repo := NewMemoryRepo()
+		err := repo.Get(ctx, "users", "1", &domain.User{})
+
+		if _, ok := err.(ports.ErrItemNotFound); !ok {
+			t.Errorf("Expected error of type ErrItemNotFound got: %v", err)
+		}
+	})
+
+	t.Run("Test update", func(t *testing.T) {
+		repo := NewMemoryRepo()
+		createDate := time.Date(2021, 6, 1, 10, 0, 0, 0, time.UTC)
+		id, _ := repo.Create(ctx, "users", domain.User{
+			Username:   "IronMan",
+			Name:       "Tony Stark",
+			CreateDate: createDate,
+		})
+
+		err := repo.Update(ctx, "users", id, domain.User{
+			Id:         "other",
+			Name:       "Anthony Stark",
+			CreateDate: time.Now(),
+		}, "createDate")
+
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+
+		got := domain.User{}
+		repo.Get(ctx, "users", id, &got)
+
+		expected := domain.User{
+			Id:         id,
+			Username:   "IronMan",
+			Name:       "Anthony Stark",
+			CreateDate: createDate,
+		}
+
+		if !cmp.Equal(expected, got) {
+			t.Errorf("Expected user: %+v got: %+v", expected, got)
+		}
+
+		err = repo.Update(ctx, "users", "other", domain.User{})
+
+		if _, ok := err.(ports.ErrItemNotFound); !ok {
+			t.Errorf("Expected error of type ErrItemNotFound got: %v", err)
+		}
+	})
+
+	t.Run("Test delete", func(t *testing.T) {
+		repo := NewMemoryRepo()
+		first, _ := repo.Create(ctx, "users", domain.User{Username: "first"})
+		second, _ := repo.Create(ctx, "users", domain.User{Username: "second"})
+
+		err := repo.Delete(ctx, "users", first)
+
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+
+		if err := repo.Get(ctx, "users", first, &domain.User{}); err == nil {
+			t.Errorf("Expected deleted item to be missing")
+		}
+
+		got := domain.User{}
+		if err := repo.Get(ctx, "users", second, &got); err != nil || got.Username != "second" {
+			t.Errorf("Expected second item to be available got: %+v with error: %v", got, err)
+		}
+
+		err = repo.Delete(ctx, "users", first)
+
+		if _, ok := err.(ports.ErrItemNotFound); !ok {
+			t.Errorf("Expected error of type ErrItemNotFound got: %v", err)
+		}
+	})
+
+	t.Run("Test list with filters, sort and pagination", func(t *testing.T) {
+		repo := NewMemoryRepo()
+		for i := 0; i < 10; i++ {
+			role := "user"
+			if i%2 == 0 {
+				role = "admin"
+			}
+
+			repo.Create(ctx, "users", domain.User{
+				Username: fmt.Sprintf("user%d", i),
+				Role:     role,
+			})
+		}
+
+		got := []domain.User{}
+		err := repo.List(ctx, "users", &got, 1, 3, []ports.Sort{{Field: "username", Descending: true}}, ports.Eq("role", "admin"))
+
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+
+		expected := []string{"user6", "user4", "user2"}
+		if len(got) != len(expected) {
+			t.Fatalf("Expected %d users got: %+v", len(expected), got)
+		}
+
+		for index, user := range got {
+			if user.Username != expected[index] {
+				t.Errorf("Expected user %d to be: %q got: %q", index, expected[index], user.Username)
+			}
+		}
+
+		count, err := repo.Count(ctx, "users", ports.Eq("role", "admin"))
+
+		if err != nil || count != 5 {
+			t.Errorf("Expected count to be: 5 got: %d with error: %v", count, err)
+		}
+
+		one := domain.User{}
+		err = repo.GetOne(ctx, "users", &one, ports.Eq("role", "user"))
+
+		if err != nil || one.Username != "user1" {
+			t.Errorf("Expected first user with role user got: %+v with error: %v", one, err)
+		}
+	})
+
+	t.Run("Test list page", func(t *testing.T) {
+		repo := NewMemoryRepo()
+		ids := []string{}
+		for i := 0; i < 5; i++ {
+			id, _ := repo.Create(ctx, "users", domain.User{Username: fmt.Sprintf("user%d", i)})
+			ids = append(ids, id)
+		}
+
+		got := []domain.User{}
+		pageInfo, err := repo.ListPage(ctx, "users", &got, "", 2)
+
+		if err != nil || len(got) != 2 || !pageInfo.HasNextPage || pageInfo.HasPreviousPage {
+			t.Errorf("Expected first page with 2 items got: %+v %+v with error: %v", got, pageInfo, err)
+		}
+
+		// Cursors keep working after their item is deleted
+		repo.Delete(ctx, "users", ids[1])
+
+		got = []domain.User{}
+		pageInfo, err = repo.ListPage(ctx, "users", &got, pageInfo.EndCursor, 5)
+
+		if err != nil || len(got) != 3 || pageInfo.HasNextPage || !pageInfo.HasPreviousPage {
+			t.Errorf("Expected last page with 3 items got: %+v %+v with error: %v", got, pageInfo, err)
+		}
+
+		if got[0].Id != ids[2] {
+			t.Errorf("Expected page to start at: %q got: %q", ids[2], got[0].Id)
+		}
+
+		_, err = repo.ListPage(ctx, "users", &got, "invalid", 5)
+
+		if _, ok := err.(ports.ErrInvalidCursor); !ok {
+			t.Errorf("Expected error of type ErrInvalidCursor got: %v", err)
+		}
+	})
+
+	t.Run("Test concurrent access", func(t *testing.T) {
+		repo := NewMemoryRepo()
+		var wg sync.WaitGroup
+
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				id, _ := repo.Create(ctx, "users", domain.User{Username: fmt.Sprintf("user%d", i)})
+				repo.Update(ctx, "users", id, domain.User{Name: "name"})
+				repo.List(ctx, "users", &[]domain.User{}, 0, 10, nil, ports.Prefix("username", "user"))
+				repo.ListPage(ctx, "users", &[]domain.User{}, "", 10)
+				if i%2 == 0 {
+					repo.Delete(ctx, "users", id)
+				}
+			}(i)
+		}
+
+		wg.Wait()
+
+		count, _ := repo.Count(ctx, "users")
+		if count != 10 {
+			t.Errorf("Expected 10 users got: %d", count)
+		}
+	})
+}
@@ -0,0 +1,196 @@
+package memory
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/sy-software/minerva-owl/internal/core/ports"
+)
+
+// ApplyFieldMask returns a copy of item with the changes of mask, item is not modified
+//
+// Items are documents serialized with the json tags of the domain models,
+// the values in mask are serialized the same way. Nested documents in a changed
+// path are copied too, so documents shared with other readers are never modified
+func ApplyFieldMask(item map[string]interface{}, mask ports.FieldMask) (map[string]interface{}, error) {
+	if err := mask.Validate(); err != nil {
+		return nil, err
+	}
+
+	doc := copyDoc(item)
+	operators := mask.Operators()
+	for _, field := range mask.Fields() {
+		// Removing values never creates the missing documents of a path
+		operator := operators[field]
+		create := operator != ports.UPDATE_UNSET && operator != ports.UPDATE_PULL
+		parent, name, err := parentDoc(doc, field, create)
+		if err != nil {
+			return nil, err
+		}
+
+		if parent == nil {
+			continue
+		}
+
+		current, exists := parent[name]
+		switch operator {
+		case ports.UPDATE_SET:
+			parent[name] = normalize(mask.Set[field])
+		case ports.UPDATE_UNSET:
+			delete(parent, name)
+		case ports.UPDATE_ADD_TO_SET:
+			elements, err := arrayValue(field, current, exists)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, value := range normalizeAll(mask.AddToSet[field]) {
+				if !containsValue(elements, value) {
+					elements = append(elements, value)
+				}
+			}
+
+			parent[name] = elements
+		case ports.UPDATE_PULL:
+			if !exists {
+				continue
+			}
+
+			elements, err := arrayValue(field, current, exists)
+			if err != nil {
+				return nil, err
+			}
+
+			values := normalizeAll(mask.Pull[field])
+			kept := []interface{}{}
+			for _, element := range elements {
+				if !containsValue(values, element) {
+					kept = append(kept, element)
+				}
+			}
+
+			parent[name] = kept
+		case ports.UPDATE_PUSH:
+			elements, err := arrayValue(field, current, exists)
+			if err != nil {
+				return nil, err
+			}
+
+			push := mask.Push[field]
+			position := pushPosition(push.Position, len(elements))
+			inserted := append([]interface{}{}, elements[:position]...)
+			inserted = append(inserted, normalizeAll(push.Values)...)
+			parent[name] = append(inserted, elements[position:]...)
+		case ports.UPDATE_INC:
+			number := float64(0)
+			if exists && current != nil {
+				value, ok := toFloat(current)
+				if !ok {
+					return nil, ports.ErrInvalidFieldMask{Field: field, Reason: "field is not a number"}
+				}
+
+				number = value
+			}
+
+			parent[name] = number + float64(mask.Inc[field])
+		}
+	}
+
+	return doc, nil
+}
+
+// parentDoc returns the document holding the last field of path and the field name,
+// existing documents are copied before being returned. Missing documents are created
+// when create is true, otherwise a nil document is returned
+func parentDoc(doc map[string]interface{}, path string, create bool) (map[string]interface{}, string, error) {
+	names := strings.Split(path, ".")
+	for _, name := range names[:len(names)-1] {
+		value, exists := doc[name]
+		if !exists || value == nil {
+			if !create {
+				return nil, "", nil
+			}
+
+			value = map[string]interface{}{}
+		}
+
+		nested, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, "", ports.ErrInvalidFieldMask{Field: path, Reason: name + " is not a document"}
+		}
+
+		nested = copyDoc(nested)
+		doc[name] = nested
+		doc = nested
+	}
+
+	return doc, names[len(names)-1], nil
+}
+
+// arrayValue returns a copy of the elements of an array field, missing fields are empty arrays
+func arrayValue(field string, value interface{}, exists bool) ([]interface{}, error) {
+	if !exists || value == nil {
+		return []interface{}{}, nil
+	}
+
+	elements, ok := toSlice(value)
+	if !ok {
+		return nil, ports.ErrInvalidFieldMask{Field: field, Reason: "field is not an array"}
+	}
+
+	return normalizeAll(elements), nil
+}
+
+// pushPosition returns the index where pushed values are inserted in an array of length size,
+// negative positions count from the end and positions out of bounds are clamped
+func pushPosition(position *int, size int) int {
+	if position == nil {
+		return size
+	}
+
+	index := *position
+	if index < 0 {
+		index += size
+	}
+
+	if index < 0 {
+		return 0
+	}
+
+	if index > size {
+		return size
+	}
+
+	return index
+}
+
+// containsValue tells if any of the normalized elements is equal to the normalized value
+func containsValue(elements []interface{}, value interface{}) bool {
+	for _, element := range elements {
+		if reflect.DeepEqual(element, value) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// normalizeAll normalizes every value
+func normalizeAll(values []interface{}) []interface{} {
+	output := make([]interface{}, len(values))
+	for index, value := range values {
+		output[index] = normalize(value)
+	}
+
+	return output
+}
+
+// copyDoc returns a shallow copy of doc
+func copyDoc(doc map[string]interface{}) map[string]interface{} {
+	output := make(map[string]interface{}, len(doc))
+	for field, value := range doc {
+		output[field] = value
+	}
+
+	return output
+}
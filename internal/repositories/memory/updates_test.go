@@ -0,0 +1,131 @@
+package memory
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sy-software/minerva-owl/internal/core/ports"
+)
+
+func TestApplyFieldMask(t *testing.T) {
+	item := map[string]interface{}{
+		"id":    "1",
+		"name":  "Tony Stark",
+		"age":   float64(48),
+		"tags":  []interface{}{"avenger", "genius"},
+		"suit":  map[string]interface{}{"mark": float64(42), "color": "red"},
+		"alias": "IronMan",
+	}
+
+	tests := []struct {
+		name     string
+		mask     ports.FieldMask
+		expected map[string]interface{}
+	}{
+		{
+			"set and unset",
+			ports.FieldMask{Set: map[string]interface{}{"name": "Anthony"}, Unset: []string{"alias"}},
+			map[string]interface{}{"name": "Anthony"},
+		},
+		{
+			"nested fields",
+			ports.FieldMask{Set: map[string]interface{}{"suit.color": "gold", "home.city": "Malibu"}, Unset: []string{"suit.mark"}},
+			map[string]interface{}{"suit": map[string]interface{}{"color": "gold"}, "home": map[string]interface{}{"city": "Malibu"}},
+		},
+		{
+			"unset a missing nested field",
+			ports.FieldMask{Unset: []string{"home.city"}},
+			map[string]interface{}{},
+		},
+		{
+			"add to set",
+			ports.FieldMask{AddToSet: map[string][]interface{}{"tags": {"genius", "billionaire", "billionaire"}, "skills": {"flight"}}},
+			map[string]interface{}{"tags": []interface{}{"avenger", "genius", "billionaire"}, "skills": []interface{}{"flight"}},
+		},
+		{
+			"pull",
+			ports.FieldMask{Pull: map[string][]interface{}{"tags": {"avenger", "missing"}, "skills": {"flight"}}},
+			map[string]interface{}{"tags": []interface{}{"genius"}},
+		},
+		{
+			"push",
+			ports.FieldMask{Push: map[string]ports.PushValues{"tags": ports.Push("a", "b")}},
+			map[string]interface{}{"tags": []interface{}{"avenger", "genius", "a", "b"}},
+		},
+		{
+			"push at position",
+			ports.FieldMask{Push: map[string]ports.PushValues{"tags": ports.PushAt(1, "a")}},
+			map[string]interface{}{"tags": []interface{}{"avenger", "a", "genius"}},
+		},
+		{
+			"push at negative position",
+			ports.FieldMask{Push: map[string]ports.PushValues{"tags": ports.PushAt(-1, "a")}},
+			map[string]interface{}{"tags": []interface{}{"avenger", "a", "genius"}},
+		},
+		{
+			"push out of bounds",
+			ports.FieldMask{Push: map[string]ports.PushValues{"tags": ports.PushAt(-10, "a")}},
+			map[string]interface{}{"tags": []interface{}{"a", "avenger", "genius"}},
+		},
+		{
+			"inc",
+			ports.FieldMask{Inc: map[string]int64{"age": 2, "suit.mark": -1, "wins": 1}},
+			map[string]interface{}{"age": float64(50), "suit": map[string]interface{}{"mark": float64(41), "color": "red"}, "wins": float64(1)},
+		},
+	}
+
+	for _, test := range tests {
+		got, err := ApplyFieldMask(item, test.mask)
+		if err != nil {
+			t.Errorf("%s: Unexpected error: %v", test.name, err)
+			continue
+		}
+
+		expected := map[string]interface{}{}
+		for field, value := range item {
+			expected[field] = value
+		}
+
+		for _, field := range test.mask.Unset {
+			delete(expected, field)
+		}
+
+		for field, value := range test.expected {
+			expected[field] = value
+		}
+
+		if !cmp.Equal(expected, got) {
+			t.Errorf("%s: Expected item: %v got: %v", test.name, expected, got)
+		}
+	}
+
+	t.Run("Test the item is not modified", func(t *testing.T) {
+		ApplyFieldMask(item, ports.FieldMask{
+			Set:      map[string]interface{}{"suit.color": "gold"},
+			AddToSet: map[string][]interface{}{"tags": {"new"}},
+		})
+
+		if item["suit"].(map[string]interface{})["color"] != "red" || len(item["tags"].([]interface{})) != 2 {
+			t.Errorf("Expected the original item to be kept got: %v", item)
+		}
+	})
+
+	t.Run("Test invalid masks", func(t *testing.T) {
+		masks := []ports.FieldMask{
+			{Set: map[string]interface{}{"id": "2"}},
+			{Set: map[string]interface{}{"suit": "none"}, Unset: []string{"suit.mark"}},
+			{Set: map[string]interface{}{"suit..mark": 1}},
+			{Set: map[string]interface{}{"$where": 1}},
+			{AddToSet: map[string][]interface{}{"name": {"a"}}},
+			{Push: map[string]ports.PushValues{"name.first": ports.Push("a")}},
+			{Inc: map[string]int64{"name": 1}},
+		}
+
+		for _, mask := range masks {
+			_, err := ApplyFieldMask(item, mask)
+			if _, ok := err.(ports.ErrInvalidFieldMask); !ok {
+				t.Errorf("Expected error of type ErrInvalidFieldMask for %+v got: %v", mask, err)
+			}
+		}
+	})
+}
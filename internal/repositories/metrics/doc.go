@@ -0,0 +1,3 @@
+// Package metrics implements a ports.Repository decorator reporting the latency
+// of every repository call as Prometheus metrics
+package metrics
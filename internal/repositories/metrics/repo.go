@@ -0,0 +1,171 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/sy-software/minerva-owl/internal/core/ports"
+)
+
+// Outcomes of a repository call
+const (
+	OUTCOME_OK        = "ok"
+	OUTCOME_NOT_FOUND = "not_found"
+	OUTCOME_ERROR     = "error"
+)
+
+var operationDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace: "owl",
+	Subsystem: "repository",
+	Name:      "operation_duration_seconds",
+	Help:      "Latency of the repository calls by collection, method and outcome",
+	Buckets:   prometheus.DefBuckets,
+}, []string{"collection", "method", "outcome"})
+
+// InstrumentedRepo is a ports.Repository decorator observing the latency of every call
+// per collection, method and outcome. Calls made inside a transaction are observed too,
+// WithTransaction itself is not since it has no collection
+type InstrumentedRepo struct {
+	repository ports.Repository
+}
+
+// NewInstrumentedRepo creates an InstrumentedRepo decorating repository
+func NewInstrumentedRepo(repository ports.Repository) *InstrumentedRepo {
+	return &InstrumentedRepo{
+		repository: repository,
+	}
+}
+
+// observe records the latency of a call started at start
+func observe(collection string, method string, start time.Time, err error) {
+	outcome := OUTCOME_OK
+	if errors.As(err, &ports.ErrItemNotFound{}) {
+		outcome = OUTCOME_NOT_FOUND
+	} else if err != nil {
+		outcome = OUTCOME_ERROR
+	}
+
+	operationDuration.WithLabelValues(collection, method, outcome).Observe(time.Since(start).Seconds())
+}
+
+// List returns a single page of items from the decorated repository
+func (repo *InstrumentedRepo) List(ctx context.Context, collection string, results interface{}, skip int, limit int, sort []ports.Sort, filters ...ports.Filter) error {
+	start := time.Now()
+	err := repo.repository.List(ctx, collection, results, skip, limit, sort, filters...)
+	observe(collection, "List", start, err)
+	return err
+}
+
+// ListPage returns a single page of items after a cursor from the decorated repository
+func (repo *InstrumentedRepo) ListPage(ctx context.Context, collection string, results interface{}, after string, limit int, filters ...ports.Filter) (ports.PageInfo, error) {
+	start := time.Now()
+	pageInfo, err := repo.repository.ListPage(ctx, collection, results, after, limit, filters...)
+	observe(collection, "ListPage", start, err)
+	return pageInfo, err
+}
+
+// Count returns how many items match the filters in the decorated repository
+func (repo *InstrumentedRepo) Count(ctx context.Context, collection string, filters ...ports.Filter) (int, error) {
+	start := time.Now()
+	count, err := repo.repository.Count(ctx, collection, filters...)
+	observe(collection, "Count", start, err)
+	return count, err
+}
+
+// Get returns a single item from the decorated repository
+func (repo *InstrumentedRepo) Get(ctx context.Context, collection string, id string, result interface{}) error {
+	start := time.Now()
+	err := repo.repository.Get(ctx, collection, id, result)
+	observe(collection, "Get", start, err)
+	return err
+}
+
+// GetMany returns the items with any of the ids from the decorated repository
+func (repo *InstrumentedRepo) GetMany(ctx context.Context, collection string, ids []string, results interface{}) error {
+	start := time.Now()
+	err := repo.repository.GetMany(ctx, collection, ids, results)
+	observe(collection, "GetMany", start, err)
+	return err
+}
+
+// GetOne returns a single item filtered from the decorated repository
+func (repo *InstrumentedRepo) GetOne(ctx context.Context, collection string, result interface{}, filters ...ports.Filter) error {
+	start := time.Now()
+	err := repo.repository.GetOne(ctx, collection, result, filters...)
+	observe(collection, "GetOne", start, err)
+	return err
+}
+
+// Create saves a new item into the decorated repository
+func (repo *InstrumentedRepo) Create(ctx context.Context, collection string, entity interface{}) (string, error) {
+	start := time.Now()
+	id, err := repo.repository.Create(ctx, collection, entity)
+	observe(collection, "Create", start, err)
+	return id, err
+}
+
+// Update saves the values of an existing item into the decorated repository
+func (repo *InstrumentedRepo) Update(ctx context.Context, collection string, id string, entity interface{}, omit ...string) error {
+	start := time.Now()
+	err := repo.repository.Update(ctx, collection, id, entity, omit...)
+	observe(collection, "Update", start, err)
+	return err
+}
+
+// Upsert updates or creates an item in the decorated repository
+func (repo *InstrumentedRepo) Upsert(ctx context.Context, collection string, filters []ports.Filter, entity interface{}, onInsertOnly ...string) (string, bool, error) {
+	start := time.Now()
+	id, created, err := repo.repository.Upsert(ctx, collection, filters, entity, onInsertOnly...)
+	observe(collection, "Upsert", start, err)
+	return id, created, err
+}
+
+// Patch changes the fields in mask of an item in the decorated repository
+func (repo *InstrumentedRepo) Patch(ctx context.Context, collection string, id string, mask ports.FieldMask) error {
+	start := time.Now()
+	err := repo.repository.Patch(ctx, collection, id, mask)
+	observe(collection, "Patch", start, err)
+	return err
+}
+
+// Delete removes an item from the decorated repository
+func (repo *InstrumentedRepo) Delete(ctx context.Context, collection string, id string) error {
+	start := time.Now()
+	err := repo.repository.Delete(ctx, collection, id)
+	observe(collection, "Delete", start, err)
+	return err
+}
+
+// CreateMany saves every entity into the decorated repository
+func (repo *InstrumentedRepo) CreateMany(ctx context.Context, collection string, entities []interface{}, stopOnError bool) ([]ports.BulkResult, error) {
+	start := time.Now()
+	bulkResults, err := repo.repository.CreateMany(ctx, collection, entities, stopOnError)
+	observe(collection, "CreateMany", start, err)
+	return bulkResults, err
+}
+
+// PatchMany applies every patch in the decorated repository
+func (repo *InstrumentedRepo) PatchMany(ctx context.Context, collection string, patches []ports.BulkPatch, stopOnError bool) ([]ports.BulkResult, error) {
+	start := time.Now()
+	bulkResults, err := repo.repository.PatchMany(ctx, collection, patches, stopOnError)
+	observe(collection, "PatchMany", start, err)
+	return bulkResults, err
+}
+
+// DeleteMany removes every item from the decorated repository
+func (repo *InstrumentedRepo) DeleteMany(ctx context.Context, collection string, ids []string, stopOnError bool) ([]ports.BulkResult, error) {
+	start := time.Now()
+	bulkResults, err := repo.repository.DeleteMany(ctx, collection, ids, stopOnError)
+	observe(collection, "DeleteMany", start, err)
+	return bulkResults, err
+}
+
+// WithTransaction calls fn with a transaction of the decorated repository whose calls are observed
+func (repo *InstrumentedRepo) WithTransaction(ctx context.Context, fn func(tx ports.Repository) error) error {
+	return repo.repository.WithTransaction(ctx, func(tx ports.Repository) error {
+		return fn(NewInstrumentedRepo(tx))
+	})
+}
@@ -0,0 +1,61 @@
+package metrics
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/sy-software/minerva-owl/internal/core/ports"
+	"github.com/sy-software/minerva-owl/internal/repositories/memory"
+	"github.com/sy-software/minerva-owl/internal/repositories/repotest"
+)
+
+func TestContract(t *testing.T) {
+	repotest.Run(t, repotest.Suite{
+		New: func(t *testing.T) ports.Repository {
+			return NewInstrumentedRepo(memory.NewMemoryRepo())
+		},
+	})
+}
+
+// sampleCount returns how many calls were observed with the labels
+func sampleCount(t *testing.T, labels ...string) uint64 {
+	metric := &dto.Metric{}
+	if err := operationDuration.WithLabelValues(labels...).(prometheus.Histogram).Write(metric); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	return metric.GetHistogram().GetSampleCount()
+}
+
+func TestInstrumentedRepo(t *testing.T) {
+	ctx := context.Background()
+	repo := NewInstrumentedRepo(memory.NewMemoryRepo())
+
+	id, _ := repo.Create(ctx, "metered", &repotest.Item{Name: "Tony Stark"})
+	repo.Get(ctx, "metered", id, &repotest.Item{})
+	repo.Get(ctx, "metered", "missing", &repotest.Item{})
+	repo.List(ctx, "metered", &[]repotest.Item{}, 0, 10, nil, ports.Filter{Name: "name", Operator: "unknown"})
+
+	repo.WithTransaction(ctx, func(tx ports.Repository) error {
+		return tx.Delete(ctx, "metered", id)
+	})
+
+	expected := []struct {
+		method  string
+		outcome string
+	}{
+		{"Create", OUTCOME_OK},
+		{"Get", OUTCOME_OK},
+		{"Get", OUTCOME_NOT_FOUND},
+		{"List", OUTCOME_ERROR},
+		{"Delete", OUTCOME_OK},
+	}
+
+	for _, e := range expected {
+		if got := sampleCount(t, "metered", e.method, e.outcome); got != 1 {
+			t.Errorf("Expected 1 %s call with outcome %q got: %d", e.method, e.outcome, got)
+		}
+	}
+}
@@ -0,0 +1,220 @@
+package mongodb
+
+import (
+	"context"
+
+	"github.com/sy-software/minerva-owl/internal/core/ports"
+	"github.com/sy-software/minerva-owl/internal/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// CreateMany saves every entity into the collection with a single bulk write, each one like Create.
+// The write is ordered when stopOnError is true so the entities after the first error are not saved
+func (repo *MongoRepo) CreateMany(ctx context.Context, collection string, entities []interface{}, stopOnError bool) ([]ports.BulkResult, error) {
+	results := make([]ports.BulkResult, len(entities))
+	models := []mongo.WriteModel{}
+	indexes := []int{}
+
+	for index, entity := range entities {
+		doc, err := toBSONDoc(entity)
+		if err != nil {
+			if failBulk(results, index, err, stopOnError) {
+				break
+			}
+
+			continue
+		}
+
+		id, doc := withId(doc)
+		results[index].Id = id
+		models = append(models, mongo.NewInsertOneModel().SetDocument(doc))
+		indexes = append(indexes, index)
+	}
+
+	if err := repo.bulkWrite(ctx, collection, models, indexes, results, stopOnError); err != nil {
+		return nil, err
+	}
+
+	for index := range results {
+		if results[index].Err != nil {
+			results[index].Id = ""
+		}
+	}
+
+	return results, nil
+}
+
+// PatchMany applies every patch to the collection with a single bulk write, each one like Patch
+//
+// Missing items are looked up before the write, an item deleted in between is reported as patched
+func (repo *MongoRepo) PatchMany(ctx context.Context, collection string, patches []ports.BulkPatch, stopOnError bool) ([]ports.BulkResult, error) {
+	ids := make([]string, len(patches))
+	for index, patch := range patches {
+		ids[index] = patch.Id
+	}
+
+	existing, err := repo.existingIds(ctx, collection, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]ports.BulkResult, len(patches))
+	models := []mongo.WriteModel{}
+	indexes := []int{}
+
+	for index, patch := range patches {
+		id := patch.Id
+		results[index].Id = id
+
+		update, err := formatFieldMask(patch.Mask)
+		if err == nil && !existing[id] {
+			err = ports.ErrItemNotFound{Id: &id, Model: collection}
+		}
+
+		if err != nil {
+			if failBulk(results, index, err, stopOnError) {
+				break
+			}
+
+			continue
+		}
+
+		// An empty update is rejected by MongoDB, the item is left as it is
+		if len(update) == 0 {
+			continue
+		}
+
+		models = append(models, mongo.NewUpdateOneModel().SetFilter(idFilter(id)).SetUpdate(update))
+		indexes = append(indexes, index)
+	}
+
+	if err := repo.bulkWrite(ctx, collection, models, indexes, results, stopOnError); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// DeleteMany removes every item with the ids from the collection with a single bulk write, each one like Delete
+//
+// Missing items are looked up before the write, an item deleted in between is reported as deleted
+func (repo *MongoRepo) DeleteMany(ctx context.Context, collection string, ids []string, stopOnError bool) ([]ports.BulkResult, error) {
+	existing, err := repo.existingIds(ctx, collection, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]ports.BulkResult, len(ids))
+	models := []mongo.WriteModel{}
+	indexes := []int{}
+
+	for index := range ids {
+		id := ids[index]
+		results[index].Id = id
+
+		if !existing[id] {
+			if failBulk(results, index, ports.ErrItemNotFound{Id: &id, Model: collection}, stopOnError) {
+				break
+			}
+
+			continue
+		}
+
+		models = append(models, mongo.NewDeleteOneModel().SetFilter(idFilter(id)))
+		indexes = append(indexes, index)
+	}
+
+	if err := repo.bulkWrite(ctx, collection, models, indexes, results, stopOnError); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// bulkWrite runs models in a single bulk write, the error of a failed model is stored in
+// the result of the item at the same position in indexes. Ordered writes stop at the first error
+func (repo *MongoRepo) bulkWrite(ctx context.Context, collection string, models []mongo.WriteModel, indexes []int, results []ports.BulkResult, ordered bool) error {
+	if len(models) == 0 {
+		return nil
+	}
+
+	utils.Logger(ctx).Debug().Msgf("%v - Bulk writing %d items", collection, len(models))
+	ctx, cancelFn := repo.context(ctx)
+	defer cancelFn()
+
+	_, err := repo.mongoGetCollection(collection).BulkWrite(ctx, models, options.BulkWrite().SetOrdered(ordered))
+	exception, ok := err.(mongo.BulkWriteException)
+	if err != nil && (!ok || len(exception.WriteErrors) == 0) {
+		utils.Logger(ctx).Debug().Err(err).Msgf("%v - Bulk write error", collection)
+		return err
+	}
+
+	for _, writeErr := range exception.WriteErrors {
+		index := indexes[writeErr.Index]
+		results[index].Err = writeErr.WriteError
+		if isDuplicatedId(writeErr.WriteError) {
+			results[index].Err = ports.ErrDuplicatedId{Id: results[index].Id, Model: collection}
+		}
+		if ordered {
+			ports.AbortBulk(results[index+1:])
+		}
+	}
+
+	return nil
+}
+
+// existingIds returns which of the ids belong to an item of the collection
+func (repo *MongoRepo) existingIds(ctx context.Context, collection string, ids []string) (map[string]bool, error) {
+	existing := map[string]bool{}
+	if len(ids) == 0 {
+		return existing, nil
+	}
+
+	values := make([]interface{}, len(ids))
+	for index, id := range ids {
+		values[index] = id
+	}
+
+	filter, err := formatFilters([]ports.Filter{ports.In("_id", values...)})
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancelFn := repo.context(ctx)
+	defer cancelFn()
+
+	opts := options.Find().SetProjection(bson.D{bson.E{Key: "_id", Value: 1}})
+	cursor, err := repo.mongoGetCollection(collection).Find(ctx, filter, opts)
+	if err != nil {
+		utils.Logger(ctx).Debug().Err(err).Msgf("%v - Bulk write error", collection)
+		return nil, err
+	}
+
+	defer cursor.Close(ctx)
+	for cursor.Next(ctx) {
+		item := struct {
+			Id interface{} `bson:"_id"`
+		}{}
+
+		if err := cursor.Decode(&item); err != nil {
+			return nil, err
+		}
+
+		existing[idString(item.Id)] = true
+	}
+
+	return existing, cursor.Err()
+}
+
+// failBulk stores err as the result of the item at index, the following items are
+// aborted when stopOnError is true. Returns true when no more items must be processed
+func failBulk(results []ports.BulkResult, index int, err error, stopOnError bool) bool {
+	results[index].Err = err
+	if stopOnError {
+		ports.AbortBulk(results[index+1:])
+	}
+
+	return stopOnError
+}
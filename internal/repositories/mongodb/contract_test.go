@@ -0,0 +1,42 @@
+package mongodb
+
+import (
+	"os"
+	"testing"
+
+	"github.com/sy-software/minerva-owl/internal/core/domain"
+	"github.com/sy-software/minerva-owl/internal/core/ports"
+	"github.com/sy-software/minerva-owl/internal/repositories/repotest"
+)
+
+// TestContract runs against the MongoDB server in OWL_TEST_MONGO_HOST, the database is
+// OWL_TEST_MONGO_DB or owl_test. Every run creates new collections which are not dropped.
+// The server must be a replica set for the transaction tests
+func TestContract(t *testing.T) {
+	host := os.Getenv("OWL_TEST_MONGO_HOST")
+	if host == "" {
+		t.Skip("Set OWL_TEST_MONGO_HOST to run the repository contract tests against MongoDB")
+	}
+
+	config := domain.DefaultConfig()
+	config.MongoDBConfig.Host = host
+	config.MongoDBConfig.DB = os.Getenv("OWL_TEST_MONGO_DB")
+	if config.MongoDBConfig.DB == "" {
+		config.MongoDBConfig.DB = "owl_test"
+	}
+
+	connections := NewConnections()
+	defer connections.CloseAll()
+
+	db, err := connections.Get(DEFAULT_CONNECTION, config.MongoDBConfig)
+	if err != nil {
+		t.Fatalf("Can't connect to MongoDB: %v", err)
+	}
+
+	repotest.Run(t, repotest.Suite{
+		New: func(t *testing.T) ports.Repository {
+			repo, _ := NewMongoRepo(db, &config)
+			return repo
+		},
+	})
+}
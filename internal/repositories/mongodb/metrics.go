@@ -0,0 +1,54 @@
+package mongodb
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.mongodb.org/mongo-driver/event"
+)
+
+var (
+	poolMaxSize = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "owl",
+		Subsystem: "mongo_pool",
+		Name:      "max_size",
+		Help:      "Max number of connections of each MongoDB connection pool",
+	}, []string{"connection"})
+	poolConnections = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "owl",
+		Subsystem: "mongo_pool",
+		Name:      "connections",
+		Help:      "Open connections of each MongoDB connection pool",
+	}, []string{"connection"})
+	poolInUse = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "owl",
+		Subsystem: "mongo_pool",
+		Name:      "in_use",
+		Help:      "Connections checked out of each MongoDB connection pool",
+	}, []string{"connection"})
+	poolCheckoutFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "owl",
+		Subsystem: "mongo_pool",
+		Name:      "checkout_failures_total",
+		Help:      "Failed connection check outs of each MongoDB connection pool",
+	}, []string{"connection"})
+)
+
+// poolMonitor updates the pool metrics of the named connection
+func poolMonitor(name string) *event.PoolMonitor {
+	return &event.PoolMonitor{
+		Event: func(evt *event.PoolEvent) {
+			switch evt.Type {
+			case event.ConnectionCreated:
+				poolConnections.WithLabelValues(name).Inc()
+			case event.ConnectionClosed:
+				poolConnections.WithLabelValues(name).Dec()
+			case event.GetSucceeded:
+				poolInUse.WithLabelValues(name).Inc()
+			case event.ConnectionReturned:
+				poolInUse.WithLabelValues(name).Dec()
+			case event.GetFailed:
+				poolCheckoutFailures.WithLabelValues(name).Inc()
+			}
+		},
+	}
+}
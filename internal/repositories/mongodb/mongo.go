@@ -3,6 +3,7 @@ package mongodb
 import (
 	"context"
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 
@@ -10,54 +11,137 @@ import (
 	"github.com/sy-software/minerva-owl/internal/core/domain"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
 )
 
+// DEFAULT_CONNECTION is the name of the connection returned by GetMongoDB
+const DEFAULT_CONNECTION = "default"
+
 type MongoDB struct {
 	client *mongo.Client
+	config domain.MDBConfig
+}
+
+// Connections keeps a set of named connections to MongoDB instances
+type Connections struct {
+	instances map[string]*MongoDB
+	mutex     sync.Mutex
 }
 
-var mdbInstance *MongoDB
-var mdbOnce sync.Once
+// NewConnections creates an empty instance of Connections
+func NewConnections() *Connections {
+	return &Connections{
+		instances: map[string]*MongoDB{},
+	}
+}
+
+var defaultConnections = NewConnections()
+
+// DefaultConnections returns the process wide connection manager
+func DefaultConnections() *Connections {
+	return defaultConnections
+}
 
-// GetMongoDB creates or returns a singleton connection to a MongoDB instance
+// GetMongoDB creates or returns the default connection to a MongoDB instance
 func GetMongoDB(config domain.MDBConfig) (*MongoDB, error) {
-	var dbErr error
-	mdbOnce.Do(func() {
-		log.Info().Msg("Initializing Mongo DB connection")
-		uri := "mongodb://"
+	return defaultConnections.Get(DEFAULT_CONNECTION, config)
+}
+
+// Get returns the connection registered with name, if it doesn't exist a new
+// connection is created using config
+func (conns *Connections) Get(name string, config domain.MDBConfig) (*MongoDB, error) {
+	conns.mutex.Lock()
+	defer conns.mutex.Unlock()
 
-		if len(config.Username) > 0 {
-			uri += config.Username + ":" + config.Password + "@"
-		}
+	if instance, exists := conns.instances[name]; exists {
+		return instance, nil
+	}
 
-		uri += fmt.Sprintf("%s:%d/", config.Host, config.Port)
+	instance, err := connect(name, config)
+	if err != nil {
+		return nil, err
+	}
 
-		clientOpts := options.Client()
-		maxPoolSize := uint64(config.MaxPoolSize)
-		clientOpts.MaxPoolSize = &maxPoolSize
+	conns.instances[name] = instance
+	return instance, nil
+}
 
-		client, err := mongo.NewClient(clientOpts.ApplyURI(uri))
+// Names returns the sorted names of all open connections
+func (conns *Connections) Names() []string {
+	conns.mutex.Lock()
+	defer conns.mutex.Unlock()
 
-		if err != nil {
-			dbErr = err
-		}
+	names := make([]string, 0, len(conns.instances))
+	for name := range conns.instances {
+		names = append(names, name)
+	}
 
-		ctx, cancelFn := context.WithTimeout(context.Background(), config.ConnectTimeout*time.Second)
-		defer cancelFn()
-		err = client.Connect(ctx)
+	sort.Strings(names)
+	return names
+}
 
-		if err != nil {
-			dbErr = err
-		}
+// Close disconnects and forgets the connection registered with name
+func (conns *Connections) Close(name string) {
+	conns.mutex.Lock()
+	instance, exists := conns.instances[name]
+	delete(conns.instances, name)
+	conns.mutex.Unlock()
 
-		mdbInstance = &MongoDB{
-			client: client,
-		}
+	if exists {
+		instance.Close()
+	}
+}
 
-		log.Info().Msg("DB Connected")
-	})
+// CloseAll disconnects every open connection
+func (conns *Connections) CloseAll() {
+	for _, name := range conns.Names() {
+		conns.Close(name)
+	}
+}
 
-	return mdbInstance, dbErr
+// connect creates a new connection to a MongoDB instance
+func connect(name string, config domain.MDBConfig) (*MongoDB, error) {
+	log.Info().Msgf("Initializing Mongo DB connection: %s", name)
+	uri := "mongodb://"
+
+	if len(config.Username) > 0 {
+		uri += config.Username + ":" + config.Password + "@"
+	}
+
+	uri += fmt.Sprintf("%s:%d/", config.Host, config.Port)
+
+	clientOpts := options.Client()
+	maxPoolSize := uint64(config.MaxPoolSize)
+	clientOpts.MaxPoolSize = &maxPoolSize
+	clientOpts.SetPoolMonitor(poolMonitor(name))
+	poolMaxSize.WithLabelValues(name).Set(float64(maxPoolSize))
+
+	client, err := mongo.NewClient(clientOpts.ApplyURI(uri))
+
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancelFn := context.WithTimeout(context.Background(), config.ConnectTimeout*time.Second)
+	defer cancelFn()
+	err = client.Connect(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	log.Info().Msgf("DB Connected: %s", name)
+	return &MongoDB{
+		client: client,
+		config: config,
+	}, nil
+}
+
+// Ping checks the database is reachable within the configured timeout or the deadline of ctx
+func (mdb *MongoDB) Ping(ctx context.Context) error {
+	ctx, cancelFn := context.WithTimeout(ctx, mdb.config.Timeout*time.Second)
+	defer cancelFn()
+	return mdb.client.Ping(ctx, readpref.Primary())
 }
 
 // Close should always be called when the process using the connection ends
@@ -2,56 +2,206 @@ package mongodb
 
 import (
 	"context"
+	"encoding/base64"
 	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/rs/zerolog/log"
 	"github.com/sy-software/minerva-owl/internal/core/domain"
 	"github.com/sy-software/minerva-owl/internal/core/ports"
+	"github.com/sy-software/minerva-owl/internal/utils"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// UNIQUE_INDEXES are the unique indexes created by NewMongoRepo, by collection
+var UNIQUE_INDEXES = map[string][]string{
+	domain.USER_COL_NAME: {"username"},
+}
+
 // MongoRepo is an implementation of ports.Repo interface with MongoDB as datasource
 type MongoRepo struct {
 	db          *MongoDB
 	collections map[string]*mongo.Collection
+	mutex       sync.RWMutex
 	config      *domain.Config
+	// indexes holds the unique indexes already created, it's shared with the transactions
+	indexes *uniqueIndexes
+	// session is the transaction used by every operation of the repository returned by WithTransaction
+	session mongo.Session
 }
 
-// NewMongoRepo creates an instance of MongoRepo
+// uniqueIndexes are the results of creating the unique indexes of a repository, by collection
+// and fields. Indexes rejected because of duplicated items are not created again
+type uniqueIndexes struct {
+	mutex   sync.Mutex
+	results map[string]error
+}
+
+// NewMongoRepo creates an instance of MongoRepo and the UNIQUE_INDEXES
 func NewMongoRepo(db *MongoDB, config *domain.Config) (*MongoRepo, error) {
-	return &MongoRepo{
+	repo := &MongoRepo{
 		db:          db,
 		collections: map[string]*mongo.Collection{},
 		config:      config,
-	}, nil
+		indexes:     &uniqueIndexes{results: map[string]error{}},
+	}
+
+	for collection, fields := range UNIQUE_INDEXES {
+		if err := repo.ensureUniqueIndex(context.Background(), collection, fields); err != nil {
+			log.Error().Err(err).Msgf("%v - Can't create unique index on: %v", collection, fields)
+			return nil, err
+		}
+	}
+
+	return repo, nil
+}
+
+// ensureUniqueIndex creates an unique index on fields of collection unless it was already created
+func (repo *MongoRepo) ensureUniqueIndex(ctx context.Context, collection string, fields []string) error {
+	name := collection + "/" + strings.Join(fields, ",")
+
+	repo.indexes.mutex.Lock()
+	defer repo.indexes.mutex.Unlock()
+
+	if err, exists := repo.indexes.results[name]; exists {
+		return err
+	}
+
+	keys := bson.D{}
+	for _, field := range fields {
+		keys = append(keys, bson.E{Key: field, Value: 1})
+	}
+
+	// Indexes can't be created inside a transaction, so the session isn't used
+	if repo.db.config.Timeout > 0 {
+		var cancelFn context.CancelFunc
+		ctx, cancelFn = context.WithTimeout(ctx, repo.db.config.Timeout*time.Second)
+		defer cancelFn()
+	}
+
+	_, err := repo.mongoGetCollection(collection).Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    keys,
+		Options: options.Index().SetUnique(true),
+	})
+
+	if err == nil || mongo.IsDuplicateKeyError(err) {
+		repo.indexes.results[name] = err
+	}
+
+	return err
+}
+
+// isDuplicatedId tells if err was caused by inserting the id of an existing item,
+// other unique indexes also fail with a duplicated key error
+func isDuplicatedId(err error) bool {
+	return strings.Contains(err.Error(), "E11000") && strings.Contains(err.Error(), " index: _id_ ")
+}
+
+// upsertKeys returns the sorted fields of filters when all of them are equality filters
+// on fields other than _id, those fields identify the item of an upsert
+func upsertKeys(filters []ports.Filter) ([]string, bool) {
+	keys := []string{}
+	for _, filter := range filters {
+		if filter.Op() != ports.OP_EQ || filter.Name == "_id" || filter.Name == "id" {
+			return nil, false
+		}
+
+		keys = append(keys, filter.Name)
+	}
+
+	sort.Strings(keys)
+	return keys, len(keys) > 0
 }
 
 // mongoGetCollection checks if we have a reference of a given collection, if no creates a new one and returns it
 func (repo *MongoRepo) mongoGetCollection(collection string) *mongo.Collection {
+	repo.mutex.RLock()
 	value, exists := repo.collections[collection]
+	repo.mutex.RUnlock()
 
 	if exists {
 		log.Debug().Msgf("Reusing connection for: %v", collection)
 		return value
-	} else {
-		log.Debug().Msgf("Getting new collection connection for: %v", collection)
-		value = repo.db.client.
-			Database(repo.config.MongoDBConfig.DB).
-			Collection(collection)
+	}
 
-		repo.collections[collection] = value
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+
+	if value, exists := repo.collections[collection]; exists {
 		return value
 	}
+
+	log.Debug().Msgf("Getting new collection connection for: %v", collection)
+	value = repo.db.client.
+		Database(repo.db.config.DB).
+		Collection(collection)
+
+	repo.collections[collection] = value
+	return value
+}
+
+// context returns the context of a single operation limited by the configured timeout,
+// bound to the current session inside WithTransaction
+func (repo *MongoRepo) context(ctx context.Context) (context.Context, context.CancelFunc) {
+	if repo.session != nil {
+		ctx = mongo.NewSessionContext(ctx, repo.session)
+	}
+
+	if repo.db.config.Timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+
+	return context.WithTimeout(ctx, repo.db.config.Timeout*time.Second)
+}
+
+// WithTransaction runs every operation of fn in a multi-document transaction of a new session,
+// committed when fn returns nil and aborted otherwise. Nested calls run in the same transaction
+//
+// Transactions require a replica set or a sharded cluster. The driver runs fn again when
+// the transaction fails with a transient error, so fn must not have other side effects
+func (repo *MongoRepo) WithTransaction(ctx context.Context, fn func(tx ports.Repository) error) error {
+	if repo.session != nil {
+		return fn(repo)
+	}
+
+	session, err := repo.db.client.StartSession()
+	if err != nil {
+		utils.Logger(ctx).Debug().Err(err).Msg("Transaction error")
+		return err
+	}
+	defer session.EndSession(context.Background())
+
+	_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		return nil, fn(&MongoRepo{
+			db:          repo.db,
+			collections: map[string]*mongo.Collection{},
+			config:      repo.config,
+			indexes:     repo.indexes,
+			session:     session,
+		})
+	})
+
+	if err != nil {
+		utils.Logger(ctx).Debug().Err(err).Msg("Transaction error")
+	}
+
+	return err
 }
 
 // List stores into results a list of items from the given collection applying the filters
 // results must be a pointer to an Slice of an struct with bson tags for serialization
-func (repo *MongoRepo) List(collection string, results interface{}, skip int, limit int, filters ...ports.Filter) error {
-	ctx, cancelFn := context.WithTimeout(context.Background(), 10*time.Second)
+//
+// Items are always sorted by _id after the provided sort so pages don't overlap
+func (repo *MongoRepo) List(ctx context.Context, collection string, results interface{}, skip int, limit int, sort []ports.Sort, filters ...ports.Filter) error {
+	ctx, cancelFn := repo.context(ctx)
 	defer cancelFn()
 
 	limit64 := int64(limit)
@@ -60,51 +210,130 @@ func (repo *MongoRepo) List(collection string, results interface{}, skip int, li
 	dbFilters, err := formatFilters(filters)
 
 	if err != nil {
-		log.Debug().Err(err).Msgf("%v - List error", collection)
+		utils.Logger(ctx).Debug().Err(err).Msgf("%v - List error", collection)
 		return err
 	}
 
-	log.Debug().Msgf("%v - Listing elements", collection)
+	utils.Logger(ctx).Debug().Msgf("%v - Listing elements", collection)
 	cur, err := repo.mongoGetCollection(collection).Find(ctx, dbFilters, &options.FindOptions{
 		Limit: &limit64,
 		Skip:  &skip64,
+		Sort:  formatSort(sort),
 	})
 
 	if err != nil {
-		log.Debug().Err(err).Msgf("%v - List error", collection)
+		utils.Logger(ctx).Debug().Err(err).Msgf("%v - List error", collection)
 		return err
 	}
 
-	ctx, cancelFn = context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancelFn()
-
 	if err = cur.All(ctx, results); err != nil {
-		log.Debug().Err(err).Msgf("%v - List error", collection)
+		utils.Logger(ctx).Debug().Err(err).Msgf("%v - List error", collection)
 		return err
 	}
 
 	return err
 }
 
-// Get stores into result an item from collection with _id equals to id
-// result must be a pointer to an instance of a struct with bson tags for serialization
-func (repo *MongoRepo) Get(collection string, id string, result interface{}) error {
-	log.Debug().Msgf("%v - Finding element with _id: %q", collection, id)
+// ListPage stores into results up to limit items from the given collection after the cursor,
+// items are sorted by _id so items with ObjectID ids are added at the end of the list, while items
+// with random ids, like UUIDs, can be added before the cursor and won't show up in later pages.
+// Legacy ObjectID ids sort after every string id, so both kinds aren't paged together.
+// results must be a pointer to an Slice of an struct with bson tags for serialization
+func (repo *MongoRepo) ListPage(ctx context.Context, collection string, results interface{}, after string, limit int, filters ...ports.Filter) (ports.PageInfo, error) {
+	pageInfo := ports.PageInfo{
+		Cursors:         []string{},
+		HasPreviousPage: after != "",
+	}
 
-	ctx, cancelFn := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancelFn := repo.context(ctx)
 	defer cancelFn()
 
-	objectId, err := primitive.ObjectIDFromHex(id)
+	if after != "" {
+		lastId, err := decodeCursor(after)
+		if err != nil {
+			return pageInfo, err
+		}
+
+		// Copy the filters so the array of the caller is never written
+		filters = append(append(make([]ports.Filter, 0, len(filters)+1), filters...), ports.Gt("_id", lastId))
+	}
 
+	dbFilters, err := formatFilters(filters)
 	if err != nil {
-		log.Debug().Err(err).Msgf("%v - Get error", collection)
-		return nil
+		utils.Logger(ctx).Debug().Err(err).Msgf("%v - List page error", collection)
+		return pageInfo, err
 	}
 
-	rawResult := repo.mongoGetCollection(collection).FindOne(ctx, bson.D{
-		primitive.E{Key: "_id", Value: objectId},
+	// One extra item tells us if there is a next page
+	limit64 := int64(limit + 1)
+
+	utils.Logger(ctx).Debug().Msgf("%v - Listing page after: %q", collection, after)
+	cur, err := repo.mongoGetCollection(collection).Find(ctx, dbFilters, &options.FindOptions{
+		Limit: &limit64,
+		Sort:  bson.D{primitive.E{Key: "_id", Value: 1}},
 	})
 
+	if err != nil {
+		utils.Logger(ctx).Debug().Err(err).Msgf("%v - List page error", collection)
+		return pageInfo, err
+	}
+
+	var docs []bson.Raw
+	if err = cur.All(ctx, &docs); err != nil {
+		utils.Logger(ctx).Debug().Err(err).Msgf("%v - List page error", collection)
+		return pageInfo, err
+	}
+
+	if len(docs) > limit {
+		pageInfo.HasNextPage = true
+		docs = docs[:limit]
+	}
+
+	resultsVal := reflect.ValueOf(results).Elem()
+	elementType := resultsVal.Type().Elem()
+	for _, doc := range docs {
+		element := reflect.New(elementType)
+		if err := bson.Unmarshal(doc, element.Interface()); err != nil {
+			return pageInfo, err
+		}
+
+		resultsVal.Set(reflect.Append(resultsVal, element.Elem()))
+		pageInfo.EndCursor = encodeCursor(doc.Lookup("_id"))
+		pageInfo.Cursors = append(pageInfo.Cursors, pageInfo.EndCursor)
+	}
+
+	return pageInfo, nil
+}
+
+// Count returns how many items from collection match the filters
+func (repo *MongoRepo) Count(ctx context.Context, collection string, filters ...ports.Filter) (int, error) {
+	ctx, cancelFn := repo.context(ctx)
+	defer cancelFn()
+
+	dbFilters, err := formatFilters(filters)
+	if err != nil {
+		utils.Logger(ctx).Debug().Err(err).Msgf("%v - Count error", collection)
+		return 0, err
+	}
+
+	count, err := repo.mongoGetCollection(collection).CountDocuments(ctx, dbFilters)
+	if err != nil {
+		utils.Logger(ctx).Debug().Err(err).Msgf("%v - Count error", collection)
+	}
+
+	return int(count), err
+}
+
+// Get stores into result an item from collection with _id equals to id
+// result must be a pointer to an instance of a struct with bson tags for serialization
+func (repo *MongoRepo) Get(ctx context.Context, collection string, id string, result interface{}) error {
+	utils.Logger(ctx).Debug().Msgf("%v - Finding element with _id: %q", collection, id)
+
+	ctx, cancelFn := repo.context(ctx)
+	defer cancelFn()
+
+	rawResult := repo.mongoGetCollection(collection).FindOne(ctx, idFilter(id))
+
 	if rawResult.Err() == mongo.ErrNoDocuments {
 		return ports.ErrItemNotFound{
 			Id:    &id,
@@ -113,39 +342,49 @@ func (repo *MongoRepo) Get(collection string, id string, result interface{}) err
 	}
 
 	if rawResult.Err() != nil {
-		log.Debug().Err(rawResult.Err()).Msgf("%v - Get error", collection)
+		utils.Logger(ctx).Debug().Err(rawResult.Err()).Msgf("%v - Get error", collection)
 		return rawResult.Err()
 	}
 
 	return rawResult.Decode(result)
 }
 
+// GetMany appends to results the items from collection with any of the ids
+// results must be a pointer to an Slice of an struct with bson tags for serialization
+func (repo *MongoRepo) GetMany(ctx context.Context, collection string, ids []string, results interface{}) error {
+	if len(ids) == 0 {
+		return ctx.Err()
+	}
+
+	return repo.List(ctx, collection, results, 0, len(ids), nil, ports.InIds(ids...))
+}
+
 // Get stores into result an item from collection matching the filters
 // result must be a pointer to an instance of a struct with bson tags for serialization
-func (repo *MongoRepo) GetOne(collection string, result interface{}, filters ...ports.Filter) error {
-	log.Debug().Msgf("%v - Finding element with filters: %+v", collection, filters)
+func (repo *MongoRepo) GetOne(ctx context.Context, collection string, result interface{}, filters ...ports.Filter) error {
+	utils.Logger(ctx).Debug().Msgf("%v - Finding element with filters: %+v", collection, filters)
 
-	ctx, cancelFn := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancelFn := repo.context(ctx)
 	defer cancelFn()
 
 	dbFilters, err := formatFilters(filters)
 
 	if err != nil {
-		log.Debug().Err(err).Msgf("%v - Get error", collection)
-		return nil
+		utils.Logger(ctx).Debug().Err(err).Msgf("%v - Get error", collection)
+		return err
 	}
 
 	rawResult := repo.mongoGetCollection(collection).FindOne(ctx, dbFilters)
 
 	if rawResult.Err() == mongo.ErrNoDocuments {
-		log.Debug().Err(rawResult.Err()).Msgf("%v - Get error", collection)
+		utils.Logger(ctx).Debug().Err(rawResult.Err()).Msgf("%v - Get error", collection)
 		return ports.ErrItemNotFound{
 			Model: collection,
 		}
 	}
 
 	if rawResult.Err() != nil {
-		log.Debug().Err(rawResult.Err()).Msgf("%v - Get error", collection)
+		utils.Logger(ctx).Debug().Err(rawResult.Err()).Msgf("%v - Get error", collection)
 		return rawResult.Err()
 	}
 
@@ -154,18 +393,31 @@ func (repo *MongoRepo) GetOne(collection string, result interface{}, filters ...
 
 // Create saves the serialized version of entity into the collection
 // entity must be an instance of a struct with bson tags for serialization
-func (repo *MongoRepo) Create(collection string, entity interface{}) (string, error) {
-	log.Debug().Msgf("%v - Saving: %v", collection, entity)
-	ctx, cancelFn := context.WithTimeout(context.Background(), 10*time.Second)
+//
+// The _id of entity is stored as it is, when it's empty the hex string of
+// a new ObjectID is used. Ids are always stored as strings
+func (repo *MongoRepo) Create(ctx context.Context, collection string, entity interface{}) (string, error) {
+	utils.Logger(ctx).Debug().Msgf("%v - Saving: %v", collection, entity)
+	ctx, cancelFn := repo.context(ctx)
 	defer cancelFn()
 
-	result, err := repo.mongoGetCollection(collection).InsertOne(ctx, entity)
+	doc, err := toBSONDoc(entity)
+	if err != nil {
+		return "", err
+	}
 
+	id, doc := withId(doc)
+	_, err = repo.mongoGetCollection(collection).InsertOne(ctx, doc)
 	if err != nil {
+		utils.Logger(ctx).Debug().Err(err).Msgf("%v - Create error", collection)
+		if isDuplicatedId(err) {
+			return "", ports.ErrDuplicatedId{Id: id, Model: collection}
+		}
+
 		return "", err
 	}
 
-	return result.InsertedID.(primitive.ObjectID).Hex(), nil
+	return id, nil
 }
 
 // Update saves the values of entity to the item with id from the collection
@@ -173,63 +425,177 @@ func (repo *MongoRepo) Create(collection string, entity interface{}) (string, er
 //
 // If you whish to omit some fields from entity from saving you can pass the field
 // names into the final omit parameter
-func (repo *MongoRepo) Update(collection string, id string, entity interface{}, omit ...string) error {
-	log.Debug().Msgf("%v - Saving: %v", collection, entity)
-	ctx, cancelFn := context.WithTimeout(context.Background(), 10*time.Second)
+func (repo *MongoRepo) Update(ctx context.Context, collection string, id string, entity interface{}, omit ...string) error {
+	utils.Logger(ctx).Debug().Msgf("%v - Saving: %v", collection, entity)
+	ctx, cancelFn := repo.context(ctx)
 	defer cancelFn()
 
-	objectId, err := primitive.ObjectIDFromHex(id)
-
-	if err != nil {
-		return err
-	}
-
 	bsonDoc, err := toBSONDoc(entity, omit...)
 	if err != nil {
 		return err
 	}
 
-	result, err := repo.mongoGetCollection(collection).UpdateOne(ctx, bson.D{
-		primitive.E{Key: "_id", Value: objectId},
-	}, bson.D{
+	result, err := repo.mongoGetCollection(collection).UpdateOne(ctx, idFilter(id), bson.D{
 		primitive.E{
 			Key:   "$set",
 			Value: bsonDoc,
 		},
 	})
 
-	log.Debug().Msgf("Update result: %+v", result)
+	if err != nil {
+		utils.Logger(ctx).Debug().Err(err).Msgf("%v - Update error", collection)
+		return err
+	}
 
-	return err
+	utils.Logger(ctx).Debug().Msgf("Update result: %+v", result)
+	if result.MatchedCount == 0 {
+		return ports.ErrItemNotFound{
+			Id:    &id,
+			Model: collection,
+		}
+	}
+
+	return nil
 }
 
-// Delete removes item with id from collection
-func (repo *MongoRepo) Delete(collection string, id string) error {
-	log.Debug().Msgf("%v - Deleting by id: %q", collection, id)
-	ctx, cancelFn := context.WithTimeout(context.Background(), 10*time.Second)
+// Upsert saves the values of entity to the first item from collection matching the filters,
+// except the fields in onInsertOnly, or creates entity if none matches
+//
+// A single findAndModify with upsert is used. When every filter is an equality filter, an unique
+// index is created on the filtered fields first, so only one of two concurrent calls can insert
+// the item, the other one fails with a duplicated key error and it's retried once as an update
+func (repo *MongoRepo) Upsert(ctx context.Context, collection string, filters []ports.Filter, entity interface{}, onInsertOnly ...string) (string, bool, error) {
+	utils.Logger(ctx).Debug().Msgf("%v - Upserting with filters %+v: %v", collection, filters, entity)
+	if keys, ok := upsertKeys(filters); ok {
+		if err := repo.ensureUniqueIndex(ctx, collection, keys); err != nil {
+			utils.Logger(ctx).Warn().Err(err).Msgf("%v - Can't create unique index on: %v", collection, keys)
+		}
+	}
+
+	id, created, err := repo.upsert(ctx, collection, filters, entity, onInsertOnly)
+	if mongo.IsDuplicateKeyError(err) {
+		utils.Logger(ctx).Debug().Err(err).Msgf("%v - Retrying upsert", collection)
+		id, created, err = repo.upsert(ctx, collection, filters, entity, onInsertOnly)
+	}
+
+	return id, created, err
+}
+
+// upsert runs a single findAndModify with upsert, see Upsert
+func (repo *MongoRepo) upsert(ctx context.Context, collection string, filters []ports.Filter, entity interface{}, onInsertOnly []string) (string, bool, error) {
+	ctx, cancelFn := repo.context(ctx)
 	defer cancelFn()
 
-	objectId, err := primitive.ObjectIDFromHex(id)
+	dbFilters, err := formatFilters(filters)
+	if err != nil {
+		utils.Logger(ctx).Debug().Err(err).Msgf("%v - Upsert error", collection)
+		return "", false, err
+	}
+
+	doc, err := toBSONDoc(entity)
+	if err != nil {
+		return "", false, err
+	}
+
+	id, update := formatUpsert(doc, onInsertOnly)
+	opts := options.FindOneAndUpdate().
+		SetUpsert(true).
+		SetReturnDocument(options.Before).
+		SetProjection(bson.D{bson.E{Key: "_id", Value: 1}})
+
+	result := repo.mongoGetCollection(collection).FindOneAndUpdate(ctx, dbFilters, update, opts)
+
+	// Without a previous document the item was inserted
+	if result.Err() == mongo.ErrNoDocuments {
+		return id, true, nil
+	}
+
+	if result.Err() != nil {
+		utils.Logger(ctx).Debug().Err(result.Err()).Msgf("%v - Upsert error", collection)
+		return "", false, result.Err()
+	}
+
+	previous := struct {
+		Id interface{} `bson:"_id"`
+	}{}
 
+	if err := result.Decode(&previous); err != nil {
+		return "", false, err
+	}
+
+	return idString(previous.Id), false, nil
+}
+
+// Patch changes the fields in mask of the item with id from the collection
+// using the $set and $unset operators
+func (repo *MongoRepo) Patch(ctx context.Context, collection string, id string, mask ports.FieldMask) error {
+	utils.Logger(ctx).Debug().Msgf("%v - Patching %q: %+v", collection, id, mask)
+	ctx, cancelFn := repo.context(ctx)
+	defer cancelFn()
+
+	update, err := formatFieldMask(mask)
 	if err != nil {
+		utils.Logger(ctx).Debug().Err(err).Msgf("%v - Patch error", collection)
 		return err
 	}
 
-	result, err := repo.mongoGetCollection(collection).DeleteOne(ctx, bson.D{
-		primitive.E{Key: "_id", Value: objectId},
-	})
+	// An empty update is rejected by MongoDB, only the item existence is checked
+	matched := int64(0)
+	if len(update) == 0 {
+		matched, err = repo.mongoGetCollection(collection).CountDocuments(ctx, idFilter(id))
+	} else {
+		var result *mongo.UpdateResult
+		result, err = repo.mongoGetCollection(collection).UpdateOne(ctx, idFilter(id), update)
+		if result != nil {
+			matched = result.MatchedCount
+		}
+	}
 
-	log.Debug().Msgf("%v - Delete result: %+v", collection, result)
+	if err != nil {
+		utils.Logger(ctx).Debug().Err(err).Msgf("%v - Patch error", collection)
+		return err
+	}
 
-	return err
+	if matched == 0 {
+		return ports.ErrItemNotFound{
+			Id:    &id,
+			Model: collection,
+		}
+	}
+
+	return nil
+}
+
+// Delete removes item with id from collection
+func (repo *MongoRepo) Delete(ctx context.Context, collection string, id string) error {
+	utils.Logger(ctx).Debug().Msgf("%v - Deleting by id: %q", collection, id)
+	ctx, cancelFn := repo.context(ctx)
+	defer cancelFn()
+
+	result, err := repo.mongoGetCollection(collection).DeleteOne(ctx, idFilter(id))
+
+	if err != nil {
+		utils.Logger(ctx).Debug().Err(err).Msgf("%v - Delete error", collection)
+		return err
+	}
+
+	utils.Logger(ctx).Debug().Msgf("%v - Delete result: %+v", collection, result)
+	if result.DeletedCount == 0 {
+		return ports.ErrItemNotFound{
+			Id:    &id,
+			Model: collection,
+		}
+	}
+
+	return nil
 }
 
-// toBSONDoc marshals the value of v into a bson.D and omits the fields matching a name from omit
+// toBSONDoc marshals the value of v into a bson.D and omits the fields matching a name from omit,
+// nested documents and arrays are kept as values of their top level field
 func toBSONDoc(v interface{}, omit ...string) (bson.D, error) {
-	// TODO: Support nested documents and arrays
 	data, err := bson.Marshal(v)
 	if err != nil {
-		return bson.D{}, nil
+		return bson.D{}, err
 	}
 
 	var doc bson.D
@@ -256,47 +622,298 @@ func toBSONDoc(v interface{}, omit ...string) (bson.D, error) {
 	return filtered, nil
 }
 
-// formatFilters takes a generic list of filters and converts them into a MongoDB query
+// formatFieldMask converts a field mask into a MongoDB update document,
+// every operator of the mask is mapped to the MongoDB operator with the same name
+func formatFieldMask(mask ports.FieldMask) (bson.D, error) {
+	if err := mask.Validate(); err != nil {
+		return bson.D{}, err
+	}
+
+	operators := mask.Operators()
+	fields := map[string]bson.D{}
+	for _, field := range mask.Fields() {
+		operator := operators[field]
+		var value interface{}
+		switch operator {
+		case ports.UPDATE_SET:
+			value = mask.Set[field]
+		case ports.UPDATE_UNSET:
+			value = ""
+		case ports.UPDATE_ADD_TO_SET:
+			value = bson.D{bson.E{Key: "$each", Value: bson.A(mask.AddToSet[field])}}
+		case ports.UPDATE_PULL:
+			value = bson.D{bson.E{Key: "$in", Value: bson.A(mask.Pull[field])}}
+		case ports.UPDATE_PUSH:
+			push := mask.Push[field]
+			each := bson.D{bson.E{Key: "$each", Value: bson.A(push.Values)}}
+			if push.Position != nil {
+				each = append(each, bson.E{Key: "$position", Value: *push.Position})
+			}
+			value = each
+		case ports.UPDATE_INC:
+			value = mask.Inc[field]
+		}
+
+		fields[operator] = append(fields[operator], bson.E{Key: field, Value: value})
+	}
+
+	update := bson.D{}
+	for _, operator := range []string{
+		ports.UPDATE_SET,
+		ports.UPDATE_UNSET,
+		ports.UPDATE_ADD_TO_SET,
+		ports.UPDATE_PULL,
+		ports.UPDATE_PUSH,
+		ports.UPDATE_INC,
+	} {
+		if len(fields[operator]) > 0 {
+			update = append(update, bson.E{Key: "$" + operator, Value: fields[operator]})
+		}
+	}
+
+	return update, nil
+}
+
+// withId returns the _id of doc and doc, a hex string of a new ObjectID
+// is prepended as _id when doc has none
+func withId(doc bson.D) (string, bson.D) {
+	for _, field := range doc {
+		if field.Key == "_id" {
+			if id := fmt.Sprintf("%v", field.Value); id != "" {
+				return id, doc
+			}
+		}
+	}
+
+	id := primitive.NewObjectID().Hex()
+	return id, append(bson.D{bson.E{Key: "_id", Value: id}}, doc...)
+}
+
+// idString returns a stored _id as string, legacy ObjectIDs are returned as hex strings
+func idString(value interface{}) string {
+	if objectId, ok := value.(primitive.ObjectID); ok {
+		return objectId.Hex()
+	}
+
+	return fmt.Sprintf("%v", value)
+}
+
+// formatUpsert splits doc into the $set and $setOnInsert operators of an upsert,
+// the _id and the fields in onInsertOnly are only written when the item is inserted.
+// Returns the _id of doc or the hex string of a new ObjectID when it's empty
+func formatUpsert(doc bson.D, onInsertOnly []string) (string, bson.D) {
+	insertOnly := map[string]bool{}
+	for _, field := range onInsertOnly {
+		insertOnly[field] = true
+	}
+
+	id := ""
+	set := bson.D{}
+	setOnInsert := bson.D{}
+	for _, field := range doc {
+		switch {
+		case field.Key == "_id":
+			id = fmt.Sprintf("%v", field.Value)
+		case insertOnly[field.Key]:
+			setOnInsert = append(setOnInsert, field)
+		default:
+			set = append(set, field)
+		}
+	}
+
+	if id == "" {
+		id = primitive.NewObjectID().Hex()
+	}
+
+	setOnInsert = append(bson.D{bson.E{Key: "_id", Value: id}}, setOnInsert...)
+	update := bson.D{}
+	if len(set) > 0 {
+		update = append(update, bson.E{Key: "$set", Value: set})
+	}
+
+	update = append(update, bson.E{Key: "$setOnInsert", Value: setOnInsert})
+	return id, update
+}
+
+// formatFilters takes a generic list of filters and converts them into a MongoDB query,
+// an item must match all the filters
 func formatFilters(filters []ports.Filter) (bson.D, error) {
 	result := bson.D{}
-	// TODO: Cast ObjectId values to the right type
+	keys := map[string]bool{}
+	repeatedKeys := false
+
 	for _, filter := range filters {
-		switch filter.Name {
-		case "$or", "$and":
-
-			if values, ok := filter.Value.([]ports.Filter); ok {
-				formated := []bson.E{}
-
-				for _, v := range values {
-					formated = append(formated, formatFilter(v))
-				}
-
-				result = append(result, bson.E{
-					Key:   filter.Name,
-					Value: formated,
-				})
-			} else {
-				return result, fmt.Errorf("invalid value for operator %q: %+v", filter.Name, filter.Value)
-			}
-		default:
-			result = append(result, formatFilter(filter))
+		if err := filter.Validate(); err != nil {
+			return bson.D{}, err
 		}
+
+		elem := formatFilter(filter)
+		repeatedKeys = repeatedKeys || keys[elem.Key]
+		keys[elem.Key] = true
+		result = append(result, elem)
+	}
+
+	// A document can't hold the same key twice so repeated fields are combined with $and
+	if repeatedKeys {
+		all := bson.A{}
+		for _, elem := range result {
+			all = append(all, bson.D{elem})
+		}
+
+		return bson.D{bson.E{Key: "$and", Value: all}}, nil
 	}
 
 	return result, nil
 }
 
-// formatFilters takes a generic filter and converts it into a MongoDB query
-func formatFilter(f ports.Filter) bson.E {
-	if value, ok := f.Value.(ports.Filter); ok {
-		return bson.E{
-			Key:   f.Name,
-			Value: formatFilter(value),
+// formatFilter takes a generic filter and converts it into a MongoDB query element,
+// the filter must be already validated
+func formatFilter(filter ports.Filter) bson.E {
+	switch filter.Op() {
+	case ports.OP_AND, ports.OP_OR:
+		nested := bson.A{}
+		for _, f := range filter.Filters {
+			nested = append(nested, bson.D{formatFilter(f)})
 		}
-	} else {
-		return bson.E{
-			Key:   f.Name,
-			Value: f.Value,
+
+		return bson.E{Key: "$" + filter.Op(), Value: nested}
+	case ports.OP_NOT:
+		return bson.E{Key: "$nor", Value: bson.A{bson.D{formatFilter(filter.Filters[0])}}}
+	case ports.OP_EQ, ports.OP_NE:
+		if filter.Name == "_id" {
+			return formatFilter(idListFilter(filter))
+		}
+
+		if filter.Op() == ports.OP_EQ {
+			return bson.E{Key: filter.Name, Value: filterValue(filter)}
+		}
+	case ports.OP_PREFIX:
+		return bson.E{Key: filter.Name, Value: primitive.Regex{
+			Pattern: "^" + regexp.QuoteMeta(filter.Value.(string)),
+		}}
+	case ports.OP_CONTAINS:
+		return bson.E{Key: filter.Name, Value: primitive.Regex{
+			Pattern: regexp.QuoteMeta(filter.Value.(string)),
+		}}
+	}
+
+	return bson.E{
+		Key:   filter.Name,
+		Value: bson.D{bson.E{Key: "$" + filter.Op(), Value: filterValue(filter)}},
+	}
+}
+
+// filterValue returns the value of a filter, _id values are matched both as strings
+// and as the legacy ObjectID values when possible
+func filterValue(filter ports.Filter) interface{} {
+	if filter.Name != "_id" {
+		return filter.Value
+	}
+
+	switch filter.Op() {
+	case ports.OP_IN, ports.OP_NIN:
+		values := reflect.ValueOf(filter.Value)
+		output := bson.A{}
+		for i := 0; i < values.Len(); i++ {
+			output = append(output, idValues(values.Index(i).Interface())...)
+		}
+
+		return output
+	}
+
+	return filter.Value
+}
+
+// idValues returns the values an id may be stored as, hex strings were stored
+// as ObjectID before ids became opaque strings
+func idValues(value interface{}) bson.A {
+	if str, ok := value.(string); ok {
+		if objectId, err := primitive.ObjectIDFromHex(str); err == nil {
+			return bson.A{str, objectId}
 		}
 	}
+
+	return bson.A{value}
+}
+
+// idListFilter converts an eq or ne filter over _id into an in or nin filter,
+// so both the string and the ObjectID forms of the id are matched
+func idListFilter(filter ports.Filter) ports.Filter {
+	if filter.Op() == ports.OP_EQ {
+		return ports.In("_id", filter.Value)
+	}
+
+	return ports.Nin("_id", filter.Value)
+}
+
+// idFilter returns a query matching the item with id
+func idFilter(id string) bson.D {
+	values := idValues(id)
+	if len(values) == 1 {
+		return bson.D{bson.E{Key: "_id", Value: id}}
+	}
+
+	return bson.D{bson.E{Key: "_id", Value: bson.D{bson.E{Key: "$in", Value: values}}}}
+}
+
+// formatSort converts the sort into a Mongo sort document using _id as tie breaker
+func formatSort(sort []ports.Sort) bson.D {
+	output := bson.D{}
+	hasId := false
+
+	for _, s := range sort {
+		direction := 1
+		if s.Descending {
+			direction = -1
+		}
+
+		hasId = hasId || s.Field == "_id"
+		output = append(output, bson.E{Key: s.Field, Value: direction})
+	}
+
+	if !hasId {
+		output = append(output, bson.E{Key: "_id", Value: 1})
+	}
+
+	return output
+}
+
+// Cursor prefixes tell the type of the _id value stored in a cursor
+const (
+	objectIdCursor = "o:"
+	stringCursor   = "s:"
+)
+
+// encodeCursor converts an _id value into an opaque cursor
+func encodeCursor(id bson.RawValue) string {
+	value := stringCursor + id.String()
+	if oid, ok := id.ObjectIDOK(); ok {
+		value = objectIdCursor + oid.Hex()
+	} else if str, ok := id.StringValueOK(); ok {
+		value = stringCursor + str
+	}
+
+	return base64.RawURLEncoding.EncodeToString([]byte(value))
+}
+
+// decodeCursor converts an opaque cursor back into an _id value
+func decodeCursor(cursor string) (interface{}, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil || len(raw) < 2 {
+		return nil, ports.ErrInvalidCursor{Cursor: cursor}
+	}
+
+	value := string(raw)
+	switch value[:2] {
+	case objectIdCursor:
+		objectId, err := primitive.ObjectIDFromHex(value[2:])
+		if err != nil {
+			return nil, ports.ErrInvalidCursor{Cursor: cursor}
+		}
+		return objectId, nil
+	case stringCursor:
+		return value[2:], nil
+	}
+
+	return nil, ports.ErrInvalidCursor{Cursor: cursor}
 }
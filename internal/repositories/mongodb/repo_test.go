@@ -1,11 +1,13 @@
 package mongodb
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/sy-software/minerva-owl/internal/core/ports"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 func TestFilters(t *testing.T) {
@@ -60,33 +62,25 @@ func TestFilters(t *testing.T) {
 		}
 	})
 
-	t.Run("Test filter with operator in value", func(t *testing.T) {
+	t.Run("Test field operators", func(t *testing.T) {
 		filters := []ports.Filter{
-			{
-				Name: "myfield1",
-				Value: ports.Filter{
-					Name:  "$in",
-					Value: []string{"v1", "v2"},
-				},
-			},
-			{
-				Name: "myfield2",
-				Value: ports.Filter{
-					Name:  "$eq",
-					Value: 10,
-				},
-			},
+			ports.In("myfield1", "v1", "v2"),
+			ports.Gte("myfield2", 10),
+			ports.Exists("myfield3", false),
+			ports.Prefix("myfield4", "a.b"),
 		}
 
 		expect := bson.D{
-			bson.E{Key: "myfield1", Value: bson.E{
-				Key:   "$in",
-				Value: []string{"v1", "v2"},
+			bson.E{Key: "myfield1", Value: bson.D{
+				bson.E{Key: "$in", Value: []interface{}{"v1", "v2"}},
+			}},
+			bson.E{Key: "myfield2", Value: bson.D{
+				bson.E{Key: "$gte", Value: 10},
 			}},
-			bson.E{Key: "myfield2", Value: bson.E{
-				Key:   "$eq",
-				Value: 10,
+			bson.E{Key: "myfield3", Value: bson.D{
+				bson.E{Key: "$exists", Value: false},
 			}},
+			bson.E{Key: "myfield4", Value: primitive.Regex{Pattern: `^a\.b`}},
 		}
 
 		got, err := formatFilters(filters)
@@ -100,45 +94,20 @@ func TestFilters(t *testing.T) {
 		}
 	})
 
-	t.Run("Test top level operators", func(t *testing.T) {
+	t.Run("Test logical operators", func(t *testing.T) {
 		filters := []ports.Filter{
-			{
-				Name: "$or",
-				Value: []ports.Filter{
-					{
-						Name: "myfield1",
-						Value: ports.Filter{
-							Name:  "$eq",
-							Value: 10,
-						},
-					},
-					{
-						Name: "myfield2",
-						Value: ports.Filter{
-							Name:  "$eq",
-							Value: 10,
-						},
-					},
-				},
-			},
+			ports.Or(
+				ports.Eq("myfield1", 10),
+				ports.Not(ports.Contains("myfield2", "value")),
+			),
 		}
 
 		expect := bson.D{
-			bson.E{Key: "$or", Value: []bson.E{
-				{
-					Key: "myfield1",
-					Value: bson.E{
-						Key:   "$eq",
-						Value: 10,
-					},
-				},
-				{
-					Key: "myfield2",
-					Value: bson.E{
-						Key:   "$eq",
-						Value: 10,
-					},
-				},
+			bson.E{Key: "$or", Value: bson.A{
+				bson.D{bson.E{Key: "myfield1", Value: 10}},
+				bson.D{bson.E{Key: "$nor", Value: bson.A{
+					bson.D{bson.E{Key: "myfield2", Value: primitive.Regex{Pattern: "value"}}},
+				}}},
 			}},
 		}
 
@@ -152,4 +121,340 @@ func TestFilters(t *testing.T) {
 			t.Errorf("Expected filter: %+v. Got: %+v", expect, got)
 		}
 	})
+
+	t.Run("Test repeated fields are combined", func(t *testing.T) {
+		filters := []ports.Filter{
+			ports.Gt("myfield", 1),
+			ports.Lt("myfield", 5),
+		}
+
+		expect := bson.D{
+			bson.E{Key: "$and", Value: bson.A{
+				bson.D{bson.E{Key: "myfield", Value: bson.D{bson.E{Key: "$gt", Value: 1}}}},
+				bson.D{bson.E{Key: "myfield", Value: bson.D{bson.E{Key: "$lt", Value: 5}}}},
+			}},
+		}
+
+		got, err := formatFilters(filters)
+
+		if err != nil {
+			t.Errorf("Filters failed to format with error: %v", err)
+		}
+
+		if !cmp.Equal(expect, got) {
+			t.Errorf("Expected filter: %+v. Got: %+v", expect, got)
+		}
+	})
+
+	t.Run("Test id values match strings and legacy ObjectID", func(t *testing.T) {
+		objectId := primitive.NewObjectID()
+		filters := []ports.Filter{
+			ports.In("_id", objectId.Hex(), "custom"),
+			ports.Ne("_id", "other"),
+		}
+
+		expect := bson.D{
+			bson.E{Key: "$and", Value: bson.A{
+				bson.D{bson.E{Key: "_id", Value: bson.D{
+					bson.E{Key: "$in", Value: bson.A{objectId.Hex(), objectId, "custom"}},
+				}}},
+				bson.D{bson.E{Key: "_id", Value: bson.D{
+					bson.E{Key: "$nin", Value: bson.A{"other"}},
+				}}},
+			}},
+		}
+
+		got, err := formatFilters(filters)
+
+		if err != nil {
+			t.Errorf("Filters failed to format with error: %v", err)
+		}
+
+		if !cmp.Equal(expect, got) {
+			t.Errorf("Expected filter: %+v. Got: %+v", expect, got)
+		}
+	})
+
+	t.Run("Test invalid filters", func(t *testing.T) {
+		filters := [][]ports.Filter{
+			{{Name: "myfield", Operator: "$where", Value: "true"}},
+			{{Name: "myfield", Operator: ports.OP_IN, Value: "v1"}},
+			{ports.Or()},
+			{{Operator: ports.OP_EXISTS, Value: true}},
+		}
+
+		for _, filter := range filters {
+			_, err := formatFilters(filter)
+
+			if _, ok := err.(ports.ErrInvalidFilter); !ok {
+				t.Errorf("Expected error of type ErrInvalidFilter for %+v got: %v", filter, err)
+			}
+		}
+	})
+}
+
+func TestCursors(t *testing.T) {
+	t.Run("Test ObjectID cursor", func(t *testing.T) {
+		expected := primitive.NewObjectID()
+		doc, _ := bson.Marshal(bson.M{"_id": expected})
+
+		got, err := decodeCursor(encodeCursor(bson.Raw(doc).Lookup("_id")))
+
+		if err != nil || got != expected {
+			t.Errorf("Expected id: %v got: %v with error: %v", expected, got, err)
+		}
+	})
+
+	t.Run("Test string cursor", func(t *testing.T) {
+		expected := "myId"
+		doc, _ := bson.Marshal(bson.M{"_id": expected})
+
+		got, err := decodeCursor(encodeCursor(bson.Raw(doc).Lookup("_id")))
+
+		if err != nil || got != expected {
+			t.Errorf("Expected id: %v got: %v with error: %v", expected, got, err)
+		}
+	})
+
+	t.Run("Test invalid cursor", func(t *testing.T) {
+		for _, cursor := range []string{"", "%%%", "eDpteUlk"} {
+			_, err := decodeCursor(cursor)
+
+			if _, ok := err.(ports.ErrInvalidCursor); !ok {
+				t.Errorf("Expected error of type ErrInvalidCursor for %q got: %v", cursor, err)
+			}
+		}
+	})
+}
+
+func TestUpsertKeys(t *testing.T) {
+	keys, ok := upsertKeys([]ports.Filter{ports.Eq("username", "tony"), ports.Eq("provider", "google")})
+	if !ok || !cmp.Equal(keys, []string{"provider", "username"}) {
+		t.Errorf("Expected sorted equality keys got: %v", keys)
+	}
+
+	invalid := [][]ports.Filter{
+		{},
+		{ports.Eq("_id", "1")},
+		{ports.Eq("username", "tony"), ports.Gt("age", 18)},
+	}
+	for _, filters := range invalid {
+		if keys, ok := upsertKeys(filters); ok {
+			t.Errorf("Expected no unique keys for: %+v got: %v", filters, keys)
+		}
+	}
+}
+
+func TestToBSONDoc(t *testing.T) {
+	type member struct {
+		Name string `bson:"name"`
+	}
+
+	entity := struct {
+		Id      string   `bson:"_id"`
+		Name    string   `bson:"name"`
+		Owner   member   `bson:"owner"`
+		Members []member `bson:"members"`
+		Tags    []string `bson:"tags"`
+	}{
+		Id:      "1",
+		Name:    "Avengers",
+		Owner:   member{Name: "Nick"},
+		Members: []member{{Name: "Steve"}, {Name: "Tony"}},
+		Tags:    []string{"heroes"},
+	}
+
+	got, err := toBSONDoc(entity, "name")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := bson.D{
+		{Key: "owner", Value: bson.D{{Key: "name", Value: "Nick"}}},
+		{Key: "members", Value: bson.A{
+			bson.D{{Key: "name", Value: "Steve"}},
+			bson.D{{Key: "name", Value: "Tony"}},
+		}},
+		{Key: "tags", Value: bson.A{"heroes"}},
+	}
+
+	if diff := cmp.Diff(expected, got); diff != "" {
+		t.Errorf("Expected nested documents and arrays to be kept: %v", diff)
+	}
+}
+
+func TestSort(t *testing.T) {
+	t.Run("Test default sort", func(t *testing.T) {
+		expect := bson.D{
+			bson.E{Key: "_id", Value: 1},
+		}
+
+		got := formatSort(nil)
+
+		if !cmp.Equal(expect, got) {
+			t.Errorf("Expected sort: %+v. Got: %+v", expect, got)
+		}
+	})
+
+	t.Run("Test multiple fields sort", func(t *testing.T) {
+		expect := bson.D{
+			bson.E{Key: "createDate", Value: -1},
+			bson.E{Key: "name", Value: 1},
+			bson.E{Key: "_id", Value: 1},
+		}
+
+		got := formatSort([]ports.Sort{
+			{Field: "createDate", Descending: true},
+			{Field: "name"},
+		})
+
+		if !cmp.Equal(expect, got) {
+			t.Errorf("Expected sort: %+v. Got: %+v", expect, got)
+		}
+	})
+
+	t.Run("Test sort by id", func(t *testing.T) {
+		expect := bson.D{
+			bson.E{Key: "_id", Value: -1},
+		}
+
+		got := formatSort([]ports.Sort{
+			{Field: "_id", Descending: true},
+		})
+
+		if !cmp.Equal(expect, got) {
+			t.Errorf("Expected sort: %+v. Got: %+v", expect, got)
+		}
+	})
+}
+
+func TestFieldMask(t *testing.T) {
+	t.Run("Test set and unset operators", func(t *testing.T) {
+		got, err := formatFieldMask(ports.FieldMask{
+			Set:   map[string]interface{}{"name": "Tony", "age": 48},
+			Unset: []string{"logo"},
+		})
+
+		expect := bson.D{
+			bson.E{Key: "$set", Value: bson.D{
+				bson.E{Key: "age", Value: 48},
+				bson.E{Key: "name", Value: "Tony"},
+			}},
+			bson.E{Key: "$unset", Value: bson.D{
+				bson.E{Key: "logo", Value: ""},
+			}},
+		}
+
+		if err != nil || !cmp.Equal(expect, got) {
+			t.Errorf("Expected update: %+v got: %+v with error: %v", expect, got, err)
+		}
+	})
+
+	t.Run("Test array operators and counters", func(t *testing.T) {
+		got, err := formatFieldMask(ports.FieldMask{
+			AddToSet: map[string][]interface{}{"techs": {"go"}},
+			Pull:     map[string][]interface{}{"tags": {"a", "b"}},
+			Push: map[string]ports.PushValues{
+				"history":     ports.PushAt(0, "created"),
+				"suit.colors": ports.Push("red"),
+			},
+			Inc: map[string]int64{"stats.wins": 1},
+		})
+
+		expect := bson.D{
+			bson.E{Key: "$addToSet", Value: bson.D{
+				bson.E{Key: "techs", Value: bson.D{bson.E{Key: "$each", Value: bson.A{"go"}}}},
+			}},
+			bson.E{Key: "$pull", Value: bson.D{
+				bson.E{Key: "tags", Value: bson.D{bson.E{Key: "$in", Value: bson.A{"a", "b"}}}},
+			}},
+			bson.E{Key: "$push", Value: bson.D{
+				bson.E{Key: "history", Value: bson.D{
+					bson.E{Key: "$each", Value: bson.A{"created"}},
+					bson.E{Key: "$position", Value: 0},
+				}},
+				bson.E{Key: "suit.colors", Value: bson.D{bson.E{Key: "$each", Value: bson.A{"red"}}}},
+			}},
+			bson.E{Key: "$inc", Value: bson.D{
+				bson.E{Key: "stats.wins", Value: int64(1)},
+			}},
+		}
+
+		if err != nil || !cmp.Equal(expect, got) {
+			t.Errorf("Expected update: %+v got: %+v with error: %v", expect, got, err)
+		}
+	})
+
+	t.Run("Test invalid field mask", func(t *testing.T) {
+		_, err := formatFieldMask(ports.FieldMask{Unset: []string{"_id"}})
+		if _, ok := err.(ports.ErrInvalidFieldMask); !ok {
+			t.Errorf("Expected error of type ErrInvalidFieldMask got: %v", err)
+		}
+	})
+}
+
+func TestUpsert(t *testing.T) {
+	t.Run("Test insert only fields and id", func(t *testing.T) {
+		doc := bson.D{
+			bson.E{Key: "_id", Value: "1"},
+			bson.E{Key: "username", Value: "IronMan"},
+			bson.E{Key: "createDate", Value: "today"},
+		}
+
+		id, got := formatUpsert(doc, []string{"createDate"})
+		expect := bson.D{
+			bson.E{Key: "$set", Value: bson.D{
+				bson.E{Key: "username", Value: "IronMan"},
+			}},
+			bson.E{Key: "$setOnInsert", Value: bson.D{
+				bson.E{Key: "_id", Value: "1"},
+				bson.E{Key: "createDate", Value: "today"},
+			}},
+		}
+
+		if id != "1" || !cmp.Equal(expect, got) {
+			t.Errorf("Expected id: \"1\" and update: %+v got: %q and %+v", expect, id, got)
+		}
+	})
+
+	t.Run("Test missing id is generated", func(t *testing.T) {
+		id, got := formatUpsert(bson.D{}, nil)
+		expect := bson.D{
+			bson.E{Key: "$setOnInsert", Value: bson.D{
+				bson.E{Key: "_id", Value: id},
+			}},
+		}
+
+		if _, err := primitive.ObjectIDFromHex(id); err != nil || !cmp.Equal(expect, got) {
+			t.Errorf("Expected an ObjectID and update: %+v got: %q and %+v", expect, id, got)
+		}
+	})
+}
+
+func TestBulk(t *testing.T) {
+	t.Run("Test failed items abort the following items", func(t *testing.T) {
+		failure := errors.New("failure")
+		results := make([]ports.BulkResult, 3)
+		if !failBulk(results, 1, failure, true) {
+			t.Errorf("Expected processing to stop")
+		}
+
+		expected := []error{nil, failure, ports.ErrBulkAborted}
+		for index, result := range results {
+			if result.Err != expected[index] {
+				t.Errorf("Expected error: %v for item %d got: %v", expected[index], index, result.Err)
+			}
+		}
+	})
+
+	t.Run("Test failed items don't abort without stop on error", func(t *testing.T) {
+		results := make([]ports.BulkResult, 3)
+		if failBulk(results, 0, errors.New("failure"), false) {
+			t.Errorf("Expected processing to continue")
+		}
+
+		if results[1].Err != nil || results[2].Err != nil {
+			t.Errorf("Expected only the first item to fail got: %+v", results)
+		}
+	})
 }
@@ -0,0 +1,2 @@
+// Package repotest provides the contract tests every ports.Repository implementation must pass
+package repotest
@@ -0,0 +1,798 @@
+package repotest
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sy-software/minerva-owl/internal/core/ports"
+)
+
+// Item is the entity stored by the contract tests, it's tagged for every backend
+type Item struct {
+	Id     string         `bson:"_id,omitempty" json:"id,omitempty"`
+	Name   string         `bson:"name,omitempty" json:"name,omitempty"`
+	Age    int            `bson:"age,omitempty" json:"age,omitempty"`
+	Active bool           `bson:"active,omitempty" json:"active,omitempty"`
+	Tags   []string       `bson:"tags,omitempty" json:"tags,omitempty"`
+	Stats  map[string]int `bson:"stats,omitempty" json:"stats,omitempty"`
+}
+
+// Suite describes the ports.Repository implementation checked by Run
+type Suite struct {
+	// New returns the repository under test, it's called once per test
+	New func(t *testing.T) ports.Repository
+	// UnsupportedOperators are the filter operators the implementation rejects
+	// with ports.ErrInvalidFilter instead of applying them
+	UnsupportedOperators []string
+	// NoSort must be true when List rejects any sort with ports.ErrInvalidSort
+	NoSort bool
+	// UnsupportedUpdates are the field mask operators the implementation rejects
+	// with ports.ErrInvalidFieldMask instead of applying them
+	UnsupportedUpdates []string
+	// NoNestedFields must be true when field masks can't change nested fields
+	NoNestedFields bool
+	// NoAtomicUpsert must be true when concurrent upserts with the same filters
+	// can create more than one item
+	NoAtomicUpsert bool
+}
+
+// Run checks repository implements the behavior expected by the services:
+// CRUD, batched gets, omitted fields on update, upserts, bulk operations, transactions, cancellation, field masks, array operators, not-found errors, filters, pagination bounds and
+// concurrent use. Every test uses a new collection so the repository can keep data
+//
+// Items without sort can be returned in any order, so results are compared as sets
+func Run(t *testing.T, suite Suite) {
+	ctx := context.Background()
+	unsupported := map[string]bool{}
+	for _, op := range suite.UnsupportedOperators {
+		unsupported[op] = true
+	}
+
+	unsupportedUpdates := map[string]bool{}
+	for _, op := range suite.UnsupportedUpdates {
+		unsupportedUpdates[op] = true
+	}
+
+	t.Run("Test create and get", func(t *testing.T) {
+		repo, collection := suite.New(t), newCollection()
+		expected := Item{Name: "Tony Stark", Age: 48, Active: true, Tags: []string{"avenger", "genius"}}
+
+		id, err := repo.Create(ctx, collection, expected)
+		if err != nil || id == "" {
+			t.Fatalf("Expected a new id got: %q with error: %v", id, err)
+		}
+
+		expected.Id = id
+		got := Item{}
+		err = repo.Get(ctx, collection, id, &got)
+		if err != nil || !cmp.Equal(expected, got) {
+			t.Errorf("Expected item: %+v got: %+v with error: %v", expected, got, err)
+		}
+	})
+
+	t.Run("Test create with a duplicated id", func(t *testing.T) {
+		repo, collection := suite.New(t), newCollection()
+		id, err := repo.Create(ctx, collection, Item{Name: "Tony Stark"})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		_, err = repo.Create(ctx, collection, Item{Id: id, Name: "Howard Stark"})
+		if !errors.As(err, &ports.ErrDuplicatedId{}) {
+			t.Errorf("Expected error of type ErrDuplicatedId got: %v", err)
+		}
+
+		got := Item{}
+		if err := repo.Get(ctx, collection, id, &got); err != nil || got.Name != "Tony Stark" {
+			t.Errorf("Expected item to keep its values got: %+v with error: %v", got, err)
+		}
+	})
+
+	t.Run("Test not found errors", func(t *testing.T) {
+		repo, collection := suite.New(t), newCollection()
+		id, _ := repo.Create(ctx, collection, Item{Name: "Deleted"})
+		if err := repo.Delete(ctx, collection, id); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		for _, missing := range []string{id, "not-an-id"} {
+			if err := repo.Get(ctx, collection, missing, &Item{}); !isNotFound(err) {
+				t.Errorf("Get %q: Expected error of type ErrItemNotFound got: %v", missing, err)
+			}
+
+			if err := repo.Update(ctx, collection, missing, Item{Name: "Missing"}); !isNotFound(err) {
+				t.Errorf("Update %q: Expected error of type ErrItemNotFound got: %v", missing, err)
+			}
+
+			mask := ports.FieldMask{Set: map[string]interface{}{"name": "Missing"}}
+			if err := repo.Patch(ctx, collection, missing, mask); !isNotFound(err) {
+				t.Errorf("Patch %q: Expected error of type ErrItemNotFound got: %v", missing, err)
+			}
+
+			if err := repo.Delete(ctx, collection, missing); !isNotFound(err) {
+				t.Errorf("Delete %q: Expected error of type ErrItemNotFound got: %v", missing, err)
+			}
+		}
+
+		if err := repo.GetOne(ctx, collection, &Item{}, ports.Eq("name", "Deleted")); !isNotFound(err) {
+			t.Errorf("GetOne: Expected error of type ErrItemNotFound got: %v", err)
+		}
+	})
+
+	t.Run("Test get many skips missing ids", func(t *testing.T) {
+		repo, collection := suite.New(t), newCollection()
+		tony, _ := repo.Create(ctx, collection, Item{Name: "Tony Stark"})
+		repo.Create(ctx, collection, Item{Name: "Steve Rogers"})
+		bruce, _ := repo.Create(ctx, collection, Item{Name: "Bruce Banner"})
+
+		got := []Item{}
+		err := repo.GetMany(ctx, collection, []string{bruce, "not-an-id", tony, bruce}, &got)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		expected := []string{tony, bruce}
+		sort.Strings(expected)
+		if !cmp.Equal(expected, itemIds(got)) {
+			t.Errorf("Expected ids: %v got: %v", expected, itemIds(got))
+		}
+
+		got = []Item{}
+		if err := repo.GetMany(ctx, collection, []string{}, &got); err != nil || len(got) != 0 {
+			t.Errorf("Expected no items got: %+v with error: %v", got, err)
+		}
+	})
+
+	t.Run("Test update keeps omitted and empty fields", func(t *testing.T) {
+		repo, collection := suite.New(t), newCollection()
+		id, _ := repo.Create(ctx, collection, Item{Name: "Tony Stark", Age: 48, Tags: []string{"avenger"}})
+
+		err := repo.Update(ctx, collection, id, Item{Id: "other", Name: "Anthony Stark", Age: 50}, "age")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		expected := Item{Id: id, Name: "Anthony Stark", Age: 48, Tags: []string{"avenger"}}
+		got := Item{}
+		err = repo.Get(ctx, collection, id, &got)
+		if err != nil || !cmp.Equal(expected, got) {
+			t.Errorf("Expected item: %+v got: %+v with error: %v", expected, got, err)
+		}
+	})
+
+	t.Run("Test upsert creates and then updates", func(t *testing.T) {
+		repo, collection := suite.New(t), newCollection()
+		repo.Create(ctx, collection, Item{Name: "Steve Rogers", Age: 100})
+		filters := []ports.Filter{ports.Eq("name", "Tony Stark")}
+
+		id, created, err := repo.Upsert(ctx, collection, filters, Item{Name: "Tony Stark", Age: 48, Tags: []string{"genius"}}, "age")
+		if err != nil || !created || id == "" {
+			t.Fatalf("Expected a new item got id: %q created: %v with error: %v", id, created, err)
+		}
+
+		got := Item{}
+		expected := Item{Id: id, Name: "Tony Stark", Age: 48, Tags: []string{"genius"}}
+		if err := repo.Get(ctx, collection, id, &got); err != nil || !cmp.Equal(expected, got) {
+			t.Errorf("Expected item: %+v got: %+v with error: %v", expected, got, err)
+		}
+
+		updatedId, created, err := repo.Upsert(ctx, collection, filters, Item{Id: "other", Name: "Tony Stark", Age: 50, Active: true}, "age")
+		if err != nil || created || updatedId != id {
+			t.Fatalf("Expected item %q to be updated got id: %q created: %v with error: %v", id, updatedId, created, err)
+		}
+
+		got = Item{}
+		expected = Item{Id: id, Name: "Tony Stark", Age: 48, Active: true, Tags: []string{"genius"}}
+		if err := repo.Get(ctx, collection, id, &got); err != nil || !cmp.Equal(expected, got) {
+			t.Errorf("Expected insert only fields to keep their value: %+v got: %+v with error: %v", expected, got, err)
+		}
+
+		if count, err := repo.Count(ctx, collection); err != nil || count != 2 {
+			t.Errorf("Expected 2 items got: %d with error: %v", count, err)
+		}
+	})
+
+	t.Run("Test concurrent upserts create a single item", func(t *testing.T) {
+		if suite.NoAtomicUpsert {
+			t.Skip("Upserts are not atomic")
+		}
+
+		repo, collection := suite.New(t), newCollection()
+		filters := []ports.Filter{ports.Eq("name", "Tony Stark")}
+		created := make(chan bool, 20)
+		wg := sync.WaitGroup{}
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func(age int) {
+				defer wg.Done()
+				_, isNew, err := repo.Upsert(ctx, collection, filters, Item{Name: "Tony Stark", Age: age})
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+
+				created <- isNew
+			}(i + 1)
+		}
+
+		wg.Wait()
+		close(created)
+		creations := 0
+		for isNew := range created {
+			if isNew {
+				creations++
+			}
+		}
+
+		count, err := repo.Count(ctx, collection)
+		if err != nil || count != 1 || creations != 1 {
+			t.Errorf("Expected a single item created once got: %d items and %d creations with error: %v", count, creations, err)
+		}
+	})
+
+	t.Run("Test patch sets and clears fields", func(t *testing.T) {
+		repo, collection := suite.New(t), newCollection()
+		id, _ := repo.Create(ctx, collection, Item{Name: "Tony Stark", Age: 48, Active: true, Tags: []string{"avenger"}})
+
+		err := repo.Patch(ctx, collection, id, ports.FieldMask{
+			Set:   map[string]interface{}{"name": "Anthony Stark"},
+			Unset: []string{"active", "tags"},
+		})
+
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		expected := Item{Id: id, Name: "Anthony Stark", Age: 48}
+		got := Item{}
+		err = repo.Get(ctx, collection, id, &got)
+		if err != nil || !cmp.Equal(expected, got) {
+			t.Errorf("Expected item: %+v got: %+v with error: %v", expected, got, err)
+		}
+
+		invalid := []ports.FieldMask{
+			{Set: map[string]interface{}{"_id": "other"}},
+			{Set: map[string]interface{}{"name": "Tony"}, Unset: []string{"name"}},
+		}
+
+		for _, mask := range invalid {
+			err := repo.Patch(ctx, collection, id, mask)
+			if !errors.As(err, &ports.ErrInvalidFieldMask{}) {
+				t.Errorf("Expected error of type ErrInvalidFieldMask for %+v got: %v", mask, err)
+			}
+		}
+	})
+
+	t.Run("Test array operators and counters", func(t *testing.T) {
+		repo, collection := suite.New(t), newCollection()
+		id, _ := repo.Create(ctx, collection, Item{Name: "Tony Stark", Age: 48, Tags: []string{"avenger", "genius"}})
+
+		steps := []struct {
+			operator string
+			mask     ports.FieldMask
+		}{
+			{ports.UPDATE_PUSH, ports.FieldMask{Push: map[string]ports.PushValues{"tags": ports.Push("leader")}}},
+			{ports.UPDATE_PUSH, ports.FieldMask{Push: map[string]ports.PushValues{"tags": ports.PushAt(0, "first")}}},
+			{ports.UPDATE_PULL, ports.FieldMask{Pull: map[string][]interface{}{"tags": {"genius", "missing"}}}},
+			{ports.UPDATE_ADD_TO_SET, ports.FieldMask{AddToSet: map[string][]interface{}{"tags": {"avenger", "new"}}}},
+			{ports.UPDATE_INC, ports.FieldMask{Inc: map[string]int64{"age": 2}}},
+		}
+
+		expected := Item{Id: id, Name: "Tony Stark", Age: 48, Tags: []string{"first", "avenger", "leader"}}
+		for _, step := range steps {
+			err := repo.Patch(ctx, collection, id, step.mask)
+			if unsupportedUpdates[step.operator] {
+				if !errors.As(err, &ports.ErrInvalidFieldMask{}) {
+					t.Errorf("%s: Expected error of type ErrInvalidFieldMask got: %v", step.operator, err)
+				}
+				continue
+			}
+
+			if err != nil {
+				t.Fatalf("%s: Unexpected error: %v", step.operator, err)
+			}
+
+			switch step.operator {
+			case ports.UPDATE_ADD_TO_SET:
+				expected.Tags = append(expected.Tags, "new")
+			case ports.UPDATE_INC:
+				expected.Age = 50
+			}
+		}
+
+		got := Item{}
+		err := repo.Get(ctx, collection, id, &got)
+		if err != nil || !cmp.Equal(expected, got) {
+			t.Errorf("Expected item: %+v got: %+v with error: %v", expected, got, err)
+		}
+	})
+
+	t.Run("Test nested fields", func(t *testing.T) {
+		repo, collection := suite.New(t), newCollection()
+		id, _ := repo.Create(ctx, collection, Item{Name: "Tony Stark", Stats: map[string]int{"wins": 1, "losses": 1}})
+
+		err := repo.Patch(ctx, collection, id, ports.FieldMask{
+			Set:   map[string]interface{}{"stats.wins": 3},
+			Unset: []string{"stats.losses"},
+		})
+
+		if suite.NoNestedFields {
+			if !errors.As(err, &ports.ErrInvalidFieldMask{}) {
+				t.Errorf("Expected error of type ErrInvalidFieldMask got: %v", err)
+			}
+			return
+		}
+
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		expected := Item{Id: id, Name: "Tony Stark", Stats: map[string]int{"wins": 3}}
+		got := Item{}
+		err = repo.Get(ctx, collection, id, &got)
+		if err != nil || !cmp.Equal(expected, got) {
+			t.Errorf("Expected item: %+v got: %+v with error: %v", expected, got, err)
+		}
+	})
+
+	t.Run("Test concurrent array updates are not lost", func(t *testing.T) {
+		repo, collection := suite.New(t), newCollection()
+		id, _ := repo.Create(ctx, collection, Item{Name: "Tony Stark"})
+
+		wg := sync.WaitGroup{}
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				mask := ports.FieldMask{Push: map[string]ports.PushValues{"tags": ports.Push(fmt.Sprintf("tag%d", i))}}
+				if err := repo.Patch(ctx, collection, id, mask); err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+			}(i)
+		}
+		wg.Wait()
+
+		got := Item{}
+		err := repo.Get(ctx, collection, id, &got)
+		if err != nil || len(got.Tags) != 20 {
+			t.Errorf("Expected 20 tags got: %v with error: %v", got.Tags, err)
+		}
+	})
+
+	t.Run("Test bulk operations", func(t *testing.T) {
+		repo, collection := suite.New(t), newCollection()
+		entities := []interface{}{Item{Name: "Tony Stark"}, Item{Name: "Steve Rogers"}, Item{Name: "Peter Parker"}}
+
+		created, err := repo.CreateMany(ctx, collection, entities, true)
+		if err != nil || len(created) != len(entities) {
+			t.Fatalf("Expected %d results got: %+v with error: %v", len(entities), created, err)
+		}
+
+		for index, result := range created {
+			got := Item{}
+			if err := repo.Get(ctx, collection, result.Id, &got); err != nil || result.Err != nil || got.Name != entities[index].(Item).Name {
+				t.Errorf("Expected item %d to be created got: %+v and result: %+v with error: %v", index, got, result, err)
+			}
+		}
+
+		set := ports.FieldMask{Set: map[string]interface{}{"age": 30}}
+		patches := []ports.BulkPatch{{Id: created[0].Id, Mask: set}, {Id: "missing", Mask: set}, {Id: created[2].Id, Mask: set}}
+
+		results, err := repo.PatchMany(ctx, collection, patches, false)
+		if err != nil || results[0].Err != nil || !isNotFound(results[1].Err) || results[2].Err != nil {
+			t.Errorf("Expected only the missing item to fail got: %+v with error: %v", results, err)
+		}
+
+		results, err = repo.PatchMany(ctx, collection, patches, true)
+		if err != nil || results[0].Err != nil || !isNotFound(results[1].Err) || results[2].Err != ports.ErrBulkAborted {
+			t.Errorf("Expected the items after the missing one to be aborted got: %+v with error: %v", results, err)
+		}
+
+		got := Item{}
+		if err := repo.Get(ctx, collection, created[2].Id, &got); err != nil || got.Age != 30 {
+			t.Errorf("Expected item to be patched by the first call got: %+v with error: %v", got, err)
+		}
+
+		ids := []string{created[0].Id, "missing", created[1].Id}
+		results, err = repo.DeleteMany(ctx, collection, ids, true)
+		if err != nil || results[0].Err != nil || !isNotFound(results[1].Err) || results[2].Err != ports.ErrBulkAborted {
+			t.Errorf("Expected the items after the missing one to be aborted got: %+v with error: %v", results, err)
+		}
+
+		results, err = repo.DeleteMany(ctx, collection, ids, false)
+		if err != nil || !isNotFound(results[0].Err) || !isNotFound(results[1].Err) || results[2].Err != nil {
+			t.Errorf("Expected only the missing items to fail got: %+v with error: %v", results, err)
+		}
+
+		if count, err := repo.Count(ctx, collection); err != nil || count != 1 {
+			t.Errorf("Expected 1 item left got: %d with error: %v", count, err)
+		}
+	})
+
+	t.Run("Test transaction commits every write", func(t *testing.T) {
+		repo, collection, other := suite.New(t), newCollection(), newCollection()
+		patched, _ := repo.Create(ctx, collection, Item{Name: "Tony Stark"})
+		deleted, _ := repo.Create(ctx, collection, Item{Name: "Loki"})
+
+		created := ""
+		err := repo.WithTransaction(ctx, func(tx ports.Repository) error {
+			var err error
+			if created, err = tx.Create(ctx, other, Item{Name: "Steve Rogers"}); err != nil {
+				return err
+			}
+
+			if err := tx.Patch(ctx, collection, patched, ports.FieldMask{Set: map[string]interface{}{"age": 48}}); err != nil {
+				return err
+			}
+
+			return tx.Delete(ctx, collection, deleted)
+		})
+
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		got := Item{}
+		if err := repo.Get(ctx, other, created, &got); err != nil || got.Name != "Steve Rogers" {
+			t.Errorf("Expected item to be created got: %+v with error: %v", got, err)
+		}
+
+		got = Item{}
+		if err := repo.Get(ctx, collection, patched, &got); err != nil || got.Age != 48 {
+			t.Errorf("Expected item to be patched got: %+v with error: %v", got, err)
+		}
+
+		if err := repo.Get(ctx, collection, deleted, &Item{}); !isNotFound(err) {
+			t.Errorf("Expected error of type ErrItemNotFound got: %v", err)
+		}
+	})
+
+	t.Run("Test transaction discards every write on error", func(t *testing.T) {
+		repo, collection := suite.New(t), newCollection()
+		kept, _ := repo.Create(ctx, collection, Item{Name: "Tony Stark", Age: 48})
+		expected := errors.New("rollback")
+
+		err := repo.WithTransaction(ctx, func(tx ports.Repository) error {
+			if _, err := tx.Create(ctx, collection, Item{Name: "Steve Rogers"}); err != nil {
+				return err
+			}
+
+			if err := tx.Patch(ctx, collection, kept, ports.FieldMask{Set: map[string]interface{}{"age": 50}}); err != nil {
+				return err
+			}
+
+			if err := tx.Delete(ctx, collection, kept); err != nil {
+				return err
+			}
+
+			return expected
+		})
+
+		if err != expected {
+			t.Errorf("Expected the error of fn got: %v", err)
+		}
+
+		got := Item{}
+		if err := repo.Get(ctx, collection, kept, &got); err != nil || got.Age != 48 {
+			t.Errorf("Expected item to be unchanged got: %+v with error: %v", got, err)
+		}
+
+		if count, err := repo.Count(ctx, collection); err != nil || count != 1 {
+			t.Errorf("Expected 1 item got: %d with error: %v", count, err)
+		}
+	})
+
+	t.Run("Test cancelled context stops operations", func(t *testing.T) {
+		repo, collection := suite.New(t), newCollection()
+		id, _ := repo.Create(ctx, collection, Item{Name: "Tony Stark"})
+		cancelled, cancelFn := context.WithCancel(ctx)
+		cancelFn()
+
+		if err := repo.Get(cancelled, collection, id, &Item{}); !errors.Is(err, context.Canceled) {
+			t.Errorf("Get: Expected error context.Canceled got: %v", err)
+		}
+
+		if _, err := repo.Create(cancelled, collection, Item{Name: "Steve Rogers"}); !errors.Is(err, context.Canceled) {
+			t.Errorf("Create: Expected error context.Canceled got: %v", err)
+		}
+
+		err := repo.WithTransaction(cancelled, func(tx ports.Repository) error {
+			_, err := tx.Create(cancelled, collection, Item{Name: "Peter Parker"})
+			return err
+		})
+
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("WithTransaction: Expected error context.Canceled got: %v", err)
+		}
+
+		if count, err := repo.Count(ctx, collection); err != nil || count != 1 {
+			t.Errorf("Expected 1 item got: %d with error: %v", count, err)
+		}
+	})
+
+	t.Run("Test delete", func(t *testing.T) {
+		repo, collection := suite.New(t), newCollection()
+		keep, _ := repo.Create(ctx, collection, Item{Name: "Keep"})
+		remove, _ := repo.Create(ctx, collection, Item{Name: "Remove"})
+
+		if err := repo.Delete(ctx, collection, remove); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		if err := repo.Get(ctx, collection, keep, &Item{}); err != nil {
+			t.Errorf("Expected other items to be kept got error: %v", err)
+		}
+
+		if count, err := repo.Count(ctx, collection); err != nil || count != 1 {
+			t.Errorf("Expected 1 item got: %d with error: %v", count, err)
+		}
+	})
+
+	t.Run("Test filters", func(t *testing.T) {
+		repo, collection := suite.New(t), newCollection()
+		ids := createHeroes(t, repo, collection)
+
+		tests := []struct {
+			name      string
+			operators []string
+			filters   []ports.Filter
+			expected  []string
+		}{
+			{"eq", nil, []ports.Filter{ports.Eq("name", "Tony Stark")}, []string{"tony"}},
+			{"eq bool", nil, []ports.Filter{ports.Eq("active", true)}, []string{"tony", "peter"}},
+			{"eq id", nil, []ports.Filter{ports.Eq("_id", ids["steve"])}, []string{"steve"}},
+			{"in ids", []string{ports.OP_IN}, []ports.Filter{ports.In("_id", ids["tony"], ids["bruce"])}, []string{"tony", "bruce"}},
+			{"ne", []string{ports.OP_NE}, []ports.Filter{ports.Ne("name", "Tony Stark")}, []string{"steve", "peter", "bruce"}},
+			{"nin", []string{ports.OP_NIN}, []ports.Filter{ports.Nin("age", 48, 17)}, []string{"steve", "bruce"}},
+			{"gt", []string{ports.OP_GT}, []ports.Filter{ports.Gt("age", 48)}, []string{"steve", "bruce"}},
+			{"gte", []string{ports.OP_GTE}, []ports.Filter{ports.Gte("age", 48)}, []string{"tony", "steve", "bruce"}},
+			{"lt", []string{ports.OP_LT}, []ports.Filter{ports.Lt("age", 48)}, []string{"peter"}},
+			{"lte", []string{ports.OP_LTE}, []ports.Filter{ports.Lte("age", 48)}, []string{"tony", "peter"}},
+			{"range", []string{ports.OP_GT, ports.OP_LT}, []ports.Filter{ports.Gt("age", 17), ports.Lt("age", 100)}, []string{"tony", "bruce"}},
+			{"prefix", []string{ports.OP_PREFIX}, []ports.Filter{ports.Prefix("name", "Tony")}, []string{"tony"}},
+			{"contains", []string{ports.OP_CONTAINS}, []ports.Filter{ports.Contains("name", "ar")}, []string{"tony", "peter"}},
+			{"exists", []string{ports.OP_EXISTS}, []ports.Filter{ports.Exists("active", false)}, []string{"steve", "bruce"}},
+			{"and", []string{ports.OP_AND, ports.OP_GT}, []ports.Filter{ports.And(ports.Eq("active", true), ports.Gt("age", 20))}, []string{"tony"}},
+			{"or", []string{ports.OP_OR}, []ports.Filter{ports.Or(ports.Eq("name", "Tony Stark"), ports.Eq("age", 17))}, []string{"tony", "peter"}},
+			{"not", []string{ports.OP_NOT}, []ports.Filter{ports.Not(ports.Eq("active", true))}, []string{"steve", "bruce"}},
+		}
+
+		for _, test := range tests {
+			supported := true
+			for _, op := range test.operators {
+				supported = supported && !unsupported[op]
+			}
+
+			got := []Item{}
+			err := repo.List(ctx, collection, &got, 0, 10, nil, test.filters...)
+			count, countErr := repo.Count(ctx, collection, test.filters...)
+
+			if !supported {
+				if !isInvalidFilter(err) || !isInvalidFilter(countErr) {
+					t.Errorf("%s: Expected errors of type ErrInvalidFilter got: %v and %v", test.name, err, countErr)
+				}
+				continue
+			}
+
+			expected := namesToIds(ids, test.expected)
+			if err != nil || !cmp.Equal(expected, itemIds(got)) {
+				t.Errorf("%s: Expected ids: %v got: %v with error: %v", test.name, expected, itemIds(got), err)
+			}
+
+			if countErr != nil || count != len(expected) {
+				t.Errorf("%s: Expected count: %d got: %d with error: %v", test.name, len(expected), count, countErr)
+			}
+		}
+
+		got := Item{}
+		err := repo.GetOne(ctx, collection, &got, ports.Eq("name", "Peter Parker"))
+		if err != nil || got.Id != ids["peter"] {
+			t.Errorf("Expected item: %q got: %+v with error: %v", ids["peter"], got, err)
+		}
+	})
+
+	t.Run("Test invalid filters", func(t *testing.T) {
+		repo, collection := suite.New(t), newCollection()
+		createHeroes(t, repo, collection)
+		invalid := ports.Filter{Name: "name", Operator: "regex", Value: "^T"}
+
+		if err := repo.List(ctx, collection, &[]Item{}, 0, 10, nil, invalid); !isInvalidFilter(err) {
+			t.Errorf("List: Expected error of type ErrInvalidFilter got: %v", err)
+		}
+
+		if _, err := repo.ListPage(ctx, collection, &[]Item{}, "", 10, invalid); !isInvalidFilter(err) {
+			t.Errorf("ListPage: Expected error of type ErrInvalidFilter got: %v", err)
+		}
+
+		if _, err := repo.Count(ctx, collection, invalid); !isInvalidFilter(err) {
+			t.Errorf("Count: Expected error of type ErrInvalidFilter got: %v", err)
+		}
+
+		if err := repo.GetOne(ctx, collection, &Item{}, invalid); !isInvalidFilter(err) {
+			t.Errorf("GetOne: Expected error of type ErrInvalidFilter got: %v", err)
+		}
+	})
+
+	t.Run("Test list bounds", func(t *testing.T) {
+		repo, collection := suite.New(t), newCollection()
+		ids := createHeroes(t, repo, collection)
+
+		got := []Item{}
+		err := repo.List(ctx, collection, &got, 0, 100, nil)
+		expected := namesToIds(ids, []string{"tony", "steve", "peter", "bruce"})
+		if err != nil || !cmp.Equal(expected, itemIds(got)) {
+			t.Errorf("Expected all ids: %v got: %v with error: %v", expected, itemIds(got), err)
+		}
+
+		got = []Item{}
+		err = repo.List(ctx, collection, &got, 4, 10, nil)
+		if err != nil || len(got) != 0 {
+			t.Errorf("Expected no items after the last one got: %+v with error: %v", got, err)
+		}
+
+		got = []Item{}
+		err = repo.List(ctx, collection, &got, 1, 2, []ports.Sort{{Field: "age", Descending: true}})
+		if suite.NoSort {
+			if !errors.As(err, &ports.ErrInvalidSort{}) {
+				t.Errorf("Expected error of type ErrInvalidSort got: %v", err)
+			}
+
+			return
+		}
+
+		if err != nil || len(got) != 2 || got[0].Id != ids["bruce"] || got[1].Id != ids["tony"] {
+			t.Errorf("Expected items: %q and %q got: %+v with error: %v", ids["bruce"], ids["tony"], got, err)
+		}
+	})
+
+	t.Run("Test list page", func(t *testing.T) {
+		repo, collection := suite.New(t), newCollection()
+		ids := createHeroes(t, repo, collection)
+
+		seen := []string{}
+		after := ""
+		for pages := 0; pages < 10; pages++ {
+			got := []Item{}
+			pageInfo, err := repo.ListPage(ctx, collection, &got, after, 3)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if len(got) > 3 || len(got) != len(pageInfo.Cursors) {
+				t.Errorf("Expected up to 3 items with a cursor each got: %d items and %d cursors", len(got), len(pageInfo.Cursors))
+			}
+
+			for _, item := range got {
+				seen = append(seen, item.Id)
+			}
+
+			if !pageInfo.HasNextPage || len(got) == 0 {
+				break
+			}
+
+			after = pageInfo.EndCursor
+		}
+
+		sort.Strings(seen)
+		expected := namesToIds(ids, []string{"tony", "steve", "peter", "bruce"})
+		if !cmp.Equal(expected, seen) {
+			t.Errorf("Expected every id once: %v got: %v", expected, seen)
+		}
+
+		_, err := repo.ListPage(ctx, collection, &[]Item{}, "not a cursor", 3)
+		if _, ok := err.(ports.ErrInvalidCursor); !ok {
+			t.Errorf("Expected error of type ErrInvalidCursor got: %v", err)
+		}
+	})
+
+	t.Run("Test concurrent use", func(t *testing.T) {
+		repo, collection := suite.New(t), newCollection()
+		const workers = 10
+
+		ids := make(chan string, workers)
+		errs := make(chan error, workers*3)
+		wg := sync.WaitGroup{}
+		for i := 0; i < workers; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				id, err := repo.Create(ctx, collection, Item{Name: fmt.Sprintf("Worker %d", i), Age: i + 1})
+				if err != nil {
+					errs <- err
+					return
+				}
+
+				ids <- id
+				errs <- repo.Update(ctx, collection, id, Item{Active: true})
+				_, err = repo.Count(ctx, collection)
+				errs <- err
+			}(i)
+		}
+
+		wg.Wait()
+		close(ids)
+		close(errs)
+
+		for err := range errs {
+			if err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		}
+
+		unique := map[string]bool{}
+		for id := range ids {
+			unique[id] = true
+		}
+
+		count, err := repo.Count(ctx, collection, ports.Eq("active", true))
+		if err != nil || len(unique) != workers || count != workers {
+			t.Errorf("Expected %d unique updated items got: %d ids and count: %d with error: %v", workers, len(unique), count, err)
+		}
+	})
+}
+
+// createHeroes creates the items used by the filter tests and returns their ids by name
+func createHeroes(t *testing.T, repo ports.Repository, collection string) map[string]string {
+	ctx := context.Background()
+	heroes := map[string]Item{
+		"tony":  {Name: "Tony Stark", Age: 48, Active: true},
+		"steve": {Name: "Steve Rogers", Age: 100},
+		"peter": {Name: "Peter Parker", Age: 17, Active: true},
+		"bruce": {Name: "Bruce Banner", Age: 49},
+	}
+
+	ids := map[string]string{}
+	for _, name := range []string{"tony", "steve", "peter", "bruce"} {
+		id, err := repo.Create(ctx, collection, heroes[name])
+		if err != nil {
+			t.Fatalf("Unexpected error creating %s: %v", name, err)
+		}
+
+		ids[name] = id
+	}
+
+	return ids
+}
+
+// newCollection returns a random collection name so every test starts empty
+func newCollection() string {
+	suffix := make([]byte, 6)
+	rand.Read(suffix)
+	return "contract_" + hex.EncodeToString(suffix)
+}
+
+// namesToIds returns the sorted ids of the named items
+func namesToIds(ids map[string]string, names []string) []string {
+	output := []string{}
+	for _, name := range names {
+		output = append(output, ids[name])
+	}
+
+	sort.Strings(output)
+	return output
+}
+
+// itemIds returns the sorted ids of items
+func itemIds(items []Item) []string {
+	output := []string{}
+	for _, item := range items {
+		output = append(output, item.Id)
+	}
+
+	sort.Strings(output)
+	return output
+}
+
+func isNotFound(err error) bool {
+	return errors.As(err, &ports.ErrItemNotFound{})
+}
+
+func isInvalidFilter(err error) bool {
+	return errors.As(err, &ports.ErrInvalidFilter{})
+}
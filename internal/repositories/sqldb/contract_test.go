@@ -0,0 +1,18 @@
+package sqldb
+
+import (
+	"testing"
+
+	"github.com/sy-software/minerva-owl/internal/core/ports"
+	"github.com/sy-software/minerva-owl/internal/repositories/repotest"
+)
+
+func TestContract(t *testing.T) {
+	repotest.Run(t, repotest.Suite{
+		New: func(t *testing.T) ports.Repository {
+			db, repo := openRepo(t)
+			t.Cleanup(db.Close)
+			return repo
+		},
+	})
+}
@@ -0,0 +1,119 @@
+package sqldb
+
+import (
+	"database/sql"
+	"embed"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/golang-migrate/migrate/v4/database"
+	migratesqlite "github.com/golang-migrate/migrate/v4/database/sqlite3"
+	"github.com/golang-migrate/migrate/v4/source"
+	"github.com/golang-migrate/migrate/v4/source/httpfs"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// SQLITE_DRIVER is the database/sql driver name of SQLite
+const SQLITE_DRIVER = "sqlite3"
+
+//go:embed migrations
+var migrations embed.FS
+
+// Dialect generates the SQL fragments which differ between databases
+//
+// Documents are stored as JSON in the doc column, field names passed to a
+// Dialect are already validated and can be used as literals
+type Dialect interface {
+	// Placeholder returns the parameter placeholder at position, starting at 1
+	Placeholder(position int) string
+	// Quote quotes an identifier
+	Quote(identifier string) string
+	// CreateTable returns the statement creating the table of a collection if it doesn't exist
+	CreateTable(table string) string
+	// Field returns an expression with the scalar value of a document field,
+	// nested fields are separated by dots
+	Field(field string) string
+	// Elements returns a table expression with a value column for each element of an
+	// array field, scalar fields have a single row
+	Elements(field string) string
+	// Exists returns a condition matching documents where the field is set, even to null
+	Exists(field string) string
+	// Position returns an expression with the 1 based position of substr in str, 0 if it's missing
+	Position(str string, substr string) string
+	// Value converts a filter value into a parameter comparable with Field
+	Value(value interface{}) interface{}
+	// Migrations returns the source of the schema migrations
+	Migrations() (source.Driver, error)
+	// MigrationDriver returns the migrate driver used to apply the migrations to db
+	MigrationDriver(db *sql.DB) (database.Driver, error)
+}
+
+var dialects = map[string]Dialect{
+	SQLITE_DRIVER: sqliteDialect{},
+}
+
+// GetDialect returns the Dialect of a database/sql driver
+func GetDialect(driver string) (Dialect, error) {
+	dialect, exists := dialects[driver]
+	if !exists {
+		return nil, fmt.Errorf("no SQL dialect for driver %q", driver)
+	}
+
+	return dialect, nil
+}
+
+// sqliteDialect implements Dialect with the JSON functions of SQLite
+type sqliteDialect struct{}
+
+func (sqliteDialect) Placeholder(position int) string {
+	return "?"
+}
+
+func (sqliteDialect) Quote(identifier string) string {
+	return `"` + strings.ReplaceAll(identifier, `"`, `""`) + `"`
+}
+
+func (dialect sqliteDialect) CreateTable(table string) string {
+	return fmt.Sprintf(
+		"CREATE TABLE IF NOT EXISTS %s (seq INTEGER PRIMARY KEY AUTOINCREMENT, id TEXT NOT NULL UNIQUE, doc TEXT NOT NULL)",
+		dialect.Quote(table),
+	)
+}
+
+func (sqliteDialect) Field(field string) string {
+	return fmt.Sprintf("json_extract(doc, '$.%s')", field)
+}
+
+func (sqliteDialect) Elements(field string) string {
+	return fmt.Sprintf("json_each(doc, '$.%s')", field)
+}
+
+func (sqliteDialect) Exists(field string) string {
+	return fmt.Sprintf("json_type(doc, '$.%s') IS NOT NULL", field)
+}
+
+func (sqliteDialect) Position(str string, substr string) string {
+	return fmt.Sprintf("instr(%s, %s)", str, substr)
+}
+
+func (sqliteDialect) Value(value interface{}) interface{} {
+	// JSON booleans are extracted as integers
+	if boolean, ok := value.(bool); ok {
+		if boolean {
+			return 1
+		}
+
+		return 0
+	}
+
+	return value
+}
+
+func (sqliteDialect) Migrations() (source.Driver, error) {
+	return httpfs.New(http.FS(migrations), "migrations/sqlite3")
+}
+
+func (sqliteDialect) MigrationDriver(db *sql.DB) (database.Driver, error) {
+	return migratesqlite.WithInstance(db, &migratesqlite.Config{})
+}
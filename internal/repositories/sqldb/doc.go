@@ -0,0 +1,3 @@
+// Package sqldb implements a ports.Repository stored in a relational database through database/sql,
+// each collection is a table and the differences between databases are handled by a Dialect
+package sqldb
@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS "users" (
+    seq INTEGER PRIMARY KEY AUTOINCREMENT,
+    id TEXT NOT NULL UNIQUE,
+    doc TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS users_username ON "users" (json_extract(doc, '$.username'));
+CREATE INDEX IF NOT EXISTS users_role ON "users" (json_extract(doc, '$.role'));
+
+CREATE TABLE IF NOT EXISTS "organizations" (
+    seq INTEGER PRIMARY KEY AUTOINCREMENT,
+    id TEXT NOT NULL UNIQUE,
+    doc TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS organizations_name ON "organizations" (json_extract(doc, '$.name'));
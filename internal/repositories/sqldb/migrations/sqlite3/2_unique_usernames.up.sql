@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS users_username;
+CREATE UNIQUE INDEX IF NOT EXISTS users_username ON "users" (json_extract(doc, '$.username'));
@@ -0,0 +1,240 @@
+package sqldb
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+
+	"github.com/sy-software/minerva-owl/internal/core/ports"
+)
+
+// idField is the document field holding the item id, it's also stored in the id column
+const idField = "id"
+
+// fieldRegex matches the field names which can be used as literals in the queries
+var fieldRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)*$`)
+
+// tableRegex matches the collection names which can be used as table names
+var tableRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// query builds a parameterized SQL statement
+type query struct {
+	dialect Dialect
+	sql     strings.Builder
+	args    []interface{}
+}
+
+// newQuery creates a query starting with the given SQL
+func newQuery(dialect Dialect, sql string, args ...interface{}) *query {
+	q := &query{dialect: dialect}
+	q.sql.WriteString(sql)
+	for _, arg := range args {
+		q.arg(arg)
+	}
+
+	return q
+}
+
+// arg adds a parameter and returns its placeholder
+func (q *query) arg(value interface{}) string {
+	q.args = append(q.args, value)
+	return q.dialect.Placeholder(len(q.args))
+}
+
+// String returns the SQL statement
+func (q *query) String() string {
+	return q.sql.String()
+}
+
+// where appends a WHERE clause matching all the filters
+func (q *query) where(filters []ports.Filter, extra ...string) error {
+	conditions := append([]string{}, extra...)
+	for _, filter := range filters {
+		condition, err := q.condition(filter)
+		if err != nil {
+			return err
+		}
+
+		conditions = append(conditions, condition)
+	}
+
+	if len(conditions) > 0 {
+		q.sql.WriteString(" WHERE ")
+		q.sql.WriteString(strings.Join(conditions, " AND "))
+	}
+
+	return nil
+}
+
+// orderBy appends an ORDER BY clause, the insertion order is used as tie breaker
+func (q *query) orderBy(sortBy []ports.Sort) error {
+	clauses := []string{}
+	for _, s := range sortBy {
+		if !fieldRegex.MatchString(s.Field) {
+			return ports.ErrInvalidSort{
+				Field:  s.Field,
+				Reason: "invalid field name",
+			}
+		}
+
+		clause := q.field(s.Field)
+		if s.Descending {
+			clause += " DESC"
+		}
+
+		clauses = append(clauses, clause)
+	}
+
+	q.sql.WriteString(" ORDER BY ")
+	q.sql.WriteString(strings.Join(append(clauses, "seq"), ", "))
+	return nil
+}
+
+// field returns the expression with the value of a field, ids are read from the id column
+func (q *query) field(name string) string {
+	if name == "_id" || name == idField {
+		return idField
+	}
+
+	return q.dialect.Field(name)
+}
+
+// condition translates a filter into a SQL condition, the filter is validated first
+func (q *query) condition(filter ports.Filter) (string, error) {
+	if err := filter.Validate(); err != nil {
+		return "", err
+	}
+
+	if !filter.IsLogical() && !fieldRegex.MatchString(filter.Name) {
+		return "", ports.ErrInvalidFilter{
+			Filter: filter,
+			Reason: "invalid field name",
+		}
+	}
+
+	switch filter.Op() {
+	case ports.OP_AND, ports.OP_OR:
+		conditions := []string{}
+		for _, nested := range filter.Filters {
+			condition, err := q.condition(nested)
+			if err != nil {
+				return "", err
+			}
+
+			conditions = append(conditions, condition)
+		}
+
+		separator := " AND "
+		if filter.Op() == ports.OP_OR {
+			separator = " OR "
+		}
+
+		return "(" + strings.Join(conditions, separator) + ")", nil
+	case ports.OP_NOT:
+		// Comparisons with missing fields are NULL instead of false
+		condition, err := q.condition(filter.Filters[0])
+		return "NOT ((" + condition + ") IS TRUE)", err
+	case ports.OP_NE, ports.OP_NIN:
+		// Same as the memory backends, missing fields are not equal to any value
+		positive := filter
+		positive.Operator = ports.OP_EQ
+		if filter.Op() == ports.OP_NIN {
+			positive.Operator = ports.OP_IN
+		}
+
+		condition, err := q.condition(positive)
+		return "NOT " + condition, err
+	}
+
+	value := normalize(filter.Value)
+	field := q.field(filter.Name)
+
+	switch filter.Op() {
+	case ports.OP_EQ:
+		if value == nil {
+			return field + " IS NULL", nil
+		}
+
+		placeholder := q.arg(q.dialect.Value(value))
+		return q.anyElement(filter.Name, func(element string) string {
+			return element + " = " + placeholder
+		}), nil
+	case ports.OP_IN:
+		values, _ := value.([]interface{})
+		if len(values) == 0 {
+			return "1 = 0", nil
+		}
+
+		placeholders := make([]string, len(values))
+		for index, element := range values {
+			placeholders[index] = q.arg(q.dialect.Value(element))
+		}
+
+		return q.anyElement(filter.Name, func(element string) string {
+			return element + " IN (" + strings.Join(placeholders, ", ") + ")"
+		}), nil
+	case ports.OP_GT:
+		return field + " > " + q.arg(q.dialect.Value(value)), nil
+	case ports.OP_GTE:
+		return field + " >= " + q.arg(q.dialect.Value(value)), nil
+	case ports.OP_LT:
+		return field + " < " + q.arg(q.dialect.Value(value)), nil
+	case ports.OP_LTE:
+		return field + " <= " + q.arg(q.dialect.Value(value)), nil
+	case ports.OP_PREFIX:
+		placeholder := q.arg(value)
+		return q.anyElement(filter.Name, func(element string) string {
+			return q.dialect.Position(element, placeholder) + " = 1"
+		}), nil
+	case ports.OP_CONTAINS:
+		placeholder := q.arg(value)
+		return q.anyElement(filter.Name, func(element string) string {
+			return q.dialect.Position(element, placeholder) + " > 0"
+		}), nil
+	case ports.OP_EXISTS:
+		if filter.Name == "_id" || filter.Name == idField {
+			// Every item has an id
+			if value.(bool) {
+				return "1 = 1", nil
+			}
+
+			return "1 = 0", nil
+		}
+
+		if value.(bool) {
+			return "(" + q.dialect.Exists(filter.Name) + ")", nil
+		}
+
+		return "NOT (" + q.dialect.Exists(filter.Name) + ")", nil
+	}
+
+	return "", ports.ErrInvalidFilter{
+		Filter: filter,
+		Reason: "operator not supported by SQL",
+	}
+}
+
+// anyElement returns a condition matching when the field, or any of its elements
+// for arrays, matches the condition created by fn for an element expression
+func (q *query) anyElement(name string, fn func(element string) string) string {
+	if name == "_id" || name == idField {
+		return fn(idField)
+	}
+
+	return "EXISTS (SELECT 1 FROM " + q.dialect.Elements(name) + " WHERE " + fn("value") + ")"
+}
+
+// normalize converts value into the same types used by the stored documents
+func normalize(value interface{}) interface{} {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return value
+	}
+
+	var output interface{}
+	if err := json.Unmarshal(data, &output); err != nil {
+		return value
+	}
+
+	return output
+}
@@ -0,0 +1,673 @@
+package sqldb
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sy-software/minerva-owl/internal/core/ports"
+	"github.com/sy-software/minerva-owl/internal/repositories/memory"
+	"github.com/sy-software/minerva-owl/internal/utils"
+)
+
+// SQLRepo is an implementation of ports.Repository stored in a relational database
+//
+// Each collection is a table with the insertion sequence, the id and the document
+// serialized with the json tags of the domain models. Tables of the known collections
+// are created by the schema migrations, other collections get a table on first use
+type SQLRepo struct {
+	db      *SQLDB
+	dialect Dialect
+	// tables has the collections with a table already created
+	tables sync.Map
+	// tx is the transaction used by every operation of the repository returned by WithTransaction
+	tx *sql.Tx
+}
+
+// conn is the subset of methods shared by sql.DB and sql.Tx
+type conn interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// NewSQLRepo creates an instance of SQLRepo
+func NewSQLRepo(db *SQLDB) (*SQLRepo, error) {
+	return &SQLRepo{
+		db:      db,
+		dialect: db.dialect,
+	}, nil
+}
+
+// operation returns the context of a single operation, limited by the configured timeout
+func (repo *SQLRepo) operation(ctx context.Context) (context.Context, context.CancelFunc) {
+	if repo.db.config.Timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+
+	return context.WithTimeout(ctx, repo.db.config.Timeout*time.Second)
+}
+
+// conn returns the connection used for single statements, the current transaction inside WithTransaction
+func (repo *SQLRepo) conn() conn {
+	if repo.tx != nil {
+		return repo.tx
+	}
+
+	return repo.db.db
+}
+
+// table returns the quoted table name of a collection creating the table if it doesn't exist
+func (repo *SQLRepo) table(ctx context.Context, collection string) (string, error) {
+	if !tableRegex.MatchString(collection) {
+		return "", fmt.Errorf("invalid collection name %q", collection)
+	}
+
+	if _, exists := repo.tables.Load(collection); !exists {
+		if _, err := repo.conn().ExecContext(ctx, repo.dialect.CreateTable(collection)); err != nil {
+			return "", err
+		}
+
+		repo.tables.Store(collection, true)
+	}
+
+	return repo.dialect.Quote(collection), nil
+}
+
+// List stores into results a list of items from the given collection applying the filters
+// results must be a pointer to an Slice of an struct with json tags for serialization
+//
+// Items without sort are returned in insertion order
+func (repo *SQLRepo) List(ctx context.Context, collection string, results interface{}, skip int, limit int, sortBy []ports.Sort, filters ...ports.Filter) error {
+	ctx, cancelFn := repo.operation(ctx)
+	defer cancelFn()
+
+	table, err := repo.table(ctx, collection)
+	if err != nil {
+		return err
+	}
+
+	q := newQuery(repo.dialect, "SELECT seq, doc FROM "+table)
+	err = q.where(filters)
+	if err == nil {
+		err = q.orderBy(sortBy)
+	}
+
+	if err != nil {
+		utils.Logger(ctx).Debug().Err(err).Msgf("%v - List error", collection)
+		return err
+	}
+
+	q.sql.WriteString(" LIMIT " + q.arg(limit) + " OFFSET " + q.arg(skip))
+
+	_, err = repo.query(ctx, q, results)
+	if err != nil {
+		utils.Logger(ctx).Debug().Err(err).Msgf("%v - List error", collection)
+	}
+
+	return err
+}
+
+// ListPage stores into results up to limit items from the given collection after the cursor.
+// results must be a pointer to an Slice of an struct with json tags for serialization
+//
+// Items are returned in insertion order, a cursor keeps working even if its item is deleted
+func (repo *SQLRepo) ListPage(ctx context.Context, collection string, results interface{}, after string, limit int, filters ...ports.Filter) (ports.PageInfo, error) {
+	ctx, cancelFn := repo.operation(ctx)
+	defer cancelFn()
+
+	pageInfo := ports.PageInfo{
+		Cursors:         []string{},
+		HasPreviousPage: after != "",
+	}
+
+	table, err := repo.table(ctx, collection)
+	if err != nil {
+		return pageInfo, err
+	}
+
+	var afterSeq int64
+	if after != "" {
+		afterSeq, err = decodeCursor(after)
+		if err != nil {
+			return pageInfo, err
+		}
+	}
+
+	q := newQuery(repo.dialect, "SELECT seq, doc FROM "+table)
+	err = q.where(filters, "seq > "+q.arg(afterSeq))
+	if err != nil {
+		utils.Logger(ctx).Debug().Err(err).Msgf("%v - List page error", collection)
+		return pageInfo, err
+	}
+
+	// An extra item tells if there is a next page
+	q.sql.WriteString(" ORDER BY seq LIMIT " + q.arg(limit+1))
+
+	resultsVal := reflect.ValueOf(results).Elem()
+	sequences, err := repo.query(ctx, q, results)
+	if err != nil {
+		utils.Logger(ctx).Debug().Err(err).Msgf("%v - List page error", collection)
+		return pageInfo, err
+	}
+
+	if len(sequences) > limit {
+		pageInfo.HasNextPage = true
+		sequences = sequences[:limit]
+		resultsVal.Set(resultsVal.Slice(0, resultsVal.Len()-1))
+	}
+
+	for _, seq := range sequences {
+		pageInfo.EndCursor = encodeCursor(seq)
+		pageInfo.Cursors = append(pageInfo.Cursors, pageInfo.EndCursor)
+	}
+
+	return pageInfo, nil
+}
+
+// query runs q and appends the documents of each row to results, it returns the sequences of the rows
+func (repo *SQLRepo) query(ctx context.Context, q *query, results interface{}) ([]int64, error) {
+	rows, err := repo.conn().QueryContext(ctx, q.String(), q.args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sequences := []int64{}
+	resultsVal := reflect.ValueOf(results).Elem()
+	for rows.Next() {
+		var seq int64
+		var doc string
+		if err := rows.Scan(&seq, &doc); err != nil {
+			return nil, err
+		}
+
+		element := reflect.New(resultsVal.Type().Elem())
+		if err := json.Unmarshal([]byte(doc), element.Interface()); err != nil {
+			return nil, err
+		}
+
+		resultsVal.Set(reflect.Append(resultsVal, element.Elem()))
+		sequences = append(sequences, seq)
+	}
+
+	return sequences, rows.Err()
+}
+
+// Count returns how many items from collection match the filters
+func (repo *SQLRepo) Count(ctx context.Context, collection string, filters ...ports.Filter) (int, error) {
+	ctx, cancelFn := repo.operation(ctx)
+	defer cancelFn()
+
+	table, err := repo.table(ctx, collection)
+	if err != nil {
+		return 0, err
+	}
+
+	q := newQuery(repo.dialect, "SELECT COUNT(*) FROM "+table)
+	if err := q.where(filters); err != nil {
+		utils.Logger(ctx).Debug().Err(err).Msgf("%v - Count error", collection)
+		return 0, err
+	}
+
+	count := 0
+	err = repo.conn().QueryRowContext(ctx, q.String(), q.args...).Scan(&count)
+	if err != nil {
+		utils.Logger(ctx).Debug().Err(err).Msgf("%v - Count error", collection)
+	}
+
+	return count, err
+}
+
+// Get stores into result an item from collection with id equals to id
+// result must be a pointer to an instance of a struct with json tags for serialization
+func (repo *SQLRepo) Get(ctx context.Context, collection string, id string, result interface{}) error {
+	ctx, cancelFn := repo.operation(ctx)
+	defer cancelFn()
+
+	table, err := repo.table(ctx, collection)
+	if err != nil {
+		return err
+	}
+
+	q := newQuery(repo.dialect, "SELECT doc FROM "+table)
+	q.sql.WriteString(" WHERE id = " + q.arg(id))
+
+	var doc string
+	err = repo.conn().QueryRowContext(ctx, q.String(), q.args...).Scan(&doc)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ports.ErrItemNotFound{
+			Id:    &id,
+			Model: collection,
+		}
+	}
+
+	if err != nil {
+		utils.Logger(ctx).Debug().Err(err).Msgf("%v - Get error", collection)
+		return err
+	}
+
+	return json.Unmarshal([]byte(doc), result)
+}
+
+// GetMany appends to results the items from collection with any of the ids
+// results must be a pointer to an Slice of an struct with json tags for serialization
+func (repo *SQLRepo) GetMany(ctx context.Context, collection string, ids []string, results interface{}) error {
+	if len(ids) == 0 {
+		return ctx.Err()
+	}
+
+	return repo.List(ctx, collection, results, 0, len(ids), nil, ports.InIds(ids...))
+}
+
+// GetOne stores into result the first inserted item from collection matching the filters
+// result must be a pointer to an instance of a struct with json tags for serialization
+func (repo *SQLRepo) GetOne(ctx context.Context, collection string, result interface{}, filters ...ports.Filter) error {
+	ctx, cancelFn := repo.operation(ctx)
+	defer cancelFn()
+
+	table, err := repo.table(ctx, collection)
+	if err != nil {
+		return err
+	}
+
+	q := newQuery(repo.dialect, "SELECT doc FROM "+table)
+	if err := q.where(filters); err != nil {
+		utils.Logger(ctx).Debug().Err(err).Msgf("%v - Get error", collection)
+		return err
+	}
+
+	q.sql.WriteString(" ORDER BY seq LIMIT 1")
+
+	var doc string
+	err = repo.conn().QueryRowContext(ctx, q.String(), q.args...).Scan(&doc)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ports.ErrItemNotFound{
+			Model: collection,
+		}
+	}
+
+	if err != nil {
+		utils.Logger(ctx).Debug().Err(err).Msgf("%v - Get error", collection)
+		return err
+	}
+
+	return json.Unmarshal([]byte(doc), result)
+}
+
+// Create saves the serialized version of entity into the collection
+// entity must be an instance of a struct with json tags for serialization
+//
+// If the entity has no id a new V4 UUID is assigned
+func (repo *SQLRepo) Create(ctx context.Context, collection string, entity interface{}) (string, error) {
+	ctx, cancelFn := repo.operation(ctx)
+	defer cancelFn()
+
+	table, err := repo.table(ctx, collection)
+	if err != nil {
+		return "", err
+	}
+
+	doc, err := encode(entity)
+	if err != nil {
+		return "", err
+	}
+
+	id, _ := doc[idField].(string)
+	if id == "" {
+		id = uuid.New().String()
+		doc[idField] = id
+	}
+
+	err = repo.transaction(ctx, func(tx *sql.Tx) error {
+		return repo.insert(ctx, tx, table, collection, id, doc)
+	})
+
+	if err != nil {
+		utils.Logger(ctx).Debug().Err(err).Msgf("%v - Create error", collection)
+		return "", err
+	}
+
+	return id, nil
+}
+
+// insert stores doc with id as the newest item of table
+func (repo *SQLRepo) insert(ctx context.Context, tx *sql.Tx, table string, collection string, id string, doc map[string]interface{}) error {
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+
+	exists, err := repo.exists(ctx, tx, table, id)
+	if err != nil {
+		return err
+	}
+
+	if exists {
+		return ports.ErrDuplicatedId{Id: id, Model: collection}
+	}
+
+	q := newQuery(repo.dialect, "INSERT INTO "+table)
+	q.sql.WriteString(" (id, doc) VALUES (" + q.arg(id) + ", " + q.arg(string(data)) + ")")
+	_, err = tx.ExecContext(ctx, q.String(), q.args...)
+	return err
+}
+
+// Update saves the values of entity to the item with id from the collection
+// entity must be an instance of a struct with json tags for serialization
+//
+// Fields without value in entity and the field names in omit keep their stored value
+func (repo *SQLRepo) Update(ctx context.Context, collection string, id string, entity interface{}, omit ...string) error {
+	ctx, cancelFn := repo.operation(ctx)
+	defer cancelFn()
+
+	table, err := repo.table(ctx, collection)
+	if err != nil {
+		return err
+	}
+
+	values, err := encode(entity)
+	if err != nil {
+		return err
+	}
+
+	err = repo.modify(ctx, table, collection, id, func(doc map[string]interface{}) (map[string]interface{}, error) {
+		mergeFields(doc, values, omit)
+		return doc, nil
+	})
+
+	if err != nil {
+		utils.Logger(ctx).Debug().Err(err).Msgf("%v - Update error", collection)
+	}
+
+	return err
+}
+
+// Upsert saves the values of entity to the first inserted item from collection matching the filters,
+// except the fields in onInsertOnly, or creates entity if none matches. Both happen in the same transaction
+func (repo *SQLRepo) Upsert(ctx context.Context, collection string, filters []ports.Filter, entity interface{}, onInsertOnly ...string) (string, bool, error) {
+	ctx, cancelFn := repo.operation(ctx)
+	defer cancelFn()
+
+	table, err := repo.table(ctx, collection)
+	if err != nil {
+		return "", false, err
+	}
+
+	values, err := encode(entity)
+	if err != nil {
+		return "", false, err
+	}
+
+	id := ""
+	created := false
+	err = repo.transaction(ctx, func(tx *sql.Tx) error {
+		q := newQuery(repo.dialect, "SELECT id, doc FROM "+table)
+		if err := q.where(filters); err != nil {
+			return err
+		}
+
+		q.sql.WriteString(" ORDER BY seq LIMIT 1")
+
+		var data string
+		err := tx.QueryRowContext(ctx, q.String(), q.args...).Scan(&id, &data)
+		if errors.Is(err, sql.ErrNoRows) {
+			id, _ = values[idField].(string)
+			if id == "" {
+				id = uuid.New().String()
+				values[idField] = id
+			}
+
+			created = true
+			return repo.insert(ctx, tx, table, collection, id, values)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		doc := map[string]interface{}{}
+		if err := json.Unmarshal([]byte(data), &doc); err != nil {
+			return err
+		}
+
+		mergeFields(doc, values, onInsertOnly)
+		updated, err := json.Marshal(doc)
+		if err != nil {
+			return err
+		}
+
+		q = newQuery(repo.dialect, "UPDATE "+table)
+		q.sql.WriteString(" SET doc = " + q.arg(string(updated)) + " WHERE id = " + q.arg(id))
+		_, err = tx.ExecContext(ctx, q.String(), q.args...)
+		return err
+	})
+
+	if err != nil {
+		utils.Logger(ctx).Debug().Err(err).Msgf("%v - Upsert error", collection)
+		return "", false, err
+	}
+
+	return id, created, nil
+}
+
+// Patch changes the fields in mask of the item with id from the collection
+func (repo *SQLRepo) Patch(ctx context.Context, collection string, id string, mask ports.FieldMask) error {
+	ctx, cancelFn := repo.operation(ctx)
+	defer cancelFn()
+
+	table, err := repo.table(ctx, collection)
+	if err != nil {
+		return err
+	}
+
+	if err := mask.Validate(); err != nil {
+		utils.Logger(ctx).Debug().Err(err).Msgf("%v - Patch error", collection)
+		return err
+	}
+
+	err = repo.modify(ctx, table, collection, id, func(doc map[string]interface{}) (map[string]interface{}, error) {
+		return memory.ApplyFieldMask(doc, mask)
+	})
+
+	if err != nil {
+		utils.Logger(ctx).Debug().Err(err).Msgf("%v - Patch error", collection)
+	}
+
+	return err
+}
+
+// modify replaces the document of the item with id with the one returned by fn
+// in a single transaction
+func (repo *SQLRepo) modify(ctx context.Context, table string, collection string, id string, fn func(doc map[string]interface{}) (map[string]interface{}, error)) error {
+	return repo.transaction(ctx, func(tx *sql.Tx) error {
+		q := newQuery(repo.dialect, "SELECT doc FROM "+table)
+		q.sql.WriteString(" WHERE id = " + q.arg(id))
+
+		var data string
+		err := tx.QueryRowContext(ctx, q.String(), q.args...).Scan(&data)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ports.ErrItemNotFound{
+				Id:    &id,
+				Model: collection,
+			}
+		}
+
+		if err != nil {
+			return err
+		}
+
+		doc := map[string]interface{}{}
+		if err := json.Unmarshal([]byte(data), &doc); err != nil {
+			return err
+		}
+
+		doc, err = fn(doc)
+		if err != nil {
+			return err
+		}
+
+		updated, err := json.Marshal(doc)
+		if err != nil {
+			return err
+		}
+
+		q = newQuery(repo.dialect, "UPDATE "+table)
+		q.sql.WriteString(" SET doc = " + q.arg(string(updated)) + " WHERE id = " + q.arg(id))
+		_, err = tx.ExecContext(ctx, q.String(), q.args...)
+		return err
+	})
+}
+
+// Delete removes the item with id from collection
+func (repo *SQLRepo) Delete(ctx context.Context, collection string, id string) error {
+	ctx, cancelFn := repo.operation(ctx)
+	defer cancelFn()
+
+	table, err := repo.table(ctx, collection)
+	if err != nil {
+		return err
+	}
+
+	q := newQuery(repo.dialect, "DELETE FROM "+table)
+	q.sql.WriteString(" WHERE id = " + q.arg(id))
+
+	result, err := repo.conn().ExecContext(ctx, q.String(), q.args...)
+	if err != nil {
+		utils.Logger(ctx).Debug().Err(err).Msgf("%v - Delete error", collection)
+		return err
+	}
+
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return ports.ErrItemNotFound{
+			Id:    &id,
+			Model: collection,
+		}
+	}
+
+	return nil
+}
+
+// CreateMany saves every entity into the collection in order, each one like Create in its own transaction
+func (repo *SQLRepo) CreateMany(ctx context.Context, collection string, entities []interface{}, stopOnError bool) ([]ports.BulkResult, error) {
+	return ports.RunBulk(len(entities), stopOnError, func(index int) (string, error) {
+		return repo.Create(ctx, collection, entities[index])
+	}), nil
+}
+
+// PatchMany applies every patch to the collection in order, each one like Patch in its own transaction
+func (repo *SQLRepo) PatchMany(ctx context.Context, collection string, patches []ports.BulkPatch, stopOnError bool) ([]ports.BulkResult, error) {
+	return ports.RunBulk(len(patches), stopOnError, func(index int) (string, error) {
+		return patches[index].Id, repo.Patch(ctx, collection, patches[index].Id, patches[index].Mask)
+	}), nil
+}
+
+// DeleteMany removes every item with the ids from the collection in order, each one like Delete in its own transaction
+func (repo *SQLRepo) DeleteMany(ctx context.Context, collection string, ids []string, stopOnError bool) ([]ports.BulkResult, error) {
+	return ports.RunBulk(len(ids), stopOnError, func(index int) (string, error) {
+		return ids[index], repo.Delete(ctx, collection, ids[index])
+	}), nil
+}
+
+// exists tells if the table has an item with id
+func (repo *SQLRepo) exists(ctx context.Context, tx *sql.Tx, table string, id string) (bool, error) {
+	q := newQuery(repo.dialect, "SELECT COUNT(*) FROM "+table)
+	q.sql.WriteString(" WHERE id = " + q.arg(id))
+
+	count := 0
+	err := tx.QueryRowContext(ctx, q.String(), q.args...).Scan(&count)
+	return count > 0, err
+}
+
+// WithTransaction runs every operation of fn in a single database transaction,
+// committed when fn returns nil and rolled back otherwise. Nested calls run in the same transaction
+//
+// Tables created inside the transaction are only known by tx, so a rollback can't leave
+// a collection marked as created when it isn't
+func (repo *SQLRepo) WithTransaction(ctx context.Context, fn func(tx ports.Repository) error) error {
+	if repo.tx != nil {
+		return fn(repo)
+	}
+
+	return repo.transaction(ctx, func(tx *sql.Tx) error {
+		return fn(&SQLRepo{
+			db:      repo.db,
+			dialect: repo.dialect,
+			tx:      tx,
+		})
+	})
+}
+
+// transaction runs fn in a transaction which is committed if fn doesn't return an error,
+// inside WithTransaction fn runs in the current one
+func (repo *SQLRepo) transaction(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	if repo.tx != nil {
+		return fn(repo.tx)
+	}
+
+	tx, err := repo.db.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// mergeFields copies into doc the fields of values, except the id and the fields in omit
+func mergeFields(doc map[string]interface{}, values map[string]interface{}, omit []string) {
+	omitMap := map[string]bool{idField: true}
+	for _, field := range omit {
+		omitMap[field] = true
+	}
+
+	for field, value := range values {
+		if !omitMap[field] {
+			doc[field] = value
+		}
+	}
+}
+
+// encode serializes entity into a document
+func encode(entity interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(entity)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := map[string]interface{}{}
+	err = json.Unmarshal(data, &doc)
+	return doc, err
+}
+
+// encodeCursor converts an insertion sequence into an opaque cursor
+func encodeCursor(seq int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(seq, 10)))
+}
+
+// decodeCursor converts an opaque cursor back into an insertion sequence
+func decodeCursor(cursor string) (int64, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, ports.ErrInvalidCursor{Cursor: cursor}
+	}
+
+	seq, err := strconv.ParseInt(string(raw), 10, 64)
+	if err != nil {
+		return 0, ports.ErrInvalidCursor{Cursor: cursor}
+	}
+
+	return seq, nil
+}
@@ -0,0 +1,240 @@
+package sqldb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sy-software/minerva-owl/internal/core/domain"
+	"github.com/sy-software/minerva-owl/internal/core/ports"
+)
+
+type testItem struct {
+	Id     string   `json:"id,omitempty"`
+	Name   string   `json:"name,omitempty"`
+	Age    int      `json:"age,omitempty"`
+	Active bool     `json:"active,omitempty"`
+	Tags   []string `json:"tags,omitempty"`
+}
+
+// openRepo creates a migrated SQLRepo stored in a temporary SQLite file
+func openRepo(t *testing.T) (*SQLDB, *SQLRepo) {
+	db, err := Open(domain.SQLConfig{
+		Driver:       SQLITE_DRIVER,
+		DSN:          filepath.Join(t.TempDir(), "owl.sqlite"),
+		MaxOpenConns: 1,
+		Timeout:      1,
+	})
+
+	if err != nil {
+		t.Fatalf("Unexpected error opening database: %v", err)
+	}
+
+	if err := db.Migrate(); err != nil {
+		db.Close()
+		t.Fatalf("Unexpected error migrating database: %v", err)
+	}
+
+	repo, _ := NewSQLRepo(db)
+	return db, repo
+}
+
+func TestSQLRepo(t *testing.T) {
+	ctx := context.Background()
+	t.Run("Test migrations can run again", func(t *testing.T) {
+		db, _ := openRepo(t)
+		defer db.Close()
+
+		if err := db.Migrate(); err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+	})
+
+	t.Run("Test usernames are unique", func(t *testing.T) {
+		db, repo := openRepo(t)
+		defer db.Close()
+
+		if _, err := repo.Create(ctx, domain.USER_COL_NAME, domain.User{Username: "IronMan"}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		if _, err := repo.Create(ctx, domain.USER_COL_NAME, domain.User{Username: "IronMan"}); err == nil {
+			t.Errorf("Expected error for duplicated username got nil")
+		}
+	})
+
+	t.Run("Test create, get, update and delete", func(t *testing.T) {
+		db, repo := openRepo(t)
+		defer db.Close()
+
+		id, err := repo.Create(ctx, domain.USER_COL_NAME, domain.User{Username: "IronMan", Name: "Tony Stark", Role: "admin"})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		if _, err := repo.Create(ctx, domain.USER_COL_NAME, domain.User{Id: id}); !errors.As(err, &ports.ErrDuplicatedId{}) {
+			t.Errorf("Expected error of type ErrDuplicatedId got: %v", err)
+		}
+
+		err = repo.Update(ctx, domain.USER_COL_NAME, id, domain.User{Id: "other", Name: "Anthony Stark", Role: "user"}, "role")
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+
+		got := domain.User{}
+		err = repo.Get(ctx, domain.USER_COL_NAME, id, &got)
+		expected := domain.User{Id: id, Username: "IronMan", Name: "Anthony Stark", Role: "admin"}
+		if err != nil || !cmp.Equal(expected, got) {
+			t.Errorf("Expected user: %+v got: %+v with error: %v", expected, got, err)
+		}
+
+		if err := repo.Delete(ctx, domain.USER_COL_NAME, id); err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+
+		if _, ok := repo.Get(ctx, domain.USER_COL_NAME, id, &got).(ports.ErrItemNotFound); !ok {
+			t.Errorf("Expected error of type ErrItemNotFound after delete")
+		}
+
+		if _, ok := repo.Delete(ctx, domain.USER_COL_NAME, id).(ports.ErrItemNotFound); !ok {
+			t.Errorf("Expected error of type ErrItemNotFound deleting twice")
+		}
+
+		if _, ok := repo.Update(ctx, domain.USER_COL_NAME, id, domain.User{}).(ports.ErrItemNotFound); !ok {
+			t.Errorf("Expected error of type ErrItemNotFound updating a deleted item")
+		}
+	})
+
+	t.Run("Test filters", func(t *testing.T) {
+		db, repo := openRepo(t)
+		defer db.Close()
+
+		items := []testItem{
+			{Id: "1", Name: "Tony Stark", Age: 48, Active: true, Tags: []string{"avenger", "genius"}},
+			{Id: "2", Name: "Steve Rogers", Age: 100, Tags: []string{"avenger"}},
+			{Id: "3", Name: "Peter Parker", Age: 17, Active: true},
+			{Id: "4", Name: "Tony Montana"},
+		}
+
+		for _, item := range items {
+			repo.Create(ctx, "heroes", item)
+		}
+
+		tests := []struct {
+			name     string
+			filters  []ports.Filter
+			expected []string
+		}{
+			{"eq", []ports.Filter{ports.Eq("name", "Tony Stark")}, []string{"1"}},
+			{"eq array element", []ports.Filter{ports.Eq("tags", "avenger")}, []string{"1", "2"}},
+			{"eq bool", []ports.Filter{ports.Eq("active", true)}, []string{"1", "3"}},
+			{"eq nil", []ports.Filter{ports.Eq("age", nil)}, []string{"4"}},
+			{"ne includes missing", []ports.Filter{ports.Ne("tags", "genius")}, []string{"2", "3", "4"}},
+			{"in", []ports.Filter{ports.In("_id", "2", "4")}, []string{"2", "4"}},
+			{"in empty", []ports.Filter{ports.In("name")}, []string{}},
+			{"nin", []ports.Filter{ports.Nin("age", 48, 17)}, []string{"2", "4"}},
+			{"gt", []ports.Filter{ports.Gt("age", 18)}, []string{"1", "2"}},
+			{"lte", []ports.Filter{ports.Lte("age", 48)}, []string{"1", "3"}},
+			{"prefix is case sensitive", []ports.Filter{ports.Prefix("name", "tony")}, []string{}},
+			{"prefix", []ports.Filter{ports.Prefix("name", "Tony")}, []string{"1", "4"}},
+			{"contains", []ports.Filter{ports.Contains("tags", "eni")}, []string{"1"}},
+			{"exists", []ports.Filter{ports.Exists("tags", false)}, []string{"3", "4"}},
+			{"or", []ports.Filter{ports.Or(ports.Eq("_id", "3"), ports.Gt("age", 50))}, []string{"2", "3"}},
+			{"not with missing fields", []ports.Filter{ports.Not(ports.Gt("age", 18))}, []string{"3", "4"}},
+			{"and", []ports.Filter{ports.And(ports.Prefix("name", "Tony"), ports.Exists("age", true))}, []string{"1"}},
+		}
+
+		for _, test := range tests {
+			got := []testItem{}
+			err := repo.List(ctx, "heroes", &got, 0, 10, nil, test.filters...)
+
+			ids := []string{}
+			for _, item := range got {
+				ids = append(ids, item.Id)
+			}
+
+			if err != nil || !cmp.Equal(test.expected, ids) {
+				t.Errorf("%s: Expected ids: %v got: %v with error: %v", test.name, test.expected, ids, err)
+			}
+
+			count, err := repo.Count(ctx, "heroes", test.filters...)
+			if err != nil || count != len(test.expected) {
+				t.Errorf("%s: Expected count: %d got: %d with error: %v", test.name, len(test.expected), count, err)
+			}
+		}
+
+		got := []testItem{}
+		err := repo.List(ctx, "heroes", &got, 1, 2, []ports.Sort{{Field: "age", Descending: true}})
+		if err != nil || len(got) != 2 || got[0].Id != "1" || got[1].Id != "3" {
+			t.Errorf("Expected heroes 1 and 3 got: %+v with error: %v", got, err)
+		}
+
+		one := testItem{}
+		err = repo.GetOne(ctx, "heroes", &one, ports.Prefix("name", "Tony"))
+		if err != nil || one.Id != "1" {
+			t.Errorf("Expected hero 1 got: %+v with error: %v", one, err)
+		}
+
+		err = repo.List(ctx, "heroes", &got, 0, 10, nil, ports.Eq("name') OR 1=1 --", "x"))
+		if _, ok := err.(ports.ErrInvalidFilter); !ok {
+			t.Errorf("Expected error of type ErrInvalidFilter got: %v", err)
+		}
+
+		err = repo.List(ctx, "heroes", &got, 0, 10, []ports.Sort{{Field: "age; DROP TABLE heroes"}})
+		if _, ok := err.(ports.ErrInvalidSort); !ok {
+			t.Errorf("Expected error of type ErrInvalidSort got: %v", err)
+		}
+
+		if _, err := repo.Count(ctx, "heroes; DROP TABLE users"); err == nil {
+			t.Errorf("Expected error for invalid collection name got nil")
+		}
+	})
+
+	t.Run("Test list page", func(t *testing.T) {
+		db, repo := openRepo(t)
+		defer db.Close()
+
+		for i := 0; i < 5; i++ {
+			repo.Create(ctx, "heroes", testItem{Id: fmt.Sprintf("%d", i), Active: true})
+		}
+
+		ids := []string{}
+		after := ""
+		for pages := 0; pages < 5; pages++ {
+			got := []testItem{}
+			pageInfo, err := repo.ListPage(ctx, "heroes", &got, after, 2, ports.Eq("active", true))
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if len(got) != len(pageInfo.Cursors) {
+				t.Errorf("Expected a cursor per item got: %d items and %d cursors", len(got), len(pageInfo.Cursors))
+			}
+
+			for _, item := range got {
+				ids = append(ids, item.Id)
+			}
+
+			if !pageInfo.HasNextPage {
+				break
+			}
+
+			after = pageInfo.EndCursor
+			// A cursor keeps working after its item is deleted
+			repo.Delete(ctx, "heroes", got[len(got)-1].Id)
+		}
+
+		expected := []string{"0", "1", "2", "3", "4"}
+		if !cmp.Equal(expected, ids) {
+			t.Errorf("Expected ids: %v got: %v", expected, ids)
+		}
+
+		_, err := repo.ListPage(ctx, "heroes", &[]testItem{}, "invalid", 2)
+		if _, ok := err.(ports.ErrInvalidCursor); !ok {
+			t.Errorf("Expected error of type ErrInvalidCursor got: %v", err)
+		}
+	})
+}
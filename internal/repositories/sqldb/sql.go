@@ -0,0 +1,95 @@
+package sqldb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/rs/zerolog/log"
+	"github.com/sy-software/minerva-owl/internal/core/domain"
+)
+
+type SQLDB struct {
+	db      *sql.DB
+	dialect Dialect
+	config  domain.SQLConfig
+}
+
+// Open connects to the database described by config
+func Open(config domain.SQLConfig) (*SQLDB, error) {
+	dialect, err := GetDialect(config.Driver)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Info().Msgf("Opening SQL DB connection: %s", config.Driver)
+	db, err := sql.Open(config.Driver, config.DSN)
+	if err != nil {
+		return nil, err
+	}
+
+	if config.MaxOpenConns > 0 {
+		db.SetMaxOpenConns(config.MaxOpenConns)
+	}
+
+	instance := &SQLDB{
+		db:      db,
+		dialect: dialect,
+		config:  config,
+	}
+
+	if err := instance.Ping(context.Background()); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return instance, nil
+}
+
+// Ping checks the database is reachable within the configured timeout or the deadline of ctx
+func (sdb *SQLDB) Ping(ctx context.Context) error {
+	ctx, cancelFn := context.WithTimeout(ctx, sdb.config.Timeout*time.Second)
+	defer cancelFn()
+
+	return sdb.db.PingContext(ctx)
+}
+
+// Close disconnects from the database
+func (sdb *SQLDB) Close() {
+	if err := sdb.db.Close(); err != nil {
+		log.Error().Err(err).Msgf("Error closing SQL DB connection: %s", sdb.config.Driver)
+	}
+}
+
+// Migrate applies the pending schema migrations of the dialect
+func (sdb *SQLDB) Migrate() error {
+	migrations, err := sdb.dialect.Migrations()
+	if err != nil {
+		return err
+	}
+
+	driver, err := sdb.dialect.MigrationDriver(sdb.db)
+	if err != nil {
+		return err
+	}
+
+	// Closing the migration instance would close the shared connection
+	instance, err := migrate.NewWithInstance("embed", migrations, sdb.config.Driver, driver)
+	if err != nil {
+		return err
+	}
+
+	err = instance.Up()
+	if errors.Is(err, migrate.ErrNoChange) {
+		return nil
+	}
+
+	if err == nil {
+		version, _, _ := instance.Version()
+		log.Info().Msgf("SQL DB schema migrated to version: %d", version)
+	}
+
+	return err
+}
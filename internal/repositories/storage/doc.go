@@ -0,0 +1,3 @@
+// Package storage builds the ports.Repository used by the services
+// based on the storage backend selected in the configuration
+package storage
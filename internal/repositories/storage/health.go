@@ -0,0 +1,83 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Health status values
+const (
+	STATUS_UP   = "up"
+	STATUS_DOWN = "down"
+)
+
+// BackendHealth is the result of checking a single backend
+type BackendHealth struct {
+	Name    string        `json:"name"`
+	Type    string        `json:"type"`
+	Status  string        `json:"status"`
+	Latency time.Duration `json:"latency"`
+	Error   string        `json:"error,omitempty"`
+}
+
+// Healthy tells if all backends in the report are up
+func Healthy(report []BackendHealth) bool {
+	for _, health := range report {
+		if health.Status != STATUS_UP {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Health pings every backend concurrently and reports their status in the same order
+// as storage.Backends, backends not answering within timeout or before ctx is done are
+// down. A timeout <= 0 only uses the deadline of ctx
+func (storage *Storage) Health(ctx context.Context, timeout time.Duration) []BackendHealth {
+	report := make([]BackendHealth, len(storage.Backends))
+
+	var wg sync.WaitGroup
+	for index, backend := range storage.Backends {
+		wg.Add(1)
+		go func(index int, backend *Backend) {
+			defer wg.Done()
+			report[index] = checkBackend(ctx, backend, timeout)
+		}(index, backend)
+	}
+
+	wg.Wait()
+	return report
+}
+
+// checkBackend pings a backend and measures how long it takes to answer
+func checkBackend(ctx context.Context, backend *Backend, timeout time.Duration) BackendHealth {
+	if timeout > 0 {
+		var cancelFn context.CancelFunc
+		ctx, cancelFn = context.WithTimeout(ctx, timeout)
+		defer cancelFn()
+	}
+
+	start := time.Now()
+	err := backend.Ping(ctx)
+	if errors.Is(err, context.DeadlineExceeded) && timeout > 0 {
+		err = fmt.Errorf("ping timed out after %v", timeout)
+	}
+
+	health := BackendHealth{
+		Name:    backend.Name,
+		Type:    backend.Type,
+		Status:  STATUS_UP,
+		Latency: time.Since(start),
+	}
+
+	if err != nil {
+		health.Status = STATUS_DOWN
+		health.Error = err.Error()
+	}
+
+	return health
+}
@@ -0,0 +1,75 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sy-software/minerva-owl/mocks"
+)
+
+func TestHealth(t *testing.T) {
+	up := NewBackend(&mocks.MemRepo{}, nil, nil)
+	up.Name = "up"
+	up.Type = MEMORY_BACKEND
+
+	down := NewBackend(&mocks.MemRepo{}, func(ctx context.Context) error {
+		return errors.New("connection refused")
+	}, nil)
+	down.Name = "down"
+	down.Type = "custom"
+
+	storage := Storage{
+		Backends: []*Backend{up, down},
+	}
+
+	report := storage.Health(context.Background(), 0)
+
+	if len(report) != 2 {
+		t.Fatalf("Expected 2 items in report got: %d", len(report))
+	}
+
+	if report[0].Name != "up" || report[0].Status != STATUS_UP || report[0].Error != "" {
+		t.Errorf("Expected backend to be up got: %+v", report[0])
+	}
+
+	if report[1].Name != "down" || report[1].Status != STATUS_DOWN || report[1].Error != "connection refused" {
+		t.Errorf("Expected backend to be down got: %+v", report[1])
+	}
+
+	if Healthy(report) {
+		t.Errorf("Expected report to be unhealthy")
+	}
+
+	if !Healthy(report[:1]) {
+		t.Errorf("Expected report to be healthy")
+	}
+}
+
+func TestHealthTimeout(t *testing.T) {
+	stopped := make(chan struct{})
+	slow := NewBackend(&mocks.MemRepo{}, func(ctx context.Context) error {
+		defer close(stopped)
+		<-ctx.Done()
+		return ctx.Err()
+	}, nil)
+	slow.Name = "slow"
+
+	storage := Storage{
+		Backends: []*Backend{slow},
+	}
+
+	report := storage.Health(context.Background(), 10*time.Millisecond)
+
+	if report[0].Status != STATUS_DOWN || !strings.Contains(report[0].Error, "timed out") {
+		t.Errorf("Expected slow backend to be down got: %+v", report[0])
+	}
+
+	select {
+	case <-stopped:
+	default:
+		t.Errorf("Expected ping to stop once the timeout expires")
+	}
+}
@@ -0,0 +1,169 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sy-software/minerva-owl/internal/core/ports"
+)
+
+// Router is an implementation of ports.Repository which sends the operations
+// of each collection to the repository configured for it
+type Router struct {
+	fallback ports.Repository
+	routes   map[string]ports.Repository
+}
+
+// NewRouter creates an instance of Router, collections without a route are sent to fallback
+func NewRouter(fallback ports.Repository, routes map[string]ports.Repository) *Router {
+	return &Router{
+		fallback: fallback,
+		routes:   routes,
+	}
+}
+
+// Route returns the repository storing the given collection
+func (router *Router) Route(collection string) ports.Repository {
+	if repo, exists := router.routes[collection]; exists {
+		return repo
+	}
+
+	return router.fallback
+}
+
+// List returns a single page of items from the collection repository
+func (router *Router) List(ctx context.Context, collection string, results interface{}, skip int, limit int, sort []ports.Sort, filters ...ports.Filter) error {
+	return router.Route(collection).List(ctx, collection, results, skip, limit, sort, filters...)
+}
+
+// ListPage returns a single page of items after a cursor from the collection repository
+func (router *Router) ListPage(ctx context.Context, collection string, results interface{}, after string, limit int, filters ...ports.Filter) (ports.PageInfo, error) {
+	return router.Route(collection).ListPage(ctx, collection, results, after, limit, filters...)
+}
+
+// Count returns how many items match the filters in the collection repository
+func (router *Router) Count(ctx context.Context, collection string, filters ...ports.Filter) (int, error) {
+	return router.Route(collection).Count(ctx, collection, filters...)
+}
+
+// Get returns a single item from the collection repository
+func (router *Router) Get(ctx context.Context, collection string, id string, result interface{}) error {
+	return router.Route(collection).Get(ctx, collection, id, result)
+}
+
+// GetMany returns the items with the ids from the collection repository
+func (router *Router) GetMany(ctx context.Context, collection string, ids []string, results interface{}) error {
+	return router.Route(collection).GetMany(ctx, collection, ids, results)
+}
+
+// GetOne returns a single item filtered from the collection repository
+func (router *Router) GetOne(ctx context.Context, collection string, result interface{}, filters ...ports.Filter) error {
+	return router.Route(collection).GetOne(ctx, collection, result, filters...)
+}
+
+// Create saves a new item into the collection repository
+func (router *Router) Create(ctx context.Context, collection string, entity interface{}) (string, error) {
+	return router.Route(collection).Create(ctx, collection, entity)
+}
+
+// Update saves the values of an existing item into the collection repository
+func (router *Router) Update(ctx context.Context, collection string, id string, entity interface{}, omit ...string) error {
+	return router.Route(collection).Update(ctx, collection, id, entity, omit...)
+}
+
+// Upsert updates or creates an item in the collection repository
+func (router *Router) Upsert(ctx context.Context, collection string, filters []ports.Filter, entity interface{}, onInsertOnly ...string) (string, bool, error) {
+	return router.Route(collection).Upsert(ctx, collection, filters, entity, onInsertOnly...)
+}
+
+// Patch changes some fields of an existing item in the collection repository
+func (router *Router) Patch(ctx context.Context, collection string, id string, mask ports.FieldMask) error {
+	return router.Route(collection).Patch(ctx, collection, id, mask)
+}
+
+// Delete removes an item from the collection repository
+func (router *Router) Delete(ctx context.Context, collection string, id string) error {
+	return router.Route(collection).Delete(ctx, collection, id)
+}
+
+// CreateMany saves new items into the collection repository
+func (router *Router) CreateMany(ctx context.Context, collection string, entities []interface{}, stopOnError bool) ([]ports.BulkResult, error) {
+	return router.Route(collection).CreateMany(ctx, collection, entities, stopOnError)
+}
+
+// PatchMany changes some fields of existing items in the collection repository
+func (router *Router) PatchMany(ctx context.Context, collection string, patches []ports.BulkPatch, stopOnError bool) ([]ports.BulkResult, error) {
+	return router.Route(collection).PatchMany(ctx, collection, patches, stopOnError)
+}
+
+// DeleteMany removes items from the collection repository
+func (router *Router) DeleteMany(ctx context.Context, collection string, ids []string, stopOnError bool) ([]ports.BulkResult, error) {
+	return router.Route(collection).DeleteMany(ctx, collection, ids, stopOnError)
+}
+
+// WithTransaction runs fn in a transaction of the default repository.
+//
+// A transaction can't span several backends, collections routed to another backend can be
+// read inside fn but writing them returns ErrCrossBackendTransaction
+func (router *Router) WithTransaction(ctx context.Context, fn func(tx ports.Repository) error) error {
+	return router.fallback.WithTransaction(ctx, func(tx ports.Repository) error {
+		routes := make(map[string]ports.Repository, len(router.routes))
+		for collection, repo := range router.routes {
+			if repo == router.fallback {
+				routes[collection] = tx
+			} else {
+				routes[collection] = outsideTransaction{repo}
+			}
+		}
+
+		return fn(NewRouter(tx, routes))
+	})
+}
+
+// ErrCrossBackendTransaction is returned when a transaction writes a collection stored in
+// a different backend than the one running the transaction
+type ErrCrossBackendTransaction struct {
+	Collection string
+}
+
+func (err ErrCrossBackendTransaction) Error() string {
+	return fmt.Sprintf("collection %q is stored in a different backend than the transaction", err.Collection)
+}
+
+// outsideTransaction wraps the repository of a collection which is not part of a transaction,
+// reads are forwarded and writes are rejected
+type outsideTransaction struct {
+	ports.Repository
+}
+
+func (repo outsideTransaction) Create(ctx context.Context, collection string, entity interface{}) (string, error) {
+	return "", ErrCrossBackendTransaction{Collection: collection}
+}
+
+func (repo outsideTransaction) Update(ctx context.Context, collection string, id string, entity interface{}, omit ...string) error {
+	return ErrCrossBackendTransaction{Collection: collection}
+}
+
+func (repo outsideTransaction) Upsert(ctx context.Context, collection string, filters []ports.Filter, entity interface{}, onInsertOnly ...string) (string, bool, error) {
+	return "", false, ErrCrossBackendTransaction{Collection: collection}
+}
+
+func (repo outsideTransaction) Patch(ctx context.Context, collection string, id string, mask ports.FieldMask) error {
+	return ErrCrossBackendTransaction{Collection: collection}
+}
+
+func (repo outsideTransaction) Delete(ctx context.Context, collection string, id string) error {
+	return ErrCrossBackendTransaction{Collection: collection}
+}
+
+func (repo outsideTransaction) CreateMany(ctx context.Context, collection string, entities []interface{}, stopOnError bool) ([]ports.BulkResult, error) {
+	return nil, ErrCrossBackendTransaction{Collection: collection}
+}
+
+func (repo outsideTransaction) PatchMany(ctx context.Context, collection string, patches []ports.BulkPatch, stopOnError bool) ([]ports.BulkResult, error) {
+	return nil, ErrCrossBackendTransaction{Collection: collection}
+}
+
+func (repo outsideTransaction) DeleteMany(ctx context.Context, collection string, ids []string, stopOnError bool) ([]ports.BulkResult, error) {
+	return nil, ErrCrossBackendTransaction{Collection: collection}
+}
@@ -0,0 +1,116 @@
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sy-software/minerva-owl/internal/core/ports"
+	"github.com/sy-software/minerva-owl/mocks"
+)
+
+type auditLog struct {
+	Id     string `json:"id,omitempty"`
+	Action string `json:"action,omitempty"`
+}
+
+func TestRouter(t *testing.T) {
+	ctx := context.Background()
+	fallback := &mocks.MemRepo{
+		Data: map[string][]map[string]interface{}{},
+	}
+	archive := &mocks.MemRepo{
+		Data: map[string][]map[string]interface{}{},
+	}
+
+	router := NewRouter(fallback, map[string]ports.Repository{
+		"audit": archive,
+	})
+
+	id, err := router.Create(ctx, "audit", auditLog{Action: "login"})
+
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	if len(archive.Data["audit"]) != 1 || len(fallback.Data["audit"]) != 0 {
+		t.Errorf("Expected audit item to be saved in the archive repository")
+	}
+
+	got := auditLog{}
+	err = router.Get(ctx, "audit", id, &got)
+
+	if err != nil || got.Action != "login" {
+		t.Errorf("Expected to read the audit item got: %+v with error: %v", got, err)
+	}
+
+	_, err = router.Create(ctx, "users", auditLog{Action: "other"})
+
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	if len(fallback.Data["users"]) != 1 || len(archive.Data["users"]) != 0 {
+		t.Errorf("Expected users item to be saved in the fallback repository")
+	}
+
+	err = router.Delete(ctx, "audit", id)
+
+	if err != nil || len(archive.Data["audit"]) != 0 {
+		t.Errorf("Expected audit item to be deleted from the archive repository, error: %v", err)
+	}
+}
+
+func TestRouterTransaction(t *testing.T) {
+	ctx := context.Background()
+	fallback := &mocks.MemRepo{
+		Data: map[string][]map[string]interface{}{},
+	}
+	archive := &mocks.MemRepo{
+		Data: map[string][]map[string]interface{}{
+			"audit": {{"id": "1", "action": "login"}},
+		},
+	}
+
+	router := NewRouter(fallback, map[string]ports.Repository{
+		"audit": archive,
+		"users": fallback,
+	})
+
+	t.Run("Test collections of the default backend are written", func(t *testing.T) {
+		err := router.WithTransaction(ctx, func(tx ports.Repository) error {
+			if _, err := tx.Create(ctx, "users", auditLog{Action: "user"}); err != nil {
+				return err
+			}
+
+			_, err := tx.Create(ctx, "orgs", auditLog{Action: "org"})
+			return err
+		})
+
+		if err != nil || len(fallback.Data["users"]) != 1 || len(fallback.Data["orgs"]) != 1 {
+			t.Errorf("Expected items to be saved in the fallback repository got: %+v with error: %v", fallback.Data, err)
+		}
+	})
+
+	t.Run("Test collections of other backends are read only", func(t *testing.T) {
+		err := router.WithTransaction(ctx, func(tx ports.Repository) error {
+			if err := tx.Get(ctx, "audit", "1", &auditLog{}); err != nil {
+				return err
+			}
+
+			if _, err := tx.Create(ctx, "users", auditLog{Action: "discarded"}); err != nil {
+				return err
+			}
+
+			_, err := tx.Create(ctx, "audit", auditLog{Action: "logout"})
+			return err
+		})
+
+		if _, ok := err.(ErrCrossBackendTransaction); !ok {
+			t.Errorf("Expected error of type ErrCrossBackendTransaction got: %v", err)
+		}
+
+		if len(fallback.Data["users"]) != 1 || len(archive.Data["audit"]) != 1 {
+			t.Errorf("Expected the transaction to be discarded got: %+v and %+v", fallback.Data, archive.Data)
+		}
+	})
+}
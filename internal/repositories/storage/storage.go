@@ -0,0 +1,500 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+	"github.com/sy-software/minerva-owl/internal/core/domain"
+	"github.com/sy-software/minerva-owl/internal/core/ports"
+	"github.com/sy-software/minerva-owl/internal/repositories/boltdb"
+	"github.com/sy-software/minerva-owl/internal/repositories/cache"
+	"github.com/sy-software/minerva-owl/internal/repositories/cassandra"
+	"github.com/sy-software/minerva-owl/internal/repositories/memory"
+	"github.com/sy-software/minerva-owl/internal/repositories/mongodb"
+	"github.com/sy-software/minerva-owl/internal/repositories/sqldb"
+)
+
+// Types of the storage backends included by default
+const (
+	MONGO_BACKEND     = "mongo"
+	CASSANDRA_BACKEND = "cassandra"
+	MEMORY_BACKEND    = "memory"
+	BOLT_BACKEND      = "bolt"
+	SQL_BACKEND       = "sql"
+)
+
+// ErrUnknownBackend is returned when a configured backend has no registered factory
+type ErrUnknownBackend struct {
+	// The configured backend name
+	Name string
+	// The names of all registered backends
+	Available []string
+}
+
+func (err ErrUnknownBackend) Error() string {
+	return fmt.Sprintf(
+		"unknown storage backend %q, available backends: %s",
+		err.Name,
+		strings.Join(err.Available, ", "),
+	)
+}
+
+// Backend is a single initialized storage backend
+type Backend struct {
+	// Name used to reference this backend in the configuration
+	Name string
+	// Type of the factory used to create this backend
+	Type       string
+	Repository ports.Repository
+	pingFn     func(ctx context.Context) error
+	closeFn    func()
+}
+
+// NewBackend creates an instance of Backend, pingFn is used for health checks and
+// closeFn is called when the backend is closed. Both can be nil if the backend holds
+// no connections
+func NewBackend(repository ports.Repository, pingFn func(ctx context.Context) error, closeFn func()) *Backend {
+	return &Backend{
+		Repository: repository,
+		pingFn:     pingFn,
+		closeFn:    closeFn,
+	}
+}
+
+// Ping checks the backend is reachable, it gives up once ctx is done
+func (backend *Backend) Ping(ctx context.Context) error {
+	if backend.pingFn == nil {
+		return nil
+	}
+
+	return backend.pingFn(ctx)
+}
+
+// Close releases the connections used by the backend
+func (backend *Backend) Close() {
+	if backend.closeFn != nil {
+		log.Info().Msgf("Closing %s storage", backend.Name)
+		backend.closeFn()
+	}
+}
+
+// Factory creates a named Backend from its settings
+type Factory func(name string, backend domain.BackendConfig, config *domain.Config) (*Backend, error)
+
+var factories = map[string]Factory{
+	MONGO_BACKEND:     newMongoBackend,
+	CASSANDRA_BACKEND: newCassandraBackend,
+	MEMORY_BACKEND:    newMemoryBackend,
+	BOLT_BACKEND:      newBoltBackend,
+	SQL_BACKEND:       newSQLBackend,
+}
+var factoriesMutex sync.RWMutex
+
+// Register makes a storage backend type available by the provided name,
+// if a type with the same name exists it's replaced
+func Register(backendType string, factory Factory) {
+	factoriesMutex.Lock()
+	defer factoriesMutex.Unlock()
+	factories[backendType] = factory
+}
+
+var sharedCache ports.Cache
+
+// SetSharedCache sets the cache shared by every instance of the service, used by the
+// read-through cache after the in-process one. It must be called before New
+func SetSharedCache(cache ports.Cache) {
+	factoriesMutex.Lock()
+	defer factoriesMutex.Unlock()
+	sharedCache = cache
+}
+
+// Backends returns the sorted names of all registered backend types
+func Backends() []string {
+	factoriesMutex.RLock()
+	defer factoriesMutex.RUnlock()
+
+	names := make([]string, 0, len(factories))
+	for name := range factories {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+// Storage bundles the ports.Repository used by the services with the
+// lifecycle of the backends behind it
+type Storage struct {
+	// Repository routes each collection to the backend storing it
+	Repository ports.Repository
+	// Backends are all the initialized backends sorted by name
+	Backends []*Backend
+}
+
+// Close should always be called when the process using the storage ends
+func (storage *Storage) Close() {
+	for _, backend := range storage.Backends {
+		backend.Close()
+	}
+}
+
+// New creates the Storage described by config.Storage
+//
+// Every backend referenced by the default backend or a route is initialized once,
+// if all collections use the same backend its repository is used directly
+// otherwise they are combined with a Router. When config.Cache enables any
+// collection the repository is decorated with a cache.CachedRepo
+func New(config *domain.Config) (*Storage, error) {
+	defaultName := normalizeName(config.Storage.Backend)
+
+	if defaultName == "" {
+		return nil, errors.New("no storage backend configured, set storage.backend in the configuration")
+	}
+
+	storage := &Storage{}
+	backends := map[string]*Backend{}
+	getBackend := func(name string) (*Backend, error) {
+		if backend, exists := backends[name]; exists {
+			return backend, nil
+		}
+
+		backend, err := newBackend(name, config)
+		if err != nil {
+			return nil, err
+		}
+
+		backends[name] = backend
+		storage.Backends = append(storage.Backends, backend)
+		return backend, nil
+	}
+
+	fallback, err := getBackend(defaultName)
+	if err != nil {
+		storage.Close()
+		return nil, err
+	}
+
+	routes := map[string]ports.Repository{}
+	for collection, name := range config.Storage.Routes {
+		backend, err := getBackend(normalizeName(name))
+		if err != nil {
+			storage.Close()
+			return nil, fmt.Errorf("invalid route for collection %q: %w", collection, err)
+		}
+
+		log.Info().Msgf("Collection %q is stored in %s storage", collection, backend.Name)
+		routes[collection] = backend.Repository
+	}
+
+	sort.Slice(storage.Backends, func(i, j int) bool {
+		return storage.Backends[i].Name < storage.Backends[j].Name
+	})
+
+	if len(storage.Backends) == 1 {
+		storage.Repository = fallback.Repository
+	} else {
+		storage.Repository = NewRouter(fallback.Repository, routes)
+	}
+
+	if cache.Enabled(config.Cache) {
+		factoriesMutex.RLock()
+		shared := sharedCache
+		factoriesMutex.RUnlock()
+
+		log.Info().Msgf("Read-through cache enabled, shared cache: %v", shared != nil)
+		storage.Repository = cache.NewCachedRepo(storage.Repository, config.Cache, shared)
+	}
+
+	return storage, nil
+}
+
+// newBackend creates the backend registered with name
+func newBackend(name string, config *domain.Config) (*Backend, error) {
+	settings := backendSettings(name, config)
+
+	factoriesMutex.RLock()
+	factory, exists := factories[settings.Type]
+	factoriesMutex.RUnlock()
+
+	if !exists {
+		available := Backends()
+		for configured := range config.Storage.Backends {
+			available = append(available, configured)
+		}
+		sort.Strings(available)
+
+		return nil, ErrUnknownBackend{
+			Name:      name,
+			Available: available,
+		}
+	}
+
+	log.Info().Msgf("Initializing %s storage of type %s", name, settings.Type)
+	backend, err := factory(name, settings, config)
+
+	if err != nil {
+		return nil, fmt.Errorf("can't initialize %s storage: %w", name, err)
+	}
+
+	backend.Name = name
+	backend.Type = settings.Type
+	return backend, nil
+}
+
+// backendSettings returns the settings of the backend with name, backends without
+// an explicit definition are treated as a backend type using the top level settings
+func backendSettings(name string, config *domain.Config) domain.BackendConfig {
+	settings, exists := config.Storage.Backends[name]
+	if !exists {
+		for configured, value := range config.Storage.Backends {
+			if normalizeName(configured) == name {
+				settings, exists = value, true
+				break
+			}
+		}
+	}
+
+	if !exists {
+		settings = domain.BackendConfig{Type: name}
+	}
+
+	settings.Type = normalizeName(settings.Type)
+	if settings.Type == "" {
+		settings.Type = name
+	}
+
+	defaults := domain.DefaultConfig()
+	if settings.MongoDB == nil {
+		settings.MongoDB = &config.MongoDBConfig
+	} else {
+		mongoDB := mongoSettings(*settings.MongoDB, defaults.MongoDBConfig)
+		settings.MongoDB = &mongoDB
+	}
+
+	if settings.CassandraDB == nil {
+		settings.CassandraDB = &config.CassandraDB
+	} else {
+		cassandraDB := cassandraSettings(*settings.CassandraDB, defaults.CassandraDB)
+		settings.CassandraDB = &cassandraDB
+	}
+
+	if settings.BoltDB == nil {
+		settings.BoltDB = &config.BoltDB
+	} else {
+		boltDB := boltSettings(*settings.BoltDB, defaults.BoltDB)
+		settings.BoltDB = &boltDB
+	}
+
+	if settings.SQLDB == nil {
+		settings.SQLDB = &config.SQLDB
+	} else {
+		sqlDB := sqlSettings(*settings.SQLDB, defaults.SQLDB)
+		settings.SQLDB = &sqlDB
+	}
+
+	return settings
+}
+
+// normalizeName makes backend names case insensitive
+func normalizeName(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// mongoSettings fills the missing values of a backend MongoDB settings with the defaults
+func mongoSettings(custom domain.MDBConfig, defaults domain.MDBConfig) domain.MDBConfig {
+	if custom.Host == "" {
+		custom.Host = defaults.Host
+	}
+
+	if custom.Port == 0 {
+		custom.Port = defaults.Port
+	}
+
+	if custom.Timeout == 0 {
+		custom.Timeout = defaults.Timeout
+	}
+
+	if custom.ConnectTimeout == 0 {
+		custom.ConnectTimeout = defaults.ConnectTimeout
+	}
+
+	if custom.MaxPoolSize == 0 {
+		custom.MaxPoolSize = defaults.MaxPoolSize
+	}
+
+	return custom
+}
+
+// cassandraSettings fills the missing values of a backend Cassandra settings with the defaults
+func cassandraSettings(custom domain.CDBConfig, defaults domain.CDBConfig) domain.CDBConfig {
+	if custom.Host == "" {
+		custom.Host = defaults.Host
+	}
+
+	if custom.Port == 0 {
+		custom.Port = defaults.Port
+	}
+
+	if custom.Timeout == 0 {
+		custom.Timeout = defaults.Timeout
+	}
+
+	if custom.ConnectTimeout == 0 {
+		custom.ConnectTimeout = defaults.ConnectTimeout
+	}
+
+	if custom.Connections == 0 {
+		custom.Connections = defaults.Connections
+	}
+
+	return custom
+}
+
+// boltSettings fills the missing values of a backend Bolt settings with the defaults
+func boltSettings(custom domain.BoltConfig, defaults domain.BoltConfig) domain.BoltConfig {
+	if custom.Path == "" {
+		custom.Path = defaults.Path
+	}
+
+	if custom.Timeout == 0 {
+		custom.Timeout = defaults.Timeout
+	}
+
+	return custom
+}
+
+// sqlSettings fills the missing values of a backend SQL settings with the defaults
+func sqlSettings(custom domain.SQLConfig, defaults domain.SQLConfig) domain.SQLConfig {
+	if custom.Driver == "" {
+		custom.Driver = defaults.Driver
+	}
+
+	if custom.DSN == "" {
+		custom.DSN = defaults.DSN
+	}
+
+	if custom.MaxOpenConns == 0 {
+		custom.MaxOpenConns = defaults.MaxOpenConns
+	}
+
+	if custom.Timeout == 0 {
+		custom.Timeout = defaults.Timeout
+	}
+
+	return custom
+}
+
+// newMongoBackend creates a Backend stored in MongoDB
+func newMongoBackend(name string, backend domain.BackendConfig, config *domain.Config) (*Backend, error) {
+	if backend.MongoDB.DB == "" {
+		return nil, errors.New("mongoDB.db is required")
+	}
+
+	// Backends named after their type share the default connection
+	connections := mongodb.DefaultConnections()
+	connection := name
+	if name == backend.Type {
+		connection = mongodb.DEFAULT_CONNECTION
+	}
+
+	db, err := connections.Get(connection, *backend.MongoDB)
+	if err != nil {
+		return nil, err
+	}
+
+	closeFn := func() {
+		connections.Close(connection)
+	}
+
+	repo, err := mongodb.NewMongoRepo(db, config)
+	if err != nil {
+		closeFn()
+		return nil, err
+	}
+
+	return NewBackend(repo, db.Ping, closeFn), nil
+}
+
+// newCassandraBackend creates a Backend stored in Cassandra
+func newCassandraBackend(name string, backend domain.BackendConfig, config *domain.Config) (*Backend, error) {
+	// Backends named after their type share the default connection
+	connections := cassandra.DefaultConnections()
+	connection := name
+	if name == backend.Type {
+		connection = cassandra.DEFAULT_CONNECTION
+	}
+
+	db, err := connections.Get(connection, *backend.CassandraDB)
+	if err != nil {
+		return nil, err
+	}
+
+	closeFn := func() {
+		connections.Close(connection)
+	}
+
+	repo, err := cassandra.NewCassandraRepo(db, config)
+	if err != nil {
+		closeFn()
+		return nil, err
+	}
+
+	return NewBackend(repo, db.Ping, closeFn), nil
+}
+
+// newMemoryBackend creates a Backend which keeps all data in memory,
+// the data is lost when the process ends
+func newMemoryBackend(name string, backend domain.BackendConfig, config *domain.Config) (*Backend, error) {
+	return NewBackend(memory.NewMemoryRepo(), nil, nil), nil
+}
+
+// newBoltBackend creates a Backend stored in a single bbolt file
+func newBoltBackend(name string, backend domain.BackendConfig, config *domain.Config) (*Backend, error) {
+	if backend.BoltDB.Path == "" {
+		return nil, errors.New("boltDB.path is required")
+	}
+
+	db, err := boltdb.Open(*backend.BoltDB)
+	if err != nil {
+		return nil, err
+	}
+
+	repo, err := boltdb.NewBoltRepo(db)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return NewBackend(repo, db.Ping, db.Close), nil
+}
+
+// newSQLBackend creates a Backend stored in a relational database,
+// pending schema migrations are applied before using it
+func newSQLBackend(name string, backend domain.BackendConfig, config *domain.Config) (*Backend, error) {
+	if backend.SQLDB.DSN == "" {
+		return nil, errors.New("sqlDB.dsn is required")
+	}
+
+	db, err := sqldb.Open(*backend.SQLDB)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.Migrate(); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	repo, err := sqldb.NewSQLRepo(db)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return NewBackend(repo, db.Ping, db.Close), nil
+}
@@ -0,0 +1,282 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/sy-software/minerva-owl/internal/core/domain"
+	"github.com/sy-software/minerva-owl/internal/repositories/boltdb"
+	"github.com/sy-software/minerva-owl/internal/repositories/cache"
+	"github.com/sy-software/minerva-owl/internal/repositories/memory"
+	"github.com/sy-software/minerva-owl/internal/repositories/sqldb"
+	"github.com/sy-software/minerva-owl/mocks"
+)
+
+func TestNewStorage(t *testing.T) {
+	ctx := context.Background()
+	t.Run("Test memory backend is created", func(t *testing.T) {
+		config := domain.DefaultConfig()
+		config.Storage.Backend = MEMORY_BACKEND
+
+		got, err := New(&config)
+
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+
+		if len(got.Backends) != 1 || got.Backends[0].Name != MEMORY_BACKEND {
+			t.Errorf("Expected a single backend named: %q got: %+v", MEMORY_BACKEND, got.Backends)
+		}
+
+		if _, ok := got.Repository.(*memory.MemoryRepo); !ok {
+			t.Errorf("Expected repository of type *memory.MemoryRepo got: %T", got.Repository)
+		}
+
+		got.Close()
+	})
+
+	t.Run("Test cached collections decorate the repository", func(t *testing.T) {
+		config := domain.DefaultConfig()
+		config.Storage.Backend = MEMORY_BACKEND
+		config.Cache.Collections = map[string]domain.CollectionCacheConfig{
+			"users": {Enabled: true},
+		}
+
+		got, err := New(&config)
+
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		if _, ok := got.Repository.(*cache.CachedRepo); !ok {
+			t.Errorf("Expected repository of type *cache.CachedRepo got: %T", got.Repository)
+		}
+
+		got.Close()
+	})
+
+	t.Run("Test bolt backend is created", func(t *testing.T) {
+		config := domain.DefaultConfig()
+		config.Storage.Backend = BOLT_BACKEND
+		config.BoltDB.Path = filepath.Join(t.TempDir(), "owl.db")
+
+		got, err := New(&config)
+
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		if _, ok := got.Repository.(*boltdb.BoltRepo); !ok {
+			t.Errorf("Expected repository of type *boltdb.BoltRepo got: %T", got.Repository)
+		}
+
+		if err := got.Backends[0].Ping(context.Background()); err != nil {
+			t.Errorf("Unexpected ping error: %v", err)
+		}
+
+		got.Close()
+	})
+
+	t.Run("Test sql backend is created with its schema", func(t *testing.T) {
+		config := domain.DefaultConfig()
+		config.Storage.Backend = SQL_BACKEND
+		config.SQLDB.DSN = filepath.Join(t.TempDir(), "owl.sqlite")
+
+		got, err := New(&config)
+
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		if _, ok := got.Repository.(*sqldb.SQLRepo); !ok {
+			t.Errorf("Expected repository of type *sqldb.SQLRepo got: %T", got.Repository)
+		}
+
+		count, err := got.Repository.Count(ctx, domain.USER_COL_NAME)
+		if err != nil || count != 0 {
+			t.Errorf("Expected empty users table got: %d with error: %v", count, err)
+		}
+
+		got.Close()
+	})
+
+	t.Run("Test backend names are case insensitive", func(t *testing.T) {
+		config := domain.DefaultConfig()
+		config.Storage.Backend = " Memory "
+
+		_, err := New(&config)
+
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+	})
+
+	t.Run("Test unknown backend returns an error", func(t *testing.T) {
+		config := domain.DefaultConfig()
+		config.Storage.Backend = "floppy"
+
+		_, err := New(&config)
+
+		if _, ok := err.(ErrUnknownBackend); !ok {
+			t.Errorf("Expected error of type ErrUnknownBackend got: %v", err)
+		}
+	})
+
+	t.Run("Test empty backend returns an error", func(t *testing.T) {
+		config := domain.DefaultConfig()
+		config.Storage.Backend = ""
+
+		_, err := New(&config)
+
+		if err == nil {
+			t.Errorf("Expected error got nil")
+		}
+	})
+
+	t.Run("Test mongo backend requires a database name", func(t *testing.T) {
+		config := domain.DefaultConfig()
+		config.Storage.Backend = MONGO_BACKEND
+		config.MongoDBConfig.DB = ""
+
+		_, err := New(&config)
+
+		if err == nil {
+			t.Errorf("Expected error got nil")
+		}
+	})
+
+	t.Run("Test factory errors are wrapped", func(t *testing.T) {
+		expected := errors.New("can't connect")
+		Register("broken", func(name string, backend domain.BackendConfig, config *domain.Config) (*Backend, error) {
+			return nil, expected
+		})
+
+		config := domain.DefaultConfig()
+		config.Storage.Backend = "broken"
+
+		_, err := New(&config)
+
+		if !errors.Is(err, expected) {
+			t.Errorf("Expected error: %v got: %v", expected, err)
+		}
+	})
+
+	t.Run("Test invalid routes return an error", func(t *testing.T) {
+		config := domain.DefaultConfig()
+		config.Storage.Backend = MEMORY_BACKEND
+		config.Storage.Routes = map[string]string{
+			"audit": "floppy",
+		}
+
+		_, err := New(&config)
+
+		var unknown ErrUnknownBackend
+		if !errors.As(err, &unknown) {
+			t.Errorf("Expected error of type ErrUnknownBackend got: %v", err)
+		}
+	})
+}
+
+func TestRoutedStorage(t *testing.T) {
+	config := domain.DefaultConfig()
+	config.Storage.Backend = MEMORY_BACKEND
+	config.Storage.Routes = map[string]string{
+		"audit": "archive",
+		"logs":  "Archive",
+	}
+	config.Storage.Backends = map[string]domain.BackendConfig{
+		"archive": {
+			Type: MEMORY_BACKEND,
+		},
+	}
+
+	got, err := New(&config)
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(got.Backends) != 2 {
+		t.Fatalf("Expected 2 backends got: %d", len(got.Backends))
+	}
+
+	if got.Backends[0].Name != "archive" || got.Backends[0].Type != MEMORY_BACKEND {
+		t.Errorf("Expected archive backend of type memory got: %+v", got.Backends[0])
+	}
+
+	router, ok := got.Repository.(*Router)
+	if !ok {
+		t.Fatalf("Expected repository of type *Router got: %T", got.Repository)
+	}
+
+	if router.Route("audit") != got.Backends[0].Repository {
+		t.Errorf("Expected audit collection to be routed to the archive backend")
+	}
+
+	if router.Route("logs") != got.Backends[0].Repository {
+		t.Errorf("Expected logs collection to be routed to the archive backend")
+	}
+
+	if router.Route("users") != got.Backends[1].Repository {
+		t.Errorf("Expected users collection to be routed to the default backend")
+	}
+}
+
+func TestRegister(t *testing.T) {
+	closed := false
+	Register("custom", func(name string, backend domain.BackendConfig, config *domain.Config) (*Backend, error) {
+		return NewBackend(&mocks.MemRepo{}, nil, func() {
+			closed = true
+		}), nil
+	})
+
+	config := domain.DefaultConfig()
+	config.Storage.Backend = "custom"
+
+	got, err := New(&config)
+
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	got.Close()
+
+	if !closed {
+		t.Errorf("Expected custom close function to be called")
+	}
+}
+
+func TestBackendSettings(t *testing.T) {
+	config := domain.DefaultConfig()
+	config.Storage.Backends = map[string]domain.BackendConfig{
+		"analytics": {
+			Type: "Mongo",
+			MongoDB: &domain.MDBConfig{
+				Host: "10.0.0.1",
+				DB:   "analytics",
+			},
+		},
+	}
+
+	got := backendSettings("analytics", &config)
+
+	if got.Type != MONGO_BACKEND {
+		t.Errorf("Expected type to be: %q got: %q", MONGO_BACKEND, got.Type)
+	}
+
+	if got.MongoDB.Host != "10.0.0.1" || got.MongoDB.DB != "analytics" {
+		t.Errorf("Expected custom MongoDB settings got: %+v", got.MongoDB)
+	}
+
+	if got.MongoDB.Port != config.MongoDBConfig.Port || got.MongoDB.Timeout != config.MongoDBConfig.Timeout {
+		t.Errorf("Expected missing MongoDB settings to use defaults got: %+v", got.MongoDB)
+	}
+
+	got = backendSettings(CASSANDRA_BACKEND, &config)
+
+	if got.Type != CASSANDRA_BACKEND || got.CassandraDB != &config.CassandraDB {
+		t.Errorf("Expected backend type settings to use top level settings got: %+v", got)
+	}
+}
@@ -0,0 +1,3 @@
+// Package tracing implements a ports.Repository decorator starting an OpenTelemetry
+// span for every repository call
+package tracing
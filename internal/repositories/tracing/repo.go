@@ -0,0 +1,181 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+
+	"github.com/sy-software/minerva-owl/internal/core/ports"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TRACER_NAME identifies the spans started by this package
+const TRACER_NAME = "github.com/sy-software/minerva-owl/internal/repositories/tracing"
+
+// Attributes of the repository spans
+const (
+	COLLECTION_KEY = attribute.Key("owl.repository.collection")
+	METHOD_KEY     = attribute.Key("owl.repository.method")
+	NOT_FOUND_KEY  = attribute.Key("owl.repository.not_found")
+)
+
+// TracedRepo is a ports.Repository decorator starting a span for every call, named after
+// the method and tagged with the collection. Spans use the global tracer provider and are
+// children of the span in the call context, ports.ErrItemNotFound is not an error status
+type TracedRepo struct {
+	repository ports.Repository
+}
+
+// NewTracedRepo creates a TracedRepo decorating repository
+func NewTracedRepo(repository ports.Repository) *TracedRepo {
+	return &TracedRepo{
+		repository: repository,
+	}
+}
+
+// start starts the span of a call to method
+func start(ctx context.Context, collection string, method string) (context.Context, trace.Span) {
+	return otel.Tracer(TRACER_NAME).Start(
+		ctx,
+		"repository."+method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(COLLECTION_KEY.String(collection), METHOD_KEY.String(method)),
+	)
+}
+
+// end records err into span and ends it
+func end(span trace.Span, err error) {
+	if errors.As(err, &ports.ErrItemNotFound{}) {
+		span.SetAttributes(NOT_FOUND_KEY.Bool(true))
+	} else if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	span.End()
+}
+
+// List returns a single page of items from the decorated repository
+func (repo *TracedRepo) List(ctx context.Context, collection string, results interface{}, skip int, limit int, sort []ports.Sort, filters ...ports.Filter) error {
+	ctx, span := start(ctx, collection, "List")
+	err := repo.repository.List(ctx, collection, results, skip, limit, sort, filters...)
+	end(span, err)
+	return err
+}
+
+// ListPage returns a single page of items after a cursor from the decorated repository
+func (repo *TracedRepo) ListPage(ctx context.Context, collection string, results interface{}, after string, limit int, filters ...ports.Filter) (ports.PageInfo, error) {
+	ctx, span := start(ctx, collection, "ListPage")
+	pageInfo, err := repo.repository.ListPage(ctx, collection, results, after, limit, filters...)
+	end(span, err)
+	return pageInfo, err
+}
+
+// Count returns how many items match the filters in the decorated repository
+func (repo *TracedRepo) Count(ctx context.Context, collection string, filters ...ports.Filter) (int, error) {
+	ctx, span := start(ctx, collection, "Count")
+	count, err := repo.repository.Count(ctx, collection, filters...)
+	end(span, err)
+	return count, err
+}
+
+// Get returns a single item from the decorated repository
+func (repo *TracedRepo) Get(ctx context.Context, collection string, id string, result interface{}) error {
+	ctx, span := start(ctx, collection, "Get")
+	err := repo.repository.Get(ctx, collection, id, result)
+	end(span, err)
+	return err
+}
+
+// GetMany returns the items with any of the ids from the decorated repository
+func (repo *TracedRepo) GetMany(ctx context.Context, collection string, ids []string, results interface{}) error {
+	ctx, span := start(ctx, collection, "GetMany")
+	err := repo.repository.GetMany(ctx, collection, ids, results)
+	end(span, err)
+	return err
+}
+
+// GetOne returns a single item filtered from the decorated repository
+func (repo *TracedRepo) GetOne(ctx context.Context, collection string, result interface{}, filters ...ports.Filter) error {
+	ctx, span := start(ctx, collection, "GetOne")
+	err := repo.repository.GetOne(ctx, collection, result, filters...)
+	end(span, err)
+	return err
+}
+
+// Create saves a new item into the decorated repository
+func (repo *TracedRepo) Create(ctx context.Context, collection string, entity interface{}) (string, error) {
+	ctx, span := start(ctx, collection, "Create")
+	id, err := repo.repository.Create(ctx, collection, entity)
+	end(span, err)
+	return id, err
+}
+
+// Update saves the values of an existing item into the decorated repository
+func (repo *TracedRepo) Update(ctx context.Context, collection string, id string, entity interface{}, omit ...string) error {
+	ctx, span := start(ctx, collection, "Update")
+	err := repo.repository.Update(ctx, collection, id, entity, omit...)
+	end(span, err)
+	return err
+}
+
+// Upsert updates or creates an item in the decorated repository
+func (repo *TracedRepo) Upsert(ctx context.Context, collection string, filters []ports.Filter, entity interface{}, onInsertOnly ...string) (string, bool, error) {
+	ctx, span := start(ctx, collection, "Upsert")
+	id, created, err := repo.repository.Upsert(ctx, collection, filters, entity, onInsertOnly...)
+	end(span, err)
+	return id, created, err
+}
+
+// Patch changes the fields in mask of an item in the decorated repository
+func (repo *TracedRepo) Patch(ctx context.Context, collection string, id string, mask ports.FieldMask) error {
+	ctx, span := start(ctx, collection, "Patch")
+	err := repo.repository.Patch(ctx, collection, id, mask)
+	end(span, err)
+	return err
+}
+
+// Delete removes an item from the decorated repository
+func (repo *TracedRepo) Delete(ctx context.Context, collection string, id string) error {
+	ctx, span := start(ctx, collection, "Delete")
+	err := repo.repository.Delete(ctx, collection, id)
+	end(span, err)
+	return err
+}
+
+// CreateMany saves every entity into the decorated repository
+func (repo *TracedRepo) CreateMany(ctx context.Context, collection string, entities []interface{}, stopOnError bool) ([]ports.BulkResult, error) {
+	ctx, span := start(ctx, collection, "CreateMany")
+	bulkResults, err := repo.repository.CreateMany(ctx, collection, entities, stopOnError)
+	end(span, err)
+	return bulkResults, err
+}
+
+// PatchMany applies every patch in the decorated repository
+func (repo *TracedRepo) PatchMany(ctx context.Context, collection string, patches []ports.BulkPatch, stopOnError bool) ([]ports.BulkResult, error) {
+	ctx, span := start(ctx, collection, "PatchMany")
+	bulkResults, err := repo.repository.PatchMany(ctx, collection, patches, stopOnError)
+	end(span, err)
+	return bulkResults, err
+}
+
+// DeleteMany removes every item from the decorated repository
+func (repo *TracedRepo) DeleteMany(ctx context.Context, collection string, ids []string, stopOnError bool) ([]ports.BulkResult, error) {
+	ctx, span := start(ctx, collection, "DeleteMany")
+	bulkResults, err := repo.repository.DeleteMany(ctx, collection, ids, stopOnError)
+	end(span, err)
+	return bulkResults, err
+}
+
+// WithTransaction calls fn with a traced transaction of the decorated repository,
+// the transaction span lasts until fn returns and the transaction ends
+func (repo *TracedRepo) WithTransaction(ctx context.Context, fn func(tx ports.Repository) error) error {
+	ctx, span := otel.Tracer(TRACER_NAME).Start(ctx, "repository.WithTransaction")
+	err := repo.repository.WithTransaction(ctx, func(tx ports.Repository) error {
+		return fn(NewTracedRepo(tx))
+	})
+	end(span, err)
+	return err
+}
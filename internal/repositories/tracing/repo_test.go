@@ -0,0 +1,80 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sy-software/minerva-owl/internal/core/ports"
+	"github.com/sy-software/minerva-owl/internal/repositories/memory"
+	"github.com/sy-software/minerva-owl/internal/repositories/repotest"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestContract(t *testing.T) {
+	repotest.Run(t, repotest.Suite{
+		New: func(t *testing.T) ports.Repository {
+			return NewTracedRepo(memory.NewMemoryRepo())
+		},
+	})
+}
+
+func TestTracedRepo(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	defer otel.SetTracerProvider(previous)
+
+	ctx, parent := provider.Tracer("test").Start(context.Background(), "parent")
+	repo := NewTracedRepo(memory.NewMemoryRepo())
+
+	id, _ := repo.Create(ctx, "traced", &repotest.Item{Name: "Tony Stark"})
+	repo.Get(ctx, "traced", "missing", &repotest.Item{})
+	repo.List(ctx, "traced", &[]repotest.Item{}, 0, 10, nil, ports.Filter{Name: "name", Operator: "unknown"})
+	repo.WithTransaction(ctx, func(tx ports.Repository) error {
+		return tx.Delete(ctx, "traced", id)
+	})
+	parent.End()
+
+	expected := []struct {
+		name   string
+		status codes.Code
+	}{
+		{"repository.Create", codes.Unset},
+		{"repository.Get", codes.Unset},
+		{"repository.List", codes.Error},
+		{"repository.Delete", codes.Unset},
+		{"repository.WithTransaction", codes.Unset},
+		{"parent", codes.Unset},
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != len(expected) {
+		t.Fatalf("Expected %d spans got: %d", len(expected), len(spans))
+	}
+
+	for index, e := range expected {
+		span := spans[index]
+		if span.Name() != e.name || span.Status().Code != e.status {
+			t.Errorf("Expected span %q with status %v got: %q with status %v", e.name, e.status, span.Name(), span.Status().Code)
+		}
+
+		if span.SpanContext().TraceID() != parent.SpanContext().TraceID() {
+			t.Errorf("Expected span %q to belong to the parent trace", span.Name())
+		}
+	}
+
+	notFound := false
+	for _, attr := range spans[1].Attributes() {
+		if attr.Key == NOT_FOUND_KEY {
+			notFound = attr.Value.AsBool()
+		}
+	}
+
+	if !notFound {
+		t.Errorf("Expected missing item to be tagged as not found")
+	}
+}
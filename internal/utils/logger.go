@@ -0,0 +1,18 @@
+package utils
+
+import (
+	"context"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// Logger returns the request-scoped logger stored into ctx with zerolog.Logger.WithContext,
+// or the global logger when ctx has none
+func Logger(ctx context.Context) *zerolog.Logger {
+	if logger := zerolog.Ctx(ctx); logger.GetLevel() != zerolog.Disabled {
+		return logger
+	}
+
+	return &log.Logger
+}
@@ -0,0 +1,30 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+func TestLogger(t *testing.T) {
+	t.Run("Context without logger returns the global logger", func(t *testing.T) {
+		if got := Logger(context.Background()); got != &log.Logger {
+			t.Errorf("Expected global logger got: %v", got)
+		}
+	})
+
+	t.Run("Context logger is returned", func(t *testing.T) {
+		out := &bytes.Buffer{}
+		logger := zerolog.New(out)
+		ctx := logger.WithContext(context.Background())
+
+		Logger(ctx).Info().Msg("scoped")
+
+		if out.Len() == 0 {
+			t.Errorf("Expected context logger to be used")
+		}
+	})
+}
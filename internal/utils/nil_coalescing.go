@@ -17,3 +17,12 @@ func CoalesceInt(source *int, fallback int) int {
 		return fallback
 	}
 }
+
+// CoalesceBool check a bool pointer if nil returns the fallback value
+func CoalesceBool(source *bool, fallback bool) bool {
+	if source != nil {
+		return *source
+	} else {
+		return fallback
+	}
+}
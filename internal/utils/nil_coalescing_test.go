@@ -35,4 +35,18 @@ func TestNilCoalescing(t *testing.T) {
 			t.Errorf("Expected: %q Got: %q", defaultValue, got)
 		}
 	})
+	t.Run("Test bool coalescing", func(t *testing.T) {
+		got := CoalesceBool(nil, true)
+
+		if !got {
+			t.Errorf("Expected: %v Got: %v", true, got)
+		}
+
+		expected := false
+		got = CoalesceBool(&expected, true)
+
+		if got != expected {
+			t.Errorf("Expected: %v Got: %v", expected, got)
+		}
+	})
 }
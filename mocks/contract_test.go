@@ -0,0 +1,16 @@
+package mocks
+
+import (
+	"testing"
+
+	"github.com/sy-software/minerva-owl/internal/core/ports"
+	"github.com/sy-software/minerva-owl/internal/repositories/repotest"
+)
+
+func TestContract(t *testing.T) {
+	repotest.Run(t, repotest.Suite{
+		New: func(t *testing.T) ports.Repository {
+			return &MemRepo{Data: map[string][]map[string]interface{}{}}
+		},
+	})
+}
@@ -0,0 +1,97 @@
+package mocks
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sy-software/minerva-owl/internal/core/ports"
+)
+
+func TestMemFilters(t *testing.T) {
+	ctx := context.Background()
+	repo := MemRepo{
+		Data: map[string][]map[string]interface{}{
+			"pokemons": {
+				{"id": "1", "name": "Bulbasaur", "generation": 1, "types": []interface{}{"grass", "poison"}},
+				{"id": "4", "name": "Charmander", "generation": 1, "types": []interface{}{"fire"}},
+				{"id": "152", "name": "Chikorita", "generation": 2, "types": []interface{}{"grass"}},
+				{"id": "155", "name": "Cyndaquil", "generation": 2, "stats": map[string]interface{}{"hp": 39}},
+			},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		filters  []ports.Filter
+		expected []string
+	}{
+		{"eq", []ports.Filter{ports.Eq("generation", 2)}, []string{"152", "155"}},
+		{"eq by id", []ports.Filter{ports.Eq("_id", "4")}, []string{"4"}},
+		{"eq array element", []ports.Filter{ports.Eq("types", "grass")}, []string{"1", "152"}},
+		{"ne", []ports.Filter{ports.Ne("generation", 1)}, []string{"152", "155"}},
+		{"in", []ports.Filter{ports.In("name", "Bulbasaur", "Cyndaquil")}, []string{"1", "155"}},
+		{"nin", []ports.Filter{ports.Nin("types", "grass", "fire")}, []string{"155"}},
+		{"in without values", []ports.Filter{ports.In("name")}, []string{}},
+		{"gt", []ports.Filter{ports.Gt("generation", 1)}, []string{"152", "155"}},
+		{"gte and lte", []ports.Filter{ports.Gte("name", "C"), ports.Lte("name", "Chikorita")}, []string{"4", "152"}},
+		{"lt", []ports.Filter{ports.Lt("generation", 2)}, []string{"1", "4"}},
+		{"prefix", []ports.Filter{ports.Prefix("name", "Ch")}, []string{"4", "152"}},
+		{"contains", []ports.Filter{ports.Contains("types", "ois")}, []string{"1"}},
+		{"exists", []ports.Filter{ports.Exists("types", false)}, []string{"155"}},
+		{"nested field", []ports.Filter{ports.Gt("stats.hp", 30)}, []string{"155"}},
+		{"or", []ports.Filter{ports.Or(ports.Eq("name", "Bulbasaur"), ports.Eq("generation", 2))}, []string{"1", "152", "155"}},
+		{"not", []ports.Filter{ports.Not(ports.And(ports.Eq("generation", 1), ports.Eq("types", "grass")))}, []string{"4", "152", "155"}},
+		{"different types", []ports.Filter{ports.Gt("name", 1)}, []string{}},
+	}
+
+	for _, test := range tests {
+		t.Run("Test "+test.name+" filter", func(t *testing.T) {
+			var got []Pokemon
+			err := repo.List(ctx, "pokemons", &got, 0, 10, nil, test.filters...)
+
+			if err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+
+			if len(got) != len(test.expected) {
+				t.Fatalf("Expected ids: %v got: %+v", test.expected, got)
+			}
+
+			for index, pokemon := range got {
+				if pokemon.Id != test.expected[index] {
+					t.Errorf("Expected ids: %v got: %+v", test.expected, got)
+				}
+			}
+
+			count, err := repo.Count(ctx, "pokemons", test.filters...)
+
+			if err != nil || count != len(test.expected) {
+				t.Errorf("Expected count to be: %d got: %d with error: %v", len(test.expected), count, err)
+			}
+		})
+	}
+
+	t.Run("Test get one with filters", func(t *testing.T) {
+		got := Pokemon{}
+		err := repo.GetOne(ctx, "pokemons", &got, ports.Eq("name", "Chikorita"))
+
+		if err != nil || got.Id != "152" {
+			t.Errorf("Expected pokemon with id 152 got: %+v with error: %v", got, err)
+		}
+
+		err = repo.GetOne(ctx, "pokemons", &got, ports.Eq("name", "Pikachu"))
+
+		if _, ok := err.(ports.ErrItemNotFound); !ok {
+			t.Errorf("Expected error of type ErrItemNotFound got: %v", err)
+		}
+	})
+
+	t.Run("Test invalid filter", func(t *testing.T) {
+		var got []Pokemon
+		err := repo.List(ctx, "pokemons", &got, 0, 10, nil, ports.Filter{Name: "name", Operator: "regex"})
+
+		if _, ok := err.(ports.ErrInvalidFilter); !ok {
+			t.Errorf("Expected error of type ErrInvalidFilter got: %v", err)
+		}
+	})
+}
@@ -1,31 +1,63 @@
 package mocks
 
 import (
+	"context"
+	"encoding/base64"
 	"encoding/json"
 	"reflect"
+	"sync"
 
 	"github.com/google/uuid"
 	"github.com/sy-software/minerva-owl/internal/core/ports"
+	"github.com/sy-software/minerva-owl/internal/repositories/memory"
 )
 
 const ID_REGEX = "^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$"
 
+// OBJECTID_REGEX matches the ids created by the default id generator
+const OBJECTID_REGEX = "^[0-9a-f]{24}$"
+
 type MemRepo struct {
-	Data              map[string][]map[string]interface{}
-	ListInterceptor   func(collection string, results interface{}, skip int, limit int, filters ...ports.Filter) error
-	GetInterceptor    func(collection string, id string, result interface{}) error
-	GetOneInterceptor func(collection string, result interface{}, filters ...ports.Filter) error
-	CreateInterceptor func(collection string, entity interface{}) (string, error)
-	UpdateInterceptor func(collection string, id string, entity interface{}, omit ...string) error
-	DeleteInterceptor func(collection string, id string) error
+	Data                map[string][]map[string]interface{}
+	ListInterceptor     func(ctx context.Context, collection string, results interface{}, skip int, limit int, sort []ports.Sort, filters ...ports.Filter) error
+	ListPageInterceptor func(ctx context.Context, collection string, results interface{}, after string, limit int, filters ...ports.Filter) (ports.PageInfo, error)
+	CountInterceptor    func(ctx context.Context, collection string, filters ...ports.Filter) (int, error)
+	GetInterceptor      func(ctx context.Context, collection string, id string, result interface{}) error
+	GetManyInterceptor  func(ctx context.Context, collection string, ids []string, results interface{}) error
+	GetOneInterceptor   func(ctx context.Context, collection string, result interface{}, filters ...ports.Filter) error
+	CreateInterceptor   func(ctx context.Context, collection string, entity interface{}) (string, error)
+	UpdateInterceptor   func(ctx context.Context, collection string, id string, entity interface{}, omit ...string) error
+	UpsertInterceptor   func(ctx context.Context, collection string, filters []ports.Filter, entity interface{}, onInsertOnly ...string) (string, bool, error)
+	PatchInterceptor    func(ctx context.Context, collection string, id string, mask ports.FieldMask) error
+	DeleteInterceptor   func(ctx context.Context, collection string, id string) error
+	// TransactionInterceptor replaces WithTransaction, fn is not called unless the interceptor does
+	TransactionInterceptor func(ctx context.Context, fn func(tx ports.Repository) error) error
+	mutex                  sync.RWMutex
 }
 
-func (repo *MemRepo) List(collection string, results interface{}, skip int, limit int, filters ...ports.Filter) error {
+func (repo *MemRepo) List(ctx context.Context, collection string, results interface{}, skip int, limit int, sortBy []ports.Sort, filters ...ports.Filter) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	if repo.ListInterceptor != nil {
-		return repo.ListInterceptor(collection, results, skip, limit, filters...)
+		return repo.ListInterceptor(ctx, collection, results, skip, limit, sortBy, filters...)
 	}
 
-	colData := repo.Data[collection]
+	return repo.list(collection, results, skip, limit, sortBy, filters...)
+}
+
+// list stores into results the items matching the filters without calling the interceptors
+func (repo *MemRepo) list(collection string, results interface{}, skip int, limit int, sortBy []ports.Sort, filters ...ports.Filter) error {
+	repo.mutex.RLock()
+	defer repo.mutex.RUnlock()
+
+	colData, err := memory.FilterItems(repo.Data[collection], filters)
+	if err != nil {
+		return err
+	}
+
+	colData = memory.SortItems(colData, sortBy)
 
 	if skip >= len(colData) {
 		return nil
@@ -63,11 +95,112 @@ func (repo *MemRepo) List(collection string, results interface{}, skip int, limi
 	return nil
 }
 
-func (repo *MemRepo) Get(collection string, id string, result interface{}) error {
+func (repo *MemRepo) ListPage(ctx context.Context, collection string, results interface{}, after string, limit int, filters ...ports.Filter) (ports.PageInfo, error) {
+	if err := ctx.Err(); err != nil {
+		return ports.PageInfo{}, err
+	}
+
+	if repo.ListPageInterceptor != nil {
+		return repo.ListPageInterceptor(ctx, collection, results, after, limit, filters...)
+	}
+
+	repo.mutex.RLock()
+	defer repo.mutex.RUnlock()
+
+	pageInfo := ports.PageInfo{
+		Cursors:         []string{},
+		HasPreviousPage: after != "",
+	}
+	colData := repo.Data[collection]
+	start := 0
+
+	if after != "" {
+		id, err := base64.RawURLEncoding.DecodeString(after)
+		if err != nil {
+			return pageInfo, ports.ErrInvalidCursor{Cursor: after}
+		}
+
+		start = -1
+		for index, item := range colData {
+			if item["id"] == string(id) {
+				start = index + 1
+				break
+			}
+		}
+
+		if start < 0 {
+			return pageInfo, ports.ErrInvalidCursor{Cursor: after}
+		}
+	}
+
+	colData, err := memory.FilterItems(colData[start:], filters)
+	if err != nil {
+		return pageInfo, err
+	}
+
+	end := limit
+	if end >= len(colData) {
+		end = len(colData)
+	} else {
+		pageInfo.HasNextPage = true
+	}
+
+	resultsPtr := reflect.ValueOf(results)
+	resultsVal := resultsPtr.Elem()
+	elementType := resultsVal.Type().Elem()
+	for _, r := range colData[:end] {
+		newElement := reflect.New(elementType).Elem()
+		jsonbody, err := json.Marshal(r)
+
+		if err != nil {
+			return pageInfo, err
+		}
+
+		parsed := newElement.Addr().Interface()
+		err = json.Unmarshal(jsonbody, &parsed)
+
+		if err != nil {
+			return pageInfo, err
+		}
+
+		resultsVal.Set(reflect.Append(resultsVal, newElement))
+		id, _ := r["id"].(string)
+		pageInfo.EndCursor = base64.RawURLEncoding.EncodeToString([]byte(id))
+		pageInfo.Cursors = append(pageInfo.Cursors, pageInfo.EndCursor)
+	}
+
+	return pageInfo, nil
+}
+
+func (repo *MemRepo) Count(ctx context.Context, collection string, filters ...ports.Filter) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	if repo.CountInterceptor != nil {
+		return repo.CountInterceptor(ctx, collection, filters...)
+	}
+
+	repo.mutex.RLock()
+	defer repo.mutex.RUnlock()
+
+	colData, err := memory.FilterItems(repo.Data[collection], filters)
+
+	return len(colData), err
+}
+
+func (repo *MemRepo) Get(ctx context.Context, collection string, id string, result interface{}) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	if repo.GetInterceptor != nil {
-		return repo.GetInterceptor(collection, id, result)
+		return repo.GetInterceptor(ctx, collection, id, result)
 	}
 
+	repo.mutex.RLock()
+	defer repo.mutex.RUnlock()
+
 	colData := repo.Data[collection]
 
 	elementPtr := reflect.ValueOf(result)
@@ -100,25 +233,80 @@ func (repo *MemRepo) Get(collection string, id string, result interface{}) error
 	}
 }
 
-func (repo *MemRepo) GetOne(collection string, result interface{}, filters ...ports.Filter) error {
+func (repo *MemRepo) GetMany(ctx context.Context, collection string, ids []string, results interface{}) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if repo.GetManyInterceptor != nil {
+		return repo.GetManyInterceptor(ctx, collection, ids, results)
+	}
+
+	if len(ids) == 0 {
+		return nil
+	}
+
+	return repo.list(collection, results, 0, len(ids), nil, ports.InIds(ids...))
+}
+
+func (repo *MemRepo) GetOne(ctx context.Context, collection string, result interface{}, filters ...ports.Filter) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	if repo.GetOneInterceptor != nil {
-		return repo.GetOneInterceptor(collection, result, filters...)
+		return repo.GetOneInterceptor(ctx, collection, result, filters...)
 	}
-	// TODO: Support queries in our mock db
-	return nil
+
+	repo.mutex.RLock()
+	defer repo.mutex.RUnlock()
+
+	colData, err := memory.FilterItems(repo.Data[collection], filters)
+	if err != nil {
+		return err
+	}
+
+	if len(colData) == 0 {
+		return ports.ErrItemNotFound{
+			Model: collection,
+		}
+	}
+
+	jsonbody, err := json.Marshal(colData[0])
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(jsonbody, result)
 }
 
-func (repo *MemRepo) Create(collection string, entity interface{}) (string, error) {
+func (repo *MemRepo) Create(ctx context.Context, collection string, entity interface{}) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
 	if repo.CreateInterceptor != nil {
-		return repo.CreateInterceptor(collection, entity)
+		return repo.CreateInterceptor(ctx, collection, entity)
 	}
+
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+
 	var inInterface map[string]interface{}
 	doc, err := json.Marshal(entity)
 	json.Unmarshal(doc, &inInterface)
 
-	newId := uuid.New().String()
+	newId, _ := inInterface["id"].(string)
+	if newId == "" {
+		newId = uuid.New().String()
+		inInterface["id"] = newId
+	}
 
-	inInterface["id"] = newId
+	for _, item := range repo.Data[collection] {
+		if item["id"] == newId {
+			return "", ports.ErrDuplicatedId{Id: newId, Model: collection}
+		}
+	}
 
 	items := repo.Data[collection]
 	items = append(items, inInterface)
@@ -127,10 +315,18 @@ func (repo *MemRepo) Create(collection string, entity interface{}) (string, erro
 	return newId, err
 }
 
-func (repo *MemRepo) Update(collection string, id string, entity interface{}, omit ...string) error {
+func (repo *MemRepo) Update(ctx context.Context, collection string, id string, entity interface{}, omit ...string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	if repo.UpdateInterceptor != nil {
-		return repo.UpdateInterceptor(collection, id, entity, omit...)
+		return repo.UpdateInterceptor(ctx, collection, id, entity, omit...)
 	}
+
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+
 	colData := repo.Data[collection]
 
 	omitMap := map[string]bool{}
@@ -156,16 +352,114 @@ func (repo *MemRepo) Update(collection string, id string, entity interface{}, om
 				}
 
 			}
-			// colData[i] = jsonMap
+
+			return nil
 		}
 	}
-	return nil
+
+	return ports.ErrItemNotFound{
+		Id:    &id,
+		Model: collection,
+	}
+}
+
+func (repo *MemRepo) Upsert(ctx context.Context, collection string, filters []ports.Filter, entity interface{}, onInsertOnly ...string) (string, bool, error) {
+	if err := ctx.Err(); err != nil {
+		return "", false, err
+	}
+
+	if repo.UpsertInterceptor != nil {
+		return repo.UpsertInterceptor(ctx, collection, filters, entity, onInsertOnly...)
+	}
+
+	for _, filter := range filters {
+		if err := filter.Validate(); err != nil {
+			return "", false, err
+		}
+	}
+
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+
+	var jsonMap map[string]interface{}
+	doc, err := json.Marshal(entity)
+	if err != nil {
+		return "", false, err
+	}
+
+	json.Unmarshal(doc, &jsonMap)
+
+	omitMap := map[string]bool{"id": true}
+	for _, v := range onInsertOnly {
+		omitMap[v] = true
+	}
+
+	for _, item := range repo.Data[collection] {
+		if memory.MatchAll(item, filters) {
+			for k, v := range jsonMap {
+				if !omitMap[k] {
+					item[k] = v
+				}
+			}
+
+			id, _ := item["id"].(string)
+			return id, false, nil
+		}
+	}
+
+	newId, _ := jsonMap["id"].(string)
+	if newId == "" {
+		newId = uuid.New().String()
+		jsonMap["id"] = newId
+	}
+
+	repo.Data[collection] = append(repo.Data[collection], jsonMap)
+	return newId, true, nil
+}
+
+func (repo *MemRepo) Patch(ctx context.Context, collection string, id string, mask ports.FieldMask) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if repo.PatchInterceptor != nil {
+		return repo.PatchInterceptor(ctx, collection, id, mask)
+	}
+
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+
+	colData := repo.Data[collection]
+	for index, item := range colData {
+		if item["id"] == id {
+			updated, err := memory.ApplyFieldMask(item, mask)
+			if err != nil {
+				return err
+			}
+
+			colData[index] = updated
+			return nil
+		}
+	}
+
+	return ports.ErrItemNotFound{
+		Id:    &id,
+		Model: collection,
+	}
 }
 
-func (repo *MemRepo) Delete(collection string, id string) error {
+func (repo *MemRepo) Delete(ctx context.Context, collection string, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	if repo.DeleteInterceptor != nil {
-		return repo.DeleteInterceptor(collection, id)
+		return repo.DeleteInterceptor(ctx, collection, id)
 	}
+
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+
 	colData := repo.Data[collection]
 	newData := []map[string]interface{}{}
 	for _, item := range colData {
@@ -174,6 +468,94 @@ func (repo *MemRepo) Delete(collection string, id string) error {
 		}
 	}
 
+	if len(newData) == len(colData) {
+		return ports.ErrItemNotFound{
+			Id:    &id,
+			Model: collection,
+		}
+	}
+
 	repo.Data[collection] = newData
 	return nil
 }
+
+func (repo *MemRepo) CreateMany(ctx context.Context, collection string, entities []interface{}, stopOnError bool) ([]ports.BulkResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return ports.RunBulk(len(entities), stopOnError, func(index int) (string, error) {
+		return repo.Create(ctx, collection, entities[index])
+	}), nil
+}
+
+func (repo *MemRepo) PatchMany(ctx context.Context, collection string, patches []ports.BulkPatch, stopOnError bool) ([]ports.BulkResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return ports.RunBulk(len(patches), stopOnError, func(index int) (string, error) {
+		return patches[index].Id, repo.Patch(ctx, collection, patches[index].Id, patches[index].Mask)
+	}), nil
+}
+
+func (repo *MemRepo) DeleteMany(ctx context.Context, collection string, ids []string, stopOnError bool) ([]ports.BulkResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return ports.RunBulk(len(ids), stopOnError, func(index int) (string, error) {
+		return ids[index], repo.Delete(ctx, collection, ids[index])
+	}), nil
+}
+
+// WithTransaction runs fn against a copy of Data with the same interceptors,
+// the copy replaces Data only when fn returns nil
+func (repo *MemRepo) WithTransaction(ctx context.Context, fn func(tx ports.Repository) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if repo.TransactionInterceptor != nil {
+		return repo.TransactionInterceptor(ctx, fn)
+	}
+
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+
+	data := make(map[string][]map[string]interface{}, len(repo.Data))
+	for collection, items := range repo.Data {
+		copied := make([]map[string]interface{}, len(items))
+		for index, item := range items {
+			copied[index] = make(map[string]interface{}, len(item))
+			for k, v := range item {
+				copied[index][k] = v
+			}
+		}
+
+		data[collection] = copied
+	}
+
+	tx := &MemRepo{
+		Data:                   data,
+		ListInterceptor:        repo.ListInterceptor,
+		ListPageInterceptor:    repo.ListPageInterceptor,
+		CountInterceptor:       repo.CountInterceptor,
+		GetInterceptor:         repo.GetInterceptor,
+		GetManyInterceptor:     repo.GetManyInterceptor,
+		GetOneInterceptor:      repo.GetOneInterceptor,
+		CreateInterceptor:      repo.CreateInterceptor,
+		UpdateInterceptor:      repo.UpdateInterceptor,
+		UpsertInterceptor:      repo.UpsertInterceptor,
+		PatchInterceptor:       repo.PatchInterceptor,
+		DeleteInterceptor:      repo.DeleteInterceptor,
+		TransactionInterceptor: repo.TransactionInterceptor,
+	}
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	repo.Data = tx.Data
+	return nil
+}
@@ -1,6 +1,7 @@
 package mocks
 
 import (
+	"context"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
@@ -19,6 +20,7 @@ type Pokemon struct {
 // if my generic MemRepo that can store any model works
 // as expected.
 func TestMemRepo(t *testing.T) {
+	ctx := context.Background()
 
 	t.Run("Test list action", func(t *testing.T) {
 		expected := []Pokemon{
@@ -60,7 +62,7 @@ func TestMemRepo(t *testing.T) {
 		}
 
 		var got []Pokemon
-		err := repo.List("pokemons", &got, 0, 10)
+		err := repo.List(ctx, "pokemons", &got, 0, 10, nil)
 
 		if err != nil {
 			t.Errorf("Unexpected error: %v", err)
@@ -71,6 +73,39 @@ func TestMemRepo(t *testing.T) {
 		}
 	})
 
+	t.Run("Test list action with sort", func(t *testing.T) {
+		repo := MemRepo{
+			Data: map[string][]map[string]interface{}{
+				"pokemons": {
+					{"id": "1", "name": "Bulbasaur", "generation": 1},
+					{"id": "152", "name": "Chikorita", "generation": 10},
+					{"id": "4", "name": "Charmander", "generation": 1},
+				},
+			},
+		}
+
+		var got []Pokemon
+		err := repo.List(ctx, "pokemons", &got, 0, 10, []ports.Sort{
+			{Field: "generation", Descending: true},
+			{Field: "name"},
+		})
+
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+
+		expected := []string{"Chikorita", "Bulbasaur", "Charmander"}
+		for index, pokemon := range got {
+			if pokemon.Name != expected[index] {
+				t.Errorf("Expected item %d to be: %q got: %q", index, expected[index], pokemon.Name)
+			}
+		}
+
+		if repo.Data["pokemons"][0]["name"] != "Bulbasaur" {
+			t.Errorf("Expected stored items to keep their order")
+		}
+	})
+
 	t.Run("Test get action", func(t *testing.T) {
 		expected := Pokemon{
 			Id:   "2",
@@ -101,7 +136,7 @@ func TestMemRepo(t *testing.T) {
 		}
 
 		got := Pokemon{}
-		err := repo.Get("pokemons", "2", &got)
+		err := repo.Get(ctx, "pokemons", "2", &got)
 
 		if err != nil {
 			t.Errorf("Unexpected error: %v", err)
@@ -136,14 +171,14 @@ func TestMemRepo(t *testing.T) {
 			Data: data,
 		}
 
-		id, err := repo.Create("pokemons", expected)
+		id, err := repo.Create(ctx, "pokemons", expected)
 
 		if err != nil {
 			t.Errorf("Unexpected error: %v", err)
 		}
 
 		got := Pokemon{}
-		err = repo.Get("pokemons", id, &got)
+		err = repo.Get(ctx, "pokemons", id, &got)
 
 		if err != nil {
 			t.Errorf("Unexpected error: %v", err)
@@ -183,14 +218,14 @@ func TestMemRepo(t *testing.T) {
 			Data: data,
 		}
 
-		err := repo.Update("pokemons", "2", expected)
+		err := repo.Update(ctx, "pokemons", "2", expected)
 
 		if err != nil {
 			t.Errorf("Unexpected error: %v", err)
 		}
 
 		got := Pokemon{}
-		err = repo.Get("pokemons", "2", &got)
+		err = repo.Get(ctx, "pokemons", "2", &got)
 
 		if err != nil {
 			t.Errorf("Unexpected error: %v", err)
@@ -232,14 +267,14 @@ func TestMemRepo(t *testing.T) {
 			Data: data,
 		}
 
-		err := repo.Update("pokemons", "2", expected, "generation")
+		err := repo.Update(ctx, "pokemons", "2", expected, "generation")
 
 		if err != nil {
 			t.Errorf("Unexpected error: %v", err)
 		}
 
 		got := Pokemon{}
-		err = repo.Get("pokemons", "2", &got)
+		err = repo.Get(ctx, "pokemons", "2", &got)
 
 		if err != nil {
 			t.Errorf("Unexpected error: %v", err)
@@ -278,14 +313,14 @@ func TestMemRepo(t *testing.T) {
 			Data: data,
 		}
 
-		err := repo.Delete("pokemons", "deleteMe")
+		err := repo.Delete(ctx, "pokemons", "deleteMe")
 
 		if err != nil {
 			t.Errorf("Unexpected error: %v", err)
 		}
 
 		got := Pokemon{}
-		err = repo.Get("pokemons", "deleteMe", &got)
+		err = repo.Get(ctx, "pokemons", "deleteMe", &got)
 
 		if err == nil {
 			t.Errorf("Expected error, got nil")
@@ -298,9 +333,10 @@ func TestMemRepo(t *testing.T) {
 }
 
 func TestInterceptors(t *testing.T) {
+	ctx := context.Background()
 	t.Run("Test list interceptor", func(t *testing.T) {
 		called := false
-		interceptor := func(collection string, results interface{}, skip, limit int, filters ...ports.Filter) error {
+		interceptor := func(ctx context.Context, collection string, results interface{}, skip, limit int, sort []ports.Sort, filters ...ports.Filter) error {
 			called = true
 			return nil
 		}
@@ -311,7 +347,7 @@ func TestInterceptors(t *testing.T) {
 		}
 
 		var res interface{}
-		repo.List("coll", res, 0, 0)
+		repo.List(ctx, "coll", res, 0, 0, nil)
 
 		if !called {
 			t.Errorf("Expected ListInterceptor to be called")
@@ -320,7 +356,7 @@ func TestInterceptors(t *testing.T) {
 
 	t.Run("Test get interceptor", func(t *testing.T) {
 		called := false
-		interceptor := func(collection, id string, result interface{}) error {
+		interceptor := func(ctx context.Context, collection, id string, result interface{}) error {
 			called = true
 			return nil
 		}
@@ -331,7 +367,7 @@ func TestInterceptors(t *testing.T) {
 		}
 
 		var res interface{}
-		repo.Get("col", "id", res)
+		repo.Get(ctx, "col", "id", res)
 
 		if !called {
 			t.Errorf("Expected GetInterceptor to be called")
@@ -340,7 +376,7 @@ func TestInterceptors(t *testing.T) {
 
 	t.Run("Test get one interceptor", func(t *testing.T) {
 		called := false
-		interceptor := func(collection string, result interface{}, filters ...ports.Filter) error {
+		interceptor := func(ctx context.Context, collection string, result interface{}, filters ...ports.Filter) error {
 			called = true
 			return nil
 		}
@@ -350,7 +386,7 @@ func TestInterceptors(t *testing.T) {
 		}
 
 		var res interface{}
-		repo.GetOne("col", res)
+		repo.GetOne(ctx, "col", res)
 
 		if !called {
 			t.Errorf("Expected GetOneInterceptor to be called")
@@ -359,7 +395,7 @@ func TestInterceptors(t *testing.T) {
 
 	t.Run("Test list interceptor", func(t *testing.T) {
 		called := false
-		interceptor := func(collection string, entity interface{}) (string, error) {
+		interceptor := func(ctx context.Context, collection string, entity interface{}) (string, error) {
 			called = true
 			return "", nil
 		}
@@ -369,7 +405,7 @@ func TestInterceptors(t *testing.T) {
 		}
 
 		var res interface{}
-		repo.Create("col", res)
+		repo.Create(ctx, "col", res)
 
 		if !called {
 			t.Errorf("Expected CreateInterceptor to be called")
@@ -378,7 +414,7 @@ func TestInterceptors(t *testing.T) {
 
 	t.Run("Test update interceptor", func(t *testing.T) {
 		called := false
-		interceptor := func(collection, id string, entity interface{}, omit ...string) error {
+		interceptor := func(ctx context.Context, collection, id string, entity interface{}, omit ...string) error {
 			called = true
 			return nil
 		}
@@ -388,7 +424,7 @@ func TestInterceptors(t *testing.T) {
 		}
 
 		var res interface{}
-		repo.Update("col", "id", res)
+		repo.Update(ctx, "col", "id", res)
 
 		if !called {
 			t.Errorf("Expected UpdateInterceptor to be called")
@@ -397,7 +433,7 @@ func TestInterceptors(t *testing.T) {
 
 	t.Run("Test delete interceptor", func(t *testing.T) {
 		called := false
-		interceptor := func(collection, id string) error {
+		interceptor := func(ctx context.Context, collection, id string) error {
 			called = true
 			return nil
 		}
@@ -406,7 +442,7 @@ func TestInterceptors(t *testing.T) {
 			DeleteInterceptor: interceptor,
 		}
 
-		repo.Delete("coll", "id")
+		repo.Delete(ctx, "coll", "id")
 
 		if !called {
 			t.Errorf("Expected DeleteInterceptor to be called")